@@ -0,0 +1,177 @@
+// Package captions extract CEA-608 and CEA-708 closed captions carried as
+// ATSC A/53 cc_data in sei user_data_registered_itu_t_t35 messages.
+package captions
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// cc_type of cc_data construct, CEA-708 4.4
+const (
+	CCTypeNTSCField1 = 0
+	CCTypeNTSCField2 = 1
+	CCTypeDTVCCData  = 2
+	CCTypeDTVCCStart = 3
+)
+
+const (
+	a53CountryCode    = 0xB5
+	a53ProviderCode   = 0x0031
+	a53UserDataTypeCC = 0x03
+)
+
+var a53Identifier = []byte("GA94")
+
+// CCConstruct one cc_valid, cc_type, cc_data_1, cc_data_2 entry of cc_data
+type CCConstruct struct {
+	Valid bool
+	Type  uint8
+	Data  [2]byte
+}
+
+// CCData cc_data structure, ATSC A/53 Part 4 6.2.3.1
+type CCData struct {
+	ProcessEmDataFlag  bool
+	ProcessCCDataFlag  bool
+	AdditionalDataFlag bool
+	EmData             uint8
+	Constructs         []CCConstruct
+}
+
+// CC608 a CEA-608 byte pair of field 1 or 2, parity bits kept
+type CC608 struct {
+	Field uint8
+	Data  [2]byte
+}
+
+// ParseA53 parse cc_data from the payload of user_data_registered_itu_t_t35
+// following itu_t_t35_country_code. ok is false if payload is not GA94 cc_data.
+func ParseA53(countryCode uint8, payload []byte) (cc *CCData, ok bool, err error) {
+	if countryCode != a53CountryCode || len(payload) < 7 {
+		return nil, false, nil
+	}
+	if provider := uint(payload[0])<<8 | uint(payload[1]); provider != a53ProviderCode {
+		return nil, false, nil
+	}
+	if !bytes.Equal(payload[2:6], a53Identifier) || payload[6] != a53UserDataTypeCC {
+		return nil, false, nil
+	}
+	cc, err = parseCCData(payload[7:])
+	return cc, true, err
+}
+
+func parseCCData(data []byte) (*CCData, error) {
+	if len(data) < 2 {
+		return nil, fmt.Errorf("cc_data truncated")
+	}
+	cc := &CCData{
+		ProcessEmDataFlag:  data[0]&0x80 != 0,
+		ProcessCCDataFlag:  data[0]&0x40 != 0,
+		AdditionalDataFlag: data[0]&0x20 != 0,
+		EmData:             data[1],
+	}
+	count := int(data[0] & 0x1F)
+	data = data[2:]
+	if len(data) < 3*count {
+		return nil, fmt.Errorf("cc_data truncated, cc_count %v with %v bytes", count, len(data))
+	}
+	cc.Constructs = make([]CCConstruct, count)
+	for i := range cc.Constructs {
+		b := data[3*i:]
+		cc.Constructs[i] = CCConstruct{
+			Valid: b[0]&0x04 != 0,
+			Type:  b[0] & 0x03,
+			Data:  [2]byte{b[1], b[2]},
+		}
+	}
+	return cc, nil
+}
+
+// CC608 return valid CEA-608 byte pairs of cc, none when process_cc_data_flag is 0
+func (cc *CCData) CC608() []CC608 {
+	if !cc.ProcessCCDataFlag {
+		return nil
+	}
+	var pairs []CC608
+	for _, c := range cc.Constructs {
+		if c.Valid && (c.Type == CCTypeNTSCField1 || c.Type == CCTypeNTSCField2) {
+			pairs = append(pairs, CC608{Field: c.Type + 1, Data: c.Data})
+		}
+	}
+	return pairs
+}
+
+// DTVCCPacket a CEA-708 caption channel packet, Data excludes packet header
+type DTVCCPacket struct {
+	SequenceNumber uint8
+	Data           []byte
+}
+
+// ServiceBlock a CEA-708 service block of a DTVCCPacket
+type ServiceBlock struct {
+	ServiceNumber uint8
+	Data          []byte
+}
+
+// ServiceBlocks split packet into service blocks, stopping at the null block
+// CEA-708 6.2 Service Blocks
+func (p *DTVCCPacket) ServiceBlocks() ([]ServiceBlock, error) {
+	var blocks []ServiceBlock
+	data := p.Data
+	for len(data) > 0 {
+		service, size := data[0]>>5, int(data[0]&0x1F)
+		if service == 0 || size == 0 {
+			break
+		}
+		data = data[1:]
+		if service == 7 {
+			if len(data) < 1 {
+				return blocks, fmt.Errorf("service block extended header truncated")
+			}
+			service = data[0] & 0x3F
+			data = data[1:]
+		}
+		if len(data) < size {
+			return blocks, fmt.Errorf("service %v block size %v exceeds packet", service, size)
+		}
+		blocks = append(blocks, ServiceBlock{ServiceNumber: service, Data: data[:size]})
+		data = data[size:]
+	}
+	return blocks, nil
+}
+
+// dtvccAssembler join cc_type 2 and 3 constructs into DTVCC packets
+type dtvccAssembler struct {
+	buf  []byte
+	size int
+}
+
+// push add a construct and return the packet it completes, nil if none
+func (a *dtvccAssembler) push(c CCConstruct) *DTVCCPacket {
+	switch {
+	case !c.Valid:
+		return nil
+	case c.Type == CCTypeDTVCCStart:
+		// an unfinished packet is dropped
+		code := int(c.Data[0] & 0x3F)
+		a.size = code * 2
+		if code == 0 {
+			a.size = 128
+		}
+		a.buf = append(a.buf[:0], c.Data[0], c.Data[1])
+	case c.Type == CCTypeDTVCCData && a.size > 0:
+		a.buf = append(a.buf, c.Data[0], c.Data[1])
+	default:
+		return nil
+	}
+	if len(a.buf) < a.size {
+		return nil
+	}
+	p := &DTVCCPacket{
+		SequenceNumber: a.buf[0] >> 6,
+		Data:           append([]byte(nil), a.buf[1:a.size]...),
+	}
+	a.buf, a.size = a.buf[:0], 0
+	return p
+}
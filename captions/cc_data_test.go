@@ -0,0 +1,84 @@
+package captions
+
+import (
+	"reflect"
+	"testing"
+)
+
+// a53Payload build GA94 cc_data payload following itu_t_t35_country_code
+func a53Payload(constructs ...CCConstruct) []byte {
+	p := []byte{0x00, 0x31, 'G', 'A', '9', '4', 0x03, 0xC0 | byte(len(constructs)), 0xFF}
+	for _, c := range constructs {
+		b := byte(0xF8) | c.Type
+		if c.Valid {
+			b |= 0x04
+		}
+		p = append(p, b, c.Data[0], c.Data[1])
+	}
+	return append(p, 0xFF)
+}
+
+func TestParseA53(t *testing.T) {
+	constructs := []CCConstruct{
+		{Valid: true, Type: CCTypeNTSCField1, Data: [2]byte{0x94, 0x20}},
+		{Valid: true, Type: CCTypeNTSCField2, Data: [2]byte{0x80, 0x80}},
+		{Valid: false, Type: CCTypeNTSCField1, Data: [2]byte{0x80, 0x80}},
+		{Valid: true, Type: CCTypeDTVCCStart, Data: [2]byte{0x42, 0x21}},
+	}
+	cc, ok, err := ParseA53(0xB5, a53Payload(constructs...))
+	if !ok || err != nil {
+		t.Fatalf("ParseA53() ok = %v, error = %v", ok, err)
+	}
+	want := &CCData{ProcessEmDataFlag: true, ProcessCCDataFlag: true, EmData: 0xFF, Constructs: constructs}
+	if !reflect.DeepEqual(cc, want) {
+		t.Errorf("ParseA53() = %+v, want %+v", cc, want)
+	}
+	pairs := []CC608{{Field: 1, Data: [2]byte{0x94, 0x20}}, {Field: 2, Data: [2]byte{0x80, 0x80}}}
+	if got := cc.CC608(); !reflect.DeepEqual(got, pairs) {
+		t.Errorf("CC608() = %v, want %v", got, pairs)
+	}
+
+	if _, ok, _ := ParseA53(0xB5, []byte{0x00, 0x2F, 'D', 'T', 'G', '1', 0x03, 0x00}); ok {
+		t.Errorf("ParseA53() accepted non GA94 payload")
+	}
+	if _, ok, _ := ParseA53(0x26, a53Payload()); ok {
+		t.Errorf("ParseA53() accepted other country code")
+	}
+	if _, ok, err := ParseA53(0xB5, a53Payload(constructs...)[:12]); !ok || err == nil {
+		t.Errorf("ParseA53() truncated ok = %v, error = %v", ok, err)
+	}
+}
+
+func TestDTVCCPacket(t *testing.T) {
+	// sequence 1, packet_size_code 3: header and 5 bytes,
+	// service 1 block of 2 bytes, service 9 extended block of 1 byte
+	constructs := []CCConstruct{
+		{Valid: true, Type: CCTypeDTVCCStart, Data: [2]byte{0x43, 0x22}},
+		{Valid: true, Type: CCTypeDTVCCData, Data: [2]byte{'h', 'i'}},
+		{Valid: false, Type: CCTypeDTVCCData, Data: [2]byte{0, 0}},
+		{Valid: true, Type: CCTypeDTVCCData, Data: [2]byte{0xE1, 0x09}},
+	}
+	var a dtvccAssembler
+	var packets []*DTVCCPacket
+	for _, c := range constructs {
+		if p := a.push(c); p != nil {
+			packets = append(packets, p)
+		}
+	}
+	if len(packets) != 1 {
+		t.Fatalf("got %v packets, want 1", len(packets))
+	}
+	p := packets[0]
+	if want := (&DTVCCPacket{SequenceNumber: 1, Data: []byte{0x22, 'h', 'i', 0xE1, 0x09}}); !reflect.DeepEqual(p, want) {
+		t.Errorf("packet = %+v, want %+v", p, want)
+	}
+	p.Data = append(p.Data, '!')
+	blocks, err := p.ServiceBlocks()
+	if err != nil {
+		t.Fatalf("ServiceBlocks() error = %v", err)
+	}
+	want := []ServiceBlock{{ServiceNumber: 1, Data: []byte("hi")}, {ServiceNumber: 9, Data: []byte("!")}}
+	if !reflect.DeepEqual(blocks, want) {
+		t.Errorf("ServiceBlocks() = %v, want %v", blocks, want)
+	}
+}
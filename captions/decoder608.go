@@ -0,0 +1,319 @@
+package captions
+
+import (
+	"strings"
+	"time"
+)
+
+// Mode608 caption display mode of CEA-608
+type Mode608 uint8
+
+const (
+	ModePopOn Mode608 = iota
+	ModeRollUp
+	ModePaintOn
+)
+
+func (m Mode608) String() string {
+	switch m {
+	case ModePopOn:
+		return "pop-on"
+	case ModeRollUp:
+		return "roll-up"
+	case ModePaintOn:
+		return "paint-on"
+	}
+	return "unknown"
+}
+
+const (
+	rows608    = 15
+	columns608 = 32
+)
+
+// Cue608 displayed caption after a change of screen, Text rows joined by "\n",
+// empty when screen was erased
+type Cue608 struct {
+	Mode Mode608
+	Text string
+	Time time.Duration
+}
+
+type screen608 [rows608][columns608]rune
+
+func (s *screen608) clear() {
+	*s = screen608{}
+}
+
+func (s *screen608) text() string {
+	var lines []string
+	for _, row := range s {
+		line := strings.TrimRight(strings.Map(func(r rune) rune {
+			if r == 0 {
+				return ' '
+			}
+			return r
+		}, string(row[:])), " ")
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Decoder608 decode one CEA-608 caption channel, CC1 to CC4, into cues
+// CEA-608-E, caption modes and control codes of section 7 and 8
+type Decoder608 struct {
+	field   uint8
+	channel uint8
+
+	mode     Mode608
+	rollRows int
+	row, col int
+
+	displayed, nonDisplayed screen608
+
+	// data channel of the last control code, 0 or 1
+	current uint8
+	// last control code, to drop its redundant repeat
+	lastCtrl [2]byte
+}
+
+// NewDecoder608 return a decoder of caption channel cc, 1 to 4
+func NewDecoder608(cc int) *Decoder608 {
+	d := &Decoder608{row: rows608 - 1}
+	if cc == 3 || cc == 4 {
+		d.field = 2
+	} else {
+		d.field = 1
+	}
+	if cc == 2 || cc == 4 {
+		d.channel = 1
+	}
+	return d
+}
+
+// Decode feed a byte pair with the time it arrived,
+// return a cue when the displayed caption changed
+func (d *Decoder608) Decode(cc CC608, t time.Duration) *Cue608 {
+	if cc.Field != d.field {
+		return nil
+	}
+	b1, b2 := cc.Data[0]&0x7F, cc.Data[1]&0x7F
+	if !oddParity(cc.Data[0]) || !oddParity(cc.Data[1]) || (b1 == 0 && b2 == 0) {
+		return nil
+	}
+	if b1 >= 0x10 && b1 <= 0x1F {
+		pair := [2]byte{b1, b2}
+		if pair == d.lastCtrl {
+			d.lastCtrl = [2]byte{}
+			return nil
+		}
+		d.lastCtrl = pair
+		d.current = (b1 >> 3) & 1
+		if d.current != d.channel {
+			return nil
+		}
+		return d.control(b1&^0x08, b2, t)
+	}
+	d.lastCtrl = [2]byte{}
+	if d.current != d.channel || b1 < 0x20 {
+		return nil
+	}
+	d.put(basicChar(b1))
+	if b2 >= 0x20 {
+		d.put(basicChar(b2))
+	}
+	return d.paintCue(t)
+}
+
+func (d *Decoder608) control(b1, b2 byte, t time.Duration) *Cue608 {
+	switch {
+	case (b1 == 0x14 || b1 == 0x15) && b2 >= 0x20 && b2 <= 0x2F:
+		return d.misc(b2, t)
+	case b1 == 0x17 && b2 >= 0x21 && b2 <= 0x23:
+		// tab offset
+		d.moveCol(d.col + int(b2-0x20))
+	case b1 == 0x11 && b2 >= 0x20 && b2 <= 0x2F:
+		// mid-row code, displayed as a space
+		d.put(' ')
+		return d.paintCue(t)
+	case b1 == 0x11 && b2 >= 0x30 && b2 <= 0x3F:
+		d.put(specialChars[b2-0x30])
+		return d.paintCue(t)
+	case (b1 == 0x12 || b1 == 0x13) && b2 >= 0x20 && b2 <= 0x3F:
+		// extended character replace the standard one sent before it
+		d.backspace()
+		d.put(extendedChars[b1-0x12][b2-0x20])
+		return d.paintCue(t)
+	case b2 >= 0x40:
+		d.preamble(b1, b2)
+	}
+	return nil
+}
+
+func (d *Decoder608) misc(code byte, t time.Duration) *Cue608 {
+	switch code {
+	case 0x20: // RCL resume caption loading
+		d.setMode(ModePopOn)
+	case 0x21: // BS backspace
+		d.backspace()
+		return d.paintCue(t)
+	case 0x24: // DER delete to end of row
+		s := d.writing()
+		for c := d.col; c < columns608; c++ {
+			s[d.row][c] = 0
+		}
+		return d.paintCue(t)
+	case 0x25, 0x26, 0x27: // RU2, RU3, RU4
+		d.setMode(ModeRollUp)
+		d.rollRows = int(code-0x25) + 2
+		if d.row < d.rollRows-1 {
+			d.row = d.rollRows - 1
+		}
+	case 0x29: // RDC resume direct captioning
+		d.setMode(ModePaintOn)
+	case 0x2C: // EDM erase displayed memory
+		d.displayed.clear()
+		return &Cue608{Mode: d.mode, Time: t}
+	case 0x2D: // CR carriage return
+		if d.mode != ModeRollUp {
+			return nil
+		}
+		// cue hold the completed row, before it rolls up
+		cue := d.cue(t)
+		top := d.row - d.rollRows + 1
+		for r := top; r < d.row; r++ {
+			if r >= 0 {
+				d.displayed[r] = d.displayed[r+1]
+			}
+		}
+		d.displayed[d.row] = [columns608]rune{}
+		d.col = 0
+		return cue
+	case 0x2E: // ENM erase non-displayed memory
+		d.nonDisplayed.clear()
+	case 0x2F: // EOC end of caption
+		d.displayed, d.nonDisplayed = d.nonDisplayed, d.displayed
+		d.setMode(ModePopOn)
+		return d.cue(t)
+	}
+	return nil
+}
+
+func (d *Decoder608) setMode(mode Mode608) {
+	if mode == d.mode {
+		return
+	}
+	if mode == ModeRollUp || d.mode == ModeRollUp {
+		d.displayed.clear()
+		d.nonDisplayed.clear()
+	}
+	d.mode = mode
+}
+
+// PAC rows by low 3 bits of first byte and bit 5 of second byte
+var pacRows = [8][2]int{{11, 11}, {1, 2}, {3, 4}, {12, 13}, {14, 15}, {5, 6}, {7, 8}, {9, 10}}
+
+func (d *Decoder608) preamble(b1, b2 byte) {
+	row := pacRows[b1&0x07][(b2>>5)&1] - 1
+	if d.mode == ModeRollUp && row != d.row {
+		// move roll-up window to new base row
+		old := d.displayed
+		d.displayed.clear()
+		for i := 0; i < d.rollRows; i++ {
+			if from, to := d.row-i, row-i; from >= 0 && to >= 0 {
+				d.displayed[to] = old[from]
+			}
+		}
+	}
+	d.row = row
+	d.col = 0
+	if b2&0x10 != 0 {
+		d.col = int((b2>>1)&0x07) * 4
+	}
+}
+
+// writing return memory written by characters of current mode
+func (d *Decoder608) writing() *screen608 {
+	if d.mode == ModePopOn {
+		return &d.nonDisplayed
+	}
+	return &d.displayed
+}
+
+func (d *Decoder608) put(r rune) {
+	d.writing()[d.row][d.col] = r
+	d.moveCol(d.col + 1)
+}
+
+func (d *Decoder608) backspace() {
+	if d.col > 0 {
+		d.col--
+		d.writing()[d.row][d.col] = 0
+	}
+}
+
+func (d *Decoder608) moveCol(col int) {
+	if col >= columns608 {
+		col = columns608 - 1
+	}
+	d.col = col
+}
+
+// paintCue return a cue for characters written straight to screen in paint-on mode
+func (d *Decoder608) paintCue(t time.Duration) *Cue608 {
+	if d.mode != ModePaintOn {
+		return nil
+	}
+	return d.cue(t)
+}
+
+func (d *Decoder608) cue(t time.Duration) *Cue608 {
+	return &Cue608{Mode: d.mode, Text: d.displayed.text(), Time: t}
+}
+
+func oddParity(b byte) bool {
+	b ^= b >> 4
+	b ^= b >> 2
+	b ^= b >> 1
+	return b&1 == 1
+}
+
+// basicChar map standard character set, CEA-608-E Annex A
+func basicChar(b byte) rune {
+	switch b {
+	case 0x27:
+		return '’'
+	case 0x2A:
+		return 'á'
+	case 0x5C:
+		return 'é'
+	case 0x5E:
+		return 'í'
+	case 0x5F:
+		return 'ó'
+	case 0x60:
+		return 'ú'
+	case 0x7B:
+		return 'ç'
+	case 0x7C:
+		return '÷'
+	case 0x7D:
+		return 'Ñ'
+	case 0x7E:
+		return 'ñ'
+	case 0x7F:
+		return '█'
+	}
+	return rune(b)
+}
+
+var specialChars = [16]rune{'®', '°', '½', '¿', '™', '¢', '£', '♪', 'à', ' ', 'è', 'â', 'ê', 'î', 'ô', 'û'}
+
+var extendedChars = [2][32]rune{
+	{'Á', 'É', 'Ó', 'Ú', 'Ü', 'ü', '‘', '¡', '*', '’', '—', '©', '℠', '•', '“', '”',
+		'À', 'Â', 'Ç', 'È', 'Ê', 'Ë', 'ë', 'Î', 'Ï', 'ï', 'Ô', 'Ù', 'ù', 'Û', '«', '»'},
+	{'Ã', 'ã', 'Í', 'Ì', 'ì', 'Ò', 'ò', 'Õ', 'õ', '{', '}', '\\', '^', '_', '|', '~',
+		'Ä', 'ä', 'Ö', 'ö', 'ß', '¥', '¤', '│', 'Å', 'å', 'Ø', 'ø', '┌', '┐', '└', '┘'},
+}
@@ -0,0 +1,129 @@
+package captions
+
+import (
+	"testing"
+	"time"
+)
+
+// pairs608 encode control codes and text as field 1 byte pairs with odd parity
+func pairs608(items ...interface{}) []CC608 {
+	var bytes []byte
+	for _, it := range items {
+		switch v := it.(type) {
+		case [2]byte:
+			if len(bytes)%2 != 0 {
+				bytes = append(bytes, 0)
+			}
+			bytes = append(bytes, v[0], v[1])
+		case string:
+			bytes = append(bytes, v...)
+		}
+	}
+	if len(bytes)%2 != 0 {
+		bytes = append(bytes, 0)
+	}
+	var pairs []CC608
+	for i := 0; i < len(bytes); i += 2 {
+		pairs = append(pairs, CC608{Field: 1, Data: [2]byte{withParity(bytes[i]), withParity(bytes[i+1])}})
+	}
+	return pairs
+}
+
+func withParity(b byte) byte {
+	if !oddParity(b) {
+		b |= 0x80
+	}
+	return b
+}
+
+var (
+	rcl  = [2]byte{0x14, 0x20}
+	ru2  = [2]byte{0x14, 0x25}
+	rdc  = [2]byte{0x14, 0x29}
+	edm  = [2]byte{0x14, 0x2C}
+	cr   = [2]byte{0x14, 0x2D}
+	enm  = [2]byte{0x14, 0x2E}
+	eoc  = [2]byte{0x14, 0x2F}
+	row1 = [2]byte{0x11, 0x40}
+	row2 = [2]byte{0x11, 0x60}
+	// row 15, indent 4
+	row15i4 = [2]byte{0x14, 0x72}
+)
+
+func decode608(d *Decoder608, pairs []CC608) []string {
+	var texts []string
+	for i, p := range pairs {
+		if cue := d.Decode(p, time.Duration(i)); cue != nil {
+			texts = append(texts, cue.Mode.String()+":"+cue.Text)
+		}
+	}
+	return texts
+}
+
+func TestDecoder608(t *testing.T) {
+	tests := []struct {
+		name  string
+		cc    int
+		pairs []CC608
+		want  []string
+	}{
+		{
+			"pop-on",
+			1,
+			pairs608(rcl, rcl, enm, enm, row1, "HELLO", row2, "WORLD", eoc, eoc, edm),
+			[]string{"pop-on:HELLO\nWORLD", "pop-on:"},
+		},
+		{
+			"pop-on indent and special characters",
+			1,
+			pairs608(rcl, row15i4, "caf", [2]byte{0x11, 0x3A}, " a", [2]byte{0x12, 0x21}, " \x2a", eoc),
+			[]string{"pop-on:    cafè É á"},
+		},
+		{
+			"roll-up",
+			1,
+			pairs608(ru2, ru2, cr, "ONE", cr, cr, "TWO", cr, "THREE", cr),
+			[]string{"roll-up:", "roll-up:ONE", "roll-up:ONE\nTWO", "roll-up:TWO\nTHREE"},
+		},
+		{
+			"paint-on backspace",
+			1,
+			pairs608(rdc, "AB", [2]byte{0x14, 0x21}),
+			[]string{"paint-on:AB", "paint-on:A"},
+		},
+		{
+			"other channel ignored",
+			2,
+			pairs608(rcl, row1, "HELLO", eoc),
+			nil,
+		},
+		{
+			"channel 2",
+			2,
+			pairs608([2]byte{0x1C, 0x20}, [2]byte{0x19, 0x40}, "CC2", [2]byte{0x1C, 0x2F}),
+			[]string{"pop-on:CC2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := decode608(NewDecoder608(tt.cc), tt.pairs)
+			if len(got) != len(tt.want) {
+				t.Fatalf("cues = %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("cue %v = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDecoder608Parity(t *testing.T) {
+	d := NewDecoder608(1)
+	d.Decode(CC608{Field: 1, Data: [2]byte{withParity(0x14), withParity(0x29)}}, 0)
+	if cue := d.Decode(CC608{Field: 1, Data: [2]byte{'A', 'B'}}, 0); cue != nil {
+		// 'A' 0x41 has even parity without bit 7
+		t.Errorf("Decode() accepted bad parity, cue = %v", cue)
+	}
+}
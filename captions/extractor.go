@@ -0,0 +1,160 @@
+package captions
+
+import (
+	"time"
+
+	"github.com/LiveStudioSolution/h264decoder/h264"
+)
+
+// CaptionData captions carried by the sei messages of one access unit
+type CaptionData struct {
+	// AccessUnit index of the access unit in decoding order, starting from 0
+	AccessUnit int
+	// Time of the access unit in decoding order, the clock ticks of the access units
+	// before it from sps timing info, valid when HasTime is true. A frame lasts two
+	// ticks and a field one, unless pic_timing sei gives its pic_struct.
+	Time    time.Duration
+	HasTime bool
+
+	CC608 []CC608
+	DTVCC []DTVCCPacket
+}
+
+// Extractor extract captions from access units in decoding order,
+// feed it every access unit returned by h264.AccessUnitReader.Next
+type Extractor struct {
+	au int
+	// ticks clock ticks of the access units before the current one
+	ticks int
+	dtvcc dtvccAssembler
+}
+
+// NewExtractor return a new Extractor
+func NewExtractor() *Extractor {
+	return &Extractor{}
+}
+
+// Push feed next access unit, return captions it carries or nil if none
+func (e *Extractor) Push(au *h264.AccessUnit) (*CaptionData, error) {
+	var sps *h264.SPS
+	if len(au.Primary) > 0 {
+		sps = au.Primary[0].Header.SPS
+	}
+	defer func() {
+		e.au++
+		e.ticks += deltaTfiDivisor(au)
+	}()
+	cd, err := e.captions(au.Sei, sps)
+	if err == nil {
+		err = au.SeiErr
+	}
+	return cd, err
+}
+
+// captions of the sei messages msgs of the access unit, sps for its time
+func (e *Extractor) captions(msgs []h264.SeiMessage, sps *h264.SPS) (*CaptionData, error) {
+	var err error
+	var cd *CaptionData
+	for _, msg := range msgs {
+		ud, ok := msg.Payload.(*h264.UserDataRegistered)
+		if !ok {
+			continue
+		}
+		cc, ok, ccErr := ParseA53(ud.CountryCode, ud.Payload)
+		if !ok {
+			continue
+		}
+		if ccErr != nil {
+			err = ccErr
+			continue
+		}
+		if cd == nil {
+			cd = e.newCaptionData(sps)
+		}
+		cd.CC608 = append(cd.CC608, cc.CC608()...)
+		if !cc.ProcessCCDataFlag {
+			continue
+		}
+		for _, c := range cc.Constructs {
+			if p := e.dtvcc.push(c); p != nil {
+				cd.DTVCC = append(cd.DTVCC, *p)
+			}
+		}
+	}
+	return cd, err
+}
+
+func (e *Extractor) newCaptionData(sps *h264.SPS) *CaptionData {
+	cd := &CaptionData{AccessUnit: e.au}
+	if sps != nil {
+		if num, den, ok := sps.FrameRate(); ok {
+			// den is twice num_units_in_tick, a tick lasting half a frame
+			cd.Time = frameTime(e.ticks, num, den/2)
+			cd.HasTime = true
+		}
+	}
+	return cd
+}
+
+// deltaTfiDivisor clock ticks of au, from pic_struct of its pic_timing sei or else
+// from field_pic_flag of its primary coded picture
+// T-REC-H.264-201402-S!!PDF-E.pdf Table E-6 Divisor for computation of Δtfi,dpb(n)
+func deltaTfiDivisor(au *h264.AccessUnit) int {
+	for _, msg := range au.Sei {
+		if pt, ok := msg.Payload.(*h264.PicTiming); ok && pt.PicStructPresent {
+			switch pt.PicStruct {
+			case 1, 2:
+				return 1
+			case 5, 6:
+				return 3
+			case 7:
+				return 4
+			case 8:
+				return 6
+			}
+			return 2
+		}
+	}
+	if len(au.Primary) > 0 && au.Primary[0].Header.FieldPicFlag {
+		return 1
+	}
+	return 2
+}
+
+// frameTime time of frame n at num / den frames per second, split into whole
+// seconds and the remainder so that it does not overflow for long streams
+func frameTime(n int, num, den uint) time.Duration {
+	ticks := uint64(n) * uint64(den)
+	return time.Duration(ticks/uint64(num))*time.Second +
+		time.Duration(ticks%uint64(num)*uint64(time.Second)/uint64(num))
+}
+
+// Reader read captions from a BitStream
+type Reader struct {
+	aur *h264.AccessUnitReader
+	ex  *Extractor
+}
+
+// NewReader return a new Reader extracting captions of bs
+func NewReader(bs *h264.BitStream) *Reader {
+	return &Reader{aur: h264.NewAccessUnitReader(bs, h264.NewParameterSetStore()), ex: NewExtractor()}
+}
+
+// Next return captions of next access unit carrying any, io.EOF at end of stream.
+// Access units before the parameter sets they refer to are skipped.
+func (r *Reader) Next() (*CaptionData, error) {
+	for {
+		au, err := r.aur.Next()
+		if _, ok := err.(h264.MissingParameterSetError); ok {
+			// joined the stream before its parameter sets, skip to them
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		cd, err := r.ex.Push(au)
+		if err != nil || cd != nil {
+			return cd, err
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package captions
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/LiveStudioSolution/h264decoder/h264"
+	"github.com/LiveStudioSolution/h264decoder/internal"
+	"github.com/LiveStudioSolution/h264decoder/internal/rbr"
+)
+
+// annexB join nalus, header byte and rbsp each, into an annex b byte stream
+func annexB(nalus ...[]byte) []byte {
+	var stream []byte
+	for _, n := range nalus {
+		stream = append(stream, 0, 0, 0, 1, n[0])
+		stream = append(stream, internal.RBSPToEBSP(n[1:])...)
+	}
+	return stream
+}
+
+// seiNalu build a sei nalu with one GA94 user_data_registered_itu_t_t35 message
+func seiNalu(constructs ...CCConstruct) []byte {
+	payload := append([]byte{0xB5}, a53Payload(constructs...)...)
+	return append(append([]byte{0x06, 0x04, byte(len(payload))}, payload...), 0x80)
+}
+
+// slice build a slice nalu of a header against the sps and pps of TestReader
+func slice(refIdc uint8, idr bool, firstMb, frameNum uint) []byte {
+	t := internal.NaluSlice
+	if idr {
+		t = internal.NaluSliceIdr
+	}
+	w := rbr.NewWriter()
+	w.WriteUe(firstMb)
+	if idr {
+		w.WriteUe(7)
+	} else {
+		w.WriteUe(5)
+	}
+	w.WriteUe(0) // pic_parameter_set_id
+	w.WriteBits(uint64(frameNum), 4)
+	if idr {
+		w.WriteUe(0)       // idr_pic_id
+		w.WriteFlag(false) // no_output_of_prior_pics_flag
+		w.WriteFlag(false) // long_term_reference_flag
+	} else {
+		w.WriteFlag(false) // num_ref_idx_active_override_flag
+		w.WriteFlag(false) // ref_pic_list_modification_flag_l0
+		w.WriteFlag(false) // adaptive_ref_pic_marking_mode_flag
+	}
+	w.WriteSe(0) // slice_qp_delta
+	w.WriteUe(1) // disable_deblocking_filter_idc
+	w.WriteTrailingBits()
+	return append([]byte{refIdc<<5 | uint8(t)}, w.Bytes()...)
+}
+
+func TestReader(t *testing.T) {
+	// 640x368 baseline sps, 60000/1001 time scale
+	sps := []byte{0x67, 0x42, 0xC0, 0x1E, 0xDA, 0x02, 0x80, 0xBF, 0xE5, 0xC0,
+		0x5A, 0x80, 0x80, 0x80, 0xA0, 0x00, 0x00, 0x7D, 0x20, 0x00, 0x1D, 0x4C, 0x01, 0xE2, 0xC5, 0xD4}
+	pps := []byte{0x68, 0xCE, 0x3C, 0x80}
+	idr := slice(3, true, 0, 0)
+	// a picture of two slices
+	p1, p1b := slice(2, false, 0, 1), slice(2, false, 500, 1)
+	p2, p3 := slice(2, false, 0, 2), slice(2, false, 0, 3)
+	cc1 := CCConstruct{Valid: true, Type: CCTypeNTSCField1, Data: [2]byte{0x94, 0x2F}}
+	start := CCConstruct{Valid: true, Type: CCTypeDTVCCStart, Data: [2]byte{0x02, 0x21}}
+	data := CCConstruct{Valid: true, Type: CCTypeDTVCCData, Data: [2]byte{'A', 'B'}}
+
+	stream := annexB(sps, pps, seiNalu(cc1, start), idr,
+		seiNalu(data), p1, p1b,
+		p2,
+		seiNalu(cc1), p3)
+	r := NewReader(h264.NewBitStream(bytes.NewReader(stream)))
+	var got []*CaptionData
+	for {
+		cd, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		got = append(got, cd)
+	}
+	want := []*CaptionData{
+		{AccessUnit: 0, Time: 0, HasTime: true, CC608: []CC608{{Field: 1, Data: cc1.Data}}},
+		{AccessUnit: 1, Time: time.Second * 2002 / 60000, HasTime: true, DTVCC: []DTVCCPacket{{Data: []byte{0x21, 'A', 'B'}}}},
+		{AccessUnit: 3, Time: time.Second * 3 * 2002 / 60000, HasTime: true, CC608: []CC608{{Field: 1, Data: cc1.Data}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		for _, cd := range got {
+			t.Logf("got %+v", cd)
+		}
+		t.Errorf("Next() got %v captions, want %v", len(got), len(want))
+	}
+}
+
+func TestReaderJoin(t *testing.T) {
+	sps := []byte{0x67, 0x42, 0xC0, 0x1E, 0xDA, 0x02, 0x80, 0xBF, 0xE5, 0xC0,
+		0x5A, 0x80, 0x80, 0x80, 0xA0, 0x00, 0x00, 0x7D, 0x20, 0x00, 0x1D, 0x4C, 0x01, 0xE2, 0xC5, 0xD4}
+	pps := []byte{0x68, 0xCE, 0x3C, 0x80}
+	cc1 := CCConstruct{Valid: true, Type: CCTypeNTSCField1, Data: [2]byte{0x94, 0x2F}}
+
+	// joined a live stream two pictures before its parameter sets
+	stream := annexB(seiNalu(cc1), slice(2, false, 0, 5),
+		seiNalu(cc1), slice(2, false, 0, 6),
+		sps, pps, seiNalu(cc1), slice(3, true, 0, 0),
+		slice(2, false, 0, 1),
+		seiNalu(cc1), slice(2, false, 0, 2))
+	r := NewReader(h264.NewBitStream(bytes.NewReader(stream)))
+	var got []*CaptionData
+	for {
+		cd, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		got = append(got, cd)
+	}
+	want := []*CaptionData{
+		{AccessUnit: 0, Time: 0, HasTime: true, CC608: []CC608{{Field: 1, Data: cc1.Data}}},
+		{AccessUnit: 2, Time: time.Second * 2 * 2002 / 60000, HasTime: true, CC608: []CC608{{Field: 1, Data: cc1.Data}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		for _, cd := range got {
+			t.Logf("got %+v", cd)
+		}
+		t.Errorf("Next() got %v captions, want %v", len(got), len(want))
+	}
+}
+
+func TestDeltaTfiDivisor(t *testing.T) {
+	picTiming := func(picStruct uint8) []h264.SeiMessage {
+		return []h264.SeiMessage{{Payload: &h264.PicTiming{PicStructPresent: true, PicStruct: picStruct}}}
+	}
+	field := []*h264.CodedSlice{{Header: &h264.SliceHeader{FieldPicFlag: true}}}
+	frame := []*h264.CodedSlice{{Header: &h264.SliceHeader{}}}
+	tests := []struct {
+		name string
+		au   h264.AccessUnit
+		want int
+	}{
+		{"frame", h264.AccessUnit{Primary: frame}, 2},
+		{"field", h264.AccessUnit{Primary: field}, 1},
+		{"no slice", h264.AccessUnit{}, 2},
+		{"pic_struct frame", h264.AccessUnit{Primary: frame, Sei: picTiming(0)}, 2},
+		{"pic_struct bottom field", h264.AccessUnit{Primary: field, Sei: picTiming(2)}, 1},
+		{"pic_struct top bottom top", h264.AccessUnit{Primary: frame, Sei: picTiming(5)}, 3},
+		{"pic_struct frame doubling", h264.AccessUnit{Primary: frame, Sei: picTiming(7)}, 4},
+		{"pic_struct frame tripling", h264.AccessUnit{Primary: frame, Sei: picTiming(8)}, 6},
+	}
+	for _, tt := range tests {
+		if got := deltaTfiDivisor(&tt.au); got != tt.want {
+			t.Errorf("deltaTfiDivisor(%v) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFrameTime(t *testing.T) {
+	tests := []struct {
+		n        int
+		num, den uint
+		want     time.Duration
+	}{
+		{0, 60000, 2002, 0},
+		{3, 60000, 2002, time.Second * 3 * 2002 / 60000},
+		{30, 60, 2, time.Second},
+		// beyond the uint64 range of n * den * time.Second
+		{30000000, 60000, 2002, 1001000 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := frameTime(tt.n, tt.num, tt.den); got != tt.want {
+			t.Errorf("frameTime(%v, %v, %v) = %v, want %v", tt.n, tt.num, tt.den, got, tt.want)
+		}
+	}
+}
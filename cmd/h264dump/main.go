@@ -0,0 +1,85 @@
+// Command h264dump decode an annex b H.264 stream and write its frames in output
+// order as raw YUV or YUV4MPEG2, to compare them with the output of reference decoders.
+//
+//	h264dump [-format planar|nv12|y4m] [-crop] in.h264 out.yuv
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/LiveStudioSolution/h264decoder/h264"
+	"github.com/LiveStudioSolution/h264decoder/yuv"
+)
+
+func main() {
+	format := flag.String("format", "planar", "output format, planar, nv12 or y4m")
+	crop := flag.Bool("crop", false, "write the sps frame cropping window only")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: h264dump [flags] in.h264 out.yuv\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	opts := yuv.Options{Crop: *crop}
+	switch *format {
+	case "planar":
+		opts.Format = yuv.Planar
+	case "nv12":
+		opts.Format = yuv.NV12
+	case "y4m":
+		opts.Format = yuv.Y4M
+	default:
+		log.Fatalf("unknown format %q", *format)
+	}
+	n, err := dump(flag.Arg(0), flag.Arg(1), opts)
+	if err != nil {
+		log.Fatalf("frame %v: %v", n, err)
+	}
+	log.Printf("wrote %v frames", n)
+}
+
+// dump decode the stream of file in and write its frames to file out, returning the
+// number of frames written
+func dump(in, out string, opts yuv.Options) (int, error) {
+	hd, err := h264.NewH264DecoderWithFile(in)
+	if err != nil {
+		return 0, err
+	}
+	file, err := os.Create(out)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	bw := bufio.NewWriter(file)
+
+	var w *yuv.Writer
+	n := 0
+	for ; ; n++ {
+		f, err := hd.NextVideoFrame()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return n, err
+		}
+		if w == nil {
+			// the sps of the first frame describes the y4m stream
+			w = yuv.NewWriter(bw, f.SPS, opts)
+		}
+		if err := w.WriteFrame(f); err != nil {
+			return n, err
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return n, err
+	}
+	return n, file.Close()
+}
@@ -0,0 +1,221 @@
+// Package colour convert decoded YCbCr frames to RGB as described by the colour
+// description of the VUI parameters, its matrix coefficients, sample range and chroma
+// sample location,
+// T-REC-H.264-201402-S!!PDF-E.pdf E.2.1 VUI parameters semantics
+package colour
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/LiveStudioSolution/h264decoder/internal"
+	"github.com/LiveStudioSolution/h264decoder/internal/frame"
+)
+
+// Matrix matrix_coefficients, Table E-5
+type Matrix uint8
+
+const (
+	// Identity GBR, Y holding G, Cb B and Cr R
+	Identity    Matrix = 0
+	BT709       Matrix = 1
+	Unspecified Matrix = 2
+	FCC         Matrix = 4
+	BT470BG     Matrix = 5
+	BT601       Matrix = 6
+	SMPTE240M   Matrix = 7
+	YCgCo       Matrix = 8
+	// BT2020NCL and BT2020CL ITU-R BT.2020 non-constant and constant luminance, the
+	// latter converted as the former
+	BT2020NCL Matrix = 9
+	BT2020CL  Matrix = 10
+)
+
+// kr KR and KB of the matrix, false for the ones not derived from them
+func (m Matrix) kr() (kr, kb float64, ok bool) {
+	switch m {
+	case BT709:
+		return 0.2126, 0.0722, true
+	case FCC:
+		return 0.30, 0.11, true
+	case BT470BG, BT601:
+		return 0.299, 0.114, true
+	case SMPTE240M:
+		return 0.212, 0.087, true
+	case BT2020NCL, BT2020CL:
+		return 0.2627, 0.0593, true
+	}
+	return 0, 0, false
+}
+
+// Params colour description of a stream
+type Params struct {
+	Matrix    Matrix
+	FullRange bool
+	// ChromaLoc chroma_sample_loc_type_top_field of 4:2:0 frames, Figure E-1
+	ChromaLoc uint
+}
+
+// FromSPS the colour description of the VUI parameters of sps, the inferred values
+// when absent. An unspecified or reserved matrix is taken as BT.709 for frames above
+// 576 lines and BT.601 below, as the broadcast practice is.
+func FromSPS(sps *internal.SPS) Params {
+	p := Params{Matrix: Unspecified}
+	vui := &sps.VuiParams
+	if sps.VuiParametersPresentFlag {
+		if vui.VideoSignalTypePresentFlag {
+			p.FullRange = vui.VideoFullRangeFlag
+			if vui.ColourDescriptionPresentFlag {
+				p.Matrix = Matrix(vui.MatrixCoefficients)
+			}
+		}
+		if vui.ChromaLocInfoPresentFlag {
+			p.ChromaLoc = vui.ChromaSampleLocTypeTopField
+		}
+	}
+	if _, _, ok := p.Matrix.kr(); !ok && p.Matrix != Identity && p.Matrix != YCgCo {
+		p.Matrix = BT601
+		if sps.CropRect().Dy() > 576 {
+			p.Matrix = BT709
+		}
+	}
+	return p
+}
+
+// Converter YCbCr to RGB conversion of frames of a colour description
+type Converter struct {
+	p Params
+	// coefficients of E'R = E'Y + rCr * E'PR, E'G = E'Y + gCb * E'PB + gCr * E'PR and
+	// E'B = E'Y + bCb * E'PB
+	rCr, gCb, gCr, bCb float64
+}
+
+// New converter of colour description p
+func New(p Params) *Converter {
+	c := &Converter{p: p}
+	if kr, kb, ok := p.Matrix.kr(); ok {
+		kg := 1 - kr - kb
+		c.rCr = 2 * (1 - kr)
+		c.bCb = 2 * (1 - kb)
+		c.gCb = -2 * kb * (1 - kb) / kg
+		c.gCr = -2 * kr * (1 - kr) / kg
+	}
+	return c
+}
+
+// rangeOf offset and scale of samples of bitDepth, E' = ( sample - offset ) / scale,
+// E-1 to E-3 and E-7 to E-9, for chroma with chroma
+func (c *Converter) rangeOf(bitDepth int, chroma bool) (offset, scale float64) {
+	n := float64(int(1) << (bitDepth - 8))
+	switch {
+	case c.p.FullRange && chroma:
+		return float64(int(1) << (bitDepth - 1)), float64(int(1)<<bitDepth - 1)
+	case c.p.FullRange:
+		return 0, float64(int(1)<<bitDepth - 1)
+	case chroma:
+		return 128 * n, 224 * n
+	}
+	return 16 * n, 219 * n
+}
+
+// RGB E'R, E'G and E'B in the range 0 to 1, unclipped, of samples y, cb and cr of
+// the bit depths bitDepthY and bitDepthC
+func (c *Converter) RGB(y, cb, cr float64, bitDepthY, bitDepthC int) (r, g, b float64) {
+	yOff, yScale := c.rangeOf(bitDepthY, false)
+	switch c.p.Matrix {
+	case Identity:
+		// E-4 to E-6, G, B and R samples of the luma range
+		return (cr - yOff) / yScale, (y - yOff) / yScale, (cb - yOff) / yScale
+	case YCgCo:
+		// E-22 to E-25, Cg and Co differences of the luma scale
+		half := float64(int(1) << (bitDepthC - 1))
+		cg, co := cb-half, cr-half
+		t := y - cg
+		return (t + co - yOff) / yScale, (y + cg - yOff) / yScale, (t - co - yOff) / yScale
+	}
+	cOff, cScale := c.rangeOf(bitDepthC, true)
+	ey := (y - yOff) / yScale
+	pb, pr := (cb-cOff)/cScale, (cr-cOff)/cScale
+	return ey + c.rCr*pr, ey + c.gCb*pb + c.gCr*pr, ey + c.bCb*pb
+}
+
+// chromaOffset location of the chroma samples relative to the luma ones, in half
+// luma samples, Figure E-1. 4:2:2 chroma is co-sited with the even luma samples.
+func (c *Converter) chromaOffset(format frame.ChromaFormat) (x, y int) {
+	if format != frame.Chroma420 {
+		return 0, 0
+	}
+	loc := c.p.ChromaLoc
+	if loc > 5 {
+		loc = 0
+	}
+	// types 1, 3 and 5 between two luma columns, 0 and 1 between two luma rows, 2 and 3
+	// on the upper one, 4 and 5 on the lower one
+	return int(loc % 2), [3]int{1, 0, 2}[loc/2]
+}
+
+// RGBA64 frame f, cropped, converted to RGB as the VUI parameters of f.SPS describe,
+// by the full range BT.601 matrix of image.YCbCr for a frame without SPS
+func RGBA64(f *frame.VideoFrame) *image.RGBA64 {
+	if f.SPS == nil {
+		return f.RGBA64()
+	}
+	return New(FromSPS(f.SPS)).Convert(f)
+}
+
+// Convert frame f, cropped, to RGB, chroma samples upsampled bilinearly from their location
+func (c *Converter) Convert(f *frame.VideoFrame) *image.RGBA64 {
+	im := image.NewRGBA64(f.Crop)
+	subW, subH := f.ChromaFormat.SubWidthC(), f.ChromaFormat.SubHeightC()
+	xOff, yOff := c.chromaOffset(f.ChromaFormat)
+	for y := f.Crop.Min.Y; y < f.Crop.Max.Y; y++ {
+		for x := f.Crop.Min.X; x < f.Crop.Max.X; x++ {
+			luma := float64(f.Planes[0].At(x, y))
+			var r, g, b float64
+			if subW == 0 {
+				yo, scale := c.rangeOf(f.BitDepthY, false)
+				r = (luma - yo) / scale
+				g, b = r, r
+			} else {
+				cb := upsample(f.Planes[1], 2*x-xOff, 2*y-yOff, subW, subH)
+				cr := upsample(f.Planes[2], 2*x-xOff, 2*y-yOff, subW, subH)
+				r, g, b = c.RGB(luma, cb, cr, f.BitDepthY, f.BitDepthC)
+			}
+			im.SetRGBA64(x, y, color.RGBA64{R: clip(r), G: clip(g), B: clip(b), A: 0xffff})
+		}
+	}
+	return im
+}
+
+// upsample chroma sample of plane p at ( x2, y2 ) in half luma samples relative to the
+// chroma sample grid, interpolated bilinearly from the nearest chroma samples
+func upsample(p *frame.VideoPlane, x2, y2, subW, subH int) float64 {
+	fx := float64(x2) / float64(2*subW)
+	fy := float64(y2) / float64(2*subH)
+	x0, y0 := int(math.Floor(fx)), int(math.Floor(fy))
+	wx, wy := fx-float64(x0), fy-float64(y0)
+	at := func(x, y int) float64 {
+		x, y = clamp(x, p.Width-1), clamp(y, p.Height-1)
+		return float64(p.At(x, y))
+	}
+	top := at(x0, y0)*(1-wx) + at(x0+1, y0)*wx
+	bottom := at(x0, y0+1)*(1-wx) + at(x0+1, y0+1)*wx
+	return top*(1-wy) + bottom*wy
+}
+
+// clip E' to 16 bits
+func clip(v float64) uint16 {
+	return uint16(math.Round(math.Min(math.Max(v, 0), 1) * 0xffff))
+}
+
+// clamp v to 0 to hi
+func clamp(v, hi int) int {
+	switch {
+	case v < 0:
+		return 0
+	case v > hi:
+		return hi
+	}
+	return v
+}
@@ -0,0 +1,163 @@
+package colour
+
+import (
+	"image"
+	"math"
+	"testing"
+
+	"github.com/LiveStudioSolution/h264decoder/internal"
+	"github.com/LiveStudioSolution/h264decoder/internal/frame"
+)
+
+func TestRGB(t *testing.T) {
+	tests := []struct {
+		name                string
+		p                   Params
+		bitDepth            int
+		y, cb, cr           float64
+		wantR, wantG, wantB float64
+	}{
+		{"BT.709 black", Params{Matrix: BT709}, 8, 16, 128, 128, 0, 0, 0},
+		{"BT.709 white", Params{Matrix: BT709}, 8, 235, 128, 128, 1, 1, 1},
+		{"BT.709 red", Params{Matrix: BT709}, 8, 62.56, 102.34, 240, 1, 0, 0},
+		{"BT.601 red", Params{Matrix: BT601}, 8, 81.48, 90.26, 240, 1, 0, 0},
+		{"BT.2020 blue", Params{Matrix: BT2020NCL}, 10, 64 + 876*0.0593, 960, 512 - 896*0.0593/(2*(1-0.2627)), 0, 0, 1},
+		{"SMPTE 240M green", Params{Matrix: SMPTE240M, FullRange: true}, 8, 255 * 0.701, 128 - 255*0.701/(2*(1-0.087)), 128 - 255*0.701/(2*(1-0.212)), 0, 1, 0},
+		{"full range white", Params{Matrix: BT601, FullRange: true}, 8, 255, 128, 128, 1, 1, 1},
+		{"full range black", Params{Matrix: BT709, FullRange: true}, 8, 0, 128, 128, 0, 0, 0},
+		{"10 bits white", Params{Matrix: BT709}, 10, 940, 512, 512, 1, 1, 1},
+		{"10 bits black", Params{Matrix: BT709}, 10, 64, 512, 512, 0, 0, 0},
+		{"GBR green", Params{Matrix: Identity}, 8, 235, 16, 16, 0, 1, 0},
+		// R 200, G 100, B 40
+		{"YCgCo", Params{Matrix: YCgCo, FullRange: true}, 8, 110, 118, 208, 200.0 / 255, 100.0 / 255, 40.0 / 255},
+	}
+	for _, tt := range tests {
+		r, g, b := New(tt.p).RGB(tt.y, tt.cb, tt.cr, tt.bitDepth, tt.bitDepth)
+		for _, c := range [][2]float64{{r, tt.wantR}, {g, tt.wantG}, {b, tt.wantB}} {
+			if math.Abs(c[0]-c[1]) > 0.005 {
+				t.Errorf("%v: RGB() = %.3f, %.3f, %.3f, want %.3f, %.3f, %.3f", tt.name, r, g, b, tt.wantR, tt.wantG, tt.wantB)
+				break
+			}
+		}
+	}
+
+	// BT.709 red taken as BT.601 is tinted
+	r, g, b := New(Params{Matrix: BT601}).RGB(62.56, 102.34, 240, 8, 8)
+	if math.Abs(r-1) < 0.05 && math.Abs(g) < 0.05 && math.Abs(b) < 0.05 {
+		t.Errorf("BT.709 red converted as BT.601 = %.3f, %.3f, %.3f, want a tint", r, g, b)
+	}
+}
+
+func TestFromSPS(t *testing.T) {
+	sd := internal.SPS{PicWidthInMbsMinus1: 44, PicHeightInMapUnitsMinus1: 35, FrameMbsOnlyFlag: true}
+	hd := internal.SPS{PicWidthInMbsMinus1: 119, PicHeightInMapUnitsMinus1: 67, FrameMbsOnlyFlag: true}
+	described := hd
+	described.VuiParametersPresentFlag = true
+	vui := &described.VuiParams
+	vui.VideoSignalTypePresentFlag, vui.VideoFullRangeFlag = true, true
+	vui.ColourDescriptionPresentFlag, vui.MatrixCoefficients = true, 9
+	vui.ChromaLocInfoPresentFlag, vui.ChromaSampleLocTypeTopField = true, 2
+	reserved := described
+	reserved.VuiParams.MatrixCoefficients = 3
+
+	tests := []struct {
+		name string
+		sps  *internal.SPS
+		want Params
+	}{
+		{"SD", &sd, Params{Matrix: BT601}},
+		{"HD", &hd, Params{Matrix: BT709}},
+		{"colour description", &described, Params{Matrix: BT2020NCL, FullRange: true, ChromaLoc: 2}},
+		{"reserved", &reserved, Params{Matrix: BT709, FullRange: true, ChromaLoc: 2}},
+	}
+	for _, tt := range tests {
+		if got := FromSPS(tt.sps); got != tt.want {
+			t.Errorf("%v: FromSPS() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUpsample(t *testing.T) {
+	p := frame.NewVideoPlane(2, 2, 8)
+	p.Set(1, 0, 100)
+	p.Set(0, 1, 200)
+	p.Set(1, 1, 250)
+	tests := []struct {
+		loc  uint
+		x, y int
+		want float64
+	}{
+		// co-sited with the upper-left luma sample
+		{2, 0, 0, 0},
+		{2, 1, 0, 50},
+		{2, 2, 0, 100},
+		// between two luma rows
+		{0, 0, 1, 50},
+		{0, 0, 0, 0},
+		// between two luma columns
+		{3, 0, 0, 0},
+		{3, 1, 0, 25},
+		{3, 2, 0, 75},
+		// on the lower luma row
+		{4, 0, 1, 0},
+		{4, 0, 2, 100},
+	}
+	for _, tt := range tests {
+		c := New(Params{ChromaLoc: tt.loc})
+		xOff, yOff := c.chromaOffset(frame.Chroma420)
+		if got := upsample(p, 2*tt.x-xOff, 2*tt.y-yOff, 2, 2); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("chroma_sample_loc_type %v: upsample(%v, %v) = %v, want %v", tt.loc, tt.x, tt.y, got, tt.want)
+		}
+	}
+	if x, y := New(Params{ChromaLoc: 5}).chromaOffset(frame.Chroma422); x != 0 || y != 0 {
+		t.Errorf("chromaOffset(4:2:2) = %v, %v, want co-sited", x, y)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	planes := [3]*frame.Plane{frame.NewPlane(4, 4), frame.NewPlane(2, 2), frame.NewPlane(2, 2)}
+	for i := range planes[0].Pix {
+		planes[0].Pix[i] = 235
+	}
+	for _, c := range planes[1:] {
+		for i := range c.Pix {
+			c.Pix[i] = 128
+		}
+	}
+	// red lower right quadrant
+	planes[0].Set(3, 3, 63)
+	planes[1].Set(1, 1, 102)
+	planes[2].Set(1, 1, 240)
+	f := frame.NewVideoFrame(planes, frame.Chroma420, 8, 8)
+	f.Crop = image.Rect(0, 0, 4, 4)
+	im := New(Params{Matrix: BT709, ChromaLoc: 2}).Convert(f)
+	if got := im.RGBA64At(0, 0); got.R != 0xffff || got.G != 0xffff || got.B != 0xffff || got.A != 0xffff {
+		t.Errorf("RGBA64At(0, 0) = %v, want white", got)
+	}
+	if got := im.RGBA64At(3, 3); got.R < 0xfe00 || got.G > 0x200 || got.B > 0x200 {
+		t.Errorf("RGBA64At(3, 3) = %v, want red", got)
+	}
+
+	mono := frame.NewVideoFrame([3]*frame.Plane{planes[0]}, frame.Monochrome, 8, 8)
+	im = New(Params{Matrix: BT709}).Convert(mono)
+	if got := im.RGBA64At(0, 0); got.R != 0xffff || got.G != 0xffff || got.B != 0xffff {
+		t.Errorf("RGBA64At() of monochrome = %v, want white", got)
+	}
+}
+
+func TestRGBA64(t *testing.T) {
+	planes := [3]*frame.Plane{frame.NewPlane(2, 2), frame.NewPlane(1, 1), frame.NewPlane(1, 1)}
+	for i := range planes[0].Pix {
+		planes[0].Pix[i] = 235
+	}
+	planes[1].Pix[0], planes[2].Pix[0] = 128, 128
+	f := frame.NewVideoFrame(planes, frame.Chroma420, 8, 8)
+	// image.YCbCr takes the nominal white of limited range for a light gray
+	if got := RGBA64(f).RGBA64At(0, 0); got != f.RGBA64().RGBA64At(0, 0) || got.R == 0xffff {
+		t.Errorf("RGBA64() without SPS = %v, want %v", got, f.RGBA64().RGBA64At(0, 0))
+	}
+	f.SPS = &internal.SPS{ChromaFormatIdc: 1, FrameMbsOnlyFlag: true}
+	if got := RGBA64(f).RGBA64At(0, 0); got.R != 0xffff || got.G != 0xffff || got.B != 0xffff {
+		t.Errorf("RGBA64() of limited range white = %v, want white", got)
+	}
+}
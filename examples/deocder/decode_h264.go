@@ -1,31 +1,37 @@
 package main
 
 import (
-	"github.com/LiveStudioSolution/h264decoder/internal"
+	"io"
+
+	"github.com/LiveStudioSolution/h264decoder/h264"
 	"github.com/LiveStudioSolution/h264decoder/internal/logger"
 )
+
 var log = logger.Log
 
 func init() {
-	logger.EnableStderrLog()
+	h264.EnableStderrLog()
 	log = logger.Log
 }
 
 func main() {
 	iFile := "docs/videosamples/txjg.h264"
-	h264Decoder, err := internal.NewH264DecoderWithFile(iFile)
+	h264Decoder, err := h264.NewH264DecoderWithFile(iFile)
 	if err != nil {
 		log.Printf("NewH264DecoderWithFile error:%v", err)
 		return
 	}
 	for {
 		frame, err := h264Decoder.NextFrame()
+		if err == io.EOF {
+			break
+		}
 		if err != nil {
 			log.Printf("NextFrame error %v", err)
 			break
 		}
 		if frame != nil {
-			log.Printf("got frame %v",frame)
+			log.Printf("got frame %v", frame.Bounds())
 		}
 	}
 }
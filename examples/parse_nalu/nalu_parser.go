@@ -2,15 +2,16 @@ package main
 
 import (
 	"fmt"
-	"github.com/LiveStudioSolution/h264decoder/internal"
-	"github.com/LiveStudioSolution/h264decoder/internal/logger"
 	"os"
+
+	"github.com/LiveStudioSolution/h264decoder/h264"
+	"github.com/LiveStudioSolution/h264decoder/internal/logger"
 )
 
 var log = logger.Log
 
 func init() {
-	logger.EnableStderrLog()
+	h264.EnableStderrLog()
 	log = logger.Log
 }
 func main() {
@@ -20,14 +21,14 @@ func main() {
 		fmt.Printf("open file error %v\n", err)
 		os.Exit(1)
 	}
-	bs := internal.NewBitStream(h264Reader)
-	var nl *internal.Nalu
+	bs := h264.NewBitStream(h264Reader)
+	var nl *h264.Nalu
 	for {
-		nl, err = bs.NextNalu();
+		nl, err = bs.NextNalu()
 		if err != nil || nl == nil {
 			break
 		}
-		log.Printf("get nalu type = %v, rbr size = %v\n", nl.Type(), nl.RbspSize())
+		log.Printf("get nalu type = %v, ref idc = %v, rbr size = %v\n", nl.Type(), nl.RefIdc(), nl.RbspSize())
 	}
 	if err != nil {
 		log.Printf("get nalu err = %v\n", err)
@@ -0,0 +1,196 @@
+package h264
+
+import (
+	"io"
+
+	"github.com/LiveStudioSolution/h264decoder/internal/sei"
+	"github.com/LiveStudioSolution/h264decoder/internal/slice"
+)
+
+// SliceHeader slice header, 7.3.3
+type SliceHeader = slice.Header
+
+// CodedSlice a slice of the primary coded picture, Nalus is the slice nalu,
+// or the data partitions A, B and C in order
+type CodedSlice struct {
+	Header *SliceHeader
+	Nalus  []*Nalu
+}
+
+// AccessUnit nalus of one access unit, 7.4.1.2.3
+type AccessUnit struct {
+	// Nalus all nalus of the access unit in decoding order
+	Nalus []*Nalu
+	// Primary slices of the primary coded picture, redundant pictures excluded
+	Primary []*CodedSlice
+	// IDR is true for an idr access unit
+	IDR bool
+	// Keyframe is true for idr access units and access units carrying a recovery point
+	// sei of recovery_frame_cnt 0, decoding can start at them. Recovery points of a
+	// positive recovery_frame_cnt, where output recovers only frames later, do not count.
+	Keyframe bool
+	// Sei messages of the sei nalus of the access unit, parsed once it is complete
+	Sei []SeiMessage
+	// SeiErr error parsing the sei nalus, the messages parsed before it are in Sei
+	SeiErr error
+}
+
+// AccessUnitReader group nalus read from a BitStream into access units
+type AccessUnitReader struct {
+	bs *BitStream
+	ps *ParameterSetStore
+
+	cur *AccessUnit
+	// last slice header of current primary coded picture, nil before it
+	last *SliceHeader
+	// err error of the nalu that completed the access unit returned before it
+	err error
+}
+
+// NewAccessUnitReader return a new AccessUnitReader of bs, parameter sets are put in ps
+func NewAccessUnitReader(bs *BitStream, ps *ParameterSetStore) *AccessUnitReader {
+	return &AccessUnitReader{bs: bs, ps: ps, cur: &AccessUnit{}}
+}
+
+// Next return next access unit, io.EOF at end of stream.
+// An access unit is complete when the first nalu of the next one is read.
+func (r *AccessUnitReader) Next() (*AccessUnit, error) {
+	if err := r.err; err != nil {
+		r.err = nil
+		return nil, err
+	}
+	for {
+		nalu, err := r.bs.NextNalu()
+		if err != nil {
+			return nil, err
+		}
+		if nalu == nil {
+			if len(r.cur.Nalus) == 0 {
+				return nil, io.EOF
+			}
+			return r.finish(), nil
+		}
+		if au, err := r.push(nalu); au != nil || err != nil {
+			return au, err
+		}
+	}
+}
+
+// push add nalu to current access unit, return the previous one when nalu start a new access unit
+func (r *AccessUnitReader) push(nalu *Nalu) (*AccessUnit, error) {
+	switch t := nalu.Type(); t {
+	case NaluSps:
+		// the previous access unit is complete before its parameter sets change
+		au := r.appendNonVcl(nalu)
+		if _, err := r.ps.PutSps(nalu.Rbsp()); err != nil {
+			return r.fail(au, err)
+		}
+		return au, nil
+	case NaluPps:
+		au := r.appendNonVcl(nalu)
+		if _, err := r.ps.PutPps(nalu.Rbsp()); err != nil {
+			return r.fail(au, err)
+		}
+		return au, nil
+	case NaluAud, NaluSei, NaluPrefix, NaluSubsetSps, NaluDps, 17, 18:
+		return r.appendNonVcl(nalu), nil
+	case NaluSlice, NaluSliceDpa, NaluSliceIdr:
+		header, err := slice.ParseHeader(nalu, r.ps)
+		if err != nil {
+			if _, ok := err.(MissingParameterSetError); ok && r.last == nil {
+				// drop the non vcl nalus of the access unit that cannot be decoded,
+				// so that they do not end up in the first one after the parameter sets
+				r.cur = &AccessUnit{}
+			}
+			return nil, err
+		}
+		if header.RedundantPicCnt > 0 {
+			r.cur.Nalus = append(r.cur.Nalus, nalu)
+			return nil, nil
+		}
+		var au *AccessUnit
+		if r.last != nil && header.StartsNewPicture(r.last) {
+			au = r.finish()
+		}
+		r.last = header
+		r.cur.Nalus = append(r.cur.Nalus, nalu)
+		r.cur.Primary = append(r.cur.Primary, &CodedSlice{Header: header, Nalus: []*Nalu{nalu}})
+		r.cur.IDR = header.IdrPicFlag
+		return au, nil
+	case NaluSliceDpb, NaluSliceDpc:
+		// partitions B and C follow partition A of their slice
+		if n := len(r.cur.Primary); n > 0 && r.cur.Primary[n-1].Header.NalUnitType == NaluSliceDpa {
+			r.cur.Primary[n-1].Nalus = append(r.cur.Primary[n-1].Nalus, nalu)
+		}
+	}
+	// end of sequence, end of stream, filler, auxiliary and extension slices
+	// stay in current access unit
+	r.cur.Nalus = append(r.cur.Nalus, nalu)
+	return nil, nil
+}
+
+// appendNonVcl add a non vcl nalu which start a new access unit after the primary coded picture
+func (r *AccessUnitReader) appendNonVcl(nalu *Nalu) *AccessUnit {
+	var au *AccessUnit
+	if r.last != nil {
+		au = r.finish()
+	}
+	r.cur.Nalus = append(r.cur.Nalus, nalu)
+	return au
+}
+
+// fail return err, after access unit au completed by the failing nalu when not nil
+func (r *AccessUnitReader) fail(au *AccessUnit, err error) (*AccessUnit, error) {
+	if au == nil {
+		return nil, err
+	}
+	r.err = err
+	return au, nil
+}
+
+// finish return current access unit and start an empty one. Its sei is parsed against
+// the sps of its primary coded picture, the slice of the next access unit read to
+// complete it having activated its own.
+func (r *AccessUnitReader) finish() *AccessUnit {
+	au := r.cur
+	ps := auParameterSets{ParameterSetStore: r.ps, sps: r.ps.ActiveSps()}
+	if len(au.Primary) > 0 {
+		ps.sps = au.Primary[0].Header.SPS
+	}
+	for _, nalu := range au.Nalus {
+		if nalu.Type() != NaluSei {
+			continue
+		}
+		msgs, err := sei.Parse(nalu, ps)
+		au.Sei = append(au.Sei, msgs...)
+		if err != nil && au.SeiErr == nil {
+			au.SeiErr = err
+		}
+	}
+	au.Keyframe = au.IDR || cleanRecoveryPoint(au.Sei)
+	r.cur = &AccessUnit{}
+	r.last = nil
+	return au
+}
+
+// cleanRecoveryPoint report whether msgs hold a recovery point of recovery_frame_cnt 0
+func cleanRecoveryPoint(msgs []SeiMessage) bool {
+	for _, msg := range msgs {
+		if rp, ok := msg.Payload.(*sei.RecoveryPoint); ok && rp.RecoveryFrameCnt == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// auParameterSets parameter sets of an access unit, the active sps being the one of
+// its primary coded picture
+type auParameterSets struct {
+	*ParameterSetStore
+	sps *SPS
+}
+
+// ActiveSps the sps of the primary coded picture of the access unit
+func (ps auParameterSets) ActiveSps() *SPS {
+	return ps.sps
+}
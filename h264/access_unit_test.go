@@ -0,0 +1,218 @@
+package h264
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/LiveStudioSolution/h264decoder/internal"
+	"github.com/LiveStudioSolution/h264decoder/internal/rbr"
+)
+
+// testNal return nal unit bytes of header and rbsp written by f
+func testNal(refIdc uint8, t NaluType, f func(w *rbr.Writer)) []byte {
+	w := rbr.NewWriter()
+	f(w)
+	w.WriteTrailingBits()
+	return append([]byte{0, 0, 0, 1, refIdc<<5 | uint8(t)}, internal.RBSPToEBSP(w.Bytes())...)
+}
+
+// testSlice write a slice header against the sps and pps of txjg.h264
+func testSlice(refIdc uint8, idr bool, firstMb, frameNum uint) []byte {
+	return testPpsSlice(refIdc, idr, firstMb, frameNum, 0)
+}
+
+// testPpsSlice write a slice header against pps ppsId of the txjg.h264 layout
+func testPpsSlice(refIdc uint8, idr bool, firstMb, frameNum, ppsId uint) []byte {
+	t := NaluSlice
+	if idr {
+		t = NaluSliceIdr
+	}
+	return testNal(refIdc, t, func(w *rbr.Writer) {
+		w.WriteUe(firstMb)
+		if idr {
+			w.WriteUe(7)
+		} else {
+			w.WriteUe(5)
+		}
+		w.WriteUe(ppsId)
+		w.WriteBits(uint64(frameNum), 4)
+		if idr {
+			w.WriteUe(0)       // idr_pic_id
+			w.WriteFlag(false) // no_output_of_prior_pics_flag
+			w.WriteFlag(false) // long_term_reference_flag
+		} else {
+			w.WriteFlag(false) // num_ref_idx_active_override_flag
+			w.WriteFlag(false) // ref_pic_list_modification_flag_l0
+			if refIdc != 0 {
+				w.WriteFlag(false) // adaptive_ref_pic_marking_mode_flag
+			}
+		}
+		w.WriteSe(0) // slice_qp_delta
+		w.WriteUe(1) // disable_deblocking_filter_idc
+	})
+}
+
+func TestAccessUnitReader(t *testing.T) {
+	sps := append([]byte{0, 0, 0, 1, 0x67}, 0x42, 0xC0, 0x1E, 0xDA, 0x02, 0x80, 0xBF, 0xE5, 0xC0,
+		0x5A, 0x80, 0x80, 0x80, 0xA0, 0x00, 0x00, 0x7D, 0x20, 0x00, 0x1D, 0x4C, 0x01, 0xE2, 0xC5, 0xD4)
+	pps := []byte{0, 0, 0, 1, 0x68, 0xCE, 0x3C, 0x80}
+	aud := testNal(0, NaluAud, func(w *rbr.Writer) { w.WriteBits(1, 3) })
+	recovery := testNal(0, NaluSei, func(w *rbr.Writer) {
+		w.WriteBits(6, 8)
+		w.WriteBits(1, 8)
+		w.WriteBits(0xA0, 8) // recovery_frame_cnt 0, exact_match_flag
+	})
+	partialRecovery := testNal(0, NaluSei, func(w *rbr.Writer) {
+		w.WriteBits(6, 8)
+		w.WriteBits(2, 8)
+		w.WriteBits(0x2400, 16) // recovery_frame_cnt 3, exact_match_flag
+	})
+	eos := []byte{0, 0, 0, 1, 0x0A}
+
+	var stream []byte
+	for _, nal := range [][]byte{
+		sps, pps, testSlice(3, true, 0, 0), testSlice(3, true, 400, 0),
+		aud, recovery, testSlice(2, false, 0, 1), testSlice(2, false, 500, 1),
+		partialRecovery, testSlice(2, false, 0, 2),
+		testSlice(0, false, 0, 2), eos,
+	} {
+		stream = append(stream, nal...)
+	}
+
+	want := []struct {
+		nalus, primary, sei int
+		idr, keyframe       bool
+	}{
+		{4, 2, 0, true, true},
+		{4, 2, 1, false, true},
+		{2, 1, 1, false, false},
+		{2, 1, 0, false, false},
+	}
+	r := NewAccessUnitReader(NewBitStream(bytes.NewReader(stream)), NewParameterSetStore())
+	for i, w := range want {
+		au, err := r.Next()
+		if err != nil {
+			t.Fatalf("Next() %v error = %v", i, err)
+		}
+		if len(au.Nalus) != w.nalus || len(au.Primary) != w.primary || len(au.Sei) != w.sei || au.IDR != w.idr || au.Keyframe != w.keyframe {
+			t.Errorf("access unit %v = %v nalus, %v slices, %v sei messages, idr %v, keyframe %v, want %+v",
+				i, len(au.Nalus), len(au.Primary), len(au.Sei), au.IDR, au.Keyframe, w)
+		}
+		if au.SeiErr != nil {
+			t.Errorf("access unit %v sei error = %v", i, au.SeiErr)
+		}
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("Next() at end error = %v, want io.EOF", err)
+	}
+}
+
+// testSps write a baseline sps of the txjg.h264 slice header layout, with
+// pic_struct_present_flag set in its vui when picStruct
+func testSps(id uint, picStruct bool) []byte {
+	return testNal(3, NaluSps, func(w *rbr.Writer) {
+		w.WriteBits(66, 8) // profile_idc
+		w.WriteBits(0, 8)
+		w.WriteBits(30, 8) // level_idc
+		w.WriteUe(id)
+		w.WriteUe(0)       // log2_max_frame_num_minus4
+		w.WriteUe(2)       // pic_order_cnt_type
+		w.WriteUe(1)       // max_num_ref_frames
+		w.WriteFlag(false) // gaps_in_frame_num_value_allowed_flag
+		w.WriteUe(39)      // pic_width_in_mbs_minus1
+		w.WriteUe(22)      // pic_height_in_map_units_minus1
+		w.WriteFlag(true)  // frame_mbs_only_flag
+		w.WriteFlag(true)  // direct_8x8_inference_flag
+		w.WriteFlag(false) // frame_cropping_flag
+		w.WriteFlag(picStruct)
+		if picStruct {
+			// aspect ratio, overscan, video signal, chroma loc, timing, nal and vcl
+			// hrd absent, pic_struct_present_flag, no bitstream restriction
+			w.WriteBits(0, 7)
+			w.WriteFlag(true)
+			w.WriteFlag(false)
+		}
+	})
+}
+
+// testPps write a cavlc pps of id id referring to sps id, as the one of txjg.h264
+func testPps(id uint) []byte {
+	return testNal(3, NaluPps, func(w *rbr.Writer) {
+		w.WriteUe(id)
+		w.WriteUe(id)     // seq_parameter_set_id
+		w.WriteBits(0, 2) // entropy_coding_mode_flag, bottom_field_pic_order_in_frame_present_flag
+		w.WriteUe(0)      // num_slice_groups_minus1
+		w.WriteUe(0)      // num_ref_idx_l0_default_active_minus1
+		w.WriteUe(0)      // num_ref_idx_l1_default_active_minus1
+		w.WriteBits(0, 3) // weighted_pred_flag, weighted_bipred_idc
+		w.WriteSe(0)      // pic_init_qp_minus26
+		w.WriteSe(0)      // pic_init_qs_minus26
+		w.WriteSe(0)      // chroma_qp_index_offset
+		w.WriteFlag(true) // deblocking_filter_control_present_flag
+		w.WriteBits(0, 2) // constrained_intra_pred_flag, redundant_pic_cnt_present_flag
+	})
+}
+
+func TestAccessUnitReaderSpsChange(t *testing.T) {
+	picTiming := testNal(0, NaluSei, func(w *rbr.Writer) {
+		w.WriteBits(1, 8)
+		w.WriteBits(1, 8)
+		w.WriteBits(0x10, 8) // pic_struct 1, clock_timestamp_flag 0
+	})
+	// the second idr picture activates sps 1, without pic_struct, when it is read to
+	// complete the first access unit
+	var stream []byte
+	for _, nal := range [][]byte{
+		testSps(0, true), testSps(1, false), testPps(0), testPps(1),
+		picTiming, testPpsSlice(3, true, 0, 0, 0),
+		testPpsSlice(3, true, 0, 0, 1),
+	} {
+		stream = append(stream, nal...)
+	}
+	r := NewAccessUnitReader(NewBitStream(bytes.NewReader(stream)), NewParameterSetStore())
+	au, err := r.Next()
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if au.SeiErr != nil || len(au.Sei) != 1 {
+		t.Fatalf("sei %v, error %v", au.Sei, au.SeiErr)
+	}
+	pt, ok := au.Sei[0].Payload.(*PicTiming)
+	if !ok || !pt.PicStructPresent || pt.PicStruct != 1 {
+		t.Errorf("sei payload %v, want pic_timing of pic_struct 1", au.Sei[0])
+	}
+	if au, err = r.Next(); err != nil || len(au.Primary) != 1 || au.Primary[0].Header.SPS.Id != 1 {
+		t.Errorf("Next() = %v, %v, want the idr access unit of sps 1", au, err)
+	}
+}
+
+func TestAccessUnitReaderSample(t *testing.T) {
+	f, err := os.Open("../docs/videosamples/txjg.h264")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer f.Close()
+	r := NewAccessUnitReader(NewBitStream(f), NewParameterSetStore())
+	count, idr := 0, 0
+	for {
+		au, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		if len(au.Primary) != 1 {
+			t.Errorf("access unit %v has %v slices, want 1", count, len(au.Primary))
+		}
+		if au.IDR {
+			idr++
+		}
+		count++
+	}
+	if count != 150 || idr != 3 {
+		t.Errorf("got %v access units, %v idr, want 150, 3", count, idr)
+	}
+}
@@ -0,0 +1,135 @@
+package h264
+
+import (
+	"image"
+	"io"
+	"os"
+
+	"github.com/LiveStudioSolution/h264decoder/colour"
+	"github.com/LiveStudioSolution/h264decoder/internal/decoder"
+	"github.com/LiveStudioSolution/h264decoder/internal/frame"
+	"github.com/LiveStudioSolution/h264decoder/internal/logger"
+)
+
+//H264Decoder  decoder of h264 codec
+type H264Decoder struct {
+	aur *AccessUnitReader
+	ps  *ParameterSetStore
+	dec *decoder.Decoder
+	// flushed the frames left in the decoder were output at end of stream
+	flushed bool
+
+	// OnSei is called with the sei messages of each access unit
+	OnSei func(msgs []SeiMessage)
+	// ColourConvert make NextFrame return an image.RGBA64 converted as the VUI colour
+	// description of the SPS specifies, its matrix and sample range, instead of an
+	// image.YCbCr that image/color converts as full range BT.601
+	ColourConvert bool
+	// SkipNonRefDeblocking leave pictures of nal_ref_idc 0 unfiltered. No picture is
+	// predicted from them so the error does not propagate, but the output is no longer
+	// the conforming one. For previews where decoding speed matters most.
+	SkipNonRefDeblocking bool
+}
+
+// NewH264Decoder return a new H264Decoder read annex b bit stream from src
+func NewH264Decoder(src io.Reader) *H264Decoder {
+	ps := NewParameterSetStore()
+	return &H264Decoder{aur: NewAccessUnitReader(NewBitStream(src), ps), ps: ps, dec: decoder.New()}
+}
+
+// NewH264DecoderWithFile return a new H264Decoder read annex b bit stream from file
+func NewH264DecoderWithFile(filePath string) (*H264Decoder, error) {
+	hd := &H264Decoder{ps: NewParameterSetStore()}
+	if err := hd.InitWithFile(filePath); err != nil {
+		return nil, err
+	}
+	return hd, nil
+}
+
+// InitWithFile reset decoder to read bit stream from file
+func (hd *H264Decoder) InitWithFile(filePath string) error {
+	iFile, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	hd.aur = NewAccessUnitReader(NewBitStream(iFile), hd.ps)
+	hd.dec = decoder.New()
+	hd.flushed = false
+	return nil
+}
+
+// SPS return the active sequence parameter set, nil before first slice
+func (hd *H264Decoder) SPS() *SPS {
+	return hd.ps.ActiveSps()
+}
+
+// PPS return the active picture parameter set, nil before first slice
+func (hd *H264Decoder) PPS() *PPS {
+	return hd.ps.ActivePps()
+}
+
+// ParameterSets return all parameter sets received by decoder,
+// set its OnChange to get notified of parameter set replacement
+func (hd *H264Decoder) ParameterSets() *ParameterSetStore {
+	return hd.ps
+}
+
+// NextFrame decode access units until the next frame is available, io.EOF at end
+// of stream
+func (hd *H264Decoder) NextFrame() (image.Image, error) {
+	f, err := hd.nextFrame()
+	if err != nil {
+		return nil, err
+	}
+	if hd.ColourConvert {
+		return colour.RGBA64(f), nil
+	}
+	return f.Image(), nil
+}
+
+// nextFrame decode access units until the decoder outputs a frame, flushing it at
+// end of stream
+func (hd *H264Decoder) nextFrame() (*frame.VideoFrame, error) {
+	for {
+		if f := hd.dec.Output(); f != nil {
+			return f, nil
+		}
+		if hd.flushed {
+			return nil, io.EOF
+		}
+		au, err := hd.aur.Next()
+		if err == io.EOF {
+			hd.dec.Flush()
+			hd.flushed = true
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(au.Sei) > 0 && hd.OnSei != nil {
+			hd.OnSei(au.Sei)
+		}
+		if au.SeiErr != nil {
+			return nil, au.SeiErr
+		}
+		if err := hd.decode(au); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// decode the primary coded picture of au
+func (hd *H264Decoder) decode(au *AccessUnit) error {
+	l := logger.Log
+	l.Printf("got access unit of %v nalus, idr = %v", len(au.Nalus), au.IDR)
+	if len(au.Primary) == 0 {
+		return nil
+	}
+	slices := make([]decoder.Slice, 0, len(au.Primary))
+	for _, s := range au.Primary {
+		l.Printf("got slice header %v", s.Header)
+		slices = append(slices, decoder.Slice{Header: s.Header, Rbsp: s.Nalus[0].Rbsp()})
+	}
+	hd.dec.Deblock.SkipNonRef = hd.SkipNonRefDeblocking
+	return hd.dec.Decode(slices)
+}
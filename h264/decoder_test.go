@@ -0,0 +1,171 @@
+package h264
+
+import (
+	"bytes"
+	"image"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/LiveStudioSolution/h264decoder/internal"
+	"github.com/LiveStudioSolution/h264decoder/internal/rbr"
+	"github.com/LiveStudioSolution/h264decoder/internal/slice"
+)
+
+func TestNextFrameIDR(t *testing.T) {
+	hd, err := NewH264DecoderWithFile("../docs/videosamples/txjg.h264")
+	if err != nil {
+		t.Skip(err)
+	}
+	im, err := hd.NextFrame()
+	if err != nil {
+		t.Fatalf("NextFrame() error = %v", err)
+	}
+	if im == nil {
+		t.Fatalf("NextFrame() = nil image")
+	}
+	if b := im.Bounds(); b.Dx() != 640 || b.Dy() != 360 {
+		t.Errorf("NextFrame() image bounds %v", b)
+	}
+}
+
+func TestNextFrameStream(t *testing.T) {
+	hd, err := NewH264DecoderWithFile("../docs/videosamples/txjg.h264")
+	if err != nil {
+		t.Skip(err)
+	}
+	n := 0
+	for {
+		im, err := hd.NextFrame()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("NextFrame() of frame %v error = %v", n, err)
+		}
+		if im == nil {
+			t.Fatalf("NextFrame() of frame %v = nil image", n)
+		}
+		n++
+	}
+	if n != 150 {
+		t.Errorf("NextFrame() returned %v frames, want 150", n)
+	}
+	if _, err := hd.NextFrame(); err != io.EOF {
+		t.Errorf("NextFrame() after the last frame error = %v, want io.EOF", err)
+	}
+}
+
+func TestNextFrameColourConvert(t *testing.T) {
+	hd, err := NewH264DecoderWithFile("../docs/videosamples/txjg.h264")
+	if err != nil {
+		t.Skip(err)
+	}
+	hd.ColourConvert = true
+	im, err := hd.NextFrame()
+	if err != nil {
+		t.Fatalf("NextFrame() error = %v", err)
+	}
+	rgba, ok := im.(*image.RGBA64)
+	if !ok {
+		t.Fatalf("NextFrame() = %T, want *image.RGBA64", im)
+	}
+	if b := rgba.Bounds(); b.Dx() != 640 || b.Dy() != 360 {
+		t.Errorf("NextFrame() image bounds %v", b)
+	}
+}
+
+// nonRefSample txjg.h264 with its last picture rewritten as a non-reference picture,
+// nal_ref_idc 0 and no dec_ref_pic_marking
+func nonRefSample(t *testing.T) []byte {
+	f, err := os.Open("../docs/videosamples/txjg.h264")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer f.Close()
+	bs := NewBitStream(f)
+	ps := NewParameterSetStore()
+	var nalus []*Nalu
+	for {
+		nl, err := bs.NextNalu()
+		if err == io.EOF || err == nil && nl == nil {
+			break
+		}
+		if err != nil {
+			t.Fatalf("NextNalu() error = %v", err)
+		}
+		switch nl.Type() {
+		case NaluSps:
+			_, err = ps.PutSps(nl.Rbsp())
+		case NaluPps:
+			_, err = ps.PutPps(nl.Rbsp())
+		}
+		if err != nil {
+			t.Fatalf("parameter set error = %v", err)
+		}
+		nalus = append(nalus, nl)
+	}
+	var stream []byte
+	for _, nl := range nalus[:len(nalus)-1] {
+		stream = append(stream, 0, 0, 0, 1, nl.RefIdc()<<5|uint8(nl.Type()))
+		stream = append(stream, internal.RBSPToEBSP(nl.Rbsp())...)
+	}
+
+	last := nalus[len(nalus)-1]
+	h, err := slice.ParseHeader(last, ps)
+	if err != nil || h.SliceType != slice.SliceP || h.NumRefIdxActiveOverrideFlag ||
+		h.RefPicListModificationFlagL0 || h.DecRefPicMarking.AdaptiveRefPicMarkingModeFlag {
+		t.Fatalf("last slice %+v, %v, want a P slice of the default lists and marking", h, err)
+	}
+	w := rbr.NewWriter()
+	w.WriteUe(h.FirstMbInSlice)
+	w.WriteUe(h.RawSliceType)
+	w.WriteUe(h.PicParameterSetId)
+	w.WriteBits(uint64(h.FrameNum), h.SPS.Log2MaxFrameNumMinus4+4)
+	w.WriteFlag(false) // num_ref_idx_active_override_flag
+	w.WriteFlag(false) // ref_pic_list_modification_flag_l0
+	w.WriteSe(h.SliceQpDelta)
+	w.WriteUe(h.DisableDeblockingFilterIdc)
+	if h.DisableDeblockingFilterIdc != 1 {
+		w.WriteSe(h.SliceAlphaC0OffsetDiv2)
+		w.WriteSe(h.SliceBetaOffsetDiv2)
+	}
+	// slice data up to rbsp_stop_one_bit, zero bits padding it to a byte
+	rbsp := last.Rbsp()
+	end := 8 * len(rbsp)
+	for rbsp[(end-1)/8]>>(7-(end-1)%8)&1 == 0 {
+		end--
+	}
+	for i := h.BitSize; i < end; i++ {
+		w.WriteBits(uint64(rbsp[i/8]>>(7-i%8)&1), 1)
+	}
+	stream = append(stream, 0, 0, 0, 1, uint8(NaluSlice))
+	return append(stream, internal.RBSPToEBSP(w.Bytes())...)
+}
+
+func TestSkipNonRefDeblocking(t *testing.T) {
+	stream := nonRefSample(t)
+	var last [2]*VideoFrame
+	for i, skip := range []bool{false, true} {
+		hd := NewH264Decoder(bytes.NewReader(stream))
+		hd.SkipNonRefDeblocking = skip
+		n := 0
+		for ; ; n++ {
+			f, err := hd.NextVideoFrame()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("skip %v: NextVideoFrame() of frame %v error = %v", skip, n, err)
+			}
+			last[i] = f
+		}
+		if n != 150 {
+			t.Fatalf("skip %v: %v frames, want 150", skip, n)
+		}
+	}
+	// only the non-reference picture is left unfiltered
+	if last[0].FrameNum != last[1].FrameNum || bytes.Equal(last[0].Planes[0].Pix, last[1].Planes[0].Pix) {
+		t.Errorf("last frame %v deblocked alike with and without SkipNonRefDeblocking", last[0].FrameNum)
+	}
+}
@@ -0,0 +1,5 @@
+// Package h264 is the public api of h264decoder.
+//
+// It exposes the annex b bit stream reader, nal units, sequence and picture
+// parameter sets and the H264Decoder built on top of them.
+package h264
@@ -0,0 +1,93 @@
+package h264_test
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/LiveStudioSolution/h264decoder/h264"
+)
+
+func ExampleBitStream_NextNalu() {
+	f, err := os.Open("../docs/videosamples/txjg.h264")
+	if err != nil {
+		fmt.Printf("open file error %v\n", err)
+		return
+	}
+	defer f.Close()
+
+	bs := h264.NewBitStream(f)
+	counts := map[h264.NaluType]int{}
+	for {
+		nl, err := bs.NextNalu()
+		if err != nil || nl == nil {
+			break
+		}
+		if counts[nl.Type()] == 0 {
+			fmt.Printf("first nalu type = %v, ref idc = %v, rbsp size = %v\n", nl.Type(), nl.RefIdc(), nl.RbspSize())
+		}
+		counts[nl.Type()]++
+	}
+	fmt.Printf("%v idr and %v non idr slices\n", counts[h264.NaluSliceIdr], counts[h264.NaluSlice])
+	// Output:
+	// first nalu type = NaluSps, ref idc = 3, rbsp size = 25
+	// first nalu type = NaluPps, ref idc = 3, rbsp size = 3
+	// first nalu type = NaluSliceIdr, ref idc = 3, rbsp size = 35894
+	// first nalu type = NaluSlice, ref idc = 2, rbsp size = 587
+	// 3 idr and 147 non idr slices
+}
+
+func ExampleParseSpsFromRBSP() {
+	f, err := os.Open("../docs/videosamples/txjg.h264")
+	if err != nil {
+		fmt.Printf("open file error %v\n", err)
+		return
+	}
+	defer f.Close()
+
+	bs := h264.NewBitStream(f)
+	for {
+		nl, err := bs.NextNalu()
+		if err != nil || nl == nil {
+			return
+		}
+		if nl.Type() != h264.NaluSps {
+			continue
+		}
+		sps, err := h264.ParseSpsFromRBSP(nl.Rbsp())
+		if err != nil {
+			fmt.Printf("parse sps error %v\n", err)
+			return
+		}
+		fmt.Printf("got sps id = %v, profile = %v, level = %v, display %v\n", sps.Id, sps.ProfileIdc, sps.LevelIdc, sps.CropRect())
+		return
+	}
+	// Output:
+	// got sps id = 0, profile = 66, level = 30, display (0,0)-(640,360)
+}
+
+func ExampleH264Decoder_NextFrame() {
+	hd, err := h264.NewH264DecoderWithFile("../docs/videosamples/txjg.h264")
+	if err != nil {
+		fmt.Printf("NewH264DecoderWithFile error:%v\n", err)
+		return
+	}
+	n := 0
+	for ; ; n++ {
+		frame, err := hd.NextFrame()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fmt.Printf("NextFrame error %v\n", err)
+			return
+		}
+		if n == 0 {
+			fmt.Printf("got frame %v\n", frame.Bounds())
+		}
+	}
+	fmt.Printf("decoded %v frames\n", n)
+	// Output:
+	// got frame (0,0)-(640,360)
+	// decoded 150 frames
+}
@@ -0,0 +1,28 @@
+package h264
+
+import (
+	"github.com/LiveStudioSolution/h264decoder/internal/frame"
+)
+
+// VideoFrame decoded frame in output order, planar Y, Cb and Cr samples with its crop
+// window, picture order count and key frame flag
+type VideoFrame = frame.VideoFrame
+
+// VideoPlane samples of one colour component of a VideoFrame
+type VideoPlane = frame.VideoPlane
+
+// ChromaFormat chroma sampling of a VideoFrame, chroma_format_idc
+type ChromaFormat = frame.ChromaFormat
+
+const (
+	Monochrome = frame.Monochrome
+	Chroma420  = frame.Chroma420
+	Chroma422  = frame.Chroma422
+	Chroma444  = frame.Chroma444
+)
+
+// NextVideoFrame decode access units until the next frame is available, io.EOF at end
+// of stream. Unlike NextFrame it keeps the planes at their bit depth.
+func (hd *H264Decoder) NextVideoFrame() (*VideoFrame, error) {
+	return hd.nextFrame()
+}
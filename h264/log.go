@@ -0,0 +1,17 @@
+package h264
+
+import (
+	"log"
+
+	"github.com/LiveStudioSolution/h264decoder/internal/logger"
+)
+
+// SetLogger set the logger used by decoder, logs are discarded by default
+func SetLogger(l *log.Logger) {
+	logger.SetLogger(l)
+}
+
+// EnableStderrLog write decoder logs to stderr
+func EnableStderrLog() {
+	logger.EnableStderrLog()
+}
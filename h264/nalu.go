@@ -0,0 +1,61 @@
+package h264
+
+import (
+	"io"
+
+	"github.com/LiveStudioSolution/h264decoder/internal"
+)
+
+// NaluType of h264 codec, see Table 7-1
+type NaluType = internal.NaluType
+
+// ITU-T Rec. H.264 (05/2003) page 48
+// Table 7-1 – NAL unit type codes
+const (
+	NaluUnspecified = internal.NaluUnspecified
+	NaluSlice       = internal.NaluSlice
+	NaluSliceDpa    = internal.NaluSliceDpa
+	NaluSliceDpb    = internal.NaluSliceDpb
+	NaluSliceDpc    = internal.NaluSliceDpc
+	NaluSliceIdr    = internal.NaluSliceIdr
+	NaluSei         = internal.NaluSei
+	NaluSps         = internal.NaluSps
+	NaluPps         = internal.NaluPps
+	NaluAud         = internal.NaluAud
+	NaluEoseq       = internal.NaluEoseq
+	NaluEostream    = internal.NaluEostream
+	NaluFiller      = internal.NaluFiller
+	// extended types, skipped by H264Decoder
+	NaluSpsExt        = internal.NaluSpsExt
+	NaluPrefix        = internal.NaluPrefix
+	NaluSubsetSps     = internal.NaluSubsetSps
+	NaluDps           = internal.NaluDps
+	NaluSliceAux      = internal.NaluSliceAux
+	NaluSliceExt      = internal.NaluSliceExt
+	NaluSliceExtDepth = internal.NaluSliceExtDepth
+)
+
+// NaluHeaderSvcExtension nal_unit_header_svc_extension of prefix and slice extension nalus
+type NaluHeaderSvcExtension = internal.NaluHeaderSvcExtension
+
+// NaluHeaderMvcExtension nal_unit_header_mvc_extension of prefix and slice extension nalus
+type NaluHeaderMvcExtension = internal.NaluHeaderMvcExtension
+
+// NaluHeader3davcExtension nal_unit_header_3davc_extension of depth slice extension nalus
+type NaluHeader3davcExtension = internal.NaluHeader3davcExtension
+
+// Nalu nal unit of h264 codec, use Type, RefIdc and Rbsp to access its fields
+type Nalu = internal.Nalu
+
+// BitStream annex b byte stream reader, split src into nalus
+type BitStream = internal.BitStream
+
+// NewBitStream return a new BitStream read from src
+func NewBitStream(src io.Reader) *BitStream {
+	return internal.NewBitStream(src)
+}
+
+// NewNalu create new Nalu, call Load to fill it from nal unit bytes
+func NewNalu() *Nalu {
+	return internal.NewNalu()
+}
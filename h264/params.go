@@ -0,0 +1,53 @@
+package h264
+
+import (
+	"github.com/LiveStudioSolution/h264decoder/internal"
+)
+
+// SPS sequence parameter set, 7.3.2.1.1
+type SPS = internal.SPS
+
+// VuiParameters vui parameters carried by SPS, Annex E.1.1
+type VuiParameters = internal.VuiParameters
+
+// HrdParameters hrd parameters carried by VuiParameters, Annex E.1.2
+type HrdParameters = internal.HrdParameters
+
+// FrameCrop frame cropping offsets of SPS
+type FrameCrop = internal.FrameCrop
+
+// PPS picture parameter set, 7.3.2.2
+type PPS = internal.PPS
+
+// ParameterSetStore hold received parameter sets by id and activate them, 7.4.1.2.1
+type ParameterSetStore = internal.ParameterSetStore
+
+// ParameterSetChange report of a parameter set replaced with different content
+type ParameterSetChange = internal.ParameterSetChange
+
+// MissingParameterSetError error of a slice whose pps or sps was not received
+type MissingParameterSetError = internal.MissingParameterSetError
+
+// NewParameterSetStore return an empty ParameterSetStore
+func NewParameterSetStore() *ParameterSetStore {
+	return internal.NewParameterSetStore()
+}
+
+// ParseSpsFromRBSP parse sequence parameter set from sps nalu rbsp
+func ParseSpsFromRBSP(rbsp []byte) (*SPS, error) {
+	return internal.ParseSpsFromRBSP(rbsp)
+}
+
+// ParsePpsFromRBSP parse picture parameter set from pps nalu rbsp
+func ParsePpsFromRBSP(rbsp []byte) (*PPS, error) {
+	return internal.ParsePpsFromRBSP(rbsp)
+}
+
+// SpsLookup return the sps of seq_parameter_set_id, nil when not received
+type SpsLookup = internal.SpsLookup
+
+// ParsePpsFromRBSPWithSps parse picture parameter set, looking up the sps it
+// refers to for the high profile pic scaling lists
+func ParsePpsFromRBSPWithSps(rbsp []byte, lookup SpsLookup) (*PPS, error) {
+	return internal.ParsePpsFromRBSPWithSps(rbsp, lookup)
+}
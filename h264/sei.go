@@ -0,0 +1,39 @@
+package h264
+
+import (
+	"github.com/LiveStudioSolution/h264decoder/internal/sei"
+)
+
+// SeiMessage sei_message with its typed payload, Annex D
+type SeiMessage = sei.Message
+
+// SeiPayloadType payloadType of SeiMessage
+type SeiPayloadType = sei.PayloadType
+
+// sei payload types decoded into typed payloads
+const (
+	SeiBufferingPeriod              = sei.PayloadBufferingPeriod
+	SeiPicTiming                    = sei.PayloadPicTiming
+	SeiUserDataRegistered           = sei.PayloadUserDataRegistered
+	SeiUserDataUnregistered         = sei.PayloadUserDataUnregistered
+	SeiRecoveryPoint                = sei.PayloadRecoveryPoint
+	SeiMasteringDisplayColourVolume = sei.PayloadMasteringDisplayColourVolume
+	SeiContentLightLevelInfo        = sei.PayloadContentLightLevelInfo
+)
+
+// typed payloads of SeiMessage
+type (
+	BufferingPeriod              = sei.BufferingPeriod
+	PicTiming                    = sei.PicTiming
+	ClockTimestamp               = sei.ClockTimestamp
+	RecoveryPoint                = sei.RecoveryPoint
+	UserDataRegistered           = sei.UserDataRegistered
+	UserDataUnregistered         = sei.UserDataUnregistered
+	MasteringDisplayColourVolume = sei.MasteringDisplayColourVolume
+	ContentLightLevelInfo        = sei.ContentLightLevelInfo
+)
+
+// ParseSei split a sei nalu into messages, using ps for hrd lengths of pic_timing
+func ParseSei(nalu *Nalu, ps *ParameterSetStore) ([]SeiMessage, error) {
+	return sei.Parse(nalu, ps)
+}
@@ -0,0 +1,46 @@
+package cabac
+
+import "github.com/LiveStudioSolution/h264decoder/internal/slice"
+
+// mn initialisation variables of a context variable
+type mn struct {
+	m, n int8
+}
+
+// context probability state of a context variable
+type context struct {
+	pStateIdx uint8
+	valMPS    uint8
+}
+
+type contextTable [1024]context
+
+// init initialise all context variables of a slice, 9.3.1.1.
+// Context variables not used by slices of sliceType are initialised too, they are never read.
+func (t *contextTable) init(sliceType slice.SliceType, cabacInitIdc uint, sliceQPY int) {
+	col := 0
+	if !sliceType.IsIntra() {
+		col = 1 + int(cabacInitIdc)
+	}
+	qp := clip3(0, 51, sliceQPY)
+	for ctxIdx := range t {
+		v := contextInitTable[ctxIdx][col]
+		preCtxState := clip3(1, 126, ((int(v.m)*qp)>>4)+int(v.n))
+		if preCtxState <= 63 {
+			t[ctxIdx] = context{pStateIdx: uint8(63 - preCtxState), valMPS: 0}
+		} else {
+			t[ctxIdx] = context{pStateIdx: uint8(preCtxState - 64), valMPS: 1}
+		}
+	}
+	t[ctxIdxTerminate] = context{pStateIdx: 63, valMPS: 0}
+}
+
+func clip3(x, y, z int) int {
+	if z < x {
+		return x
+	}
+	if z > y {
+		return y
+	}
+	return z
+}
@@ -0,0 +1,156 @@
+// Package cabac decode slice data syntax elements coded with CABAC,
+// T-REC-H.264-201402-S!!PDF-E.pdf 9.3 CABAC parsing process for slice data
+package cabac
+
+import (
+	"fmt"
+	"math/bits"
+
+	"github.com/LiveStudioSolution/h264decoder/internal/rbr"
+	"github.com/LiveStudioSolution/h264decoder/internal/slice"
+)
+
+// rangeTabLPS codIRangeLPS by pStateIdx and qCodIRangeIdx, Table 9-44
+var rangeTabLPS = [64][4]uint8{
+	{128, 176, 208, 240}, {128, 167, 197, 227}, {128, 158, 187, 216}, {123, 150, 178, 205},
+	{116, 142, 169, 195}, {111, 135, 160, 185}, {105, 128, 152, 175}, {100, 122, 144, 166},
+	{95, 116, 137, 158}, {90, 110, 130, 150}, {85, 104, 123, 142}, {81, 99, 117, 135},
+	{77, 94, 111, 128}, {73, 89, 105, 122}, {69, 85, 100, 116}, {66, 80, 95, 110},
+	{62, 76, 90, 104}, {59, 72, 86, 99}, {56, 69, 81, 94}, {53, 65, 77, 89},
+	{51, 62, 73, 85}, {48, 59, 69, 80}, {46, 56, 66, 76}, {43, 53, 63, 72},
+	{41, 50, 59, 69}, {39, 48, 56, 65}, {37, 45, 54, 62}, {35, 43, 51, 59},
+	{33, 41, 48, 56}, {32, 39, 46, 53}, {30, 37, 43, 50}, {29, 35, 41, 48},
+	{27, 33, 39, 45}, {26, 31, 37, 43}, {24, 30, 35, 41}, {23, 28, 33, 39},
+	{22, 27, 32, 37}, {21, 26, 30, 35}, {20, 24, 29, 33}, {19, 23, 27, 31},
+	{18, 22, 26, 30}, {17, 21, 25, 28}, {16, 20, 23, 27}, {15, 19, 22, 25},
+	{14, 18, 21, 24}, {14, 17, 20, 23}, {13, 16, 19, 22}, {12, 15, 18, 21},
+	{12, 14, 17, 20}, {11, 14, 16, 19}, {11, 13, 15, 18}, {10, 12, 15, 17},
+	{10, 12, 14, 16}, {9, 11, 13, 15}, {9, 11, 12, 14}, {8, 10, 12, 14},
+	{8, 9, 11, 13}, {7, 9, 11, 12}, {7, 9, 10, 12}, {7, 8, 10, 11},
+	{6, 8, 9, 11}, {6, 7, 9, 10}, {6, 7, 8, 9}, {2, 2, 2, 2},
+}
+
+// transIdxLPS and transIdxMPS state transition after decoding a LPS or MPS, Table 9-45
+var transIdxLPS = [64]uint8{
+	0, 0, 1, 2, 2, 4, 4, 5, 6, 7, 8, 9, 9, 11, 11, 12,
+	13, 13, 15, 15, 16, 16, 18, 18, 19, 19, 21, 21, 22, 22, 23, 24,
+	24, 25, 26, 26, 27, 27, 28, 29, 29, 30, 30, 30, 31, 32, 32, 33,
+	33, 33, 34, 34, 35, 35, 35, 36, 36, 36, 37, 37, 37, 38, 38, 63,
+}
+
+var transIdxMPS = [64]uint8{
+	1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16,
+	17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32,
+	33, 34, 35, 36, 37, 38, 39, 40, 41, 42, 43, 44, 45, 46, 47, 48,
+	49, 50, 51, 52, 53, 54, 55, 56, 57, 58, 59, 60, 61, 62, 62, 63,
+}
+
+// ctxIdxTerminate context of end_of_slice_flag and of the mb_type bin indicating I_PCM
+const ctxIdxTerminate = 276
+
+// Decoder CABAC arithmetic decoding engine together with the context variables of a slice
+type Decoder struct {
+	br         *rbr.Reader
+	codIRange  uint32
+	codIOffset uint32
+	sliceType  slice.SliceType
+	ctx        contextTable
+}
+
+// NewDecoder initialise the context variables for the slice and the decoding engine,
+// br must be positioned at the first bit after cabac_alignment_one_bit, 9.3.1
+func NewDecoder(br *rbr.Reader, sliceType slice.SliceType, cabacInitIdc uint, sliceQPY int) (*Decoder, error) {
+	if cabacInitIdc > 2 {
+		return nil, fmt.Errorf("invalid cabac_init_idc %v", cabacInitIdc)
+	}
+	d := &Decoder{br: br, sliceType: sliceType}
+	d.ctx.init(sliceType, cabacInitIdc, sliceQPY)
+	if err := d.InitEngine(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// InitEngine initialise the arithmetic decoding engine, invoked at the start of slice
+// data and again after the pcm samples of an I_PCM macroblock, 9.3.1.2
+func (d *Decoder) InitEngine() error {
+	v, err := d.br.Read32(9)
+	if err != nil {
+		return fmt.Errorf("cabac init: %v", err)
+	}
+	if v == 510 || v == 511 {
+		return fmt.Errorf("invalid codIOffset %v", v)
+	}
+	d.codIRange, d.codIOffset = 510, v
+	return nil
+}
+
+// Reader return the bit reader of slice data, positioned after the last bit consumed
+// by the engine. After DecodeTerminate returned 1 it points right behind the
+// rbsp_stop_one_bit or at pcm_alignment_zero_bit.
+func (d *Decoder) Reader() *rbr.Reader {
+	return d.br
+}
+
+// DecodeDecision decode a bin with context ctxIdx, 9.3.3.2.1
+func (d *Decoder) DecodeDecision(ctxIdx int) (int, error) {
+	c := &d.ctx[ctxIdx]
+	codIRangeLPS := uint32(rangeTabLPS[c.pStateIdx][(d.codIRange>>6)&3])
+	d.codIRange -= codIRangeLPS
+	var binVal int
+	if d.codIOffset >= d.codIRange {
+		binVal = int(1 - c.valMPS)
+		d.codIOffset -= d.codIRange
+		d.codIRange = codIRangeLPS
+		if c.pStateIdx == 0 {
+			c.valMPS = 1 - c.valMPS
+		}
+		c.pStateIdx = transIdxLPS[c.pStateIdx]
+	} else {
+		binVal = int(c.valMPS)
+		c.pStateIdx = transIdxMPS[c.pStateIdx]
+	}
+	return binVal, d.renorm()
+}
+
+// DecodeBypass decode a bin with uniform probability, 9.3.3.2.3
+func (d *Decoder) DecodeBypass() (int, error) {
+	b, err := d.br.Read1()
+	if err != nil {
+		return 0, fmt.Errorf("cabac bypass: %v", err)
+	}
+	d.codIOffset <<= 1
+	if b {
+		d.codIOffset |= 1
+	}
+	if d.codIOffset >= d.codIRange {
+		d.codIOffset -= d.codIRange
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// DecodeTerminate decode end_of_slice_flag or the bin indicating I_PCM, 9.3.3.2.4.
+// No renormalization is carried out when it returns 1.
+func (d *Decoder) DecodeTerminate() (int, error) {
+	d.codIRange -= 2
+	if d.codIOffset >= d.codIRange {
+		return 1, nil
+	}
+	return 0, d.renorm()
+}
+
+// renorm RenormD, 9.3.3.2.2
+func (d *Decoder) renorm() error {
+	if d.codIRange >= 0x100 {
+		return nil
+	}
+	n := uint(bits.LeadingZeros32(d.codIRange) - 23)
+	v, err := d.br.Read32(n)
+	if err != nil {
+		return fmt.Errorf("cabac renorm: %v", err)
+	}
+	d.codIRange <<= n
+	d.codIOffset = d.codIOffset<<n | v
+	return nil
+}
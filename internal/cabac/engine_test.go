@@ -0,0 +1,233 @@
+package cabac
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/LiveStudioSolution/h264decoder/internal/rbr"
+	"github.com/LiveStudioSolution/h264decoder/internal/slice"
+)
+
+// encoder arithmetic encoding engine of the informative 9.3.4, producing the
+// synthetic slice data the tests decode
+type encoder struct {
+	w               *rbr.Writer
+	codILow         uint32
+	codIRange       uint32
+	firstBitFlag    bool
+	bitsOutstanding int
+	ctx             contextTable
+}
+
+func newEncoder(sliceType slice.SliceType, cabacInitIdc uint, sliceQPY int) *encoder {
+	e := &encoder{w: rbr.NewWriter()}
+	e.ctx.init(sliceType, cabacInitIdc, sliceQPY)
+	e.init()
+	return e
+}
+
+// init InitEncoder, 9.3.4.1
+func (e *encoder) init() {
+	e.codILow, e.codIRange = 0, 510
+	e.firstBitFlag, e.bitsOutstanding = true, 0
+}
+
+// encodeDecision EncodeDecision, 9.3.4.2
+func (e *encoder) encodeDecision(ctxIdx int, binVal int) {
+	c := &e.ctx[ctxIdx]
+	codIRangeLPS := uint32(rangeTabLPS[c.pStateIdx][(e.codIRange>>6)&3])
+	e.codIRange -= codIRangeLPS
+	if binVal != int(c.valMPS) {
+		e.codILow += e.codIRange
+		e.codIRange = codIRangeLPS
+		if c.pStateIdx == 0 {
+			c.valMPS = 1 - c.valMPS
+		}
+		c.pStateIdx = transIdxLPS[c.pStateIdx]
+	} else {
+		c.pStateIdx = transIdxMPS[c.pStateIdx]
+	}
+	e.renorm()
+}
+
+// renorm RenormE, 9.3.4.3
+func (e *encoder) renorm() {
+	for e.codIRange < 256 {
+		if e.codILow < 256 {
+			e.putBit(0)
+		} else if e.codILow >= 512 {
+			e.codILow -= 512
+			e.putBit(1)
+		} else {
+			e.codILow -= 256
+			e.bitsOutstanding++
+		}
+		e.codIRange <<= 1
+		e.codILow <<= 1
+	}
+}
+
+// putBit PutBit, 9.3.4.3
+func (e *encoder) putBit(b int) {
+	if e.firstBitFlag {
+		e.firstBitFlag = false
+	} else {
+		e.w.WriteBits(uint64(b), 1)
+	}
+	for ; e.bitsOutstanding > 0; e.bitsOutstanding-- {
+		e.w.WriteBits(uint64(1-b), 1)
+	}
+}
+
+// encodeBypass EncodeBypass, 9.3.4.4
+func (e *encoder) encodeBypass(binVal int) {
+	e.codILow <<= 1
+	if binVal != 0 {
+		e.codILow += e.codIRange
+	}
+	if e.codILow >= 1024 {
+		e.putBit(1)
+		e.codILow -= 1024
+	} else if e.codILow < 512 {
+		e.putBit(0)
+	} else {
+		e.codILow -= 512
+		e.bitsOutstanding++
+	}
+}
+
+// encodeTerminate EncodeTerminate and EncodeFlush, 9.3.4.5
+func (e *encoder) encodeTerminate(binVal int) {
+	e.codIRange -= 2
+	if binVal == 0 {
+		e.renorm()
+		return
+	}
+	e.codILow += e.codIRange
+	e.codIRange = 2
+	e.renorm()
+	e.putBit(int(e.codILow>>9) & 1)
+	e.w.WriteBits(uint64((e.codILow>>7)&3|1), 2)
+}
+
+func TestContextInit(t *testing.T) {
+	tests := []struct {
+		name         string
+		sliceType    slice.SliceType
+		cabacInitIdc uint
+		sliceQPY     int
+		ctxIdx       int
+		want         context
+	}{
+		{"mb_type SI", slice.SliceSI, 0, 26, 0, context{46, 0}},
+		{"mb_skip_flag P idc 0", slice.SliceP, 0, 26, 11, context{6, 1}},
+		{"mb_skip_flag B idc 2", slice.SliceB, 2, 30, 26, context{9, 0}},
+		{"negative m", slice.SliceI, 0, 51, 6, context{26, 0}},
+		{"SliceQPY clipped to 51", slice.SliceI, 0, 60, 6, context{26, 0}},
+		{"negative SliceQPY clipped to 0", slice.SliceP, 1, -12, 277, context{62, 1}},
+		{"preCtxState clipped to 126", slice.SliceI, 0, 51, 189, context{62, 1}},
+		{"preCtxState clipped to 1", slice.SliceI, 0, 51, 408, context{62, 0}},
+		{"terminate", slice.SliceB, 1, 26, 276, context{63, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ct contextTable
+			ct.init(tt.sliceType, tt.cabacInitIdc, tt.sliceQPY)
+			if got := ct[tt.ctxIdx]; got != tt.want {
+				t.Errorf("init() ctx[%v] = %+v, want %+v", tt.ctxIdx, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewDecoder(t *testing.T) {
+	if _, err := NewDecoder(rbr.NewReader([]byte{0, 0}), slice.SliceP, 3, 26); err == nil {
+		t.Errorf("NewDecoder() cabac_init_idc 3 error = nil")
+	}
+	if _, err := NewDecoder(rbr.NewReader([]byte{0xff, 0x80}), slice.SliceI, 0, 26); err == nil {
+		t.Errorf("NewDecoder() codIOffset 511 error = nil")
+	}
+	if _, err := NewDecoder(rbr.NewReader([]byte{0}), slice.SliceI, 0, 26); err == nil {
+		t.Errorf("NewDecoder() short slice data error = nil")
+	}
+}
+
+// engineOp one bin of the engine round trip, kind 0 decision, 1 bypass, 2 terminate
+type engineOp struct {
+	kind, ctxIdx, bin int
+}
+
+func TestEngineRoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(13))
+	for n := 0; n < 200; n++ {
+		sliceType := slice.SliceType(rnd.Intn(5))
+		cabacInitIdc, sliceQPY := uint(rnd.Intn(3)), rnd.Intn(52)
+		e := newEncoder(sliceType, cabacInitIdc, sliceQPY)
+		e.w.WriteBits(0x5, 3) // bits before slice data are left alone
+
+		// skewed bins per context so that both the MPS and LPS paths are taken
+		skew := rnd.Float64()
+		ops := make([]engineOp, 1+rnd.Intn(3000))
+		for i := range ops {
+			op := engineOp{kind: rnd.Intn(10)}
+			switch {
+			case op.kind < 7:
+				op.kind = 0
+				op.ctxIdx = rnd.Intn(8)
+				if rnd.Intn(2) == 0 {
+					op.ctxIdx = rnd.Intn(1024)
+				}
+				if op.ctxIdx == ctxIdxTerminate {
+					op.ctxIdx++
+				}
+				if rnd.Float64() < skew {
+					op.bin = 1
+				}
+			case op.kind < 9:
+				op.kind, op.bin = 1, rnd.Intn(2)
+			default:
+				op.kind = 2
+			}
+			ops[i] = op
+		}
+		for _, op := range ops {
+			switch op.kind {
+			case 0:
+				e.encodeDecision(op.ctxIdx, op.bin)
+			case 1:
+				e.encodeBypass(op.bin)
+			case 2:
+				e.encodeTerminate(0)
+			}
+		}
+		e.encodeTerminate(1)
+		size := e.w.BitPos()
+
+		br := rbr.NewReader(e.w.Bytes())
+		br.Skip(3)
+		d, err := NewDecoder(br, sliceType, cabacInitIdc, sliceQPY)
+		if err != nil {
+			t.Fatalf("NewDecoder() error = %v", err)
+		}
+		for i, op := range ops {
+			var bin int
+			switch op.kind {
+			case 0:
+				bin, err = d.DecodeDecision(op.ctxIdx)
+			case 1:
+				bin, err = d.DecodeBypass()
+			case 2:
+				bin, err = d.DecodeTerminate()
+			}
+			if err != nil || bin != op.bin {
+				t.Fatalf("round %v: bin %v %+v decoded %v, %v", n, i, op, bin, err)
+			}
+		}
+		if bin, err := d.DecodeTerminate(); err != nil || bin != 1 {
+			t.Fatalf("round %v: DecodeTerminate() = %v, %v, want 1", n, bin, err)
+		}
+		if br.BitPos() != size {
+			t.Fatalf("round %v: decoder stopped at bit %v, want %v after rbsp_stop_one_bit", n, br.BitPos(), size)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package cabac
+
+import "fmt"
+
+// ctxBlockCat of the different transform coefficient blocks, Table 9-42
+const (
+	CatLumaDC = iota
+	CatLumaAC
+	CatLuma4x4
+	CatChromaDC
+	CatChromaAC
+	CatLuma8x8
+	CatCbDC
+	CatCbAC
+	CatCb4x4
+	CatCb8x8
+	CatCrDC
+	CatCrAC
+	CatCr4x4
+	CatCr8x8
+)
+
+// residualCtx ctxIdxOffset + ctxIdxBlockCatOffset of the residual syntax elements
+// for one ctxBlockCat, Table 9-34 and Table 9-40
+type residualCtx struct {
+	codedBlockFlag int
+	sigFrame       int
+	sigField       int
+	lastFrame      int
+	lastField      int
+	absLevel       int
+}
+
+var residualCtxs = [14]residualCtx{
+	{85 + 0, 105 + 0, 277 + 0, 166 + 0, 338 + 0, 227 + 0},
+	{85 + 4, 105 + 15, 277 + 15, 166 + 15, 338 + 15, 227 + 10},
+	{85 + 8, 105 + 29, 277 + 29, 166 + 29, 338 + 29, 227 + 20},
+	{85 + 12, 105 + 44, 277 + 44, 166 + 44, 338 + 44, 227 + 30},
+	{85 + 16, 105 + 47, 277 + 47, 166 + 47, 338 + 47, 227 + 39},
+	{1012 + 0, 402, 436, 417, 451, 426},
+	{460 + 0, 484 + 0, 776 + 0, 572 + 0, 864 + 0, 952 + 0},
+	{460 + 4, 484 + 15, 776 + 15, 572 + 15, 864 + 15, 952 + 10},
+	{460 + 8, 484 + 29, 776 + 29, 572 + 29, 864 + 29, 952 + 20},
+	{1012 + 4, 660, 675, 690, 699, 708},
+	{472 + 0, 528 + 0, 820 + 0, 616 + 0, 908 + 0, 982 + 0},
+	{472 + 4, 528 + 15, 820 + 15, 616 + 15, 908 + 15, 982 + 10},
+	{472 + 8, 528 + 29, 820 + 29, 616 + 29, 908 + 29, 982 + 20},
+	{1012 + 8, 718, 733, 748, 757, 766},
+}
+
+// sigCoeffFlagOffset8x8 ctxIdxInc of significant_coeff_flag in frame and field coded
+// 8x8 blocks and of last_significant_coeff_flag by levelListIdx, Table 9-43
+var sigCoeffFlagOffset8x8 = [3][63]uint8{
+	{
+		0, 1, 2, 3, 4, 5, 5, 4, 4, 3, 3, 4, 4, 4, 5, 5,
+		4, 4, 4, 4, 3, 3, 6, 7, 7, 7, 8, 9, 10, 9, 8, 7,
+		7, 6, 11, 12, 13, 11, 6, 7, 8, 9, 14, 10, 9, 8, 6, 11,
+		12, 13, 11, 6, 9, 14, 10, 9, 11, 12, 13, 11, 14, 10, 12,
+	},
+	{
+		0, 1, 1, 2, 2, 3, 3, 4, 5, 6, 7, 7, 7, 8, 4, 5,
+		6, 9, 10, 10, 8, 11, 12, 11, 9, 9, 10, 10, 8, 11, 12, 11,
+		9, 9, 10, 10, 8, 11, 12, 11, 9, 9, 10, 10, 8, 13, 13, 9,
+		9, 10, 10, 8, 13, 13, 9, 9, 10, 10, 14, 14, 14, 14, 14,
+	},
+	{
+		0, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+		2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2,
+		3, 3, 3, 3, 3, 3, 3, 3, 4, 4, 4, 4, 4, 4, 4, 4,
+		5, 5, 5, 5, 6, 6, 6, 6, 7, 7, 7, 7, 8, 8, 8,
+	},
+}
+
+// ResidualBlock parse residual_block_cabac, coeffLevel[startIdx..endIdx] are set in
+// scan order and the others left untouched. len(coeffLevel) must be maxNumCoeff.
+// codedBlockFlagInc is condTermFlagA + 2*condTermFlagB of 9.3.3.1.1.9, or -1 when
+// coded_block_flag is not present, which is the case for 8x8 luma blocks unless
+// ChromaArrayType is 3. field selects the significance map contexts of field macroblocks.
+// Return the count of non-zero coefficients, 0 meaning coded_block_flag equal to 0.
+// T-REC-H.264-201402-S!!PDF-E.pdf 7.3.5.3.3 Residual block CABAC syntax
+func (d *Decoder) ResidualBlock(ctxBlockCat int, codedBlockFlagInc int, field bool, coeffLevel []int32, startIdx, endIdx, maxNumCoeff int) (int, error) {
+	if ctxBlockCat < 0 || ctxBlockCat >= len(residualCtxs) {
+		return 0, fmt.Errorf("invalid ctxBlockCat %v", ctxBlockCat)
+	}
+	for i := startIdx; i <= endIdx; i++ {
+		coeffLevel[i] = 0
+	}
+	rc := &residualCtxs[ctxBlockCat]
+	if codedBlockFlagInc >= 0 {
+		b, err := d.DecodeDecision(rc.codedBlockFlag + codedBlockFlagInc)
+		if err != nil {
+			return 0, fmt.Errorf("coded_block_flag: %v", err)
+		}
+		if b == 0 {
+			return 0, nil
+		}
+	}
+
+	sigCtx, lastCtx := rc.sigFrame, rc.lastFrame
+	if field {
+		sigCtx, lastCtx = rc.sigField, rc.lastField
+	}
+	is8x8 := ctxBlockCat == CatLuma8x8 || ctxBlockCat == CatCb8x8 || ctxBlockCat == CatCr8x8
+	var significant [64]bool
+	numCoeff := endIdx + 1
+	for i := startIdx; i < numCoeff-1; i++ {
+		// ctxIdxInc of significant_coeff_flag and last_significant_coeff_flag, 9.3.3.1.3
+		sigInc, lastInc := i, i
+		switch {
+		case ctxBlockCat == CatChromaDC:
+			numC8x8 := maxNumCoeff / 4
+			sigInc = i / numC8x8
+			if sigInc > 2 {
+				sigInc = 2
+			}
+			lastInc = sigInc
+		case is8x8:
+			sigInc = int(sigCoeffFlagOffset8x8[boolToInt(field)][i])
+			lastInc = int(sigCoeffFlagOffset8x8[2][i])
+		}
+		b, err := d.DecodeDecision(sigCtx + sigInc)
+		if err != nil {
+			return 0, fmt.Errorf("significant_coeff_flag: %v", err)
+		}
+		if b == 0 {
+			continue
+		}
+		significant[i] = true
+		if b, err = d.DecodeDecision(lastCtx + lastInc); err != nil {
+			return 0, fmt.Errorf("last_significant_coeff_flag: %v", err)
+		}
+		if b == 1 {
+			numCoeff = i + 1
+		}
+	}
+	significant[numCoeff-1] = true
+
+	// coeff_abs_level_minus1 and coeff_sign_flag in reverse scan order
+	numDecodAbsLevelEq1, numDecodAbsLevelGt1 := 0, 0
+	maxGt1Inc := 4
+	if ctxBlockCat == CatChromaDC {
+		maxGt1Inc = 3
+	}
+	total := 0
+	for i := numCoeff - 1; i >= startIdx; i-- {
+		if !significant[i] {
+			continue
+		}
+		inc0 := 0
+		if numDecodAbsLevelGt1 == 0 {
+			inc0 = imin(4, 1+numDecodAbsLevelEq1)
+		}
+		absLevelMinus1, err := d.coeffAbsLevelMinus1(rc.absLevel, inc0, 5+imin(maxGt1Inc, numDecodAbsLevelGt1))
+		if err != nil {
+			return 0, fmt.Errorf("coeff_abs_level_minus1: %v", err)
+		}
+		sign, err := d.DecodeBypass()
+		if err != nil {
+			return 0, fmt.Errorf("coeff_sign_flag: %v", err)
+		}
+		level := int32(absLevelMinus1) + 1
+		if sign == 1 {
+			level = -level
+		}
+		coeffLevel[i] = level
+		if absLevelMinus1 == 0 {
+			numDecodAbsLevelEq1++
+		} else {
+			numDecodAbsLevelGt1++
+		}
+		total++
+	}
+	return total, nil
+}
+
+// coeffAbsLevelMinus1 decode UEG0 with signedValFlag 0 and uCoff 14, prefix bin 0 with
+// ctxIdxInc inc0 and the others with incN, 9.3.2.3
+func (d *Decoder) coeffAbsLevelMinus1(ctxIdxOffset, inc0, incN int) (int, error) {
+	v, err := d.unary(ctxIdxOffset, []int{inc0, incN}, 14)
+	if err != nil || v < 14 {
+		return v, err
+	}
+	suffix, err := d.expGolombBypass(0)
+	return v + suffix, err
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func imin(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
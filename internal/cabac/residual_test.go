@@ -0,0 +1,179 @@
+package cabac
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/LiveStudioSolution/h264decoder/internal/rbr"
+	"github.com/LiveStudioSolution/h264decoder/internal/slice"
+)
+
+// encodeResidualBlock encode residual_block_cabac of coeffLevel, 7.3.5.3.3
+func (e *encoder) encodeResidualBlock(ctxBlockCat, codedBlockFlagInc int, field bool, coeffLevel []int32, startIdx, endIdx, maxNumCoeff int) {
+	rc := residualCtxs[ctxBlockCat]
+	last := -1
+	for i := startIdx; i <= endIdx; i++ {
+		if coeffLevel[i] != 0 {
+			last = i
+		}
+	}
+	if codedBlockFlagInc >= 0 {
+		e.encodeDecision(rc.codedBlockFlag+codedBlockFlagInc, boolToInt(last >= 0))
+	}
+	if last < 0 {
+		return
+	}
+	sigCtx, lastCtx := rc.sigFrame, rc.lastFrame
+	if field {
+		sigCtx, lastCtx = rc.sigField, rc.lastField
+	}
+	for i := startIdx; i < endIdx && i <= last; i++ {
+		sigInc, lastInc := i, i
+		switch ctxBlockCat {
+		case CatChromaDC:
+			sigInc = imin(i/(maxNumCoeff/4), 2)
+			lastInc = sigInc
+		case CatLuma8x8, CatCb8x8, CatCr8x8:
+			sigInc = int(sigCoeffFlagOffset8x8[boolToInt(field)][i])
+			lastInc = int(sigCoeffFlagOffset8x8[2][i])
+		}
+		e.encodeDecision(sigCtx+sigInc, boolToInt(coeffLevel[i] != 0))
+		if coeffLevel[i] != 0 {
+			e.encodeDecision(lastCtx+lastInc, boolToInt(i == last))
+		}
+	}
+	eq1, gt1 := 0, 0
+	for i := last; i >= startIdx; i-- {
+		if coeffLevel[i] == 0 {
+			continue
+		}
+		absLevel := int(coeffLevel[i])
+		if absLevel < 0 {
+			absLevel = -absLevel
+		}
+		inc0 := 0
+		if gt1 == 0 {
+			inc0 = imin(4, 1+eq1)
+		}
+		incN := 5 + imin(4-boolToInt(ctxBlockCat == CatChromaDC), gt1)
+		e.encodeUnary(rc.absLevel, []int{inc0, incN}, 14, absLevel-1)
+		if absLevel-1 >= 14 {
+			e.encodeUEGkSuffix(0, absLevel-1-14)
+		}
+		e.encodeBypass(boolToInt(coeffLevel[i] < 0))
+		if absLevel == 1 {
+			eq1++
+		} else {
+			gt1++
+		}
+	}
+}
+
+// maxNumCoeffs by ctxBlockCat, chroma DC of 4:2:0
+var maxNumCoeffs = [14]int{16, 15, 16, 4, 15, 64, 16, 15, 16, 64, 16, 15, 16, 64}
+
+type residualCase struct {
+	ctxBlockCat, codedBlockFlagInc int
+	field                          bool
+	startIdx, endIdx, maxNumCoeff  int
+	coeffLevel                     []int32
+}
+
+func randomResidual(rnd *rand.Rand) residualCase {
+	c := residualCase{ctxBlockCat: rnd.Intn(14), codedBlockFlagInc: rnd.Intn(4), field: rnd.Intn(2) == 1}
+	c.maxNumCoeff = maxNumCoeffs[c.ctxBlockCat]
+	if c.ctxBlockCat == CatChromaDC && rnd.Intn(2) == 0 {
+		c.maxNumCoeff = 8
+	}
+	if c.ctxBlockCat == CatLuma8x8 && rnd.Intn(2) == 0 {
+		c.codedBlockFlagInc = -1
+	}
+	c.endIdx = c.maxNumCoeff - 1
+	if rnd.Intn(4) == 0 {
+		c.startIdx = rnd.Intn(c.maxNumCoeff)
+		c.endIdx = c.startIdx + rnd.Intn(c.maxNumCoeff-c.startIdx)
+	}
+	c.coeffLevel = make([]int32, c.maxNumCoeff)
+	density := rnd.Float64()
+	nonZero := false
+	for i := c.startIdx; i <= c.endIdx; i++ {
+		if rnd.Float64() >= density {
+			continue
+		}
+		level := int32(1 + rnd.Intn(3))
+		if rnd.Intn(8) == 0 {
+			level = int32(rnd.Intn(3000))
+		}
+		if rnd.Intn(2) == 0 {
+			level = -level
+		}
+		c.coeffLevel[i] = level
+		nonZero = nonZero || level != 0
+	}
+	if !nonZero && c.codedBlockFlagInc < 0 {
+		c.coeffLevel[c.endIdx] = 1
+	}
+	return c
+}
+
+func TestResidualBlockRoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(12))
+	for n := 0; n < 100; n++ {
+		sliceType := slice.SliceType(rnd.Intn(5))
+		cabacInitIdc, sliceQPY := uint(rnd.Intn(3)), rnd.Intn(52)
+		e := newEncoder(sliceType, cabacInitIdc, sliceQPY)
+		cases := make([]residualCase, 1+rnd.Intn(100))
+		for i := range cases {
+			c := randomResidual(rnd)
+			cases[i] = c
+			e.encodeResidualBlock(c.ctxBlockCat, c.codedBlockFlagInc, c.field, c.coeffLevel, c.startIdx, c.endIdx, c.maxNumCoeff)
+		}
+		e.encodeTerminate(1)
+
+		d, err := NewDecoder(rbr.NewReader(e.w.Bytes()), sliceType, cabacInitIdc, sliceQPY)
+		if err != nil {
+			t.Fatalf("NewDecoder() error = %v", err)
+		}
+		for i, c := range cases {
+			got := make([]int32, c.maxNumCoeff)
+			for j := range got {
+				got[j] = -7
+			}
+			total, err := d.ResidualBlock(c.ctxBlockCat, c.codedBlockFlagInc, c.field, got, c.startIdx, c.endIdx, c.maxNumCoeff)
+			if err != nil {
+				t.Fatalf("round %v block %v: ResidualBlock() error = %v", n, i, err)
+			}
+			want, wantTotal := make([]int32, c.maxNumCoeff), 0
+			for j := range want {
+				want[j] = -7
+				if j >= c.startIdx && j <= c.endIdx {
+					want[j] = c.coeffLevel[j]
+					if want[j] != 0 {
+						wantTotal++
+					}
+				}
+			}
+			if total != wantTotal || !reflect.DeepEqual(got, want) {
+				t.Fatalf("round %v block %v %+v: ResidualBlock() = %v, %v, want %v, %v", n, i, c, total, got, wantTotal, want)
+			}
+		}
+		if end, err := d.EndOfSliceFlag(); err != nil || !end {
+			t.Fatalf("round %v: EndOfSliceFlag() = %v, %v, want true", n, end, err)
+		}
+	}
+}
+
+func TestResidualBlockInvalid(t *testing.T) {
+	d, err := NewDecoder(rbr.NewReader([]byte{0, 0, 0, 0}), slice.SliceI, 0, 26)
+	if err != nil {
+		t.Fatal(err)
+	}
+	coeffLevel := make([]int32, 16)
+	if _, err := d.ResidualBlock(14, 0, false, coeffLevel, 0, 15, 16); err == nil {
+		t.Errorf("ResidualBlock() ctxBlockCat 14 error = nil")
+	}
+	if _, err := d.ResidualBlock(-1, 0, false, coeffLevel, 0, 15, 16); err == nil {
+		t.Errorf("ResidualBlock() ctxBlockCat -1 error = nil")
+	}
+}
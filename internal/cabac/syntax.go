@@ -0,0 +1,441 @@
+package cabac
+
+import (
+	"fmt"
+
+	"github.com/LiveStudioSolution/h264decoder/internal/slice"
+)
+
+// ctxIdxOffset of syntax elements decoded with a fixed set of context variables, Table 9-34
+const (
+	ctxIdxMbTypeSI           = 0
+	ctxIdxMbTypeI            = 3
+	ctxIdxMbSkipP            = 11
+	ctxIdxMbTypePPrefix      = 14
+	ctxIdxMbTypePSuffix      = 17
+	ctxIdxSubMbTypeP         = 21
+	ctxIdxMbSkipB            = 24
+	ctxIdxMbTypeBPrefix      = 27
+	ctxIdxMbTypeBSuffix      = 32
+	ctxIdxSubMbTypeB         = 36
+	ctxIdxMvdX               = 40
+	ctxIdxMvdY               = 47
+	ctxIdxRefIdx             = 54
+	ctxIdxMbQpDelta          = 60
+	ctxIdxIntraChromaPred    = 64
+	ctxIdxPrevIntraPredFlag  = 68
+	ctxIdxRemIntraPredMode   = 69
+	ctxIdxMbFieldDecoding    = 70
+	ctxIdxCbpLuma            = 73
+	ctxIdxCbpChroma          = 77
+	ctxIdxTransformSize8x8   = 399
+	mbTypeIPCM               = 25
+	maxUnaryBins             = 64
+	maxExpGolombSuffixLength = 30
+)
+
+// NeighbourCbp coded_block_pattern of a neighbouring macroblock as seen by the
+// ctxIdxInc derivation of coded_block_pattern, 9.3.3.1.1.4. An unavailable neighbour
+// reads as luma blocks coded and chroma not coded, an I_PCM one as everything coded
+// and a skipped one as nothing coded.
+func NeighbourCbp(available, skip, pcm bool, cbp uint) uint {
+	switch {
+	case !available:
+		return 0x0f
+	case pcm:
+		return 0x2f
+	case skip:
+		return 0
+	}
+	return cbp
+}
+
+// decodeFlag decode a single bin of ctxIdx as a flag
+func (d *Decoder) decodeFlag(ctxIdx int) (bool, error) {
+	b, err := d.DecodeDecision(ctxIdx)
+	return b == 1, err
+}
+
+// MbSkipFlag parse mb_skip_flag of P, SP and B slices, ctxIdxInc = condTermFlagA + condTermFlagB, 9.3.3.1.1.1
+func (d *Decoder) MbSkipFlag(ctxIdxInc int) (bool, error) {
+	if d.sliceType == slice.SliceB {
+		return d.decodeFlag(ctxIdxMbSkipB + ctxIdxInc)
+	}
+	return d.decodeFlag(ctxIdxMbSkipP + ctxIdxInc)
+}
+
+// MbFieldDecodingFlag parse mb_field_decoding_flag, ctxIdxInc = condTermFlagA + condTermFlagB, 9.3.3.1.1.2
+func (d *Decoder) MbFieldDecodingFlag(ctxIdxInc int) (bool, error) {
+	return d.decodeFlag(ctxIdxMbFieldDecoding + ctxIdxInc)
+}
+
+// EndOfSliceFlag parse end_of_slice_flag
+func (d *Decoder) EndOfSliceFlag() (bool, error) {
+	b, err := d.DecodeTerminate()
+	return b == 1, err
+}
+
+// MbType parse mb_type, value is the one of Table 7-11, 7-13 or 7-14 for the slice type,
+// intra macroblock types in P and B slices are offset by 5 and 23 as in ue(v) coding.
+// ctxIdxInc of the first bin is condTermFlagA + condTermFlagB of 9.3.3.1.1.3 for the slice
+// type, for SI slices ctxIdxIncI is the one of the I macroblock type suffix.
+// Once I_PCM is returned, pcm samples are read from Reader and InitEngine is invoked.
+func (d *Decoder) MbType(ctxIdxInc, ctxIdxIncI int) (uint, error) {
+	switch d.sliceType {
+	case slice.SliceI:
+		return d.mbTypeI(ctxIdxMbTypeI, ctxIdxInc, false)
+	case slice.SliceSI:
+		b, err := d.DecodeDecision(ctxIdxMbTypeSI + ctxIdxInc)
+		if err != nil || b == 0 {
+			return 0, err
+		}
+		mbType, err := d.mbTypeI(ctxIdxMbTypeI, ctxIdxIncI, false)
+		return 1 + mbType, err
+	case slice.SliceP, slice.SliceSP:
+		return d.mbTypeP()
+	case slice.SliceB:
+		return d.mbTypeB(ctxIdxInc)
+	}
+	return 0, fmt.Errorf("invalid slice type %v", d.sliceType)
+}
+
+// mbTypeI decode the I macroblock type binarization of Table 9-36, either as mb_type
+// of I slices or as suffix of P and B slices which use fixed ctxIdxInc, Table 9-39
+func (d *Decoder) mbTypeI(ctxIdxOffset, ctxIdxInc int, suffix bool) (uint, error) {
+	b, err := d.DecodeDecision(ctxIdxOffset + ctxIdxInc)
+	if err != nil || b == 0 {
+		return 0, err
+	}
+	if b, err = d.DecodeTerminate(); err != nil || b == 1 {
+		return mbTypeIPCM, err
+	}
+	// ctxIdxInc of the bins coding CodedBlockPatternLuma, CodedBlockPatternChroma not 0,
+	// CodedBlockPatternChroma 2 and the two bins of Intra16x16PredMode
+	inc := [5]int{3, 4, 5, 6, 7}
+	if suffix {
+		inc = [5]int{1, 2, 2, 3, 3}
+	}
+	luma, err := d.DecodeDecision(ctxIdxOffset + inc[0])
+	if err != nil {
+		return 0, err
+	}
+	chroma, err := d.DecodeDecision(ctxIdxOffset + inc[1])
+	if err != nil {
+		return 0, err
+	}
+	if chroma == 1 {
+		b, err := d.DecodeDecision(ctxIdxOffset + inc[2])
+		if err != nil {
+			return 0, err
+		}
+		chroma += b
+	}
+	mode := 0
+	for _, i := range inc[3:] {
+		b, err := d.DecodeDecision(ctxIdxOffset + i)
+		if err != nil {
+			return 0, err
+		}
+		mode = mode<<1 | b
+	}
+	return uint(1 + mode + 4*chroma + 12*luma), nil
+}
+
+// mbTypeP decode mb_type prefix and suffix of P and SP slices, Table 9-37
+func (d *Decoder) mbTypeP() (uint, error) {
+	b, err := d.DecodeDecision(ctxIdxMbTypePPrefix)
+	if err != nil {
+		return 0, err
+	}
+	if b == 1 {
+		mbType, err := d.mbTypeI(ctxIdxMbTypePSuffix, 0, true)
+		return 5 + mbType, err
+	}
+	if b, err = d.DecodeDecision(ctxIdxMbTypePPrefix + 1); err != nil {
+		return 0, err
+	}
+	if b == 0 {
+		// 0 0 0 P_L0_16x16, 0 0 1 P_8x8
+		b, err = d.DecodeDecision(ctxIdxMbTypePPrefix + 2)
+		return uint(3 * b), err
+	}
+	// 0 1 1 P_L0_L0_16x8, 0 1 0 P_L0_L0_8x16
+	b, err = d.DecodeDecision(ctxIdxMbTypePPrefix + 3)
+	return uint(2 - b), err
+}
+
+// mbTypeB decode mb_type prefix and suffix of B slices, Table 9-37
+func (d *Decoder) mbTypeB(ctxIdxInc int) (uint, error) {
+	b, err := d.DecodeDecision(ctxIdxMbTypeBPrefix + ctxIdxInc)
+	if err != nil || b == 0 {
+		return 0, err
+	}
+	if b, err = d.DecodeDecision(ctxIdxMbTypeBPrefix + 3); err != nil {
+		return 0, err
+	}
+	if b == 0 {
+		b, err = d.DecodeDecision(ctxIdxMbTypeBPrefix + 5)
+		return uint(1 + b), err
+	}
+	if b, err = d.DecodeDecision(ctxIdxMbTypeBPrefix + 4); err != nil {
+		return 0, err
+	}
+	v := b
+	for i := 0; i < 3; i++ {
+		if b, err = d.DecodeDecision(ctxIdxMbTypeBPrefix + 5); err != nil {
+			return 0, err
+		}
+		v = v<<1 | b
+	}
+	switch {
+	case v < 8:
+		return uint(3 + v), nil
+	case v == 13:
+		mbType, err := d.mbTypeI(ctxIdxMbTypeBSuffix, 0, true)
+		return 23 + mbType, err
+	case v == 14:
+		return 11, nil
+	case v == 15:
+		return 22, nil
+	}
+	if b, err = d.DecodeDecision(ctxIdxMbTypeBPrefix + 5); err != nil {
+		return 0, err
+	}
+	return uint(v<<1|b) - 4, nil
+}
+
+// SubMbType parse sub_mb_type of P, SP and B slices, Table 9-38
+func (d *Decoder) SubMbType() (uint, error) {
+	if d.sliceType == slice.SliceB {
+		return d.subMbTypeB()
+	}
+	b, err := d.DecodeDecision(ctxIdxSubMbTypeP)
+	if err != nil || b == 1 {
+		return 0, err
+	}
+	if b, err = d.DecodeDecision(ctxIdxSubMbTypeP + 1); err != nil || b == 0 {
+		return 1, err
+	}
+	b, err = d.DecodeDecision(ctxIdxSubMbTypeP + 2)
+	return uint(3 - b), err
+}
+
+func (d *Decoder) subMbTypeB() (uint, error) {
+	b, err := d.DecodeDecision(ctxIdxSubMbTypeB)
+	if err != nil || b == 0 {
+		return 0, err
+	}
+	if b, err = d.DecodeDecision(ctxIdxSubMbTypeB + 1); err != nil {
+		return 0, err
+	}
+	if b == 0 {
+		b, err = d.DecodeDecision(ctxIdxSubMbTypeB + 3)
+		return uint(1 + b), err
+	}
+	if b, err = d.DecodeDecision(ctxIdxSubMbTypeB + 2); err != nil {
+		return 0, err
+	}
+	first, n := 3, 2
+	if b == 1 {
+		if b, err = d.DecodeDecision(ctxIdxSubMbTypeB + 3); err != nil {
+			return 0, err
+		}
+		first, n = 7, 2
+		if b == 1 {
+			first, n = 11, 1
+		}
+	}
+	v := 0
+	for i := 0; i < n; i++ {
+		if b, err = d.DecodeDecision(ctxIdxSubMbTypeB + 3); err != nil {
+			return 0, err
+		}
+		v = v<<1 | b
+	}
+	return uint(first + v), nil
+}
+
+// unary decode a U or TU binarization of at most cMax, bins past the last entry of inc
+// reuse its ctxIdxInc
+func (d *Decoder) unary(ctxIdxOffset int, inc []int, cMax int) (int, error) {
+	v := 0
+	for v < cMax {
+		i := v
+		if i >= len(inc) {
+			i = len(inc) - 1
+		}
+		b, err := d.DecodeDecision(ctxIdxOffset + inc[i])
+		if err != nil {
+			return 0, err
+		}
+		if b == 0 {
+			return v, nil
+		}
+		v++
+	}
+	return v, nil
+}
+
+// RefIdx parse ref_idx_l0 or ref_idx_l1, ctxIdxInc = condTermFlagA + 2*condTermFlagB, 9.3.3.1.1.6
+func (d *Decoder) RefIdx(ctxIdxInc int) (uint, error) {
+	v, err := d.unary(ctxIdxRefIdx, []int{ctxIdxInc, 4, 5}, maxUnaryBins)
+	if err == nil && v == maxUnaryBins {
+		err = fmt.Errorf("ref_idx exceeds %v", maxUnaryBins)
+	}
+	return uint(v), err
+}
+
+// Mvd parse a component of mvd_l0 or mvd_l1, absMvdSum is absMvdCompA + absMvdCompB, 9.3.3.1.1.7
+func (d *Decoder) Mvd(compIdx int, absMvdSum int) (int, error) {
+	ctxIdxOffset := ctxIdxMvdX
+	if compIdx == 1 {
+		ctxIdxOffset = ctxIdxMvdY
+	}
+	ctxIdxInc := 1
+	if absMvdSum < 3 {
+		ctxIdxInc = 0
+	} else if absMvdSum > 32 {
+		ctxIdxInc = 2
+	}
+	// UEG3 with signedValFlag 1 and uCoff 9, 9.3.2.3
+	v, err := d.unary(ctxIdxOffset, []int{ctxIdxInc, 3, 4, 5, 6}, 9)
+	if err != nil || v == 0 {
+		return 0, err
+	}
+	if v == 9 {
+		suffix, err := d.expGolombBypass(3)
+		if err != nil {
+			return 0, err
+		}
+		v += suffix
+	}
+	sign, err := d.DecodeBypass()
+	if sign == 1 {
+		v = -v
+	}
+	return v, err
+}
+
+// expGolombBypass decode a k-th order Exp-Golomb suffix with bypass bins, 9.3.2.3
+func (d *Decoder) expGolombBypass(k uint) (int, error) {
+	v := 0
+	for {
+		b, err := d.DecodeBypass()
+		if err != nil {
+			return 0, err
+		}
+		if b == 0 {
+			break
+		}
+		v += 1 << k
+		if k++; k > maxExpGolombSuffixLength {
+			return 0, fmt.Errorf("exp-golomb suffix exceeds %v bits", maxExpGolombSuffixLength)
+		}
+	}
+	for k > 0 {
+		k--
+		b, err := d.DecodeBypass()
+		if err != nil {
+			return 0, err
+		}
+		v += b << k
+	}
+	return v, nil
+}
+
+// MbQpDelta parse mb_qp_delta, ctxIdxInc is 1 when the previous macroblock in decoding
+// order has a non-zero mb_qp_delta, 9.3.3.1.1.5
+func (d *Decoder) MbQpDelta(ctxIdxInc int) (int, error) {
+	v, err := d.unary(ctxIdxMbQpDelta, []int{ctxIdxInc, 2, 3}, maxUnaryBins)
+	if err != nil {
+		return 0, err
+	}
+	if v == maxUnaryBins {
+		return 0, fmt.Errorf("mb_qp_delta exceeds %v bins", maxUnaryBins)
+	}
+	// mapped as se(v), Table 9-3
+	if v%2 == 1 {
+		return (v + 1) / 2, nil
+	}
+	return -v / 2, nil
+}
+
+// IntraChromaPredMode parse intra_chroma_pred_mode, ctxIdxInc = condTermFlagA + condTermFlagB, 9.3.3.1.1.8
+func (d *Decoder) IntraChromaPredMode(ctxIdxInc int) (uint, error) {
+	v, err := d.unary(ctxIdxIntraChromaPred, []int{ctxIdxInc, 3}, 3)
+	return uint(v), err
+}
+
+// PrevIntraPredModeFlag parse prev_intra4x4_pred_mode_flag or prev_intra8x8_pred_mode_flag
+func (d *Decoder) PrevIntraPredModeFlag() (bool, error) {
+	return d.decodeFlag(ctxIdxPrevIntraPredFlag)
+}
+
+// RemIntraPredMode parse rem_intra4x4_pred_mode or rem_intra8x8_pred_mode, FL with cMax 7
+func (d *Decoder) RemIntraPredMode() (uint, error) {
+	v := 0
+	for i := 0; i < 3; i++ {
+		b, err := d.DecodeDecision(ctxIdxRemIntraPredMode)
+		if err != nil {
+			return 0, err
+		}
+		v |= b << i
+	}
+	return uint(v), nil
+}
+
+// CodedBlockPattern parse coded_block_pattern, cbpA and cbpB are NeighbourCbp of the
+// macroblocks left and above. Only bits of the 8x8 blocks adjacent to the current
+// macroblock are used, in MBAFF frames the left bits may come from different macroblocks.
+// The chroma suffix is present when ChromaArrayType is 1 or 2, 9.3.2.6
+func (d *Decoder) CodedBlockPattern(cbpA, cbpB uint, chromaArrayType uint) (uint, error) {
+	luma := uint(0)
+	for b8 := uint(0); b8 < 4; b8++ {
+		// luma8x8BlkIdxA and luma8x8BlkIdxB, with A and B inside current macroblock for some blocks
+		var bitA, bitB uint
+		if b8&1 == 0 {
+			bitA = cbpA >> (b8 + 1) & 1
+		} else {
+			bitA = luma >> (b8 - 1) & 1
+		}
+		if b8 < 2 {
+			bitB = cbpB >> (b8 + 2) & 1
+		} else {
+			bitB = luma >> (b8 - 2) & 1
+		}
+		ctxIdxInc := int(1-bitA) + 2*int(1-bitB)
+		b, err := d.DecodeDecision(ctxIdxCbpLuma + ctxIdxInc)
+		if err != nil {
+			return 0, err
+		}
+		luma |= uint(b) << b8
+	}
+	if chromaArrayType != 1 && chromaArrayType != 2 {
+		return luma, nil
+	}
+	chromaA, chromaB := cbpA>>4, cbpB>>4
+	chroma := uint(0)
+	for binIdx := uint(0); binIdx < 2; binIdx++ {
+		ctxIdxInc := 4 * int(binIdx)
+		if chromaA > binIdx {
+			ctxIdxInc++
+		}
+		if chromaB > binIdx {
+			ctxIdxInc += 2
+		}
+		b, err := d.DecodeDecision(ctxIdxCbpChroma + ctxIdxInc)
+		if err != nil {
+			return 0, err
+		}
+		if b == 0 {
+			break
+		}
+		chroma++
+	}
+	return luma | chroma<<4, nil
+}
+
+// TransformSize8x8Flag parse transform_size_8x8_flag, ctxIdxInc = condTermFlagA + condTermFlagB, 9.3.3.1.1.10
+func (d *Decoder) TransformSize8x8Flag(ctxIdxInc int) (bool, error) {
+	return d.decodeFlag(ctxIdxTransformSize8x8 + ctxIdxInc)
+}
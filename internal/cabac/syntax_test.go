@@ -0,0 +1,457 @@
+package cabac
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/LiveStudioSolution/h264decoder/internal/rbr"
+	"github.com/LiveStudioSolution/h264decoder/internal/slice"
+)
+
+// mbTypeIBins bin strings of mb_type in I slices, Table 9-36, bin 1 is decoded with DecodeTerminate
+var mbTypeIBins = []string{
+	"0",
+	"1 0 0 0 0 0", "1 0 0 0 0 1", "1 0 0 0 1 0", "1 0 0 0 1 1",
+	"1 0 0 1 0 0 0", "1 0 0 1 0 0 1", "1 0 0 1 0 1 0", "1 0 0 1 0 1 1",
+	"1 0 0 1 1 0 0", "1 0 0 1 1 0 1", "1 0 0 1 1 1 0", "1 0 0 1 1 1 1",
+	"1 0 1 0 0 0", "1 0 1 0 0 1", "1 0 1 0 1 0", "1 0 1 0 1 1",
+	"1 0 1 1 0 0 0", "1 0 1 1 0 0 1", "1 0 1 1 0 1 0", "1 0 1 1 0 1 1",
+	"1 0 1 1 1 0 0", "1 0 1 1 1 0 1", "1 0 1 1 1 1 0", "1 0 1 1 1 1 1",
+	"1 1",
+}
+
+// mbTypePBins and mbTypeBBins bin strings of inter mb_type and of the intra prefix in B slices, Table 9-37
+var mbTypePBins = []string{"0 0 0", "0 1 1", "0 1 0", "0 0 1"}
+
+var mbTypeBBins = []string{
+	"0", "1 0 0", "1 0 1",
+	"1 1 0 0 0 0", "1 1 0 0 0 1", "1 1 0 0 1 0", "1 1 0 0 1 1",
+	"1 1 0 1 0 0", "1 1 0 1 0 1", "1 1 0 1 1 0", "1 1 0 1 1 1", "1 1 1 1 1 0",
+	"1 1 1 0 0 0 0", "1 1 1 0 0 0 1", "1 1 1 0 0 1 0", "1 1 1 0 0 1 1",
+	"1 1 1 0 1 0 0", "1 1 1 0 1 0 1", "1 1 1 0 1 1 0", "1 1 1 0 1 1 1",
+	"1 1 1 1 0 0 0", "1 1 1 1 0 0 1", "1 1 1 1 1 1",
+	"1 1 1 1 0 1",
+}
+
+// subMbTypePBins and subMbTypeBBins bin strings of sub_mb_type, Table 9-38
+var subMbTypePBins = []string{"1", "0 0", "0 1 1", "0 1 0"}
+
+var subMbTypeBBins = []string{
+	"0", "1 0 0", "1 0 1",
+	"1 1 0 0 0", "1 1 0 0 1", "1 1 0 1 0", "1 1 0 1 1",
+	"1 1 1 0 0 0", "1 1 1 0 0 1", "1 1 1 0 1 0", "1 1 1 0 1 1",
+	"1 1 1 1 0", "1 1 1 1 1",
+}
+
+func parseBins(s string) []int {
+	var b []int
+	for _, c := range strings.ReplaceAll(s, " ", "") {
+		b = append(b, int(c-'0'))
+	}
+	return b
+}
+
+// encodeMbTypeI encode Table 9-36 bins with the ctxIdxInc of Table 9-39 and Table 9-41
+func (e *encoder) encodeMbTypeI(ctxIdxOffset, ctxIdxInc int, suffix bool, mbType int) {
+	b := parseBins(mbTypeIBins[mbType])
+	for binIdx, bin := range b {
+		inc := 0
+		switch {
+		case binIdx == 0:
+			inc = ctxIdxInc
+		case binIdx == 1:
+			e.encodeTerminate(bin)
+			if bin == 1 {
+				// pcm_alignment_zero_bit and a single pcm sample, then the engine restarts
+				for e.w.BitPos()%8 != 0 {
+					e.w.WriteBits(0, 1)
+				}
+				e.w.WriteBits(pcmSample, 8)
+				e.init()
+			}
+			continue
+		case !suffix:
+			inc = []int{3, 4, 0, 0, 7}[binIdx-2]
+			if binIdx == 4 {
+				inc = 6 - b[3]
+			} else if binIdx == 5 {
+				inc = 7 - b[3]
+			}
+		default:
+			inc = []int{1, 2, 0, 3, 3}[binIdx-2]
+			if binIdx == 4 {
+				inc = 3 - b[3]
+			}
+		}
+		e.encodeDecision(ctxIdxOffset+inc, bin)
+	}
+}
+
+func (e *encoder) encodeMbType(sliceType slice.SliceType, ctxIdxInc, ctxIdxIncI int, mbType int) {
+	switch sliceType {
+	case slice.SliceI:
+		e.encodeMbTypeI(3, ctxIdxInc, false, mbType)
+	case slice.SliceSI:
+		if mbType == 0 {
+			e.encodeDecision(ctxIdxInc, 0)
+			return
+		}
+		e.encodeDecision(ctxIdxInc, 1)
+		e.encodeMbTypeI(3, ctxIdxIncI, false, mbType-1)
+	case slice.SliceP:
+		if mbType >= 5 {
+			e.encodeDecision(14, 1)
+			e.encodeMbTypeI(17, 0, true, mbType-5)
+			return
+		}
+		b := parseBins(mbTypePBins[mbType])
+		e.encodeDecision(14, b[0])
+		e.encodeDecision(15, b[1])
+		e.encodeDecision(16+b[1], b[2])
+	case slice.SliceB:
+		prefix := mbType
+		if mbType >= 23 {
+			prefix = 23
+		}
+		b := parseBins(mbTypeBBins[prefix])
+		for binIdx, bin := range b {
+			inc := 5
+			switch binIdx {
+			case 0:
+				inc = ctxIdxInc
+			case 1:
+				inc = 3
+			case 2:
+				inc = 5 - b[1]
+			}
+			e.encodeDecision(27+inc, bin)
+		}
+		if mbType >= 23 {
+			e.encodeMbTypeI(32, 0, true, mbType-23)
+		}
+	}
+}
+
+func (e *encoder) encodeSubMbType(sliceType slice.SliceType, subMbType int) {
+	if sliceType == slice.SliceB {
+		b := parseBins(subMbTypeBBins[subMbType])
+		for binIdx, bin := range b {
+			inc := 3
+			if binIdx < 2 {
+				inc = binIdx
+			} else if binIdx == 2 {
+				inc = 3 - b[1]
+			}
+			e.encodeDecision(36+inc, bin)
+		}
+		return
+	}
+	for binIdx, bin := range parseBins(subMbTypePBins[subMbType]) {
+		e.encodeDecision(21+binIdx, bin)
+	}
+}
+
+// encodeUnary encode U or TU bins, bins past the last entry of inc reuse it
+func (e *encoder) encodeUnary(ctxIdxOffset int, inc []int, cMax, v int) {
+	for binIdx := 0; binIdx <= v && binIdx < cMax; binIdx++ {
+		i := binIdx
+		if i >= len(inc) {
+			i = len(inc) - 1
+		}
+		bin := 0
+		if binIdx < v {
+			bin = 1
+		}
+		e.encodeDecision(ctxIdxOffset+inc[i], bin)
+	}
+}
+
+// encodeUEGkSuffix k-th order Exp-Golomb suffix with bypass bins, 9.3.2.3
+func (e *encoder) encodeUEGkSuffix(k uint, sufS int) {
+	for sufS >= 1<<k {
+		e.encodeBypass(1)
+		sufS -= 1 << k
+		k++
+	}
+	e.encodeBypass(0)
+	for k > 0 {
+		k--
+		e.encodeBypass(sufS >> k & 1)
+	}
+}
+
+func (e *encoder) encodeMvd(compIdx, absMvdSum, v int) {
+	inc := 1
+	if absMvdSum < 3 {
+		inc = 0
+	} else if absMvdSum > 32 {
+		inc = 2
+	}
+	a := v
+	if a < 0 {
+		a = -a
+	}
+	e.encodeUnary(40+7*compIdx, []int{inc, 3, 4, 5, 6}, 9, a)
+	if a >= 9 {
+		e.encodeUEGkSuffix(3, a-9)
+	}
+	if a != 0 {
+		sign := 0
+		if v < 0 {
+			sign = 1
+		}
+		e.encodeBypass(sign)
+	}
+}
+
+func (e *encoder) encodeCodedBlockPattern(cbpA, cbpB uint, chromaArrayType uint, cbp uint) {
+	for b8 := uint(0); b8 < 4; b8++ {
+		// 8x8 block left and above of b8, in the neighbour macroblock for the outer ones
+		a, b := cbpA>>(b8+1)&1, cbpB>>(b8+2)&1
+		if b8 == 1 || b8 == 3 {
+			a = cbp >> (b8 - 1) & 1
+		}
+		if b8 >= 2 {
+			b = cbp >> (b8 - 2) & 1
+		}
+		e.encodeDecision(73+int(1-a)+2*int(1-b), int(cbp>>b8&1))
+	}
+	if chromaArrayType == 0 || chromaArrayType == 3 {
+		return
+	}
+	chroma := int(cbp >> 4)
+	for binIdx := 0; binIdx <= chroma && binIdx < 2; binIdx++ {
+		inc := 4 * binIdx
+		if int(cbpA>>4) > binIdx {
+			inc++
+		}
+		if int(cbpB>>4) > binIdx {
+			inc += 2
+		}
+		bin := 0
+		if binIdx < chroma {
+			bin = 1
+		}
+		e.encodeDecision(77+inc, bin)
+	}
+}
+
+// syntaxElement one syntax element of the round trip with its context input
+type syntaxElement struct {
+	name       string
+	v          int
+	inc, incI  int
+	compIdx    int
+	cbpA, cbpB uint
+	chroma     uint
+}
+
+func (e *encoder) encodeElement(sliceType slice.SliceType, se syntaxElement) {
+	switch se.name {
+	case "mb_skip_flag":
+		offset := 11
+		if sliceType == slice.SliceB {
+			offset = 24
+		}
+		e.encodeDecision(offset+se.inc, se.v)
+	case "mb_field_decoding_flag":
+		e.encodeDecision(70+se.inc, se.v)
+	case "mb_type":
+		e.encodeMbType(sliceType, se.inc, se.incI, se.v)
+	case "sub_mb_type":
+		e.encodeSubMbType(sliceType, se.v)
+	case "ref_idx":
+		e.encodeUnary(54, []int{se.inc, 4, 5}, 1<<20, se.v)
+	case "mvd":
+		e.encodeMvd(se.compIdx, se.inc, se.v)
+	case "mb_qp_delta":
+		k := 2*se.v - 1
+		if se.v <= 0 {
+			k = -2 * se.v
+		}
+		e.encodeUnary(60, []int{se.inc, 2, 3}, 1<<20, k)
+	case "intra_chroma_pred_mode":
+		e.encodeUnary(64, []int{se.inc, 3}, 3, se.v)
+	case "prev_intra_pred_mode_flag":
+		e.encodeDecision(68, se.v)
+	case "rem_intra_pred_mode":
+		for i := 0; i < 3; i++ {
+			e.encodeDecision(69, se.v>>i&1)
+		}
+	case "coded_block_pattern":
+		e.encodeCodedBlockPattern(se.cbpA, se.cbpB, se.chroma, uint(se.v))
+	case "transform_size_8x8_flag":
+		e.encodeDecision(399+se.inc, se.v)
+	case "end_of_slice_flag":
+		e.encodeTerminate(se.v)
+	}
+}
+
+func decodeElement(d *Decoder, se syntaxElement) (int, error) {
+	var v uint
+	var b bool
+	var err error
+	switch se.name {
+	case "mb_skip_flag":
+		b, err = d.MbSkipFlag(se.inc)
+	case "mb_field_decoding_flag":
+		b, err = d.MbFieldDecodingFlag(se.inc)
+	case "mb_type":
+		v, err = d.MbType(se.inc, se.incI)
+		if err == nil && int(v) == pcmMbType[d.sliceType] {
+			err = readPcm(d)
+		}
+	case "sub_mb_type":
+		v, err = d.SubMbType()
+	case "ref_idx":
+		v, err = d.RefIdx(se.inc)
+	case "mvd":
+		return d.Mvd(se.compIdx, se.inc)
+	case "mb_qp_delta":
+		return d.MbQpDelta(se.inc)
+	case "intra_chroma_pred_mode":
+		v, err = d.IntraChromaPredMode(se.inc)
+	case "prev_intra_pred_mode_flag":
+		b, err = d.PrevIntraPredModeFlag()
+	case "rem_intra_pred_mode":
+		v, err = d.RemIntraPredMode()
+	case "coded_block_pattern":
+		v, err = d.CodedBlockPattern(se.cbpA, se.cbpB, se.chroma)
+	case "transform_size_8x8_flag":
+		b, err = d.TransformSize8x8Flag(se.inc)
+	case "end_of_slice_flag":
+		b, err = d.EndOfSliceFlag()
+	}
+	if b {
+		v = 1
+	}
+	return int(v), err
+}
+
+// pcmMbType mb_type value of I_PCM by slice type
+var pcmMbType = map[slice.SliceType]int{slice.SliceI: 25, slice.SliceSI: 26, slice.SliceP: 30, slice.SliceB: 48}
+
+const pcmSample = 0xa5
+
+// readPcm read the pcm sample written by encodeMbTypeI and restart the engine
+func readPcm(d *Decoder) error {
+	br := d.Reader()
+	for !br.ByteAligned() {
+		if b, err := br.Read1(); err != nil || b {
+			return fmt.Errorf("pcm_alignment_zero_bit %v, %v", b, err)
+		}
+	}
+	if v, err := br.Read8(8); err != nil || v != pcmSample {
+		return fmt.Errorf("pcm sample %#x, %v", v, err)
+	}
+	return d.InitEngine()
+}
+
+// randomElement pick a syntax element valid for sliceType with random value and context input
+func randomElement(rnd *rand.Rand, sliceType slice.SliceType) syntaxElement {
+	names := []string{"mb_field_decoding_flag", "mb_type", "ref_idx", "mvd", "mb_qp_delta",
+		"intra_chroma_pred_mode", "prev_intra_pred_mode_flag", "rem_intra_pred_mode",
+		"coded_block_pattern", "transform_size_8x8_flag", "end_of_slice_flag"}
+	if !sliceType.IsIntra() {
+		names = append(names, "mb_skip_flag", "sub_mb_type")
+	}
+	se := syntaxElement{name: names[rnd.Intn(len(names))], inc: rnd.Intn(3)}
+	switch se.name {
+	case "mb_skip_flag", "mb_field_decoding_flag", "prev_intra_pred_mode_flag", "transform_size_8x8_flag":
+		se.v = rnd.Intn(2)
+	case "end_of_slice_flag":
+		se.v = 0
+	case "mb_type":
+		se.incI = rnd.Intn(3)
+		switch sliceType {
+		case slice.SliceI:
+			se.v = rnd.Intn(26)
+		case slice.SliceSI:
+			se.v = rnd.Intn(27)
+		case slice.SliceP:
+			if se.v = rnd.Intn(31); se.v == 4 {
+				se.v = 3
+			}
+		case slice.SliceB:
+			se.v = rnd.Intn(49)
+		}
+	case "sub_mb_type":
+		se.v = rnd.Intn(4)
+		if sliceType == slice.SliceB {
+			se.v = rnd.Intn(13)
+		}
+	case "ref_idx":
+		se.inc, se.v = rnd.Intn(4), rnd.Intn(32)
+	case "mvd":
+		se.compIdx, se.inc = rnd.Intn(2), rnd.Intn(40)
+		se.v = rnd.Intn(33) - 16
+		if rnd.Intn(4) == 0 {
+			se.v = rnd.Intn(1<<15) - 1<<14
+		}
+	case "mb_qp_delta":
+		se.inc, se.v = rnd.Intn(2), rnd.Intn(53)-26
+	case "intra_chroma_pred_mode":
+		se.v = rnd.Intn(4)
+	case "rem_intra_pred_mode":
+		se.v = rnd.Intn(8)
+	case "coded_block_pattern":
+		se.chroma = uint(rnd.Intn(4))
+		se.cbpA, se.cbpB = uint(rnd.Intn(48)), uint(rnd.Intn(48))
+		se.v = rnd.Intn(16)
+		if se.chroma == 1 || se.chroma == 2 {
+			se.v |= rnd.Intn(3) << 4
+		}
+	}
+	return se
+}
+
+func TestSyntaxElementsRoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(9))
+	for n := 0; n < 300; n++ {
+		sliceType := []slice.SliceType{slice.SliceP, slice.SliceB, slice.SliceI, slice.SliceSI}[rnd.Intn(4)]
+		cabacInitIdc, sliceQPY := uint(rnd.Intn(3)), rnd.Intn(52)
+		e := newEncoder(sliceType, cabacInitIdc, sliceQPY)
+		elements := make([]syntaxElement, 1+rnd.Intn(400))
+		for i := range elements {
+			elements[i] = randomElement(rnd, sliceType)
+			e.encodeElement(sliceType, elements[i])
+		}
+		e.encodeTerminate(1)
+
+		d, err := NewDecoder(rbr.NewReader(e.w.Bytes()), sliceType, cabacInitIdc, sliceQPY)
+		if err != nil {
+			t.Fatalf("NewDecoder() error = %v", err)
+		}
+		for i, se := range elements {
+			got, err := decodeElement(d, se)
+			if err != nil || got != se.v {
+				t.Fatalf("round %v %v slice: element %v %+v decoded %v, %v", n, sliceType, i, se, got, err)
+			}
+		}
+		if end, err := d.EndOfSliceFlag(); err != nil || !end {
+			t.Fatalf("round %v: EndOfSliceFlag() = %v, %v, want true", n, end, err)
+		}
+	}
+}
+
+func TestNeighbourCbp(t *testing.T) {
+	tests := []struct {
+		name                 string
+		available, skip, pcm bool
+		cbp, want            uint
+	}{
+		{"unavailable", false, false, false, 0x20, 0x0f},
+		{"I_PCM", true, false, true, 0, 0x2f},
+		{"skip", true, true, false, 0x2f, 0},
+		{"coded", true, false, false, 0x15, 0x15},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NeighbourCbp(tt.available, tt.skip, tt.pcm, tt.cbp); got != tt.want {
+				t.Errorf("NeighbourCbp() = %#x, want %#x", got, tt.want)
+			}
+		})
+	}
+}
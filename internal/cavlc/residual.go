@@ -0,0 +1,216 @@
+// Package cavlc decode transform coefficient levels coded with CAVLC,
+// T-REC-H.264-201402-S!!PDF-E.pdf 9.2 CAVLC parsing process for transform coefficient levels
+package cavlc
+
+import (
+	"fmt"
+
+	"github.com/LiveStudioSolution/h264decoder/internal/rbr"
+)
+
+// nC values selecting the coeff_token vlc of chroma DC blocks
+const (
+	NCChromaDC420 = -1
+	NCChromaDC422 = -2
+)
+
+// NC derive nC of a block from total coefficients nA and nB of its left and above
+// neighbour blocks, 9.2.1. nN of an unavailable neighbour is ignored.
+func NC(availableA, availableB bool, nA, nB int) int {
+	switch {
+	case availableA && availableB:
+		return (nA + nB + 1) >> 1
+	case availableA:
+		return nA
+	case availableB:
+		return nB
+	}
+	return 0
+}
+
+// coeffTokenTable select coeff_token vlc of nC
+func coeffTokenTable(nC int) *vlcTable {
+	switch {
+	case nC == NCChromaDC420:
+		return coeffTokenTables[4]
+	case nC == NCChromaDC422:
+		return coeffTokenTables[5]
+	case nC < 0:
+		return nil
+	case nC < 2:
+		return coeffTokenTables[0]
+	case nC < 4:
+		return coeffTokenTables[1]
+	case nC < 8:
+		return coeffTokenTables[2]
+	}
+	return coeffTokenTables[3]
+}
+
+// totalZerosTable select total_zeros vlc of tzVlcIndex by maxNumCoeff
+func totalZerosTable(maxNumCoeff, tzVlcIndex int) *vlcTable {
+	switch maxNumCoeff {
+	case 4:
+		return totalZeros2x2[tzVlcIndex-1]
+	case 8:
+		return totalZeros2x4[tzVlcIndex-1]
+	}
+	return totalZeros4x4[tzVlcIndex-1]
+}
+
+// ResidualBlock parse residual_block_cavlc, coeffLevel[startIdx..endIdx] are
+// set in scan order and the others left untouched. len(coeffLevel) must be maxNumCoeff.
+// Return TotalCoeff( coeff_token ), kept by caller for nC of following blocks.
+// T-REC-H.264-201402-S!!PDF-E.pdf 7.3.5.3.2 Residual block CAVLC syntax
+func ResidualBlock(br *rbr.Reader, nC int, coeffLevel []int32, startIdx, endIdx, maxNumCoeff int) (int, error) {
+	for i := startIdx; i <= endIdx; i++ {
+		coeffLevel[i] = 0
+	}
+	table := coeffTokenTable(nC)
+	if table == nil {
+		return 0, fmt.Errorf("invalid nC %v", nC)
+	}
+	token, err := table.read(br)
+	if err != nil {
+		return 0, fmt.Errorf("coeff_token: %v", err)
+	}
+	totalCoeff, trailingOnes := int(token>>2), int(token&3)
+	if totalCoeff == 0 {
+		return 0, nil
+	}
+	if totalCoeff > endIdx-startIdx+1 {
+		return 0, fmt.Errorf("TotalCoeff %v exceeds block of %v coefficients", totalCoeff, endIdx-startIdx+1)
+	}
+
+	var levelVal [16]int32
+	if err := readLevels(br, levelVal[:totalCoeff], trailingOnes); err != nil {
+		return 0, err
+	}
+
+	zerosLeft := 0
+	if totalCoeff < endIdx-startIdx+1 {
+		tz, err := totalZerosTable(maxNumCoeff, totalCoeff).read(br)
+		if err != nil {
+			return 0, fmt.Errorf("total_zeros: %v", err)
+		}
+		zerosLeft = int(tz)
+		if totalCoeff+zerosLeft > endIdx-startIdx+1 {
+			return 0, fmt.Errorf("total_zeros %v exceeds block", zerosLeft)
+		}
+	}
+	var runVal [16]int
+	for i := 0; i < totalCoeff-1; i++ {
+		if zerosLeft > 0 {
+			idx := zerosLeft
+			if idx > 7 {
+				idx = 7
+			}
+			run, err := runBeforeTables[idx-1].read(br)
+			if err != nil {
+				return 0, fmt.Errorf("run_before: %v", err)
+			}
+			if int(run) > zerosLeft {
+				return 0, fmt.Errorf("run_before %v exceeds zerosLeft %v", run, zerosLeft)
+			}
+			runVal[i] = int(run)
+			zerosLeft -= int(run)
+		}
+	}
+	runVal[totalCoeff-1] = zerosLeft
+
+	coeffNum := -1
+	for i := totalCoeff - 1; i >= 0; i-- {
+		coeffNum += runVal[i] + 1
+		coeffLevel[startIdx+coeffNum] = levelVal[i]
+	}
+	return totalCoeff, nil
+}
+
+// readLevels parse trailing_ones_sign_flag, level_prefix and level_suffix
+// into levelVal, highest frequency first, 9.2.2
+func readLevels(br *rbr.Reader, levelVal []int32, trailingOnes int) error {
+	totalCoeff := len(levelVal)
+	for i := 0; i < trailingOnes; i++ {
+		sign, err := br.Read1()
+		if err != nil {
+			return err
+		}
+		levelVal[i] = 1
+		if sign {
+			levelVal[i] = -1
+		}
+	}
+	suffixLength := uint(0)
+	if totalCoeff > 10 && trailingOnes < 3 {
+		suffixLength = 1
+	}
+	for i := trailingOnes; i < totalCoeff; i++ {
+		levelPrefix, err := readLevelPrefix(br)
+		if err != nil {
+			return err
+		}
+		levelSuffixSize := suffixLength
+		if levelPrefix == 14 && suffixLength == 0 {
+			levelSuffixSize = 4
+		} else if levelPrefix >= 15 {
+			levelSuffixSize = levelPrefix - 3
+		}
+		levelSuffix := uint32(0)
+		if levelSuffixSize > 0 {
+			if levelSuffix, err = br.Read32(levelSuffixSize); err != nil {
+				return err
+			}
+		}
+		prefix := levelPrefix
+		if prefix > 15 {
+			prefix = 15
+		}
+		levelCode := int32(prefix<<suffixLength) + int32(levelSuffix)
+		if levelPrefix >= 15 && suffixLength == 0 {
+			levelCode += 15
+		}
+		if levelPrefix >= 16 {
+			levelCode += (1 << (levelPrefix - 3)) - 4096
+		}
+		if i == trailingOnes && trailingOnes < 3 {
+			levelCode += 2
+		}
+		if levelCode%2 == 0 {
+			levelVal[i] = (levelCode + 2) >> 1
+		} else {
+			levelVal[i] = (-levelCode - 1) >> 1
+		}
+		if suffixLength == 0 {
+			suffixLength = 1
+		}
+		if abs(levelVal[i]) > 3<<(suffixLength-1) && suffixLength < 6 {
+			suffixLength++
+		}
+	}
+	return nil
+}
+
+// readLevelPrefix parse level_prefix, 9.2.2.1
+func readLevelPrefix(br *rbr.Reader) (uint, error) {
+	for zeros := uint(0); ; zeros++ {
+		b, err := br.Read1()
+		if err != nil {
+			return 0, err
+		}
+		if b {
+			return zeros, nil
+		}
+		// level_prefix greater than 15 is only allowed in high profiles,
+		// bound it to keep levelSuffixSize readable
+		if zeros > 28 {
+			return 0, fmt.Errorf("invalid level_prefix")
+		}
+	}
+}
+
+func abs(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
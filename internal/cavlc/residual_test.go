@@ -0,0 +1,299 @@
+package cavlc
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/LiveStudioSolution/h264decoder/internal/rbr"
+)
+
+// bitsReader return a reader of a bit string, spaces ignored
+func bitsReader(s string) *rbr.Reader {
+	w := rbr.NewWriter()
+	writeCode(w, s)
+	return rbr.NewReader(w.Bytes())
+}
+
+func writeCode(w *rbr.Writer, code string) {
+	for _, c := range strings.ReplaceAll(code, " ", "") {
+		w.WriteFlag(c == '1')
+	}
+}
+
+func TestResidualBlock(t *testing.T) {
+	tests := []struct {
+		name        string
+		bits        string
+		nC          int
+		maxNumCoeff int
+		want        []int32
+		totalCoeff  int
+	}{
+		{
+			// coeff_token, trailing ones signs, levels 1 and 3, total_zeros 3, run_before 1 0 0 1
+			"4x4 five coefficients",
+			"0000100 011 1 0010 111 10 1 1 01",
+			0, 16,
+			[]int32{0, 3, 0, 1, -1, -1, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0},
+			5,
+		},
+		{
+			"4x4 empty",
+			"1",
+			0, 16,
+			make([]int32, 16),
+			0,
+		},
+		{
+			// level 100 with level_prefix 15 escape, suffix 166
+			"4x4 escape level",
+			"0001 01 0000 0000 0000 0001 0000 1010 0110 1",
+			1, 16,
+			[]int32{100, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+			1,
+		},
+		{
+			// nC 2..3 vlc, two trailing ones, total_zeros 14 of tzVlcIndex 2, run_before 0
+			"4x4 nC 3",
+			"011 10 0000 00 111",
+			3, 16,
+			[]int32{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, -1},
+			2,
+		},
+		{
+			"chroma DC 2x2",
+			"1 0 1",
+			NCChromaDC420, 4,
+			[]int32{1, 0, 0, 0},
+			1,
+		},
+		{
+			// TotalCoeff 2 with no trailing ones, levels -2 and 4, total_zeros 1, run_before 1
+			"chroma DC 2x4",
+			"0001 110 01 0001 0 01 0",
+			NCChromaDC422, 8,
+			[]int32{4, 0, -2, 0, 0, 0, 0, 0},
+			2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			br := bitsReader(tt.bits)
+			got := make([]int32, tt.maxNumCoeff)
+			totalCoeff, err := ResidualBlock(br, tt.nC, got, 0, tt.maxNumCoeff-1, tt.maxNumCoeff)
+			if err != nil {
+				t.Fatalf("ResidualBlock() error = %v", err)
+			}
+			if totalCoeff != tt.totalCoeff || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ResidualBlock() = %v, %v, want %v, %v", totalCoeff, got, tt.totalCoeff, tt.want)
+			}
+			if want := len(strings.ReplaceAll(tt.bits, " ", "")); br.BitPos() != want {
+				t.Errorf("ResidualBlock() read %v bits, want %v", br.BitPos(), want)
+			}
+		})
+	}
+}
+
+func TestResidualBlockInvalid(t *testing.T) {
+	tests := []struct {
+		name        string
+		bits        string
+		nC          int
+		maxNumCoeff int
+	}{
+		{"no valid coeff_token", "0000 0000 0000 0000", 0, 16},
+		{"TotalCoeff over block size", "0000 0000 0000 0100", 0, 15},
+		{"truncated", "0000 100", 0, 16},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coeff := make([]int32, tt.maxNumCoeff)
+			if _, err := ResidualBlock(bitsReader(tt.bits), tt.nC, coeff, 0, tt.maxNumCoeff-1, tt.maxNumCoeff); err == nil {
+				t.Errorf("ResidualBlock() error = nil")
+			}
+		})
+	}
+}
+
+func TestNC(t *testing.T) {
+	tests := []struct {
+		availableA, availableB bool
+		nA, nB, want           int
+	}{
+		{true, true, 3, 4, 4},
+		{true, false, 3, 4, 3},
+		{false, true, 3, 4, 4},
+		{false, false, 3, 4, 0},
+	}
+	for _, tt := range tests {
+		if got := NC(tt.availableA, tt.availableB, tt.nA, tt.nB); got != tt.want {
+			t.Errorf("NC(%v) = %v, want %v", tt, got, tt.want)
+		}
+	}
+}
+
+// encodeResidual write residual_block_cavlc of coeffLevel, the inverse of ResidualBlock
+func encodeResidual(w *rbr.Writer, nC int, coeffLevel []int32, maxNumCoeff int) {
+	var levels []int32
+	var runs []int
+	run := 0
+	for _, c := range coeffLevel {
+		if c == 0 {
+			run++
+			continue
+		}
+		levels = append([]int32{c}, levels...)
+		runs = append([]int{run}, runs...)
+		run = 0
+	}
+	totalCoeff := len(levels)
+	trailingOnes := 0
+	for trailingOnes < totalCoeff && trailingOnes < 3 && abs(levels[trailingOnes]) == 1 {
+		trailingOnes++
+	}
+	col := 0
+	switch {
+	case nC == NCChromaDC420:
+		col = 4
+	case nC == NCChromaDC422:
+		col = 5
+	case nC >= 8:
+		col = 3
+	case nC >= 4:
+		col = 2
+	case nC >= 2:
+		col = 1
+	}
+	writeCode(w, coeffTokenCodes[col][trailingOnes][totalCoeff])
+	if totalCoeff == 0 {
+		return
+	}
+	for i := 0; i < trailingOnes; i++ {
+		w.WriteFlag(levels[i] < 0)
+	}
+	suffixLength := uint(0)
+	if totalCoeff > 10 && trailingOnes < 3 {
+		suffixLength = 1
+	}
+	for i := trailingOnes; i < totalCoeff; i++ {
+		levelCode := 2*abs(levels[i]) - 2
+		if levels[i] < 0 {
+			levelCode++
+		}
+		if i == trailingOnes && trailingOnes < 3 {
+			levelCode -= 2
+		}
+		switch {
+		case suffixLength == 0 && levelCode < 14:
+			writeCode(w, strings.Repeat("0", int(levelCode))+"1")
+		case suffixLength == 0 && levelCode < 30:
+			writeCode(w, strings.Repeat("0", 14)+"1")
+			w.WriteBits(uint64(levelCode-14), 4)
+		case suffixLength > 0 && levelCode < 15<<suffixLength:
+			writeCode(w, strings.Repeat("0", int(levelCode>>suffixLength))+"1")
+			w.WriteBits(uint64(levelCode)&(1<<suffixLength-1), suffixLength)
+		default:
+			base := int32(15 << suffixLength)
+			if suffixLength == 0 {
+				base += 15
+			}
+			prefix := uint(15)
+			for ; ; prefix++ {
+				b := base
+				if prefix >= 16 {
+					b += 1<<(prefix-3) - 4096
+				}
+				if levelCode-b < 1<<(prefix-3) {
+					base = b
+					break
+				}
+			}
+			writeCode(w, strings.Repeat("0", int(prefix))+"1")
+			w.WriteBits(uint64(levelCode-base), prefix-3)
+		}
+		if suffixLength == 0 {
+			suffixLength = 1
+		}
+		if abs(levels[i]) > 3<<(suffixLength-1) && suffixLength < 6 {
+			suffixLength++
+		}
+	}
+	// total_zeros count zeros before the highest frequency coefficient
+	zerosLeft := 0
+	for _, r := range runs {
+		zerosLeft += r
+	}
+	if totalCoeff < len(coeffLevel) {
+		var codes []string
+		switch maxNumCoeff {
+		case 4:
+			codes = totalZeros2x2Codes[totalCoeff-1]
+		case 8:
+			codes = totalZeros2x4Codes[totalCoeff-1]
+		default:
+			codes = totalZeros4x4Codes[totalCoeff-1]
+		}
+		writeCode(w, codes[zerosLeft])
+	}
+	for i := 0; i < totalCoeff-1 && zerosLeft > 0; i++ {
+		idx := zerosLeft
+		if idx > 7 {
+			idx = 7
+		}
+		writeCode(w, runBeforeCodes[idx-1][runs[i]])
+		zerosLeft -= runs[i]
+	}
+}
+
+func TestResidualBlockRoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	blocks := []struct {
+		nC          int
+		maxNumCoeff int
+	}{
+		{0, 16}, {1, 15}, {2, 16}, {5, 16}, {9, 15}, {NCChromaDC420, 4}, {NCChromaDC422, 8},
+	}
+	for n := 0; n < 2000; n++ {
+		b := blocks[n%len(blocks)]
+		coeff := make([]int32, b.maxNumCoeff)
+		density := rnd.Intn(100)
+		for i := range coeff {
+			if rnd.Intn(100) >= density {
+				continue
+			}
+			switch rnd.Intn(4) {
+			case 0:
+				coeff[i] = int32(rnd.Intn(3000)) - 1500
+			case 1:
+				coeff[i] = int32(rnd.Intn(40)) - 20
+			default:
+				coeff[i] = int32(rnd.Intn(2)*2 - 1)
+			}
+		}
+		w := rbr.NewWriter()
+		encodeResidual(w, b.nC, coeff, b.maxNumCoeff)
+		bitSize := w.BitPos()
+		w.WriteTrailingBits()
+
+		br := rbr.NewReader(w.Bytes())
+		got := make([]int32, b.maxNumCoeff)
+		totalCoeff, err := ResidualBlock(br, b.nC, got, 0, b.maxNumCoeff-1, b.maxNumCoeff)
+		if err != nil {
+			t.Fatalf("ResidualBlock(%v) nC %v error = %v", coeff, b.nC, err)
+		}
+		if !reflect.DeepEqual(got, coeff) || br.BitPos() != bitSize {
+			t.Fatalf("ResidualBlock() nC %v = %v, %v bits, want %v, %v bits", b.nC, got, br.BitPos(), coeff, bitSize)
+		}
+		nonZero := 0
+		for _, c := range coeff {
+			if c != 0 {
+				nonZero++
+			}
+		}
+		if totalCoeff != nonZero {
+			t.Fatalf("ResidualBlock() TotalCoeff = %v, want %v", totalCoeff, nonZero)
+		}
+	}
+}
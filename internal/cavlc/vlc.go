@@ -0,0 +1,212 @@
+package cavlc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/LiveStudioSolution/h264decoder/internal/rbr"
+)
+
+// coeffTokenCodes codewords of coeff_token indexed by vlc, TrailingOnes and TotalCoeff,
+// empty for combinations not allowed
+// T-REC-H.264-201402-S!!PDF-E.pdf Table 9-5 – coeff_token mapping to TotalCoeff( coeff_token ) and TrailingOnes( coeff_token )
+var coeffTokenCodes = [6][4][17]string{
+	// 0 <= nC < 2
+	{
+		{"1", "0001 01", "0000 0111", "0000 0011 1", "0000 0001 11", "0000 0000 111", "0000 0000 0111 1", "0000 0000 0101 1", "0000 0000 0100 0", "0000 0000 0011 11", "0000 0000 0010 11", "0000 0000 0001 111", "0000 0000 0001 011", "0000 0000 0000 1111", "0000 0000 0000 1011", "0000 0000 0000 0111", "0000 0000 0000 0100"},
+		{"", "01", "0001 00", "0000 0110", "0000 0011 0", "0000 0001 10", "0000 0000 110", "0000 0000 0111 0", "0000 0000 0101 0", "0000 0000 0011 10", "0000 0000 0010 10", "0000 0000 0001 110", "0000 0000 0001 010", "0000 0000 0000 001", "0000 0000 0000 1110", "0000 0000 0000 1010", "0000 0000 0000 0110"},
+		{"", "", "001", "0000 101", "0000 0101", "0000 0010 1", "0000 0001 01", "0000 0000 101", "0000 0000 0110 1", "0000 0000 0100 1", "0000 0000 0011 01", "0000 0000 0010 01", "0000 0000 0001 101", "0000 0000 0001 001", "0000 0000 0000 1101", "0000 0000 0000 1001", "0000 0000 0000 0101"},
+		{"", "", "", "0001 1", "0000 11", "0000 100", "0000 0100", "0000 0010 0", "0000 0001 00", "0000 0000 100", "0000 0000 0110 0", "0000 0000 0011 00", "0000 0000 0010 00", "0000 0000 0001 100", "0000 0000 0001 000", "0000 0000 0000 1100", "0000 0000 0000 1000"},
+	},
+	// 2 <= nC < 4
+	{
+		{"11", "0010 11", "0001 11", "0000 111", "0000 0111", "0000 0100", "0000 0011 1", "0000 0001 111", "0000 0001 011", "0000 0000 1111", "0000 0000 1011", "0000 0000 1000", "0000 0000 0111 1", "0000 0000 0101 1", "0000 0000 0011 1", "0000 0000 0010 01", "0000 0000 0001 11"},
+		{"", "10", "0011 1", "0010 10", "0001 10", "0000 110", "0000 0110", "0000 0011 0", "0000 0001 110", "0000 0001 010", "0000 0000 1110", "0000 0000 1010", "0000 0000 0111 0", "0000 0000 0101 0", "0000 0000 0010 11", "0000 0000 0010 00", "0000 0000 0001 10"},
+		{"", "", "011", "0010 01", "0001 01", "0000 101", "0000 0101", "0000 0010 1", "0000 0001 101", "0000 0001 001", "0000 0000 1101", "0000 0000 1001", "0000 0000 0110 1", "0000 0000 0100 1", "0000 0000 0011 0", "0000 0000 0010 10", "0000 0000 0001 01"},
+		{"", "", "", "0101", "0100", "0011 0", "0010 00", "0001 00", "0000 100", "0000 0010 0", "0000 0001 100", "0000 0001 000", "0000 0000 1100", "0000 0000 0110 0", "0000 0000 0100 0", "0000 0000 0000 1", "0000 0000 0001 00"},
+	},
+	// 4 <= nC < 8
+	{
+		{"1111", "0011 11", "0010 11", "0010 00", "0001 111", "0001 011", "0001 001", "0001 000", "0000 1111", "0000 1011", "0000 0111 1", "0000 0101 1", "0000 0100 0", "0000 0011 01", "0000 0010 01", "0000 0001 01", "0000 0000 01"},
+		{"", "1110", "0111 1", "0110 0", "0101 0", "0100 0", "0011 10", "0010 10", "0001 110", "0000 1110", "0000 1010", "0000 0111 0", "0000 0101 0", "0000 0011 1", "0000 0011 00", "0000 0010 00", "0000 0001 00"},
+		{"", "", "1101", "0111 0", "0101 1", "0100 1", "0011 01", "0010 01", "0001 101", "0001 010", "0000 1101", "0000 1001", "0000 0110 1", "0000 0100 1", "0000 0010 11", "0000 0001 11", "0000 0000 11"},
+		{"", "", "", "1100", "1011", "1010", "1001", "1000", "0110 1", "0011 00", "0001 100", "0000 1100", "0000 1000", "0000 0110 0", "0000 0010 10", "0000 0001 10", "0000 0000 10"},
+	},
+	// 8 <= nC
+	{
+		{"0000 11", "0000 00", "0001 00", "0010 00", "0011 00", "0100 00", "0101 00", "0110 00", "0111 00", "1000 00", "1001 00", "1010 00", "1011 00", "1100 00", "1101 00", "1110 00", "1111 00"},
+		{"", "0000 01", "0001 01", "0010 01", "0011 01", "0100 01", "0101 01", "0110 01", "0111 01", "1000 01", "1001 01", "1010 01", "1011 01", "1100 01", "1101 01", "1110 01", "1111 01"},
+		{"", "", "0001 10", "0010 10", "0011 10", "0100 10", "0101 10", "0110 10", "0111 10", "1000 10", "1001 10", "1010 10", "1011 10", "1100 10", "1101 10", "1110 10", "1111 10"},
+		{"", "", "", "0010 11", "0011 11", "0100 11", "0101 11", "0110 11", "0111 11", "1000 11", "1001 11", "1010 11", "1011 11", "1100 11", "1101 11", "1110 11", "1111 11"},
+	},
+	// nC == -1
+	{
+		{"01", "0001 11", "0001 00", "0000 11", "0000 10", "", "", "", "", "", "", "", "", "", "", "", ""},
+		{"", "1", "0001 10", "0000 011", "0000 0011", "", "", "", "", "", "", "", "", "", "", "", ""},
+		{"", "", "001", "0000 010", "0000 0010", "", "", "", "", "", "", "", "", "", "", "", ""},
+		{"", "", "", "0001 01", "0000 000", "", "", "", "", "", "", "", "", "", "", "", ""},
+	},
+	// nC == -2
+	{
+		{"1", "0001 111", "0001 110", "0000 0011 1", "0000 0011 0", "0000 0001 11", "0000 0000 111", "0000 0000 0111", "0000 0000 0011 1", "", "", "", "", "", "", "", ""},
+		{"", "01", "0001 101", "0001 100", "0000 0010 1", "0000 0001 10", "0000 0000 110", "0000 0000 0110", "0000 0000 0101", "", "", "", "", "", "", "", ""},
+		{"", "", "001", "0001 011", "0001 010", "0000 0010 0", "0000 0001 01", "0000 0000 101", "0000 0000 0100", "", "", "", "", "", "", "", ""},
+		{"", "", "", "0000 1", "0000 01", "0001 001", "0001 000", "0000 0001 00", "0000 0000 100", "", "", "", "", "", "", "", ""},
+	},
+}
+
+// totalZeros4x4Codes codewords of total_zeros indexed by tzVlcIndex-1 and total_zeros
+// T-REC-H.264-201402-S!!PDF-E.pdf Table 9-7 and Table 9-8
+var totalZeros4x4Codes = [15][]string{
+	{"1", "011", "010", "0011", "0010", "0001 1", "0001 0", "0000 11", "0000 10", "0000 011", "0000 010", "0000 0011", "0000 0010", "0000 0001 1", "0000 0001 0", "0000 0000 1"},
+	{"111", "110", "101", "100", "011", "0101", "0100", "0011", "0010", "0001 1", "0001 0", "0000 11", "0000 10", "0000 01", "0000 00"},
+	{"0101", "111", "110", "101", "0100", "0011", "100", "011", "0010", "0001 1", "0001 0", "0000 01", "0000 1", "0000 00"},
+	{"0001 1", "111", "0101", "0100", "110", "101", "100", "0011", "011", "0010", "0001 0", "0000 1", "0000 0"},
+	{"0101", "0100", "0011", "111", "110", "101", "100", "011", "0010", "0000 1", "0001", "0000 0"},
+	{"0000 01", "0000 1", "111", "110", "101", "100", "011", "010", "0001", "001", "0000 00"},
+	{"0000 01", "0000 1", "101", "100", "011", "11", "010", "0001", "001", "0000 00"},
+	{"0000 01", "0001", "0000 1", "011", "11", "10", "010", "001", "0000 00"},
+	{"0000 01", "0000 00", "0001", "11", "10", "001", "01", "0000 1"},
+	{"0000 1", "0000 0", "001", "11", "10", "01", "0001"},
+	{"0000", "0001", "001", "010", "1", "011"},
+	{"0000", "0001", "01", "1", "001"},
+	{"000", "001", "1", "01"},
+	{"00", "01", "1"},
+	{"0", "1"},
+}
+
+// totalZeros2x2Codes codewords of total_zeros for chroma DC 2x2 blocks
+// T-REC-H.264-201402-S!!PDF-E.pdf Table 9-9 (a)
+var totalZeros2x2Codes = [3][]string{
+	{"1", "01", "001", "000"},
+	{"1", "01", "00"},
+	{"1", "0"},
+}
+
+// totalZeros2x4Codes codewords of total_zeros for chroma DC 2x4 blocks
+// T-REC-H.264-201402-S!!PDF-E.pdf Table 9-9 (b)
+var totalZeros2x4Codes = [7][]string{
+	{"1", "010", "011", "0010", "0011", "0001", "0000 1", "0000 0"},
+	{"000", "01", "001", "100", "101", "110", "111"},
+	{"000", "001", "01", "10", "110", "111"},
+	{"110", "00", "01", "10", "111"},
+	{"00", "01", "10", "11"},
+	{"00", "01", "1"},
+	{"0", "1"},
+}
+
+// runBeforeCodes codewords of run_before indexed by Min(zerosLeft, 7)-1 and run_before
+// T-REC-H.264-201402-S!!PDF-E.pdf Table 9-10 – Tables for run_before
+var runBeforeCodes = [7][]string{
+	{"1", "0"},
+	{"1", "01", "00"},
+	{"11", "10", "01", "00"},
+	{"11", "10", "01", "001", "000"},
+	{"11", "10", "011", "010", "001", "000"},
+	{"11", "000", "001", "011", "010", "101", "100"},
+	{"111", "110", "101", "100", "011", "010", "001", "0001", "0000 1", "0000 01", "0000 001", "0000 0001", "0000 0000 1", "0000 0000 01", "0000 0000 001"},
+}
+
+// vlcEntry decoded value and codeword length, length 0 marks an invalid code
+type vlcEntry struct {
+	value uint16
+	len   uint8
+}
+
+// vlcTable lookup table indexed by the next maxLen bits
+type vlcTable struct {
+	maxLen uint
+	lut    []vlcEntry
+}
+
+// newVlcTable build lookup table of codes, code i decoding to values[i],
+// empty codes are skipped
+func newVlcTable(codes []string, values []uint16) *vlcTable {
+	bits := make([]string, len(codes))
+	t := &vlcTable{}
+	for i, c := range codes {
+		bits[i] = strings.ReplaceAll(c, " ", "")
+		if uint(len(bits[i])) > t.maxLen {
+			t.maxLen = uint(len(bits[i]))
+		}
+	}
+	t.lut = make([]vlcEntry, 1<<t.maxLen)
+	for i, b := range bits {
+		if b == "" {
+			continue
+		}
+		code := 0
+		for _, c := range b {
+			code = code<<1 | int(c-'0')
+		}
+		shift := t.maxLen - uint(len(b))
+		for j := code << shift; j < (code+1)<<shift; j++ {
+			if t.lut[j].len != 0 {
+				panic(fmt.Sprintf("vlc code %v is not prefix free", b))
+			}
+			t.lut[j] = vlcEntry{value: values[i], len: uint8(len(b))}
+		}
+	}
+	return t
+}
+
+// newIndexVlcTable build lookup table of codes, code i decoding to i
+func newIndexVlcTable(codes []string) *vlcTable {
+	values := make([]uint16, len(codes))
+	for i := range values {
+		values[i] = uint16(i)
+	}
+	return newVlcTable(codes, values)
+}
+
+// read decode next codeword of br
+func (t *vlcTable) read(br *rbr.Reader) (uint16, error) {
+	n := t.maxLen
+	left := uint(br.BitsLeft())
+	if left < n {
+		n = left
+	}
+	bits, err := br.Peek32(n)
+	if err != nil {
+		return 0, err
+	}
+	e := t.lut[bits<<(t.maxLen-n)]
+	if e.len == 0 || uint(e.len) > n {
+		return 0, fmt.Errorf("invalid vlc code")
+	}
+	return e.value, br.Skip(uint(e.len))
+}
+
+var (
+	coeffTokenTables [6]*vlcTable
+	totalZeros4x4    [15]*vlcTable
+	totalZeros2x2    [3]*vlcTable
+	totalZeros2x4    [7]*vlcTable
+	runBeforeTables  [7]*vlcTable
+)
+
+func init() {
+	for i := range coeffTokenTables {
+		var codes []string
+		var values []uint16
+		for t1 := 0; t1 < 4; t1++ {
+			for tc := 0; tc < 17; tc++ {
+				codes = append(codes, coeffTokenCodes[i][t1][tc])
+				values = append(values, uint16(tc<<2|t1))
+			}
+		}
+		coeffTokenTables[i] = newVlcTable(codes, values)
+	}
+	for i := range totalZeros4x4 {
+		totalZeros4x4[i] = newIndexVlcTable(totalZeros4x4Codes[i])
+	}
+	for i := range totalZeros2x2 {
+		totalZeros2x2[i] = newIndexVlcTable(totalZeros2x2Codes[i])
+	}
+	for i := range totalZeros2x4 {
+		totalZeros2x4[i] = newIndexVlcTable(totalZeros2x4Codes[i])
+	}
+	for i := range runBeforeTables {
+		runBeforeTables[i] = newIndexVlcTable(runBeforeCodes[i])
+	}
+}
@@ -0,0 +1,292 @@
+// Package deblock the in-loop deblocking filter, applied to a decoded picture once
+// all its macroblocks are constructed,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.7 Deblocking filter process
+package deblock
+
+import (
+	"fmt"
+
+	"github.com/LiveStudioSolution/h264decoder/internal"
+	"github.com/LiveStudioSolution/h264decoder/internal/frame"
+	"github.com/LiveStudioSolution/h264decoder/internal/macroblock"
+	"github.com/LiveStudioSolution/h264decoder/internal/motion"
+	"github.com/LiveStudioSolution/h264decoder/internal/slice"
+	"github.com/LiveStudioSolution/h264decoder/internal/transform"
+)
+
+// Options of the deblocking filter
+type Options struct {
+	// SkipNonRef leave pictures of nal_ref_idc 0 unfiltered. No picture is predicted
+	// from them so the error does not propagate, but the output is no longer the
+	// conforming one. For previews where decoding speed matters most.
+	SkipNonRef bool
+}
+
+// Picture a decoded picture and what its filtering depends on
+type Picture struct {
+	// Planes luma and chroma samples of the frame, for a field picture the frame the
+	// field belongs to
+	Planes [3]*frame.Plane
+	// Syntax macroblocks of the picture as parsed
+	Syntax *macroblock.Picture
+	// Motion of the inter macroblocks of the picture
+	Motion *motion.Picture
+	// Headers slice headers of the picture by SliceNum
+	Headers []*slice.Header
+}
+
+// filter state of the filtering of one picture
+type filter struct {
+	*Picture
+	sps             *internal.SPS
+	chromaArrayType int
+	fieldPic        bool
+	// planes of the picture, the field planes of a field picture
+	planes [3]*frame.Plane
+}
+
+// Filter apply the deblocking filter to the macroblocks of pic in increasing address
+// order, in place
+func Filter(pic *Picture, opts Options) error {
+	if len(pic.Headers) == 0 {
+		return fmt.Errorf("no slice header")
+	}
+	h := pic.Headers[0]
+	if opts.SkipNonRef && h.NalRefIdc == 0 {
+		return nil
+	}
+	f := &filter{
+		Picture:         pic,
+		sps:             h.SPS,
+		chromaArrayType: int(h.SPS.ChromaArrayType()),
+		fieldPic:        h.FieldPicFlag,
+	}
+	numPlanes := 3
+	if f.chromaArrayType == 0 {
+		numPlanes = 1
+	}
+	for c := 0; c < numPlanes; c++ {
+		if pic.Planes[c] == nil {
+			return fmt.Errorf("no plane %v", c)
+		}
+		f.planes[c] = pic.Planes[c]
+		if f.fieldPic {
+			f.planes[c] = pic.Planes[c].Field(h.BottomFieldFlag)
+		}
+	}
+	w := pic.Syntax.WidthInMbs
+	if h := len(pic.Syntax.Mbs) / w; f.planes[0].Width < 16*w || f.planes[0].Height < 16*h {
+		return fmt.Errorf("plane %vx%v smaller than %vx%v macroblocks", f.planes[0].Width, f.planes[0].Height, w, h)
+	}
+	for mbAddr := range pic.Syntax.Mbs {
+		if n := pic.Syntax.Mbs[mbAddr].SliceNum; n < 0 || n >= len(pic.Headers) {
+			return fmt.Errorf("macroblock %v of slice %v without header", mbAddr, n)
+		}
+	}
+	for mbAddr := range pic.Syntax.Mbs {
+		f.macroblock(mbAddr)
+	}
+	return nil
+}
+
+// edge an edge of a macroblock, ( xE, yE ) its first sample relative to the macroblock
+type edge struct {
+	vertical  bool
+	fieldMode bool
+	xE, yE    int
+	// bS by luma sample k along the edge
+	bS [16]int
+}
+
+// macroblock filter the edges of macroblock mbAddr, left vertical edge and internal
+// vertical ones first, then the top horizontal edge and internal horizontal ones
+func (f *filter) macroblock(mbAddr int) {
+	mb := &f.Syntax.Mbs[mbAddr]
+	idc := f.Headers[mb.SliceNum].DisableDeblockingFilterIdc
+	if idc == 1 {
+		return
+	}
+	mbaff := f.Syntax.MbaffFrameFlag
+	w := f.Syntax.WidthInMbs
+	fieldModeMbFlag := f.field(mbAddr)
+
+	col, top := mbAddr%w, mbAddr < w
+	if mbaff {
+		col = mbAddr / 2 % w
+		top = mbAddr/2 < w && (fieldModeMbFlag || mbAddr%2 == 0)
+	}
+	filterLeftMbEdgeFlag := col != 0 && (idc != 2 || f.Syntax.MbNeighbour(mbAddr, macroblock.NeighbourA) >= 0)
+	filterTopMbEdgeFlag := !top && (idc != 2 || f.Syntax.MbNeighbour(mbAddr, macroblock.NeighbourB) >= 0)
+	// the top edge of a frame macroblock under a field macroblock pair filtered as
+	// the edges of the two fields
+	fieldTopEdge := mbaff && mbAddr%2 == 0 && mbAddr >= 2*w && !fieldModeMbFlag &&
+		f.Syntax.Mbs[mbAddr-2*w+1].FieldDecodingFlag
+
+	// luma edges by position, 4 being the second edge of the field top edge
+	var vertical, horizontal [5]*edge
+	for i := 0; i < 4; i++ {
+		if i > 0 || filterLeftMbEdgeFlag {
+			vertical[i] = f.newEdge(mbAddr, true, fieldModeMbFlag, 4*i, 0)
+		}
+		if i > 0 || filterTopMbEdgeFlag {
+			horizontal[i] = f.newEdge(mbAddr, false, fieldModeMbFlag, 0, 4*i)
+		}
+	}
+	if filterTopMbEdgeFlag && fieldTopEdge {
+		horizontal[0] = f.newEdge(mbAddr, false, true, 0, 0)
+		horizontal[4] = f.newEdge(mbAddr, false, true, 0, 1)
+	}
+
+	transform8x8 := mb.TransformSize8x8Flag
+	for _, edges := range [][5]*edge{vertical, horizontal} {
+		for i, e := range edges {
+			if e != nil && !(transform8x8 && i%2 == 1 && i < 4) {
+				f.filterEdge(0, mbAddr, e, e.xE, e.yE, func(k int) int { return e.bS[k] })
+			}
+		}
+	}
+	if f.chromaArrayType == 0 {
+		return
+	}
+	subW, subH := f.sps.SubWidthC(), f.sps.SubHeightC()
+	for c := 1; c < 3; c++ {
+		for xE := 0; xE < f.Syntax.MbWidthC; xE += 4 {
+			e := vertical[xE*subW/4]
+			if e == nil || f.chromaArrayType == 3 && transform8x8 && xE%8 == 4 {
+				continue
+			}
+			f.filterEdge(c, mbAddr, e, xE, 0, func(k int) int {
+				switch {
+				case subH == 1:
+					return e.bS[k]
+				case fieldModeMbFlag:
+					return e.bS[2*k]
+				}
+				// the luma row of the same field
+				return e.bS[4*(k/2)+k%2]
+			})
+		}
+		for yE := 0; yE < f.Syntax.MbHeightC; yE += 4 {
+			if f.chromaArrayType == 3 && transform8x8 && yE%8 == 4 {
+				continue
+			}
+			for _, e := range []*edge{horizontal[yE*subH/4], horizontal[4]} {
+				if e == nil || e == horizontal[4] && yE != 0 {
+					continue
+				}
+				f.filterEdge(c, mbAddr, e, 0, yE+e.yE%2, func(k int) int { return e.bS[subW*k] })
+			}
+		}
+	}
+}
+
+// newEdge the luma edge at ( xE, yE ) of macroblock mbAddr with its bS
+func (f *filter) newEdge(mbAddr int, vertical, fieldMode bool, xE, yE int) *edge {
+	e := &edge{vertical: vertical, fieldMode: fieldMode, xE: xE, yE: yE}
+	for k := range e.bS {
+		x, y, dx, dy := f.samples(0, mbAddr, e, xE, yE, k)
+		e.bS[k] = f.strength(f.mbAt(0, x+dx, y+dy), f.mbAt(0, x, y), vertical)
+	}
+	return e
+}
+
+// samples location ( x, y ) of sample q0 of sample set k of edge e of component c,
+// with its edge at ( xE, yE ) of macroblock mbAddr, and the step ( dx, dy ) from qi
+// to qi-1, from q0 to p0 and from pi to pi+1,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.7.1 Filtering process for block edges
+func (f *filter) samples(c, mbAddr int, e *edge, xE, yE, k int) (x, y, dx, dy int) {
+	w := f.Syntax.WidthInMbs
+	xI, yI := mbAddr%w*16, mbAddr/w*16
+	if f.Syntax.MbaffFrameFlag {
+		xI, yI = mbAddr/2%w*16, mbAddr/2/w*32
+		if f.Syntax.Mbs[mbAddr].FieldDecodingFlag {
+			yI += mbAddr % 2
+		} else {
+			yI += mbAddr % 2 * 16
+		}
+	}
+	xP, yP := xI, yI
+	if c != 0 {
+		subW, subH := f.sps.SubWidthC(), f.sps.SubHeightC()
+		xP, yP = xI/subW, (yI+subH-1)/subH
+	}
+	step := 1
+	if e.fieldMode && f.Syntax.MbaffFrameFlag {
+		step = 2
+	}
+	if e.vertical {
+		return xP + xE, yP + step*k, -1, 0
+	}
+	return xP + k, yP + step*yE - yE%2, 0, -step
+}
+
+// filterEdge filter the samples across edge e of component c, ( xE, yE ) its location
+// in the samples of the component and bS the strength of sample set k
+func (f *filter) filterEdge(c, mbAddr int, e *edge, xE, yE int, bS func(k int) int) {
+	n := 16
+	if c != 0 {
+		n = f.Syntax.MbHeightC
+		if !e.vertical {
+			n = f.Syntax.MbWidthC
+		}
+	}
+	bitDepth := int(f.sps.BitDepthY())
+	if c != 0 {
+		bitDepth = int(f.sps.BitDepthC())
+	}
+	plane := f.planes[c]
+	hq := f.Headers[f.Syntax.Mbs[mbAddr].SliceNum]
+	for k := 0; k < n; k++ {
+		strength := bS(k)
+		if strength == 0 {
+			continue
+		}
+		x, y, dx, dy := f.samples(c, mbAddr, e, xE, yE, k)
+		p := f.mbAt(c, x+dx, y+dy)
+		indexA, alpha, beta := thresholds(f.qP(c, p.mbAddr), f.qP(c, mbAddr), hq.FilterOffsetA, hq.FilterOffsetB, bitDepth)
+		var s edgeSamples
+		for i := 0; i < 4; i++ {
+			s.q[i] = int(plane.At(x-i*dx, y-i*dy))
+			s.p[i] = int(plane.At(x+(i+1)*dx, y+(i+1)*dy))
+		}
+		if !s.filter(strength, indexA, alpha, beta, c != 0 && f.chromaArrayType != 3, bitDepth) {
+			continue
+		}
+		for i := 0; i < 3; i++ {
+			if !f.lossless(mbAddr) {
+				plane.Set(x-i*dx, y-i*dy, uint16(s.q[i]))
+			}
+			if !f.lossless(p.mbAddr) {
+				plane.Set(x+(i+1)*dx, y+(i+1)*dy, uint16(s.p[i]))
+			}
+		}
+	}
+}
+
+// qP quantisation parameter of macroblock mbAddr for the edges of component c, QPY of
+// luma or QPC of chroma, the one of QPY 0 for I_PCM macroblocks
+func (f *filter) qP(c, mbAddr int) int {
+	mb := &f.Syntax.Mbs[mbAddr]
+	qpy := mb.QPY
+	if mb.MbType == macroblock.MbIPCM {
+		qpy = 0
+	}
+	if c == 0 {
+		return qpy
+	}
+	pps := f.Headers[mb.SliceNum].PPS
+	offset := pps.ChromaQpIndexOffset
+	if c == 2 {
+		offset = pps.SecondChromaQpIndexOffset
+	}
+	bitDepthC := int(f.sps.BitDepthC())
+	return transform.ChromaQP(qpy, offset, bitDepthC) - 6*(bitDepthC-8)
+}
+
+// lossless report whether macroblock mbAddr is coded losslessly, qpprime_y_zero_transform_bypass_flag
+// equal to 1 and QP'Y equal to 0, its samples left unfiltered
+func (f *filter) lossless(mbAddr int) bool {
+	mb := &f.Syntax.Mbs[mbAddr]
+	return f.sps.QpprimeYZeroTransformBypassFlag && mb.MbType != macroblock.MbIPCM &&
+		mb.QPY+6*int(f.sps.BitDepthLumaMinus8) == 0
+}
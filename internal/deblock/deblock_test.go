@@ -0,0 +1,351 @@
+package deblock
+
+import (
+	"testing"
+
+	"github.com/LiveStudioSolution/h264decoder/internal"
+	"github.com/LiveStudioSolution/h264decoder/internal/frame"
+	"github.com/LiveStudioSolution/h264decoder/internal/macroblock"
+	"github.com/LiveStudioSolution/h264decoder/internal/motion"
+	"github.com/LiveStudioSolution/h264decoder/internal/slice"
+)
+
+// testPicture 4:2:0 picture of w x h intra macroblocks of QPY 40 in one slice, the
+// left half of each macroblock row of samples 60 and the right half 90
+func testPicture(w, h int, mbaff bool) *Picture {
+	hdr := &slice.Header{
+		SliceType:      slice.SliceI,
+		NalRefIdc:      1,
+		MbaffFrameFlag: mbaff,
+		SPS:            &internal.SPS{ChromaFormatIdc: 1},
+		PPS:            &internal.PPS{},
+	}
+	pic := &Picture{
+		Syntax: &macroblock.Picture{
+			Mbs:            make([]macroblock.Macroblock, w*h),
+			WidthInMbs:     w,
+			MbaffFrameFlag: mbaff,
+			MbWidthC:       8,
+			MbHeightC:      8,
+		},
+		Motion:  motion.NewPicture(motion.Frame, mbaff, w, w*h),
+		Headers: []*slice.Header{hdr},
+	}
+	for i := range pic.Syntax.Mbs {
+		pic.Syntax.Mbs[i].Addr = i
+		pic.Syntax.Mbs[i].QPY = 40
+	}
+	for c := range pic.Planes {
+		mbW := 16
+		if c != 0 {
+			mbW = 8
+		}
+		pl := frame.NewPlane(w*mbW, h*mbW)
+		for y := 0; y < pl.Height; y++ {
+			for x := 0; x < pl.Width; x++ {
+				v := uint16(60)
+				if x >= pl.Width/2 {
+					v = 90
+				}
+				pl.Set(x, y, v)
+			}
+		}
+		pic.Planes[c] = pl
+	}
+	return pic
+}
+
+// row samples of row y of plane p
+func row(p *frame.Plane, y int) []uint16 {
+	return append([]uint16(nil), p.Pix[y*p.Stride:y*p.Stride+p.Width]...)
+}
+
+func TestThresholds(t *testing.T) {
+	tests := []struct {
+		qPp, qPq, offsetA, offsetB, bitDepth int
+		indexA, alpha, beta                  int
+	}{
+		{30, 31, 0, 0, 8, 31, 28, 8},
+		{30, 31, 12, -12, 8, 43, 113, 3},
+		{30, 31, 0, 0, 10, 31, 112, 32},
+		{51, 51, 12, 12, 8, 51, 255, 18},
+		{0, 0, -12, -12, 8, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		indexA, alpha, beta := thresholds(tt.qPp, tt.qPq, tt.offsetA, tt.offsetB, tt.bitDepth)
+		if indexA != tt.indexA || alpha != tt.alpha || beta != tt.beta {
+			t.Errorf("thresholds(%v, %v, %v, %v, %v) = %v %v %v, want %v %v %v", tt.qPp, tt.qPq, tt.offsetA,
+				tt.offsetB, tt.bitDepth, indexA, alpha, beta, tt.indexA, tt.alpha, tt.beta)
+		}
+	}
+}
+
+func TestEdgeSamplesFilter(t *testing.T) {
+	flat := edgeSamples{p: [4]int{60, 60, 60, 60}, q: [4]int{70, 70, 70, 70}}
+	tests := []struct {
+		name   string
+		in     edgeSamples
+		bS     int
+		chroma bool
+		ok     bool
+		want   edgeSamples
+	}{
+		{"bS 0", flat, 0, false, false, flat},
+		{"above alpha", edgeSamples{p: [4]int{0, 0, 0, 0}, q: [4]int{90, 90, 90, 90}}, 2, false, false,
+			edgeSamples{p: [4]int{0, 0, 0, 0}, q: [4]int{90, 90, 90, 90}}},
+		{"above beta", edgeSamples{p: [4]int{60, 80, 60, 60}, q: [4]int{70, 70, 70, 70}}, 2, false, false,
+			edgeSamples{p: [4]int{60, 80, 60, 60}, q: [4]int{70, 70, 70, 70}}},
+		{"bS 1", flat, 1, false, true, edgeSamples{p: [4]int{64, 62, 60, 60}, q: [4]int{66, 67, 70, 70}}},
+		{"bS 1 chroma", flat, 1, true, true, edgeSamples{p: [4]int{64, 60, 60, 60}, q: [4]int{66, 70, 70, 70}}},
+		{"bS 4", flat, 4, false, true, edgeSamples{p: [4]int{64, 63, 61, 60}, q: [4]int{66, 68, 69, 70}}},
+		{"bS 4 chroma", flat, 4, true, true, edgeSamples{p: [4]int{63, 60, 60, 60}, q: [4]int{68, 70, 70, 70}}},
+		{"bS 4 step", edgeSamples{p: [4]int{60, 60, 60, 60}, q: [4]int{90, 90, 90, 90}}, 4, false, true,
+			edgeSamples{p: [4]int{68, 60, 60, 60}, q: [4]int{83, 90, 90, 90}}},
+	}
+	// indexA and indexB 40
+	alpha, beta := 80, 13
+	for _, tt := range tests {
+		s := tt.in
+		if ok := s.filter(tt.bS, 40, alpha, beta, tt.chroma, 8); ok != tt.ok || s != tt.want {
+			t.Errorf("%v: filter() = %v %v, want %v %v", tt.name, s, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestStrength(t *testing.T) {
+	// p0 in macroblock 0, q0 in macroblock 1
+	p, q := location{0, 15, 0}, location{1, 0, 0}
+	refs := &motion.RefLists{{{ID: 1}, {ID: 2}}, {{ID: 2}, {ID: 1}}}
+	inter := func(refIdx [2]int8, mv [2][2]int32) motion.Mb {
+		m := motion.Mb{Refs: refs}
+		for l := range m.RefIdx {
+			m.RefIdx[l] = [4]int8{refIdx[l], refIdx[l], refIdx[l], refIdx[l]}
+			for blk := range m.Mv[l] {
+				m.Mv[l][blk] = mv[l]
+			}
+		}
+		return m
+	}
+	tests := []struct {
+		name     string
+		fieldPic bool
+		intra    bool
+		vertical bool
+		coded    bool
+		mbs      [2]motion.Mb
+		want     int
+	}{
+		{"intra", false, true, true, false, [2]motion.Mb{}, 4},
+		{"intra field horizontal", true, true, false, false, [2]motion.Mb{}, 3},
+		{"intra field vertical", true, true, true, false, [2]motion.Mb{}, 4},
+		{"coefficients", false, false, true, true, [2]motion.Mb{inter([2]int8{0, -1}, [2][2]int32{}), inter([2]int8{0, -1}, [2][2]int32{})}, 2},
+		{"same motion", false, false, true, false,
+			[2]motion.Mb{inter([2]int8{0, -1}, [2][2]int32{{3, 3}}), inter([2]int8{0, -1}, [2][2]int32{{0, 0}})}, 0},
+		{"motion vector", false, false, true, false,
+			[2]motion.Mb{inter([2]int8{0, -1}, [2][2]int32{{4, 0}}), inter([2]int8{0, -1}, [2][2]int32{{0, 0}})}, 1},
+		{"field motion vector", true, false, true, false,
+			[2]motion.Mb{inter([2]int8{0, -1}, [2][2]int32{{0, 2}}), inter([2]int8{0, -1}, [2][2]int32{{0, 0}})}, 1},
+		{"reference picture", false, false, true, false,
+			[2]motion.Mb{inter([2]int8{0, -1}, [2][2]int32{}), inter([2]int8{1, -1}, [2][2]int32{})}, 1},
+		// the same picture from list 0 and list 1
+		{"same picture other list", false, false, true, false,
+			[2]motion.Mb{inter([2]int8{0, -1}, [2][2]int32{}), inter([2]int8{-1, 1}, [2][2]int32{})}, 0},
+		{"number of motion vectors", false, false, true, false,
+			[2]motion.Mb{inter([2]int8{0, -1}, [2][2]int32{}), inter([2]int8{0, 0}, [2][2]int32{})}, 1},
+		{"bi-predicted swapped", false, false, true, false,
+			[2]motion.Mb{inter([2]int8{1, 1}, [2][2]int32{{1, 1}, {-8, 0}}), inter([2]int8{0, 0}, [2][2]int32{{-8, 0}, {1, 1}})}, 0},
+		{"bi-predicted", false, false, true, false,
+			[2]motion.Mb{inter([2]int8{1, 1}, [2][2]int32{{1, 1}, {-8, 0}}), inter([2]int8{1, 1}, [2][2]int32{{-8, 0}, {1, 1}})}, 1},
+		// twice the same picture, one of the pairings close enough
+		{"bi-predicted same picture", false, false, true, false,
+			[2]motion.Mb{inter([2]int8{0, 1}, [2][2]int32{{1, 1}, {-8, 0}}), inter([2]int8{0, 1}, [2][2]int32{{-8, 0}, {1, 1}})}, 0},
+	}
+	for _, tt := range tests {
+		pic := testPicture(2, 1, false)
+		pic.Headers[0].FieldPicFlag = tt.fieldPic
+		pic.Headers[0].SliceType = slice.SliceB
+		f := &filter{Picture: pic, sps: pic.Headers[0].SPS, fieldPic: tt.fieldPic}
+		for i := range pic.Syntax.Mbs {
+			if !tt.intra {
+				pic.Syntax.Mbs[i].MbType = macroblock.MbBDirect16x16
+			}
+			pic.Motion.Mbs[i] = tt.mbs[i]
+		}
+		if tt.coded {
+			pic.Syntax.Mbs[0].TotalCoeff[0][5] = 1
+		}
+		if got := f.strength(p, q, tt.vertical); got != tt.want {
+			t.Errorf("%v: strength() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStrengthInternal(t *testing.T) {
+	pic := testPicture(1, 1, false)
+	f := &filter{Picture: pic, sps: pic.Headers[0].SPS}
+	if got := f.strength(location{0, 3, 0}, location{0, 4, 0}, true); got != 3 {
+		t.Errorf("strength(intra internal edge) = %v, want 3", got)
+	}
+	mb := &pic.Syntax.Mbs[0]
+	mb.MbType, mb.TransformSize8x8Flag = macroblock.MbPL016x16, true
+	pic.Motion.Mbs[0].RefIdx = [2][4]int8{{0, 0, 0, 0}, {-1, -1, -1, -1}}
+	// the coefficients of an 8x8 transform block counted for its four 4x4 blocks
+	mb.TotalCoeff[0][3] = 1
+	if got := f.strength(location{0, 7, 4}, location{0, 8, 4}, true); got != 2 {
+		t.Errorf("strength(8x8 transform block) = %v, want 2", got)
+	}
+}
+
+func TestMbaffStrength(t *testing.T) {
+	// a field macroblock pair above a frame macroblock pair, all inter with the same motion
+	pic := testPicture(1, 4, true)
+	f := &filter{Picture: pic, sps: pic.Headers[0].SPS}
+	for i := range pic.Syntax.Mbs {
+		pic.Syntax.Mbs[i].MbType = macroblock.MbPL016x16
+		pic.Syntax.Mbs[i].FieldDecodingFlag = i < 2
+		pic.Motion.Mbs[i].RefIdx = [2][4]int8{{0, 0, 0, 0}, {-1, -1, -1, -1}}
+		pic.Motion.Mbs[i].Field = i < 2
+	}
+	if got := f.mbAt(0, 3, 31); got != (location{1, 3, 15}) {
+		t.Errorf("mbAt(field pair) = %v, want {1 3 15}", got)
+	}
+	if got := f.mbAt(0, 3, 49); got != (location{3, 3, 1}) {
+		t.Errorf("mbAt(frame pair) = %v, want {3 3 1}", got)
+	}
+	if got := f.strength(f.mbAt(0, 0, 30), f.mbAt(0, 0, 32), false); got != 1 {
+		t.Errorf("strength(mixed edge) = %v, want 1", got)
+	}
+	if got := f.strength(f.mbAt(0, 0, 15), f.mbAt(0, 0, 16), false); got != 0 {
+		t.Errorf("strength(frame pair internal edge) = %v, want 0", got)
+	}
+	pic.Syntax.Mbs[1].MbType = macroblock.MbINxN
+	// horizontal edge between a field and a frame macroblock
+	if got := f.strength(f.mbAt(0, 0, 31), f.mbAt(0, 0, 33), false); got != 3 {
+		t.Errorf("strength(mixed intra edge) = %v, want 3", got)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	pic := testPicture(2, 2, false)
+	if err := Filter(pic, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	want := []uint16{60, 60, 68, 83, 90, 90}
+	// rows away from the horizontal macroblock edge, filtered before the vertical
+	// edge of the macroblock right of it
+	for _, y := range []int{0, 8, 24, 28} {
+		if got := row(pic.Planes[0], y)[13:19]; !equal(got, want) {
+			t.Fatalf("Filter() luma row %v = %v, want %v", y, got, want)
+		}
+	}
+	for _, y := range []int{0, 4, 12, 15} {
+		if got := row(pic.Planes[1], y)[5:11]; !equal(got, want) {
+			t.Fatalf("Filter() chroma row %v = %v, want %v", y, got, want)
+		}
+	}
+}
+
+func TestFilterDisabled(t *testing.T) {
+	tests := []struct {
+		name string
+		idc  uint
+		ref  uint8
+		opts Options
+		// rows of the luma macroblock edge filtered
+		filtered [2]bool
+	}{
+		{"disabled", 1, 1, Options{}, [2]bool{false, false}},
+		{"non-reference skipped", 0, 0, Options{SkipNonRef: true}, [2]bool{false, false}},
+		{"non-reference", 0, 0, Options{}, [2]bool{true, true}},
+		{"reference", 0, 1, Options{SkipNonRef: true}, [2]bool{true, true}},
+		// macroblock 1 in another slice than its left neighbour, 3 in the same one
+		{"slice edges", 2, 1, Options{}, [2]bool{false, true}},
+	}
+	for _, tt := range tests {
+		pic := testPicture(2, 2, false)
+		pic.Headers[0].DisableDeblockingFilterIdc = tt.idc
+		pic.Headers[0].NalRefIdc = tt.ref
+		if tt.idc == 2 {
+			pic.Headers = append(pic.Headers, pic.Headers[0])
+			for i := 1; i < 4; i++ {
+				pic.Syntax.Mbs[i].SliceNum = 1
+			}
+		}
+		if err := Filter(pic, tt.opts); err != nil {
+			t.Fatal(err)
+		}
+		for i, y := range []int{0, 16} {
+			got := row(pic.Planes[0], y)[15] != 60
+			if got != tt.filtered[i] {
+				t.Errorf("%v: Filter() row %v filtered %v, want %v", tt.name, y, got, tt.filtered[i])
+			}
+		}
+	}
+}
+
+func TestFilterField(t *testing.T) {
+	// bottom field of 2x1 macroblocks of a 32x32 frame
+	pic := testPicture(2, 2, false)
+	pic.Syntax.Mbs = pic.Syntax.Mbs[:2]
+	pic.Headers[0].FieldPicFlag, pic.Headers[0].BottomFieldFlag = true, true
+	if err := Filter(pic, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	for y := 0; y < 32; y++ {
+		want := []uint16{60, 90}
+		if y%2 == 1 {
+			want = []uint16{68, 83}
+		}
+		if got := row(pic.Planes[0], y)[15:17]; !equal(got, want) {
+			t.Errorf("Filter() luma row %v = %v, want %v", y, got, want)
+		}
+	}
+}
+
+func TestFilterMbaff(t *testing.T) {
+	// a frame macroblock pair left of a field macroblock pair
+	pic := testPicture(2, 2, true)
+	pic.Syntax.Mbs[2].FieldDecodingFlag, pic.Syntax.Mbs[3].FieldDecodingFlag = true, true
+	// QPY 0 for the bottom field macroblock, its rows left unfiltered
+	pic.Syntax.Mbs[3].QPY = 0
+	if err := Filter(pic, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	for y := 0; y < 32; y++ {
+		want := []uint16{68, 83}
+		if y%2 == 1 {
+			want = []uint16{60, 90}
+		}
+		if got := row(pic.Planes[0], y)[15:17]; !equal(got, want) {
+			t.Errorf("Filter() luma row %v = %v, want %v", y, got, want)
+		}
+	}
+}
+
+func TestFilterErrors(t *testing.T) {
+	pic := testPicture(1, 1, false)
+	pic.Syntax.Mbs[0].SliceNum = 1
+	if err := Filter(pic, Options{}); err == nil {
+		t.Errorf("Filter(missing slice header) succeeded")
+	}
+	pic = testPicture(1, 1, false)
+	pic.Planes[0] = frame.NewPlane(8, 8)
+	if err := Filter(pic, Options{}); err == nil {
+		t.Errorf("Filter(small plane) succeeded")
+	}
+	if err := Filter(&Picture{}, Options{}); err == nil {
+		t.Errorf("Filter(no slice) succeeded")
+	}
+}
+
+func equal(a, b []uint16) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
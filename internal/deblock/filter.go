@@ -0,0 +1,129 @@
+package deblock
+
+// alphaTable α' by indexA, Table 8-16
+var alphaTable = [52]int{
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 4, 4, 5, 6, 7, 8, 9, 10, 12, 13,
+	15, 17, 20, 22, 25, 28, 32, 36, 40, 45, 50, 56, 63, 71, 80, 90, 101, 113, 127, 144, 162, 182, 203, 226, 255, 255,
+}
+
+// betaTable β' by indexB, Table 8-16
+var betaTable = [52]int{
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2, 2, 2, 3, 3, 3, 3, 4, 4, 4,
+	6, 6, 7, 7, 8, 8, 9, 9, 10, 10, 11, 11, 12, 12, 13, 13, 14, 14, 15, 15, 16, 16, 17, 17, 18, 18,
+}
+
+// tc0Table t'C0 by bS - 1 and indexA, Table 8-17
+var tc0Table = [3][52]int{
+	{
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 3, 3, 3, 4, 4, 4, 5, 6, 6, 7, 8, 9, 10, 11, 13,
+	},
+	{
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 2, 2, 2, 2, 3, 3, 3, 4, 4, 5, 5, 6, 7, 8, 8, 10, 11, 12, 13, 15, 17,
+	},
+	{
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+		1, 2, 2, 2, 2, 3, 3, 3, 4, 4, 4, 5, 6, 6, 7, 8, 9, 10, 11, 13, 14, 16, 18, 20, 23, 25,
+	},
+}
+
+// thresholds indexA, α and β of an edge between macroblocks of quantisation parameters
+// qPp and qPq,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.7.2.2 Derivation process for the thresholds for each block edge
+func thresholds(qPp, qPq, filterOffsetA, filterOffsetB, bitDepth int) (indexA, alpha, beta int) {
+	qPav := (qPp + qPq + 1) >> 1
+	indexA = clip3(0, 51, qPav+filterOffsetA)
+	indexB := clip3(0, 51, qPav+filterOffsetB)
+	return indexA, alphaTable[indexA] << uint(bitDepth-8), betaTable[indexB] << uint(bitDepth-8)
+}
+
+// edgeSamples p0..p3 and q0..q3 of a set of samples across an edge, p[ 0 ] and q[ 0 ]
+// next to it, Figure 8-11
+type edgeSamples struct {
+	p, q [4]int
+}
+
+// filter the samples across an edge of strength bS, p'i and q'i with i = 0..2
+// replacing pi and qi. It reports whether the samples were filtered.
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.7.2 Filtering process for a set of samples across a horizontal or vertical block edge
+func (s *edgeSamples) filter(bS, indexA, alpha, beta int, chromaStyleFiltering bool, bitDepth int) bool {
+	p, q := &s.p, &s.q
+	if bS == 0 || abs(p[0]-q[0]) >= alpha || abs(p[1]-p[0]) >= beta || abs(q[1]-q[0]) >= beta {
+		return false
+	}
+	if bS < 4 {
+		s.filterNormal(bS, indexA, beta, chromaStyleFiltering, bitDepth)
+	} else {
+		s.filterStrong(alpha, beta, chromaStyleFiltering)
+	}
+	return true
+}
+
+// filterNormal T-REC-H.264-201402-S!!PDF-E.pdf 8.7.2.3 Filtering process for edges with bS less than 4
+func (s *edgeSamples) filterNormal(bS, indexA, beta int, chromaStyleFiltering bool, bitDepth int) {
+	p, q := &s.p, &s.q
+	tc0 := tc0Table[bS-1][indexA] << uint(bitDepth-8)
+	ap, aq := abs(p[2]-p[0]), abs(q[2]-q[0])
+	tc := tc0 + 1
+	if !chromaStyleFiltering {
+		tc = tc0
+		if ap < beta {
+			tc++
+		}
+		if aq < beta {
+			tc++
+		}
+	}
+	delta := clip3(-tc, tc, (((q[0]-p[0])<<2)+(p[1]-q[1])+4)>>3)
+	p0, q0 := p[0], q[0]
+	max := 1<<uint(bitDepth) - 1
+	p[0] = clip3(0, max, p0+delta)
+	q[0] = clip3(0, max, q0-delta)
+	if chromaStyleFiltering {
+		return
+	}
+	if ap < beta {
+		p[1] += clip3(-tc0, tc0, (p[2]+((p0+q0+1)>>1)-(p[1]<<1))>>1)
+	}
+	if aq < beta {
+		q[1] += clip3(-tc0, tc0, (q[2]+((p0+q0+1)>>1)-(q[1]<<1))>>1)
+	}
+}
+
+// filterStrong T-REC-H.264-201402-S!!PDF-E.pdf 8.7.2.4 Filtering process for edges for bS equal to 4
+func (s *edgeSamples) filterStrong(alpha, beta int, chromaStyleFiltering bool) {
+	p, q := s.p, s.q
+	strong := !chromaStyleFiltering && abs(p[0]-q[0]) < (alpha>>2)+2
+	if strong && abs(p[2]-p[0]) < beta {
+		s.p[0] = (p[2] + 2*p[1] + 2*p[0] + 2*q[0] + q[1] + 4) >> 3
+		s.p[1] = (p[2] + p[1] + p[0] + q[0] + 2) >> 2
+		s.p[2] = (2*p[3] + 3*p[2] + p[1] + p[0] + q[0] + 4) >> 3
+	} else {
+		s.p[0] = (2*p[1] + p[0] + q[1] + 2) >> 2
+	}
+	if strong && abs(q[2]-q[0]) < beta {
+		s.q[0] = (p[1] + 2*p[0] + 2*q[0] + 2*q[1] + q[2] + 4) >> 3
+		s.q[1] = (p[0] + q[0] + q[1] + q[2] + 2) >> 2
+		s.q[2] = (2*q[3] + 3*q[2] + q[1] + q[0] + p[0] + 4) >> 3
+	} else {
+		s.q[0] = (2*q[1] + q[0] + p[1] + 2) >> 2
+	}
+}
+
+func clip3(x, y, z int) int {
+	if z < x {
+		return x
+	}
+	if z > y {
+		return y
+	}
+	return z
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,175 @@
+package deblock
+
+import (
+	"github.com/LiveStudioSolution/h264decoder/internal/motion"
+	"github.com/LiveStudioSolution/h264decoder/internal/slice"
+)
+
+// location a sample of a macroblock, ( x, y ) relative to its upper-left sample in
+// the macroblock's own frame or field rows
+type location struct {
+	mbAddr, x, y int
+}
+
+// mbAt the macroblock of the picture containing sample ( x, y ) of component c and
+// the location of the sample inside it
+func (f *filter) mbAt(c, x, y int) location {
+	mbW, mbH := 16, 16
+	if c != 0 {
+		mbW, mbH = f.Syntax.MbWidthC, f.Syntax.MbHeightC
+	}
+	w := f.Syntax.WidthInMbs
+	if !f.Syntax.MbaffFrameFlag {
+		return location{y/mbH*w + x/mbW, x % mbW, y % mbH}
+	}
+	top := 2 * (y/(2*mbH)*w + x/mbW)
+	if f.Syntax.Mbs[top].FieldDecodingFlag {
+		return location{top + y%2, x % mbW, y % (2 * mbH) / 2}
+	}
+	return location{top + y%(2*mbH)/mbH, x % mbW, y % mbH}
+}
+
+// field report whether macroblock mbAddr is a field macroblock
+func (f *filter) field(mbAddr int) bool {
+	return f.fieldPic || f.Syntax.MbaffFrameFlag && f.Syntax.Mbs[mbAddr].FieldDecodingFlag
+}
+
+// intraOrSwitching report whether macroblock mbAddr is intra coded or in an SP or SI slice
+func (f *filter) intraOrSwitching(mbAddr int) bool {
+	mb := &f.Syntax.Mbs[mbAddr]
+	t := f.Headers[mb.SliceNum].SliceType
+	return mb.IsIntra() || t == slice.SliceSP || t == slice.SliceSI
+}
+
+// strength bS of the edge between luma samples p0 and q0,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.7.2.1 Derivation process for the luma content dependent boundary filtering strength
+func (f *filter) strength(p, q location, verticalEdgeFlag bool) int {
+	mbaff := f.Syntax.MbaffFrameFlag
+	mixedModeEdgeFlag := mbaff && p.mbAddr/2 != q.mbAddr/2 && f.field(p.mbAddr) != f.field(q.mbAddr)
+	intra := f.intraOrSwitching(p.mbAddr) || f.intraOrSwitching(q.mbAddr)
+	if p.mbAddr != q.mbAddr && intra {
+		if !f.field(p.mbAddr) && !f.field(q.mbAddr) || (mbaff || f.fieldPic) && verticalEdgeFlag {
+			return 4
+		}
+	}
+	switch {
+	case intra:
+		return 3
+	case f.coded(p) || f.coded(q):
+		return 2
+	case mixedModeEdgeFlag:
+		return 1
+	}
+	// a vertical difference of 4 in quarter frame samples is 2 in quarter field samples
+	limit := int32(4)
+	if f.field(q.mbAddr) {
+		limit = 2
+	}
+	if f.motionDiffers(f.prediction(p), f.prediction(q), limit) {
+		return 1
+	}
+	return 0
+}
+
+// coded report whether the luma transform block containing l has non-zero transform
+// coefficient levels
+func (f *filter) coded(l location) bool {
+	mb := &f.Syntax.Mbs[l.mbAddr]
+	if !mb.TransformSize8x8Flag {
+		return mb.TotalCoeff[0][8*(l.y/8)+4*(l.x/8)+2*(l.y%8/4)+l.x%8/4] != 0
+	}
+	blk8x8 := 2*(l.y/8) + l.x/8
+	for _, n := range mb.TotalCoeff[0][4*blk8x8 : 4*blk8x8+4] {
+		if n != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// refPic identify a reference frame, complementary field pair or field
+type refPic struct {
+	id        int
+	structure motion.Structure
+}
+
+// prediction reference pictures and motion vectors of the partition containing l,
+// the used lists first
+type prediction struct {
+	n    int
+	refs [2]refPic
+	mvs  [2][2]int32
+}
+
+func (f *filter) prediction(l location) prediction {
+	m := &f.Motion.Mbs[l.mbAddr]
+	q := 2*(l.y/8) + l.x/8
+	var pred prediction
+	for list := 0; list < 2; list++ {
+		if !m.PredFlag(list, q) {
+			continue
+		}
+		pred.refs[pred.n] = f.refPic(l.mbAddr, m, list, int(m.RefIdx[list][q]))
+		pred.mvs[pred.n] = m.Mv[list][4*(l.y/4)+l.x/4]
+		pred.n++
+	}
+	return pred
+}
+
+// refPic the picture referred to by refIdx of list of macroblock mbAddr, the field of
+// a frame reference for a field macroblock of an MBAFF frame
+func (f *filter) refPic(mbAddr int, m *motion.Mb, list, refIdx int) refPic {
+	fieldMb := f.Syntax.MbaffFrameFlag && m.Field
+	i := refIdx
+	if fieldMb {
+		i = refIdx >> 1
+	}
+	if m.Refs == nil || i >= len(m.Refs[list]) || m.Refs[list][i] == nil {
+		return refPic{id: -1}
+	}
+	r := m.Refs[list][i]
+	if !fieldMb {
+		return refPic{r.ID, r.Structure}
+	}
+	// same parity as the current macroblock for even indices
+	bottom := mbAddr%2 == 1
+	if refIdx%2 == 1 {
+		bottom = !bottom
+	}
+	if bottom {
+		return refPic{r.ID, motion.BottomField}
+	}
+	return refPic{r.ID, motion.TopField}
+}
+
+// motionDiffers report whether the partitions of p and q use different reference
+// pictures, a different number of motion vectors, or motion vectors for the same
+// reference picture differing by limit or more
+func (f *filter) motionDiffers(p, q prediction, limit int32) bool {
+	if p.n != q.n {
+		return true
+	}
+	if p.n == 1 {
+		return p.refs[0] != q.refs[0] || mvDiffers(p.mvs[0], q.mvs[0], limit)
+	}
+	same := p.refs[0] == q.refs[0] && p.refs[1] == q.refs[1]
+	swapped := p.refs[0] == q.refs[1] && p.refs[1] == q.refs[0]
+	switch {
+	case !same && !swapped:
+		return true
+	case p.refs[0] != p.refs[1] && same:
+		return mvDiffers(p.mvs[0], q.mvs[0], limit) || mvDiffers(p.mvs[1], q.mvs[1], limit)
+	case p.refs[0] != p.refs[1]:
+		return mvDiffers(p.mvs[0], q.mvs[1], limit) || mvDiffers(p.mvs[1], q.mvs[0], limit)
+	}
+	// both motion vectors for the same picture, differing whatever the pairing
+	return (mvDiffers(p.mvs[0], q.mvs[0], limit) || mvDiffers(p.mvs[1], q.mvs[1], limit)) &&
+		(mvDiffers(p.mvs[0], q.mvs[1], limit) || mvDiffers(p.mvs[1], q.mvs[0], limit))
+}
+
+// mvDiffers report whether the horizontal components of a and b differ by 4 or more
+// or the vertical ones by limit or more
+func mvDiffers(a, b [2]int32, limit int32) bool {
+	dx, dy := a[0]-b[0], a[1]-b[1]
+	return dx >= 4 || dx <= -4 || dy >= limit || dy <= -limit
+}
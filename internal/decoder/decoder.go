@@ -0,0 +1,167 @@
+// Package decoder decode the primary coded pictures of access units into frames,
+// running the slice data of each picture through macroblock parsing, prediction,
+// the inverse transform and the deblocking filter,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8 Decoding process
+package decoder
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/LiveStudioSolution/h264decoder/internal/deblock"
+	"github.com/LiveStudioSolution/h264decoder/internal/dpb"
+	"github.com/LiveStudioSolution/h264decoder/internal/frame"
+	"github.com/LiveStudioSolution/h264decoder/internal/poc"
+	"github.com/LiveStudioSolution/h264decoder/internal/reflist"
+	"github.com/LiveStudioSolution/h264decoder/internal/slice"
+)
+
+// Slice a coded slice of a primary coded picture
+type Slice struct {
+	Header *slice.Header
+	// Rbsp of the slice nalu, the slice header included
+	Rbsp []byte
+}
+
+// Decoder decode primary coded pictures in decoding order and output their frames in
+// output order
+type Decoder struct {
+	// Deblock options of the deblocking filter
+	Deblock deblock.Options
+
+	poc poc.Counter
+	dpb *dpb.DPB
+	// headers first slice header of the pictures in the DPB not output yet, by luma plane
+	headers map[*frame.Plane]*slice.Header
+	out     []*frame.VideoFrame
+	// numOut frames output, the timestamp of the next one in frame durations
+	numOut int
+}
+
+// New return a new Decoder
+func New() *Decoder {
+	return &Decoder{dpb: dpb.New(), headers: map[*frame.Plane]*slice.Header{}}
+}
+
+// Decode decode the slices of a primary coded picture in decoding order and store it
+// into the DPB, the frames it outputs are then returned by Output
+func (d *Decoder) Decode(slices []Slice) error {
+	if len(slices) == 0 {
+		return fmt.Errorf("picture without slices")
+	}
+	h := slices[0].Header
+	if err := supported(h); err != nil {
+		return err
+	}
+	gap, err := d.dpb.Gap(h)
+	if err != nil {
+		return err
+	}
+	d.output(gap)
+	top, bottom, err := d.poc.Derive(h)
+	if err != nil {
+		return err
+	}
+	pic := newPicture(h, top, bottom)
+	for i, s := range slices {
+		if err := supported(s.Header); err != nil {
+			return err
+		}
+		lists, err := reflist.Build(d.dpb.Frames(), &reflist.Slice{Header: s.Header, TopPoc: top, BottomPoc: bottom})
+		if err != nil {
+			return fmt.Errorf("slice %v: %v", i, err)
+		}
+		if err := pic.decodeSlice(s, i, lists); err != nil {
+			return fmt.Errorf("slice %v: %v", i, err)
+		}
+	}
+	err = deblock.Filter(&deblock.Picture{Planes: pic.planes, Syntax: pic.syntax, Motion: pic.motion, Headers: pic.headers}, d.Deblock)
+	if err != nil {
+		return err
+	}
+	d.headers[pic.planes[0]] = h
+	out, err := d.dpb.Store(&dpb.Picture{Header: h, TopPoc: top, BottomPoc: bottom, Planes: pic.planes, Motion: pic.motion})
+	d.output(out)
+	return err
+}
+
+// Flush output the frames left in the DPB, at the end of the stream
+func (d *Decoder) Flush() {
+	d.output(d.dpb.Flush())
+	d.headers = map[*frame.Plane]*slice.Header{}
+}
+
+// Output return the next frame in output order, nil when there is none
+func (d *Decoder) Output() *frame.VideoFrame {
+	if len(d.out) == 0 {
+		return nil
+	}
+	f := d.out[0]
+	d.out = d.out[1:]
+	return f
+}
+
+// output queue the frames output by the DPB, the non-existing frames inferred for
+// gaps in frame_num left out
+func (d *Decoder) output(frames []*dpb.Frame) {
+	for _, f := range frames {
+		h := d.headers[f.Planes[0]]
+		if f.NonExisting || h == nil {
+			continue
+		}
+		delete(d.headers, f.Planes[0])
+		d.out = append(d.out, videoFrame(f, h, d.numOut))
+		d.numOut++
+	}
+}
+
+// videoFrame output frame n of frame buffer f, h the first slice header of its picture
+func videoFrame(f *dpb.Frame, h *slice.Header, n int) *frame.VideoFrame {
+	sps := h.SPS
+	format := frame.ChromaFormat(sps.ChromaFormatIdc)
+	vf := frame.NewVideoFrame(f.Planes, format, int(sps.BitDepthY()), int(sps.BitDepthC()))
+	if r := sps.CropRect(); !r.Empty() {
+		vf.Crop = r.Intersect(vf.Crop)
+	}
+	vf.Poc, vf.TopPoc, vf.BottomPoc = f.PicOrderCnt(), f.Poc[0], f.Poc[1]
+	vf.FrameNum = f.FrameNum
+	switch {
+	case !f.FieldPic:
+		vf.Structure = frame.FrameStructure
+	case f.Decoded[0] && f.Decoded[1]:
+		vf.Structure = frame.FieldPair
+	case f.Decoded[0]:
+		vf.Structure = frame.TopField
+	default:
+		vf.Structure = frame.BottomField
+	}
+	vf.KeyFrame = h.IdrPicFlag
+	vf.SPS = sps
+	// frames of the VUI frame rate in output order
+	if num, den, ok := sps.FrameRate(); ok {
+		vf.Timestamp = frameTime(n, num, den)
+		vf.Duration = frameTime(n+1, num, den) - vf.Timestamp
+	}
+	return vf
+}
+
+// frameTime time of frame n at num / den frames per second, split into whole
+// seconds and the remainder so that it does not overflow for long streams
+func frameTime(n int, num, den uint) time.Duration {
+	ticks := uint64(n) * uint64(den)
+	return time.Duration(ticks/uint64(num))*time.Second +
+		time.Duration(ticks%uint64(num)*uint64(time.Second)/uint64(num))
+}
+
+// supported return an error for the coding tools of slice h not decoded yet
+func supported(h *slice.Header) error {
+	switch {
+	case h.SliceType == slice.SliceSP || h.SliceType == slice.SliceSI:
+		return fmt.Errorf("%v slices not supported", h.SliceType)
+	case h.FieldPicFlag || h.MbaffFrameFlag:
+		return fmt.Errorf("field and mbaff pictures not supported")
+	case h.SPS.SeparateColourPlaneFlag:
+		return fmt.Errorf("separate colour planes not supported")
+	}
+	return nil
+}
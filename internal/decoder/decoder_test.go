@@ -0,0 +1,161 @@
+package decoder
+
+import (
+	"image"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/LiveStudioSolution/h264decoder/internal"
+	"github.com/LiveStudioSolution/h264decoder/internal/frame"
+	"github.com/LiveStudioSolution/h264decoder/internal/macroblock"
+	"github.com/LiveStudioSolution/h264decoder/internal/motion"
+	"github.com/LiveStudioSolution/h264decoder/internal/slice"
+	"github.com/LiveStudioSolution/h264decoder/internal/transform"
+)
+
+// testSliceDecoder decoder of a one macroblock 4:2:0 picture of 8 bits
+func testSliceDecoder() *sliceDecoder {
+	sps := &internal.SPS{ChromaFormatIdc: 1, FrameMbsOnlyFlag: true}
+	pps := &internal.PPS{ScalingMatrices: internal.FlatScalingMatrices()}
+	h := &slice.Header{SliceType: slice.SliceI, PicSizeInMbs: 1, SPS: sps, PPS: pps}
+	pic := newPicture(h, 0, 0)
+	pic.headers = append(pic.headers, h)
+	pic.syntax.Mbs[0].SliceNum = 0
+	return &sliceDecoder{
+		pic:    pic,
+		h:      h,
+		ls:     transform.NewLevelScale(&pps.ScalingMatrices),
+		motion: &motion.Slice{Syntax: pic.syntax, Motion: pic.motion, SliceType: h.SliceType},
+	}
+}
+
+// checkPlane report the samples of pl not equal to want
+func checkPlane(t *testing.T, name string, pl *frame.Plane, want func(x, y int) uint16) {
+	t.Helper()
+	for y := 0; y < pl.Height; y++ {
+		for x := 0; x < pl.Width; x++ {
+			if got := pl.At(x, y); got != want(x, y) {
+				t.Fatalf("%v sample ( %v, %v ) = %v, want %v", name, x, y, got, want(x, y))
+			}
+		}
+	}
+}
+
+func TestPCM(t *testing.T) {
+	sd := testSliceDecoder()
+	mb := &sd.pic.syntax.Mbs[0]
+	mb.MbType = macroblock.MbIPCM
+	for i := range mb.Levels[0] {
+		mb.Levels[0][i] = int32(i)
+	}
+	for i := 0; i < 64; i++ {
+		mb.Levels[1][i], mb.Levels[2][i] = int32(100+i), int32(200-i)
+	}
+	if err := sd.decode(mb); err != nil {
+		t.Fatalf("decode() error = %v", err)
+	}
+	checkPlane(t, "Y", sd.pic.planes[0], func(x, y int) uint16 { return uint16(16*y + x) })
+	checkPlane(t, "Cb", sd.pic.planes[1], func(x, y int) uint16 { return uint16(100 + 8*y + x) })
+	checkPlane(t, "Cr", sd.pic.planes[2], func(x, y int) uint16 { return uint16(200 - 8*y - x) })
+}
+
+func TestIntra16x16DC(t *testing.T) {
+	sd := testSliceDecoder()
+	mb := &sd.pic.syntax.Mbs[0]
+	// I_16x16_2_0_0, DC prediction of 128 without neighbours and a DC level of 3
+	// adding 3 to each luma sample at QP 28
+	mb.MbType = macroblock.MbI16x16 + 2
+	mb.QPY = 28
+	mb.DCLevels[0][0] = 3
+	if err := sd.decode(mb); err != nil {
+		t.Fatalf("decode() error = %v", err)
+	}
+	checkPlane(t, "Y", sd.pic.planes[0], func(x, y int) uint16 { return 131 })
+	checkPlane(t, "Cb", sd.pic.planes[1], func(x, y int) uint16 { return 128 })
+}
+
+// TestDecodeSample decode the Baseline sample, one slice per picture, into its
+// 150 frames in output order
+func TestDecodeSample(t *testing.T) {
+	f, err := os.Open("../../docs/videosamples/txjg.h264")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer f.Close()
+	bs := internal.NewBitStream(f)
+	ps := internal.NewParameterSetStore()
+	d := New()
+	var frames []*frame.VideoFrame
+	for {
+		nl, err := bs.NextNalu()
+		if err == io.EOF || err == nil && nl == nil {
+			break
+		}
+		if err != nil {
+			t.Fatalf("NextNalu() error = %v", err)
+		}
+		switch nl.Type() {
+		case internal.NaluSps:
+			_, err = ps.PutSps(nl.Rbsp())
+		case internal.NaluPps:
+			_, err = ps.PutPps(nl.Rbsp())
+		case internal.NaluSliceIdr, internal.NaluSlice:
+			var h *slice.Header
+			if h, err = slice.ParseHeader(nl, ps); err == nil {
+				err = d.Decode([]Slice{{Header: h, Rbsp: nl.Rbsp()}})
+			}
+		}
+		if err != nil {
+			t.Fatalf("picture %v: %v", len(frames), err)
+		}
+		for fr := d.Output(); fr != nil; fr = d.Output() {
+			frames = append(frames, fr)
+		}
+	}
+	d.Flush()
+	for fr := d.Output(); fr != nil; fr = d.Output() {
+		frames = append(frames, fr)
+	}
+	if len(frames) != 150 {
+		t.Fatalf("decoded %v frames, want 150", len(frames))
+	}
+	keys := 0
+	for i, fr := range frames {
+		// the sps crops the 368 coded lines to 360
+		if y := fr.Planes[0]; y.Width != 640 || y.Height != 368 || fr.Crop != image.Rect(0, 0, 640, 360) {
+			t.Errorf("frame %v of %vx%v cropped to %v", i, y.Width, y.Height, fr.Crop)
+		}
+		if fr.KeyFrame {
+			keys++
+		}
+		// 30000 / 1001 frames per second
+		if want := time.Duration(i) * time.Second * 1001 / 30000; fr.Timestamp != want || fr.Structure != frame.FrameStructure {
+			t.Errorf("frame %v at %v, structure %v, want %v", i, fr.Timestamp, fr.Structure, want)
+		}
+		if d := fr.Duration; d < 33366666 || d > 33366667 {
+			t.Errorf("frame %v lasts %v", i, d)
+		}
+	}
+	if !frames[0].KeyFrame || keys != 3 {
+		t.Errorf("first frame key frame %v, %v key frames", frames[0].KeyFrame, keys)
+	}
+	// the last picture is not flat grey, the prediction from the earlier ones holds
+	lo, hi := uint16(255), uint16(0)
+	y := frames[len(frames)-1].Planes[0]
+	for j := 0; j < y.Height; j++ {
+		for i := 0; i < y.Width; i++ {
+			v := y.At(i, j)
+			if v < lo {
+				lo = v
+			}
+			if v > hi {
+				hi = v
+			}
+		}
+	}
+	if hi-lo < 128 {
+		t.Errorf("luma samples of the last frame from %v to %v", lo, hi)
+	}
+}
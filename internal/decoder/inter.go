@@ -0,0 +1,133 @@
+package decoder
+
+import (
+	"fmt"
+
+	"github.com/LiveStudioSolution/h264decoder/internal/inter"
+	"github.com/LiveStudioSolution/h264decoder/internal/intra"
+	"github.com/LiveStudioSolution/h264decoder/internal/macroblock"
+	"github.com/LiveStudioSolution/h264decoder/internal/slice"
+)
+
+// part a macroblock or sub-macroblock partition of w x h luma samples at ( x, y ) in
+// its macroblock
+type part struct {
+	x, y, w, h int
+}
+
+// inter predict the inter macroblock mb from the motion derived for it and add its
+// residual, T-REC-H.264-201402-S!!PDF-E.pdf 8.4 Inter prediction process
+func (sd *sliceDecoder) inter(mb *macroblock.Macroblock) error {
+	for _, pt := range sd.partitions(mb) {
+		if err := sd.predict(mb, pt); err != nil {
+			return err
+		}
+	}
+	numLuma := 1
+	if sd.pic.chromaArrayType == 3 {
+		numLuma = 3
+	}
+	for c := 0; c < numLuma; c++ {
+		if mb.TransformSize8x8Flag {
+			for b8 := 0; b8 < 4; b8++ {
+				sd.residual8x8(mb, c, b8, intra.DC)
+			}
+			continue
+		}
+		for blk := 0; blk < 16; blk++ {
+			sd.residual4x4(mb, c, blk, intra.DC)
+		}
+	}
+	if cat := sd.pic.chromaArrayType; cat == 1 || cat == 2 {
+		for c := 1; c <= 2; c++ {
+			sd.chromaResidual(mb, c, intra.DC)
+		}
+	}
+	return nil
+}
+
+// partitions the partitions of mb predicted apart, direct predicted 8x8 blocks being
+// split into 4x4 blocks unless direct_8x8_inference_flag
+func (sd *sliceDecoder) partitions(mb *macroblock.Macroblock) []part {
+	var parts []part
+	direct := func(q int) {
+		if sd.pic.sps.Direct8X8InferenceFlag {
+			parts = append(parts, part{q % 2 * 8, q / 2 * 8, 8, 8})
+			return
+		}
+		for i := 0; i < 4; i++ {
+			parts = append(parts, part{q%2*8 + i%2*4, q/2*8 + i/2*4, 4, 4})
+		}
+	}
+	t := mb.MbType
+	switch {
+	case t == macroblock.MbPSkip:
+		parts = append(parts, part{0, 0, 16, 16})
+	case t == macroblock.MbBSkip || t == macroblock.MbBDirect16x16:
+		for q := 0; q < 4; q++ {
+			direct(q)
+		}
+	case t.NumMbPart() == 4:
+		for q, st := range mb.SubMbType {
+			if st == macroblock.SubBDirect8x8 {
+				direct(q)
+				continue
+			}
+			w, h := st.SubMbPartWidth(), st.SubMbPartHeight()
+			for i := 0; i < st.NumSubMbPart(); i++ {
+				parts = append(parts, part{q%2*8 + i%(8/w)*w, q/2*8 + i/(8/w)*h, w, h})
+			}
+		}
+	default:
+		w, h := t.MbPartWidth(), t.MbPartHeight()
+		for i := 0; i < t.NumMbPart(); i++ {
+			parts = append(parts, part{i % (16 / w) * w, i / (16 / w) * h, w, h})
+		}
+	}
+	return parts
+}
+
+// predict the samples of partition pt of mb from the reference pictures of its
+// reference indices, T-REC-H.264-201402-S!!PDF-E.pdf 8.4.2 Decoding process for Inter prediction samples
+func (sd *sliceDecoder) predict(mb *macroblock.Macroblock, pt part) error {
+	m := &sd.pic.motion.Mbs[mb.Addr]
+	x, y := sd.mbPos(mb.Addr)
+	p := &inter.Partition{
+		X: x + pt.x, Y: y + pt.y, W: pt.w, H: pt.h,
+		Field: sd.h.FieldPicFlag, Bottom: sd.h.BottomFieldFlag,
+	}
+	var refIdx [2]int
+	for l := range refIdx {
+		refIdx[l] = int(m.RefIdx[l][2*(pt.y/8)+pt.x/8])
+		if refIdx[l] < 0 {
+			continue
+		}
+		if refIdx[l] >= len(sd.lists[l]) || sd.lists[l][refIdx[l]] == nil {
+			return fmt.Errorf("ref_idx_l%v %v without reference picture", l, refIdx[l])
+		}
+		p.PredFlag[l] = true
+		p.Mv[l] = m.Mv[l][4*(pt.y/4)+pt.x/4]
+		p.Ref[l] = sd.lists[l][refIdx[l]].Samples()
+	}
+	sd.weights(p, refIdx)
+	params := inter.Params{ChromaArrayType: sd.pic.chromaArrayType, BitDepthY: sd.pic.bitDepthY, BitDepthC: sd.pic.bitDepthC}
+	return inter.Predict(sd.pic.planes, p, params)
+}
+
+// weights select the weighted sample prediction of partition p of reference indices
+// refIdx, a negative index for a list not used, 8.4.2.3
+func (sd *sliceDecoder) weights(p *inter.Partition, refIdx [2]int) {
+	pps, t := sd.h.PPS, sd.h.SliceType
+	switch {
+	case pps.WeightedPredFlag && (t == slice.SliceP || t == slice.SliceSP),
+		pps.WeightedBipredIdc == 1 && t == slice.SliceB:
+		p.Weighting = inter.WeightExplicit
+		p.Weights = inter.ExplicitWeights(&sd.h.PredWeightTable, refIdx, sd.pic.bitDepthY, sd.pic.bitDepthC)
+	case pps.WeightedBipredIdc == 2 && t == slice.SliceB:
+		p.Weighting = inter.WeightImplicit
+		if p.PredFlag[0] && p.PredFlag[1] {
+			r0, r1 := sd.lists[0][refIdx[0]].Motion(), sd.lists[1][refIdx[1]].Motion()
+			p.Weights = inter.ImplicitWeights(sd.pic.currPoc(), r0.Poc(), r1.Poc(), r0.LongTerm || r1.LongTerm)
+		}
+	}
+}
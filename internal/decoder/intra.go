@@ -0,0 +1,147 @@
+package decoder
+
+import (
+	"github.com/LiveStudioSolution/h264decoder/internal/intra"
+	"github.com/LiveStudioSolution/h264decoder/internal/macroblock"
+)
+
+// intra predict the intra macroblock mb and add its residual, luma then chroma,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.3 Intra prediction process
+func (sd *sliceDecoder) intra(mb *macroblock.Macroblock) error {
+	// with ChromaArrayType 3 Cb and Cr are predicted as luma, 8.3.4.5
+	numLuma := 1
+	if sd.pic.chromaArrayType == 3 {
+		numLuma = 3
+	}
+	for c := 0; c < numLuma; c++ {
+		if err := sd.intraLuma(mb, c); err != nil {
+			return err
+		}
+	}
+	if cat := sd.pic.chromaArrayType; cat != 1 && cat != 2 {
+		return nil
+	}
+	w, h := sd.pic.syntax.MbWidthC, sd.pic.syntax.MbHeightC
+	xC, yC := sd.chromaPos(mb.Addr)
+	mode := intra.ChromaMode(mb.IntraChromaPredMode)
+	avail := sd.avail(mb, 0, 0, w, false)
+	for c := 1; c <= 2; c++ {
+		if err := intra.Chroma(sd.pic.planes[c], xC, yC, w, h, mode, avail, sd.pic.bitDepthC); err != nil {
+			return err
+		}
+		sd.chromaResidual(mb, c, chromaBypassMode(mode))
+	}
+	return nil
+}
+
+// intraLuma predict and construct luma, or Cb or Cr of ChromaArrayType 3, for c,
+// the 4x4 and 8x8 blocks each before the next one is predicted from it
+func (sd *sliceDecoder) intraLuma(mb *macroblock.Macroblock, c int) error {
+	pl := sd.pic.planes[c]
+	x, y := sd.mbPos(mb.Addr)
+	bitDepth := sd.bitDepth(c)
+	modes := &sd.pic.predModes[mb.Addr]
+	switch mb.MbPartPredMode(0) {
+	case macroblock.PredIntra4x4:
+		for blk := 0; blk < 16; blk++ {
+			if c == 0 {
+				modes[blk] = sd.predMode(mb, blk, false)
+			}
+			bx, by := luma4x4BlkPos(blk)
+			avail := sd.avail(mb, bx, by, 4, true)
+			// samples p[ x, -1 ] with x > 3 are not decoded yet for blocks 3 and 11
+			if blk == 3 || blk == 11 {
+				avail.TopRight = false
+			}
+			if err := intra.Intra4x4(pl, x+bx, y+by, modes[blk], avail, bitDepth); err != nil {
+				return err
+			}
+			sd.residual4x4(mb, c, blk, modes[blk])
+		}
+	case macroblock.PredIntra8x8:
+		for b8 := 0; b8 < 4; b8++ {
+			if c == 0 {
+				mode := sd.predMode(mb, b8, true)
+				for i := 4 * b8; i < 4*b8+4; i++ {
+					modes[i] = mode
+				}
+			}
+			bx, by := b8%2*8, b8/2*8
+			if err := intra.Intra8x8(pl, x+bx, y+by, modes[4*b8], sd.avail(mb, bx, by, 8, true), bitDepth); err != nil {
+				return err
+			}
+			sd.residual8x8(mb, c, b8, modes[4*b8])
+		}
+	default:
+		mode := intra.Mode16x16(mb.MbType.Intra16x16PredMode())
+		if err := intra.Intra16x16(pl, x, y, mode, sd.avail(mb, 0, 0, 16, true), bitDepth); err != nil {
+			return err
+		}
+		sd.residual16x16(mb, c, intra.Mode(mode))
+	}
+	return nil
+}
+
+// avail availability for intra prediction of the neighbouring samples of the w wide
+// block at ( bx, by ) of macroblock mb, in luma or chroma samples
+func (sd *sliceDecoder) avail(mb *macroblock.Macroblock, bx, by, w int, luma bool) intra.Avail {
+	at := func(xN, yN int) bool {
+		mbAddrN, _, _ := sd.pic.syntax.Location(mb.Addr, xN, yN, luma)
+		if mbAddrN < 0 {
+			return false
+		}
+		mbN := &sd.pic.syntax.Mbs[mbAddrN]
+		return intra.Available(true, !mbN.IsIntra(), mbN.MbType == macroblock.MbSI, mb.MbType == macroblock.MbSI,
+			sd.h.PPS.ConstrainedIntraPredFlag)
+	}
+	return intra.Avail{Left: at(bx-1, by), Top: at(bx, by-1), TopRight: at(bx+w, by-1), TopLeft: at(bx-1, by-1)}
+}
+
+// predMode Intra4x4PredMode of 4x4 block blk, or Intra8x8PredMode of 8x8 block blk
+// for eight, from the modes of the neighbouring blocks A and B,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.3.1.1 and 8.3.2.1
+func (sd *sliceDecoder) predMode(mb *macroblock.Macroblock, blk int, eight bool) intra.Mode {
+	syntax := sd.pic.syntax
+	var modes [2]int
+	for n := macroblock.NeighbourA; n <= macroblock.NeighbourB; n++ {
+		var mbAddrN, blkN int
+		if eight {
+			mbAddrN, blkN = syntax.Luma8x8Neighbour(mb.Addr, blk, n)
+		} else {
+			mbAddrN, blkN = syntax.Luma4x4Neighbour(mb.Addr, blk, n)
+		}
+		if mbAddrN < 0 || !syntax.Mbs[mbAddrN].IsIntra() && sd.h.PPS.ConstrainedIntraPredFlag {
+			// dcPredModePredictedFlag
+			modes[n] = -1
+			continue
+		}
+		switch syntax.Mbs[mbAddrN].MbPartPredMode(0) {
+		case macroblock.PredIntra4x4:
+			if eight {
+				// 4x4 block 1 of the 8x8 block for A, 2 for B
+				blkN = 4*blkN + 1 + n
+			}
+			modes[n] = int(sd.pic.predModes[mbAddrN][blkN])
+		case macroblock.PredIntra8x8:
+			if eight {
+				blkN *= 4
+			}
+			modes[n] = int(sd.pic.predModes[mbAddrN][blkN])
+		default:
+			modes[n] = int(intra.DC)
+		}
+	}
+	return intra.DerivePredMode(modes[0], modes[1], mb.PrevIntraPredModeFlag[blk], mb.RemIntraPredMode[blk])
+}
+
+// chromaBypassMode the luma mode of the direction of chroma mode for the intra
+// residual transform-bypass, DC for none
+func chromaBypassMode(mode intra.ChromaMode) intra.Mode {
+	switch mode {
+	case intra.ChromaHorizontal:
+		return intra.Horizontal
+	case intra.ChromaVertical:
+		return intra.Vertical
+	}
+	return intra.DC
+}
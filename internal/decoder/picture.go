@@ -0,0 +1,200 @@
+package decoder
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/LiveStudioSolution/h264decoder/internal"
+	"github.com/LiveStudioSolution/h264decoder/internal/frame"
+	"github.com/LiveStudioSolution/h264decoder/internal/intra"
+	"github.com/LiveStudioSolution/h264decoder/internal/macroblock"
+	"github.com/LiveStudioSolution/h264decoder/internal/motion"
+	"github.com/LiveStudioSolution/h264decoder/internal/reflist"
+	"github.com/LiveStudioSolution/h264decoder/internal/slice"
+	"github.com/LiveStudioSolution/h264decoder/internal/transform"
+)
+
+// picture the picture being decoded
+type picture struct {
+	sps *internal.SPS
+	// planes Y, Cb and Cr of the frame, chroma planes nil for ChromaArrayType 0
+	planes [3]*frame.Plane
+	syntax *macroblock.Picture
+	// headers slice headers by SliceNum
+	headers []*slice.Header
+	// predModes Intra4x4PredMode of the macroblocks by luma4x4BlkIdx, Intra8x8PredMode
+	// repeated over the four 4x4 blocks of each 8x8 block
+	predModes [][16]intra.Mode
+	// motion of the macroblocks, for the prediction, the deblocking filter and the
+	// direct prediction of later pictures
+	motion *motion.Picture
+	// topPoc and bottomPoc TopFieldOrderCnt and BottomFieldOrderCnt
+	topPoc    int
+	bottomPoc int
+
+	chromaArrayType      int
+	bitDepthY, bitDepthC int
+}
+
+// newPicture allocate the picture of first slice h, of order counts top and bottom
+func newPicture(h *slice.Header, top, bottom int) *picture {
+	sps := h.SPS
+	width := int(sps.PicWidthInMbs())
+	pic := &picture{
+		sps:             sps,
+		syntax:          macroblock.NewPicture(h),
+		predModes:       make([][16]intra.Mode, h.PicSizeInMbs),
+		motion:          motion.NewPicture(motion.Frame, false, width, int(h.PicSizeInMbs)),
+		topPoc:          top,
+		bottomPoc:       bottom,
+		chromaArrayType: int(sps.ChromaArrayType()),
+		bitDepthY:       int(sps.BitDepthY()),
+		bitDepthC:       int(sps.BitDepthC()),
+	}
+	width, height := 16*width, 16*int(sps.FrameHeightInMbs())
+	pic.planes[0] = frame.NewPlane(width, height)
+	if pic.chromaArrayType != 0 {
+		for c := 1; c <= 2; c++ {
+			pic.planes[c] = frame.NewPlane(width/sps.SubWidthC(), height/sps.SubHeightC())
+		}
+	}
+	return pic
+}
+
+// decodeSlice parse and construct the macroblocks of slice s of reference picture
+// lists lists, sliceNum its index in the picture
+func (pic *picture) decodeSlice(s Slice, sliceNum int, lists reflist.Lists) error {
+	p, err := macroblock.NewParser(pic.syntax, s.Header, s.Rbsp, sliceNum)
+	if err != nil {
+		return err
+	}
+	h := s.Header
+	pic.headers = append(pic.headers, h)
+	sd := &sliceDecoder{
+		pic:   pic,
+		h:     h,
+		ls:    transform.NewLevelScale(&h.PPS.ScalingMatrices),
+		lists: lists,
+		motion: &motion.Slice{
+			Syntax:                  pic.syntax,
+			Motion:                  pic.motion,
+			Refs:                    lists.Motion(),
+			SliceType:               h.SliceType,
+			FieldPicFlag:            h.FieldPicFlag,
+			BottomFieldFlag:         h.BottomFieldFlag,
+			DirectSpatialMvPredFlag: h.DirectSpatialMvPredFlag,
+			Direct8x8InferenceFlag:  h.SPS.Direct8X8InferenceFlag,
+			TopPoc:                  pic.topPoc,
+			BottomPoc:               pic.bottomPoc,
+		},
+	}
+	for {
+		mb, err := p.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := sd.decode(mb); err != nil {
+			return fmt.Errorf("macroblock %v: %v", mb.Addr, err)
+		}
+	}
+}
+
+// currPoc PicOrderCnt( CurrPic ), 8.2.1
+func (pic *picture) currPoc() int {
+	if pic.bottomPoc < pic.topPoc {
+		return pic.bottomPoc
+	}
+	return pic.topPoc
+}
+
+// sliceDecoder construct the macroblocks of one slice of the picture
+type sliceDecoder struct {
+	pic    *picture
+	h      *slice.Header
+	ls     *transform.LevelScale
+	lists  reflist.Lists
+	motion *motion.Slice
+}
+
+// decode construct the samples of macroblock mb, prior to deblocking
+func (sd *sliceDecoder) decode(mb *macroblock.Macroblock) error {
+	if err := sd.motion.Derive(mb.Addr); err != nil {
+		return err
+	}
+	switch {
+	case mb.MbType == macroblock.MbIPCM:
+		sd.pcm(mb)
+		return nil
+	case mb.IsIntra():
+		return sd.intra(mb)
+	}
+	return sd.inter(mb)
+}
+
+// mbPos upper-left luma sample of macroblock mbAddr, 6.4.1 without MBAFF
+func (sd *sliceDecoder) mbPos(mbAddr int) (int, int) {
+	w := sd.pic.syntax.WidthInMbs
+	return 16 * (mbAddr % w), 16 * (mbAddr / w)
+}
+
+// chromaPos upper-left chroma sample of macroblock mbAddr
+func (sd *sliceDecoder) chromaPos(mbAddr int) (int, int) {
+	w := sd.pic.syntax.WidthInMbs
+	return sd.pic.syntax.MbWidthC * (mbAddr % w), sd.pic.syntax.MbHeightC * (mbAddr / w)
+}
+
+// pcm copy the samples of an I_PCM macroblock, 8.3.5
+func (sd *sliceDecoder) pcm(mb *macroblock.Macroblock) {
+	x, y := sd.mbPos(mb.Addr)
+	for i, v := range mb.Levels[0] {
+		sd.pic.planes[0].Set(x+i%16, y+i/16, uint16(v))
+	}
+	if sd.pic.chromaArrayType == 0 {
+		return
+	}
+	w, h := sd.pic.syntax.MbWidthC, sd.pic.syntax.MbHeightC
+	xC, yC := sd.chromaPos(mb.Addr)
+	for c := 1; c <= 2; c++ {
+		for i, v := range mb.Levels[c][:w*h] {
+			sd.pic.planes[c].Set(xC+i%w, yC+i/w, uint16(v))
+		}
+	}
+}
+
+// bitDepth of colour component c
+func (sd *sliceDecoder) bitDepth(c int) int {
+	if c == 0 {
+		return sd.pic.bitDepthY
+	}
+	return sd.pic.bitDepthC
+}
+
+// qP QP'Y of the macroblock for c 0, else QP'C of Cb or Cr, 7.4.5 and 8.5.8
+func (sd *sliceDecoder) qP(mb *macroblock.Macroblock, c int) int {
+	pps := sd.h.PPS
+	switch c {
+	case 0:
+		return mb.QPY + 6*int(sd.pic.sps.BitDepthLumaMinus8)
+	case 1:
+		return transform.ChromaQP(mb.QPY, pps.ChromaQpIndexOffset, sd.pic.bitDepthC)
+	}
+	return transform.ChromaQP(mb.QPY, pps.SecondChromaQpIndexOffset, sd.pic.bitDepthC)
+}
+
+// bypass TransformBypassModeFlag of the macroblock, 7.4.5
+func (sd *sliceDecoder) bypass(mb *macroblock.Macroblock) bool {
+	return sd.pic.sps.QpprimeYZeroTransformBypassFlag && sd.qP(mb, 0) == 0
+}
+
+// field whether the blocks of the macroblock use the field scan
+func (sd *sliceDecoder) field(mb *macroblock.Macroblock) bool {
+	return sd.h.FieldPicFlag || mb.FieldDecodingFlag
+}
+
+// luma4x4BlkPos upper-left location of 4x4 luma block blkIdx in its macroblock, 6.4.3
+func luma4x4BlkPos(blkIdx int) (int, int) {
+	return blkIdx/4%2*8 + blkIdx%2*4, blkIdx/8*8 + blkIdx%4/2*4
+}
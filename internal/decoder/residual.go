@@ -0,0 +1,143 @@
+package decoder
+
+import (
+	"github.com/LiveStudioSolution/h264decoder/internal/intra"
+	"github.com/LiveStudioSolution/h264decoder/internal/macroblock"
+	"github.com/LiveStudioSolution/h264decoder/internal/transform"
+)
+
+// chromaDC422 index in ChromaDCLevel of each coefficient of the 2x4 array c of
+// ChromaArrayType 2 in raster order, 8.5.11.1
+var chromaDC422 = [8]int{0, 2, 1, 5, 3, 6, 4, 7}
+
+// residual4x4 add the residual of 4x4 block blk of component c of mb to its
+// prediction, mode being the intra prediction direction for the transform bypass,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.5.1 Specification of transform decoding process for 4x4 luma residual blocks
+func (sd *sliceDecoder) residual4x4(mb *macroblock.Macroblock, c, blk int, mode intra.Mode) {
+	levels := mb.Level4x4(c, blk)
+	if allZero(levels) {
+		return
+	}
+	var coeff [16]int32
+	transform.InverseScan4x4(levels, sd.field(mb), &coeff)
+	if sd.bypass(mb) {
+		bypassIntra(coeff[:], 4, 4, mb, mode)
+	} else {
+		sd.ls.Scale4x4(&coeff, c, mb.IsIntra(), sd.qP(mb, c), false)
+		transform.Inverse4x4(&coeff)
+	}
+	x, y := sd.mbPos(mb.Addr)
+	bx, by := luma4x4BlkPos(blk)
+	transform.AddResidual(sd.pic.planes[c], x+bx, y+by, 4, 4, coeff[:], sd.bitDepth(c))
+}
+
+// residual8x8 add the residual of 8x8 block b8 of component c of mb to its prediction,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.5.3 Specification of transform decoding process for 8x8 luma residual blocks
+func (sd *sliceDecoder) residual8x8(mb *macroblock.Macroblock, c, b8 int, mode intra.Mode) {
+	levels := mb.Level8x8(c, b8)
+	if allZero(levels) {
+		return
+	}
+	var coeff [64]int32
+	transform.InverseScan8x8(levels, sd.field(mb), &coeff)
+	if sd.bypass(mb) {
+		bypassIntra(coeff[:], 8, 8, mb, mode)
+	} else {
+		sd.ls.Scale8x8(&coeff, c, mb.IsIntra(), sd.qP(mb, c))
+		transform.Inverse8x8(&coeff)
+	}
+	x, y := sd.mbPos(mb.Addr)
+	transform.AddResidual(sd.pic.planes[c], x+b8%2*8, y+b8/2*8, 8, 8, coeff[:], sd.bitDepth(c))
+}
+
+// residual16x16 add the residual of component c of an Intra_16x16 macroblock to its
+// prediction, the DC coefficients of the 4x4 blocks coded apart,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.5.2 Specification of transform decoding process for luma samples of Intra_16x16 macroblock prediction mode
+func (sd *sliceDecoder) residual16x16(mb *macroblock.Macroblock, c int, mode intra.Mode) {
+	bypass, field := sd.bypass(mb), sd.field(mb)
+	qP := sd.qP(mb, c)
+	var dc [16]int32
+	transform.InverseScan4x4(mb.DCLevels[c][:], field, &dc)
+	if !bypass {
+		sd.ls.LumaDC(&dc, c, qP)
+	}
+	var r [256]int32
+	for blk := 0; blk < 16; blk++ {
+		bx, by := luma4x4BlkPos(blk)
+		var coeff [16]int32
+		transform.InverseScan4x4(mb.Level4x4(c, blk), field, &coeff)
+		coeff[0] = dc[by+bx/4]
+		if !bypass {
+			sd.ls.Scale4x4(&coeff, c, true, qP, true)
+			transform.Inverse4x4(&coeff)
+		}
+		for j := 0; j < 4; j++ {
+			copy(r[(by+j)*16+bx:(by+j)*16+bx+4], coeff[4*j:4*j+4])
+		}
+	}
+	if bypass {
+		bypassIntra(r[:], 16, 16, mb, mode)
+	}
+	x, y := sd.mbPos(mb.Addr)
+	transform.AddResidual(sd.pic.planes[c], x, y, 16, 16, r[:], sd.bitDepth(c))
+}
+
+// chromaResidual add the residual of Cb or Cr of ChromaArrayType 1 or 2 to its
+// prediction, mode the intra prediction direction for the transform bypass,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.5.11 Specification of transform decoding process for chroma samples
+func (sd *sliceDecoder) chromaResidual(mb *macroblock.Macroblock, c int, mode intra.Mode) {
+	if mb.CodedBlockPatternChroma == 0 {
+		return
+	}
+	w, h := sd.pic.syntax.MbWidthC, sd.pic.syntax.MbHeightC
+	numBlk := w * h / 16
+	bypass, field, isIntra := sd.bypass(mb), sd.field(mb), mb.IsIntra()
+	qP := sd.qP(mb, c)
+	var dc [8]int32
+	if numBlk == 4 {
+		copy(dc[:], mb.DCLevels[c][:4])
+	} else {
+		for i, k := range chromaDC422 {
+			dc[i] = mb.DCLevels[c][k]
+		}
+	}
+	if !bypass {
+		sd.ls.ChromaDC(dc[:numBlk], c, isIntra, qP)
+	}
+	var r [128]int32
+	for blk := 0; blk < numBlk; blk++ {
+		var coeff [16]int32
+		transform.InverseScan4x4(mb.Level4x4(c, blk), field, &coeff)
+		coeff[0] = dc[blk]
+		if !bypass {
+			sd.ls.Scale4x4(&coeff, c, isIntra, qP, true)
+			transform.Inverse4x4(&coeff)
+		}
+		bx, by := blk%2*4, blk/2*4
+		for j := 0; j < 4; j++ {
+			copy(r[(by+j)*w+bx:(by+j)*w+bx+4], coeff[4*j:4*j+4])
+		}
+	}
+	if bypass {
+		bypassIntra(r[:w*h], w, h, mb, mode)
+	}
+	xC, yC := sd.chromaPos(mb.Addr)
+	transform.AddResidual(sd.pic.planes[c], xC, yC, w, h, r[:w*h], sd.pic.bitDepthC)
+}
+
+// bypassIntra apply the intra residual transform-bypass to the nW x nH residual r of
+// an intra macroblock predicted vertically or horizontally in mode, 8.5.15
+func bypassIntra(r []int32, nW, nH int, mb *macroblock.Macroblock, mode intra.Mode) {
+	if mb.IsIntra() && (mode == intra.Vertical || mode == intra.Horizontal) {
+		transform.BypassIntra(r, nW, nH, mode == intra.Horizontal)
+	}
+}
+
+func allZero(levels []int32) bool {
+	for _, v := range levels {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,350 @@
+// Package dpb the decoded picture buffer, holding the decoded pictures used for
+// reference or waiting for output. It marks them for reference after each decoded
+// picture and outputs them in output order as the buffer fills up,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.2.5 Decoded reference picture marking process
+// and C.4 Operation of the output order DPB
+package dpb
+
+import (
+	"fmt"
+
+	"github.com/LiveStudioSolution/h264decoder/internal"
+	"github.com/LiveStudioSolution/h264decoder/internal/frame"
+	"github.com/LiveStudioSolution/h264decoder/internal/motion"
+	"github.com/LiveStudioSolution/h264decoder/internal/slice"
+)
+
+// noLongTermFrameIdx MaxLongTermFrameIdx equal to "no long-term frame indices"
+const noLongTermFrameIdx = -1
+
+// Picture a decoded picture to store into the DPB
+type Picture struct {
+	// Header of a slice of the picture
+	Header *slice.Header
+	// TopPoc and BottomPoc TopFieldOrderCnt and BottomFieldOrderCnt, only the one of
+	// its parity for a field
+	TopPoc    int
+	BottomPoc int
+	// Planes of the frame, for a second field the planes of its first field
+	Planes [3]*frame.Plane
+	Motion *motion.Picture
+}
+
+// parity 0 for a top field, 1 for a bottom field, -1 for a frame
+func (p *Picture) parity() int {
+	switch {
+	case !p.Header.FieldPicFlag:
+		return -1
+	case p.Header.BottomFieldFlag:
+		return 1
+	}
+	return 0
+}
+
+// DPB decoded picture buffer
+type DPB struct {
+	frames []*Frame
+	sps    *internal.SPS
+	// size of the buffer in frames, reorder the frames waiting for output above which
+	// one is output
+	size    int
+	reorder int
+
+	maxLongTermFrameIdx int
+	prevRefFrameNum     int
+	// last frame buffer stored when holding a first field, for its second field
+	last   *Frame
+	nextID int
+}
+
+// New return an empty DPB, sized by the sequence parameter set of the first picture
+func New() *DPB {
+	return &DPB{maxLongTermFrameIdx: noLongTermFrameIdx}
+}
+
+// Frames frame buffers of the DPB, reference frames or fields and frames waiting
+// for output
+func (d *DPB) Frames() []*Frame {
+	return d.frames
+}
+
+// configure size the DPB for sps
+func (d *DPB) configure(sps *internal.SPS) {
+	d.sps = sps
+	d.size, d.reorder = bufferSizes(sps)
+}
+
+// FirstField the frame buffer holding the first field of the complementary field
+// pair the picture of slice h is the second field of, nil when it is not a second field
+func (d *DPB) FirstField(h *slice.Header) *Frame {
+	f := d.last
+	if f == nil || !h.FieldPicFlag || h.IdrPicFlag || f.FrameNum != int(h.FrameNum) {
+		return nil
+	}
+	parity := boolToInt(h.BottomFieldFlag)
+	if f.Decoded[parity] || !f.Decoded[1-parity] {
+		return nil
+	}
+	// both fields reference fields or both non-reference
+	if (h.NalRefIdc != 0) != f.IsRef() {
+		return nil
+	}
+	return f
+}
+
+// Gap fill a gap in frame_num before the picture of slice h with non-existing frames
+// marked as used for short-term reference, returning the frames output to make room
+// for them,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.2.5.2 Decoding process for gaps in frame_num
+func (d *DPB) Gap(h *slice.Header) ([]*Frame, error) {
+	if h.IdrPicFlag || d.sps == nil {
+		return nil, nil
+	}
+	maxFrameNum := int(h.SPS.MaxFrameNum())
+	frameNum := int(h.FrameNum)
+	if frameNum == d.prevRefFrameNum || frameNum == (d.prevRefFrameNum+1)%maxFrameNum {
+		return nil, nil
+	}
+	if !h.SPS.GapsInFrameNumValueAllowedFlag {
+		return nil, fmt.Errorf("frame_num %v after %v, gaps not allowed", frameNum, d.prevRefFrameNum)
+	}
+	d.last = nil
+	var out []*Frame
+	for n := (d.prevRefFrameNum + 1) % maxFrameNum; n != frameNum; n = (n + 1) % maxFrameNum {
+		d.slidingWindow(n, maxFrameNum)
+		f := &Frame{
+			ID:          d.newID(),
+			FrameNum:    n,
+			Decoded:     [2]bool{true, true},
+			Marking:     [2]Marking{ShortTerm, ShortTerm},
+			NonExisting: true,
+		}
+		o, err := d.insert(f, 0)
+		if err != nil {
+			return out, err
+		}
+		out = append(out, o...)
+		d.prevRefFrameNum = n
+	}
+	return out, nil
+}
+
+// Store mark the reference pictures after decoding pic and store it, returning the
+// frames output in output order. Output frames may still be used for reference, they
+// are not to be modified.
+func (d *DPB) Store(pic *Picture) ([]*Frame, error) {
+	h := pic.Header
+	if h.IdrPicFlag && d.sps != h.SPS || d.sps == nil {
+		d.configure(h.SPS)
+	}
+	parity := pic.parity()
+	first := d.FirstField(h)
+	marking, out, err := d.mark(pic, first)
+	if err != nil {
+		return out, err
+	}
+	if marking.mmco5 {
+		adjustMmco5(pic, parity)
+	}
+	if h.NalRefIdc != 0 {
+		d.prevRefFrameNum = int(h.FrameNum)
+		if marking.mmco5 {
+			d.prevRefFrameNum = 0
+		}
+	}
+
+	if first != nil {
+		first.Decoded[parity] = true
+		first.Marking[parity] = marking.marking
+		first.Poc[parity] = pocOf(pic, parity)
+		first.FieldMotion[parity] = pic.Motion
+		if marking.marking == LongTerm {
+			first.LongTermFrameIdx = marking.longTermFrameIdx
+		}
+		d.last = nil
+		return append(out, d.bumpReordered()...), nil
+	}
+
+	f := &Frame{
+		ID:               d.newID(),
+		Planes:           pic.Planes,
+		FrameNum:         int(h.FrameNum),
+		LongTermFrameIdx: marking.longTermFrameIdx,
+		FieldPic:         parity >= 0,
+		NeededForOutput:  true,
+	}
+	if marking.mmco5 {
+		f.FrameNum = 0
+	}
+	if parity < 0 {
+		f.Decoded = [2]bool{true, true}
+		f.Marking = [2]Marking{marking.marking, marking.marking}
+		f.Poc = [2]int{pic.TopPoc, pic.BottomPoc}
+		f.Motion = pic.Motion
+	} else {
+		f.Decoded[parity] = true
+		f.Marking[parity] = marking.marking
+		f.Poc[parity] = pocOf(pic, parity)
+		f.FieldMotion[parity] = pic.Motion
+	}
+	o, err := d.insert(f, h.NalRefIdc)
+	out = append(out, o...)
+	if err != nil {
+		return out, err
+	}
+	d.last = nil
+	if parity >= 0 && d.stored(f) {
+		d.last = f
+		return out, nil
+	}
+	return append(out, d.bumpReordered()...), nil
+}
+
+// Flush output all the frames waiting for output and empty the DPB, at the end of
+// the stream
+func (d *DPB) Flush() []*Frame {
+	out := d.bumpAll()
+	d.frames = nil
+	d.last = nil
+	return out
+}
+
+// insert store frame buffer f after removing the frames no longer needed and
+// outputting frames until there is room for it. A non-reference picture preceding
+// the frames waiting for output is output right away when the DPB is full.
+// T-REC-H.264-201402-S!!PDF-E.pdf C.4.5 Storage and marking of a reference decoded picture into the DPB
+func (d *DPB) insert(f *Frame, nalRefIdc uint8) ([]*Frame, error) {
+	d.removeUnused()
+	var out []*Frame
+	for len(d.frames) >= d.size {
+		if nalRefIdc == 0 && !f.NonExisting && d.precedesOutput(f) {
+			f.NeededForOutput = false
+			return append(out, f), nil
+		}
+		b := d.bump()
+		if b == nil {
+			return out, fmt.Errorf("decoded picture buffer full of %v reference frames", len(d.frames))
+		}
+		out = append(out, b)
+	}
+	d.frames = append(d.frames, f)
+	return out, nil
+}
+
+// stored report whether f is in the DPB
+func (d *DPB) stored(f *Frame) bool {
+	for _, g := range d.frames {
+		if g == f {
+			return true
+		}
+	}
+	return false
+}
+
+// removeUnused empty the frame buffers neither used for reference nor waiting for output
+func (d *DPB) removeUnused() {
+	frames := d.frames[:0]
+	for _, f := range d.frames {
+		if f.IsRef() || f.NeededForOutput {
+			frames = append(frames, f)
+		}
+	}
+	for i := len(frames); i < len(d.frames); i++ {
+		d.frames[i] = nil
+	}
+	d.frames = frames
+}
+
+// precedesOutput report whether f precedes all the frames waiting for output in
+// output order
+func (d *DPB) precedesOutput(f *Frame) bool {
+	for _, g := range d.frames {
+		if g.NeededForOutput && g.PicOrderCnt() <= f.PicOrderCnt() {
+			return false
+		}
+	}
+	return true
+}
+
+// bump output the frame waiting for output of the smallest PicOrderCnt( ), emptying
+// its frame buffer when not used for reference. It returns nil when no frame waits
+// for output.
+// T-REC-H.264-201402-S!!PDF-E.pdf C.4.5.3 "Bumping" process
+func (d *DPB) bump() *Frame {
+	var next *Frame
+	for _, f := range d.frames {
+		if f.NeededForOutput && (next == nil || f.PicOrderCnt() < next.PicOrderCnt()) {
+			next = f
+		}
+	}
+	if next == nil {
+		return nil
+	}
+	next.NeededForOutput = false
+	if d.last == next {
+		d.last = nil
+	}
+	d.removeUnused()
+	return next
+}
+
+// bumpAll output all the frames waiting for output
+func (d *DPB) bumpAll() []*Frame {
+	var out []*Frame
+	for f := d.bump(); f != nil; f = d.bump() {
+		out = append(out, f)
+	}
+	return out
+}
+
+// bumpReordered output frames while more than max_num_reorder_frames wait for output
+func (d *DPB) bumpReordered() []*Frame {
+	var out []*Frame
+	for {
+		n := 0
+		for _, f := range d.frames {
+			if f.NeededForOutput {
+				n++
+			}
+		}
+		if n <= d.reorder {
+			return out
+		}
+		out = append(out, d.bump())
+	}
+}
+
+func (d *DPB) newID() int {
+	d.nextID++
+	return d.nextID
+}
+
+// pocOf PicOrderCnt( ) of field parity of pic
+func pocOf(pic *Picture, parity int) int {
+	if parity == 1 {
+		return pic.BottomPoc
+	}
+	return pic.TopPoc
+}
+
+// adjustMmco5 the order counts of a picture with memory_management_control_operation
+// equal to 5, relative to the picture itself after its decoding, 8.2.1
+func adjustMmco5(pic *Picture, parity int) {
+	switch parity {
+	case 0:
+		pic.TopPoc = 0
+	case 1:
+		pic.BottomPoc = 0
+	default:
+		temp := imin(pic.TopPoc, pic.BottomPoc)
+		pic.TopPoc -= temp
+		pic.BottomPoc -= temp
+	}
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,370 @@
+package dpb
+
+import (
+	"testing"
+
+	"github.com/LiveStudioSolution/h264decoder/internal"
+	"github.com/LiveStudioSolution/h264decoder/internal/slice"
+)
+
+// testSPS sequence parameter set of MaxFrameNum 16 with max_dec_frame_buffering size and
+// max_num_reorder_frames reorder
+func testSPS(numRefFrames, size, reorder uint) *internal.SPS {
+	sps := &internal.SPS{NumRefFrames: numRefFrames, FrameMbsOnlyFlag: true, VuiParametersPresentFlag: true}
+	sps.VuiParams.BitstreamRestrictionFlag = true
+	sps.VuiParams.MaxDecFrameBuffering, sps.VuiParams.MaxNumReorderFrames = size, reorder
+	return sps
+}
+
+// testPic frame of frame_num frameNum and PicOrderCnt( ) poc, a reference one for ref,
+// with memory_management_control_operation commands ops
+func testPic(sps *internal.SPS, idr, ref bool, frameNum uint, poc int, ops ...slice.MemoryManagementOperation) *Picture {
+	h := &slice.Header{SPS: sps, IdrPicFlag: idr, FrameNum: frameNum}
+	if ref {
+		h.NalRefIdc = 1
+	}
+	if len(ops) > 0 {
+		h.DecRefPicMarking.AdaptiveRefPicMarkingModeFlag = true
+		h.DecRefPicMarking.Operations = ops
+	}
+	return &Picture{Header: h, TopPoc: poc, BottomPoc: poc}
+}
+
+// testField field of parity bottom, as testPic
+func testField(sps *internal.SPS, idr, ref, bottom bool, frameNum uint, poc int, ops ...slice.MemoryManagementOperation) *Picture {
+	pic := testPic(sps, idr, ref, frameNum, poc, ops...)
+	pic.Header.FieldPicFlag, pic.Header.BottomFieldFlag = true, bottom
+	return pic
+}
+
+// store pics into d and return PicOrderCnt( ) of the frames output
+func store(t *testing.T, d *DPB, pics ...*Picture) []int {
+	t.Helper()
+	var pocs []int
+	for _, pic := range pics {
+		if _, err := d.Gap(pic.Header); err != nil {
+			t.Fatalf("Gap(frame_num %v) error %v", pic.Header.FrameNum, err)
+		}
+		out, err := d.Store(pic)
+		if err != nil {
+			t.Fatalf("Store(frame_num %v) error %v", pic.Header.FrameNum, err)
+		}
+		for _, f := range out {
+			pocs = append(pocs, f.PicOrderCnt())
+		}
+	}
+	return pocs
+}
+
+// refs frame_num and marking of the reference frames of d
+func refs(d *DPB) map[int][2]Marking {
+	m := map[int][2]Marking{}
+	for _, f := range d.Frames() {
+		if f.IsRef() {
+			m[f.FrameNum] = f.Marking
+		}
+	}
+	return m
+}
+
+func equalPocs(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func equalRefs(a, b map[int][2]Marking) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+var (
+	short = [2]Marking{ShortTerm, ShortTerm}
+	long  = [2]Marking{LongTerm, LongTerm}
+)
+
+func TestSlidingWindow(t *testing.T) {
+	sps := testSPS(2, 3, 0)
+	d := New()
+	out := store(t, d, testPic(sps, true, true, 0, 0), testPic(sps, false, true, 1, 2), testPic(sps, false, true, 2, 4))
+	if want := []int{0, 2, 4}; !equalPocs(out, want) {
+		t.Errorf("Store() output %v, want %v", out, want)
+	}
+	if got, want := refs(d), map[int][2]Marking{1: short, 2: short}; !equalRefs(got, want) {
+		t.Errorf("reference frames %v, want %v", got, want)
+	}
+	if len(d.Frames()) != 2 {
+		t.Errorf("%v frames, want the 2 reference frames", len(d.Frames()))
+	}
+}
+
+func TestOutputOrder(t *testing.T) {
+	tests := []struct {
+		name       string
+		size       uint
+		reorder    uint
+		pocs       []int
+		want       []int
+		wantRemain []int
+	}{
+		// I0 P6 B2 B4, two frames reordered
+		{"reorder", 4, 2, []int{0, 6, 2, 4}, []int{0, 2}, []int{4, 6}},
+		// bumped as the DPB fills up
+		{"full", 2, 2, []int{0, 6, 2, 4}, []int{0, 2}, []int{4, 6}},
+		// non-reference picture preceding the frames waiting for output, output right away
+		{"non-reference output", 1, 1, []int{4, -2}, []int{-2}, []int{4}},
+	}
+	for _, tt := range tests {
+		sps := testSPS(1, tt.size, tt.reorder)
+		d := New()
+		pics := []*Picture{testPic(sps, true, true, 0, tt.pocs[0])}
+		last := tt.pocs[0]
+		for _, poc := range tt.pocs[1:] {
+			// P frames following the previous ones in output order, non-reference B frames
+			ref := poc > last
+			last = imax(last, poc)
+			pics = append(pics, testPic(sps, false, ref, 1, poc))
+		}
+		if got := store(t, d, pics...); !equalPocs(got, tt.want) {
+			t.Errorf("%v: Store() output %v, want %v", tt.name, got, tt.want)
+		}
+		var remain []int
+		for _, f := range d.Flush() {
+			remain = append(remain, f.PicOrderCnt())
+		}
+		if !equalPocs(remain, tt.wantRemain) {
+			t.Errorf("%v: Flush() %v, want %v", tt.name, remain, tt.wantRemain)
+		}
+		if len(d.Frames()) != 0 {
+			t.Errorf("%v: %v frames after Flush()", tt.name, len(d.Frames()))
+		}
+	}
+}
+
+func TestTooManyReferences(t *testing.T) {
+	// adaptive marking leaving max_num_ref_frames reference frames besides the current one
+	sps := testSPS(2, 2, 2)
+	d := New()
+	store(t, d, testPic(sps, true, true, 0, 0), testPic(sps, false, true, 1, 2))
+	pic := testPic(sps, false, true, 2, 4, slice.MemoryManagementOperation{MemoryManagementControlOperation: 4})
+	if _, err := d.Store(pic); err == nil {
+		t.Errorf("Store(above max_num_ref_frames) succeeded")
+	}
+}
+
+func TestMmco(t *testing.T) {
+	sps := testSPS(4, 4, 0)
+	d := New()
+	store(t, d, testPic(sps, true, true, 0, 0), testPic(sps, false, true, 1, 2), testPic(sps, false, true, 2, 4))
+	store(t, d, testPic(sps, false, true, 3, 6,
+		slice.MemoryManagementOperation{MemoryManagementControlOperation: 4, MaxLongTermFrameIdxPlus1: 2},
+		// picNumX 1
+		slice.MemoryManagementOperation{MemoryManagementControlOperation: 1, DifferenceOfPicNumsMinus1: 1},
+		// picNumX 2 to long-term index 1
+		slice.MemoryManagementOperation{MemoryManagementControlOperation: 3, LongTermFrameIdx: 1},
+		slice.MemoryManagementOperation{MemoryManagementControlOperation: 6, LongTermFrameIdx: 0},
+	))
+	if got, want := refs(d), map[int][2]Marking{0: short, 2: long, 3: long}; !equalRefs(got, want) {
+		t.Errorf("reference frames %v, want %v", got, want)
+	}
+	store(t, d, testPic(sps, false, true, 4, 8,
+		slice.MemoryManagementOperation{MemoryManagementControlOperation: 2, LongTermPicNum: 1},
+		slice.MemoryManagementOperation{MemoryManagementControlOperation: 4},
+	))
+	if got, want := refs(d), map[int][2]Marking{0: short, 4: short}; !equalRefs(got, want) {
+		t.Errorf("reference frames %v, want %v", got, want)
+	}
+
+	// long-term frame index of another frame reassigned
+	store(t, d, testPic(sps, false, true, 5, 10,
+		slice.MemoryManagementOperation{MemoryManagementControlOperation: 4, MaxLongTermFrameIdxPlus1: 1},
+		slice.MemoryManagementOperation{MemoryManagementControlOperation: 3, DifferenceOfPicNumsMinus1: 4, LongTermFrameIdx: 0},
+	))
+	store(t, d, testPic(sps, false, true, 6, 12,
+		slice.MemoryManagementOperation{MemoryManagementControlOperation: 6, LongTermFrameIdx: 0},
+	))
+	if got, want := refs(d), map[int][2]Marking{4: short, 5: short, 6: long}; !equalRefs(got, want) {
+		t.Errorf("reference frames %v, want %v", got, want)
+	}
+}
+
+func TestMmcoErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		op   slice.MemoryManagementOperation
+	}{
+		{"mmco 1 of no picture", slice.MemoryManagementOperation{MemoryManagementControlOperation: 1, DifferenceOfPicNumsMinus1: 5}},
+		{"mmco 2 of no picture", slice.MemoryManagementOperation{MemoryManagementControlOperation: 2, LongTermPicNum: 0}},
+		{"mmco 3 above MaxLongTermFrameIdx", slice.MemoryManagementOperation{MemoryManagementControlOperation: 3}},
+		{"mmco 6 above MaxLongTermFrameIdx", slice.MemoryManagementOperation{MemoryManagementControlOperation: 6}},
+	}
+	for _, tt := range tests {
+		sps := testSPS(2, 2, 0)
+		d := New()
+		store(t, d, testPic(sps, true, true, 0, 0))
+		if _, err := d.Store(testPic(sps, false, true, 1, 2, tt.op)); err == nil {
+			t.Errorf("%v: Store() succeeded", tt.name)
+		}
+	}
+}
+
+func TestMmco5(t *testing.T) {
+	sps := testSPS(2, 3, 3)
+	d := New()
+	store(t, d, testPic(sps, true, true, 0, 0), testPic(sps, false, true, 1, 4))
+	pic := testPic(sps, false, true, 2, 8, slice.MemoryManagementOperation{MemoryManagementControlOperation: 5})
+	out := store(t, d, pic)
+	if want := []int{0, 4}; !equalPocs(out, want) {
+		t.Errorf("Store(mmco 5) output %v, want %v", out, want)
+	}
+	if got, want := refs(d), map[int][2]Marking{0: short}; !equalRefs(got, want) {
+		t.Errorf("reference frames %v, want %v", got, want)
+	}
+	if f := d.Frames()[0]; f.Poc != [2]int{0, 0} {
+		t.Errorf("Poc %v after mmco 5, want [0 0]", f.Poc)
+	}
+	// frame_num 1 following the frame_num 0 inferred after mmco 5
+	store(t, d, testPic(sps, false, true, 1, 2))
+}
+
+func TestIdr(t *testing.T) {
+	sps := testSPS(2, 3, 3)
+	d := New()
+	store(t, d, testPic(sps, true, true, 0, 0), testPic(sps, false, true, 1, 2))
+	idr := testPic(sps, true, true, 0, 0)
+	idr.Header.DecRefPicMarking.NoOutputOfPriorPicsFlag = true
+	idr.Header.DecRefPicMarking.LongTermReferenceFlag = true
+	if out := store(t, d, idr); len(out) != 0 {
+		t.Errorf("Store(no_output_of_prior_pics_flag) output %v", out)
+	}
+	if got, want := refs(d), map[int][2]Marking{0: long}; !equalRefs(got, want) {
+		t.Errorf("reference frames %v, want %v", got, want)
+	}
+	if len(d.Frames()) != 1 {
+		t.Errorf("%v frames after IDR, want 1", len(d.Frames()))
+	}
+	if out := store(t, d, testPic(sps, true, true, 0, 0)); !equalPocs(out, []int{0}) {
+		t.Errorf("Store(IDR) output %v, want [0]", out)
+	}
+}
+
+func TestGap(t *testing.T) {
+	sps := testSPS(2, 3, 3)
+	sps.GapsInFrameNumValueAllowedFlag = true
+	d := New()
+	store(t, d, testPic(sps, true, true, 0, 0), testPic(sps, false, true, 3, 6))
+	if got, want := refs(d), map[int][2]Marking{2: short, 3: short}; !equalRefs(got, want) {
+		t.Errorf("reference frames %v, want %v", got, want)
+	}
+	for _, f := range d.Frames() {
+		if f.FrameNum == 2 && !f.NonExisting {
+			t.Errorf("frame_num 2 not a non-existing frame")
+		}
+	}
+	var out []int
+	for _, f := range d.Flush() {
+		out = append(out, f.PicOrderCnt())
+	}
+	if want := []int{0, 6}; !equalPocs(out, want) {
+		t.Errorf("Flush() %v, want %v", out, want)
+	}
+
+	sps.GapsInFrameNumValueAllowedFlag = false
+	d = New()
+	store(t, d, testPic(sps, true, true, 0, 0))
+	if _, err := d.Gap(testPic(sps, false, true, 3, 6).Header); err == nil {
+		t.Errorf("Gap(gaps not allowed) succeeded")
+	}
+	// non-reference pictures repeat the frame_num following the previous reference one
+	store(t, d, testPic(sps, false, false, 1, 2), testPic(sps, false, false, 1, 4), testPic(sps, false, true, 1, 6))
+}
+
+func TestFields(t *testing.T) {
+	sps := testSPS(2, 3, 0)
+	d := New()
+	top := testField(sps, true, true, false, 0, 0)
+	bottom := testField(sps, false, true, true, 0, 1)
+	store(t, d, top)
+	if f := d.FirstField(bottom.Header); f == nil || !f.Decoded[0] || f.Decoded[1] {
+		t.Fatalf("FirstField() = %v, want the top field", f)
+	}
+	if out := store(t, d, bottom); !equalPocs(out, []int{0}) {
+		t.Errorf("Store(second field) output %v, want [0]", out)
+	}
+	if len(d.Frames()) != 1 || !d.Frames()[0].complete() {
+		t.Fatalf("complementary field pair not stored in one frame buffer")
+	}
+	// the top field of frame_num 0, PicNum 1 of CurrPicNum 3
+	store(t, d, testField(sps, false, true, false, 1, 4,
+		slice.MemoryManagementOperation{MemoryManagementControlOperation: 1, DifferenceOfPicNumsMinus1: 1}))
+	if got, want := refs(d), map[int][2]Marking{0: {Unused, ShortTerm}, 1: {ShortTerm, Unused}}; !equalRefs(got, want) {
+		t.Errorf("reference fields %v, want %v", got, want)
+	}
+	// second field of another parity only
+	if f := d.FirstField(testField(sps, false, true, false, 1, 5).Header); f != nil {
+		t.Errorf("FirstField(same parity) = %v, want nil", f)
+	}
+	// non-reference second field of a reference field
+	if f := d.FirstField(testField(sps, false, false, true, 1, 5).Header); f != nil {
+		t.Errorf("FirstField(non-reference) = %v, want nil", f)
+	}
+}
+
+func TestBufferSizes(t *testing.T) {
+	tests := []struct {
+		name          string
+		sps           internal.SPS
+		size, reorder int
+	}{
+		{"vui", *testSPS(2, 3, 1), 3, 1},
+		{"vui below max_num_ref_frames", *testSPS(4, 3, 1), 4, 1},
+		// 720x576 at level 3, 8100 / 1620
+		{"level 3", internal.SPS{LevelIdc: 30, PicWidthInMbsMinus1: 44, PicHeightInMapUnitsMinus1: 35, FrameMbsOnlyFlag: true}, 5, 5},
+		// 1920x1088 at level 4, 32768 / 8160
+		{"level 4", internal.SPS{LevelIdc: 40, PicWidthInMbsMinus1: 119, PicHeightInMapUnitsMinus1: 33}, 4, 4},
+		// QCIF at level 1b, 396 / 99
+		{"level 1b", internal.SPS{ProfileIdc: 66, ConstraintSet3Flag: true, LevelIdc: 11, PicWidthInMbsMinus1: 10, PicHeightInMapUnitsMinus1: 8, FrameMbsOnlyFlag: true}, 4, 4},
+		{"level 1.1", internal.SPS{ProfileIdc: 100, ConstraintSet3Flag: false, LevelIdc: 11, PicWidthInMbsMinus1: 10, PicHeightInMapUnitsMinus1: 8, FrameMbsOnlyFlag: true}, 9, 9},
+		{"intra profile", internal.SPS{ProfileIdc: 100, ConstraintSet3Flag: true, LevelIdc: 40, FrameMbsOnlyFlag: true}, 1, 0},
+		{"unknown level", internal.SPS{LevelIdc: 99, FrameMbsOnlyFlag: true}, 16, 16},
+	}
+	for _, tt := range tests {
+		if size, reorder := bufferSizes(&tt.sps); size != tt.size || reorder != tt.reorder {
+			t.Errorf("%v: bufferSizes() = %v, %v, want %v, %v", tt.name, size, reorder, tt.size, tt.reorder)
+		}
+	}
+}
+
+func TestPicNum(t *testing.T) {
+	f := &Frame{FrameNum: 14, LongTermFrameIdx: 2}
+	tests := []struct {
+		parity, currParity, currFrameNum int
+		picNum, longTermPicNum           int
+	}{
+		{0, -1, 15, 14, 2},
+		{0, -1, 3, -2, 2},
+		{0, 0, 3, -3, 5},
+		{1, 0, 3, -4, 4},
+	}
+	for _, tt := range tests {
+		if got := f.PicNum(tt.parity, tt.currParity, tt.currFrameNum, 16); got != tt.picNum {
+			t.Errorf("PicNum(%v, %v, %v) = %v, want %v", tt.parity, tt.currParity, tt.currFrameNum, got, tt.picNum)
+		}
+		if got := f.LongTermPicNum(tt.parity, tt.currParity); got != tt.longTermPicNum {
+			t.Errorf("LongTermPicNum(%v, %v) = %v, want %v", tt.parity, tt.currParity, got, tt.longTermPicNum)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package dpb
+
+import (
+	"github.com/LiveStudioSolution/h264decoder/internal/frame"
+	"github.com/LiveStudioSolution/h264decoder/internal/motion"
+)
+
+// Marking reference marking of a field
+type Marking uint8
+
+const (
+	Unused Marking = iota
+	ShortTerm
+	LongTerm
+)
+
+func (m Marking) String() string {
+	switch m {
+	case ShortTerm:
+		return "short-term"
+	case LongTerm:
+		return "long-term"
+	}
+	return "unused"
+}
+
+// Frame a frame buffer of the DPB, holding a decoded frame, a complementary field
+// pair or a single field. Fields are indexed 0 for top and 1 for bottom, both set
+// alike for a frame.
+type Frame struct {
+	// ID identify the frame buffer content, unique over the decoder lifetime
+	ID     int
+	Planes [3]*frame.Plane
+
+	FrameNum         int
+	LongTermFrameIdx int
+	Decoded          [2]bool
+	Marking          [2]Marking
+	// Poc TopFieldOrderCnt and BottomFieldOrderCnt
+	Poc [2]int
+
+	// FieldPic whether the fields were decoded as field pictures, Motion the motion
+	// of a frame picture and FieldMotion the one of each field picture
+	FieldPic    bool
+	Motion      *motion.Picture
+	FieldMotion [2]*motion.Picture
+
+	// NonExisting frame inferred for a gap in frame_num, neither decoded nor output
+	NonExisting     bool
+	NeededForOutput bool
+}
+
+// IsRef report whether a field of the frame is marked as used for reference
+func (f *Frame) IsRef() bool {
+	return f.Marking[0] != Unused || f.Marking[1] != Unused
+}
+
+// IsShortTermFrame report whether both fields are marked as used for short-term reference
+func (f *Frame) IsShortTermFrame() bool {
+	return f.Marking[0] == ShortTerm && f.Marking[1] == ShortTerm
+}
+
+// IsLongTermFrame report whether both fields are marked as used for long-term reference
+func (f *Frame) IsLongTermFrame() bool {
+	return f.Marking[0] == LongTerm && f.Marking[1] == LongTerm
+}
+
+// complete report whether both fields of the frame are decoded
+func (f *Frame) complete() bool {
+	return f.Decoded[0] && f.Decoded[1]
+}
+
+// PicOrderCnt PicOrderCnt( ) of the frame, complementary field pair or single field
+func (f *Frame) PicOrderCnt() int {
+	switch {
+	case !f.Decoded[0]:
+		return f.Poc[1]
+	case !f.Decoded[1]:
+		return f.Poc[0]
+	}
+	return imin(f.Poc[0], f.Poc[1])
+}
+
+// FrameNumWrap FrameNumWrap of a short-term reference frame relative to the current
+// frame_num, 8.2.4.1
+func (f *Frame) FrameNumWrap(currFrameNum, maxFrameNum int) int {
+	if f.FrameNum > currFrameNum {
+		return f.FrameNum - maxFrameNum
+	}
+	return f.FrameNum
+}
+
+// PicNum PicNum of field parity of a short-term reference frame relative to the current
+// picture, of the frame when currParity is -1 for a frame picture, else of the field
+// of parity, currParity being the one of the current field,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.2.4.1 Decoding process for picture numbers
+func (f *Frame) PicNum(parity, currParity, currFrameNum, maxFrameNum int) int {
+	wrap := f.FrameNumWrap(currFrameNum, maxFrameNum)
+	if currParity < 0 {
+		return wrap
+	}
+	if parity == currParity {
+		return 2*wrap + 1
+	}
+	return 2 * wrap
+}
+
+// LongTermPicNum LongTermPicNum of field parity of a long-term reference frame, as PicNum
+func (f *Frame) LongTermPicNum(parity, currParity int) int {
+	if currParity < 0 {
+		return f.LongTermFrameIdx
+	}
+	if parity == currParity {
+		return 2*f.LongTermFrameIdx + 1
+	}
+	return 2 * f.LongTermFrameIdx
+}
+
+func imin(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func imax(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
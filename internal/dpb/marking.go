@@ -0,0 +1,232 @@
+package dpb
+
+import (
+	"fmt"
+
+	"github.com/LiveStudioSolution/h264decoder/internal/slice"
+)
+
+// currentMarking marking of the current picture
+type currentMarking struct {
+	marking          Marking
+	longTermFrameIdx int
+	mmco5            bool
+}
+
+// mark the reference pictures of the DPB and derive the marking of the current
+// picture pic, first being the frame buffer of its first field for a second field.
+// It returns the frames output when all of them are removed by an IDR picture or
+// memory_management_control_operation 5.
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.2.5.1 Sequence of operations for decoded reference picture marking process
+func (d *DPB) mark(pic *Picture, first *Frame) (currentMarking, []*Frame, error) {
+	h := pic.Header
+	drpm := &h.DecRefPicMarking
+	if h.NalRefIdc == 0 {
+		return currentMarking{}, nil, nil
+	}
+	if h.IdrPicFlag {
+		var out []*Frame
+		if !drpm.NoOutputOfPriorPicsFlag {
+			out = d.bumpAll()
+		}
+		d.frames, d.last = nil, nil
+		if drpm.LongTermReferenceFlag {
+			d.maxLongTermFrameIdx = 0
+			return currentMarking{marking: LongTerm}, out, nil
+		}
+		d.maxLongTermFrameIdx = noLongTermFrameIdx
+		return currentMarking{marking: ShortTerm}, out, nil
+	}
+
+	curr := currentMarking{marking: ShortTerm}
+	maxFrameNum := int(h.SPS.MaxFrameNum())
+	parity := pic.parity()
+	switch {
+	case drpm.AdaptiveRefPicMarkingModeFlag:
+		var err error
+		if curr, err = d.adaptive(h, parity, first); err != nil {
+			return curr, nil, err
+		}
+	case first != nil && first.Marking[1-parity] == ShortTerm:
+		// second field of a pair whose first field is a short-term reference
+	default:
+		d.slidingWindow(int(h.FrameNum), maxFrameNum)
+	}
+
+	var out []*Frame
+	if curr.mmco5 {
+		// the current picture follows all the prior ones in output order, C.4.4
+		out = d.bumpAll()
+		d.frames, d.last = nil, nil
+	}
+	n := 0
+	for _, f := range d.frames {
+		if f.IsRef() && f != first {
+			n++
+		}
+	}
+	if n >= imax(int(h.SPS.NumRefFrames), 1) {
+		return curr, out, fmt.Errorf("%v reference frames and the current picture above max_num_ref_frames %v", n, h.SPS.NumRefFrames)
+	}
+	return curr, out, nil
+}
+
+// slidingWindow mark the short-term reference frame, complementary field pair or
+// non-paired field of the smallest FrameNumWrap as unused for reference when the
+// reference frames fill max_num_ref_frames,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.2.5.3 Sliding window decoded reference picture marking process
+func (d *DPB) slidingWindow(currFrameNum, maxFrameNum int) {
+	numShortTerm, numLongTerm := 0, 0
+	var oldest *Frame
+	for _, f := range d.frames {
+		if f.Marking[0] == LongTerm || f.Marking[1] == LongTerm {
+			numLongTerm++
+		}
+		if f.Marking[0] != ShortTerm && f.Marking[1] != ShortTerm {
+			continue
+		}
+		numShortTerm++
+		if oldest == nil || f.FrameNumWrap(currFrameNum, maxFrameNum) < oldest.FrameNumWrap(currFrameNum, maxFrameNum) {
+			oldest = f
+		}
+	}
+	if numShortTerm+numLongTerm < imax(int(d.sps.NumRefFrames), 1) || oldest == nil {
+		return
+	}
+	for parity, m := range oldest.Marking {
+		if m == ShortTerm {
+			oldest.Marking[parity] = Unused
+		}
+	}
+}
+
+// adaptive apply the memory_management_control_operation commands of slice h, of a
+// picture of parity, and return the marking of the current picture,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.2.5.4 Adaptive memory control decoded reference picture marking process
+func (d *DPB) adaptive(h *slice.Header, parity int, first *Frame) (currentMarking, error) {
+	curr := currentMarking{marking: ShortTerm}
+	maxFrameNum := int(h.SPS.MaxFrameNum())
+	frameNum := int(h.FrameNum)
+	currPicNum := frameNum
+	if parity >= 0 {
+		currPicNum = 2*frameNum + 1
+	}
+	for _, op := range h.DecRefPicMarking.Operations {
+		switch op.MemoryManagementControlOperation {
+		case 1:
+			picNumX := currPicNum - int(op.DifferenceOfPicNumsMinus1+1)
+			f, fieldParity := d.shortTerm(picNumX, parity, frameNum, maxFrameNum)
+			if f == nil {
+				return curr, fmt.Errorf("mmco 1 of no short-term picture of picNum %v", picNumX)
+			}
+			setMarking(f, fieldParity, Unused)
+		case 2:
+			f, fieldParity := d.longTerm(int(op.LongTermPicNum), parity)
+			if f == nil {
+				return curr, fmt.Errorf("mmco 2 of no long-term picture of LongTermPicNum %v", op.LongTermPicNum)
+			}
+			setMarking(f, fieldParity, Unused)
+		case 3:
+			picNumX := currPicNum - int(op.DifferenceOfPicNumsMinus1+1)
+			f, fieldParity := d.shortTerm(picNumX, parity, frameNum, maxFrameNum)
+			if f == nil {
+				return curr, fmt.Errorf("mmco 3 of no short-term picture of picNum %v", picNumX)
+			}
+			idx := int(op.LongTermFrameIdx)
+			if idx > d.maxLongTermFrameIdx {
+				return curr, fmt.Errorf("mmco 3 LongTermFrameIdx %v above MaxLongTermFrameIdx %v", idx, d.maxLongTermFrameIdx)
+			}
+			d.freeLongTermFrameIdx(idx, f)
+			setMarking(f, fieldParity, LongTerm)
+			f.LongTermFrameIdx = idx
+		case 4:
+			d.maxLongTermFrameIdx = int(op.MaxLongTermFrameIdxPlus1) - 1
+			for _, f := range d.frames {
+				if f.LongTermFrameIdx > d.maxLongTermFrameIdx {
+					unmarkLongTerm(f)
+				}
+			}
+		case 5:
+			for _, f := range d.frames {
+				f.Marking = [2]Marking{}
+			}
+			d.maxLongTermFrameIdx = noLongTermFrameIdx
+			curr.mmco5 = true
+		case 6:
+			idx := int(op.LongTermFrameIdx)
+			if idx > d.maxLongTermFrameIdx {
+				return curr, fmt.Errorf("mmco 6 LongTermFrameIdx %v above MaxLongTermFrameIdx %v", idx, d.maxLongTermFrameIdx)
+			}
+			d.freeLongTermFrameIdx(idx, first)
+			curr.marking, curr.longTermFrameIdx = LongTerm, idx
+		}
+	}
+	return curr, nil
+}
+
+// shortTerm the frame buffer and field parity, -1 for a frame, of the short-term
+// reference picture of PicNum picNum, nil when there is none
+func (d *DPB) shortTerm(picNum, currParity, currFrameNum, maxFrameNum int) (*Frame, int) {
+	for _, f := range d.frames {
+		if currParity < 0 {
+			if f.IsShortTermFrame() && f.PicNum(0, currParity, currFrameNum, maxFrameNum) == picNum {
+				return f, -1
+			}
+			continue
+		}
+		for parity, m := range f.Marking {
+			if m == ShortTerm && f.PicNum(parity, currParity, currFrameNum, maxFrameNum) == picNum {
+				return f, parity
+			}
+		}
+	}
+	return nil, 0
+}
+
+// longTerm the frame buffer and field parity, -1 for a frame, of the long-term
+// reference picture of LongTermPicNum longTermPicNum, nil when there is none
+func (d *DPB) longTerm(longTermPicNum, currParity int) (*Frame, int) {
+	for _, f := range d.frames {
+		if currParity < 0 {
+			if f.IsLongTermFrame() && f.LongTermPicNum(0, currParity) == longTermPicNum {
+				return f, -1
+			}
+			continue
+		}
+		for parity, m := range f.Marking {
+			if m == LongTerm && f.LongTermPicNum(parity, currParity) == longTermPicNum {
+				return f, parity
+			}
+		}
+	}
+	return nil, 0
+}
+
+// freeLongTermFrameIdx mark the long-term fields of LongTermFrameIdx idx as unused
+// for reference, but the ones of frame buffer keep, holding the field paired with
+// the picture being assigned idx
+func (d *DPB) freeLongTermFrameIdx(idx int, keep *Frame) {
+	for _, f := range d.frames {
+		if f != keep && f.LongTermFrameIdx == idx {
+			unmarkLongTerm(f)
+		}
+	}
+}
+
+// setMarking mark field parity of f, both fields for parity -1
+func setMarking(f *Frame, parity int, m Marking) {
+	if parity < 0 {
+		f.Marking = [2]Marking{m, m}
+		return
+	}
+	f.Marking[parity] = m
+}
+
+// unmarkLongTerm mark the long-term fields of f as unused for reference
+func unmarkLongTerm(f *Frame) {
+	for parity, m := range f.Marking {
+		if m == LongTerm {
+			f.Marking[parity] = Unused
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package dpb
+
+import "github.com/LiveStudioSolution/h264decoder/internal"
+
+// maxDpbMbs MaxDpbMbs by level_idc, Table A-1
+var maxDpbMbs = map[uint8]int{
+	9:  396,
+	10: 396,
+	11: 900,
+	12: 2376,
+	13: 2376,
+	20: 2376,
+	21: 4752,
+	22: 8100,
+	30: 8100,
+	31: 18000,
+	32: 20480,
+	40: 32768,
+	41: 32768,
+	42: 34816,
+	50: 110400,
+	51: 184320,
+	52: 184320,
+	60: 696320,
+	61: 696320,
+	62: 696320,
+}
+
+// maxDpbFrames MaxDpbFrames of the level of sps, 16 for unknown levels,
+// T-REC-H.264-201402-S!!PDF-E.pdf A.3.1 Level limits common to the Baseline, Constrained Baseline, Main, and Extended profiles
+func maxDpbFrames(sps *internal.SPS) int {
+	level := sps.LevelIdc
+	// level 1b of the Baseline, Main and Extended profiles
+	if level == 11 && sps.ConstraintSet3Flag && (sps.ProfileIdc == 66 || sps.ProfileIdc == 77 || sps.ProfileIdc == 88) {
+		level = 9
+	}
+	mbs, ok := maxDpbMbs[level]
+	if !ok {
+		return 16
+	}
+	return imin(mbs/int(sps.PicSizeInMbs()), 16)
+}
+
+// intraProfile report whether sps is of one of the intra profiles, whose pictures
+// are output right after decoding
+func intraProfile(sps *internal.SPS) bool {
+	switch sps.ProfileIdc {
+	case 44:
+		return true
+	case 86, 100, 110, 122, 244:
+		return sps.ConstraintSet3Flag
+	}
+	return false
+}
+
+// bufferSizes size of the DPB in frames and the maximum number of frames preceding
+// a frame in decoding order and following it in output order, max_dec_frame_buffering
+// and max_num_reorder_frames when present, else their inferred values, E.2.1
+func bufferSizes(sps *internal.SPS) (size, reorder int) {
+	vui := &sps.VuiParams
+	switch {
+	case sps.VuiParametersPresentFlag && vui.BitstreamRestrictionFlag:
+		size, reorder = int(vui.MaxDecFrameBuffering), int(vui.MaxNumReorderFrames)
+	case intraProfile(sps):
+		size, reorder = 0, 0
+	default:
+		size = maxDpbFrames(sps)
+		reorder = size
+	}
+	// room for the current reference picture of streams without reference frames
+	size = imax(size, imax(int(sps.NumRefFrames), 1))
+	return size, imin(reorder, size)
+}
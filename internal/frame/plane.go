@@ -0,0 +1,36 @@
+package frame
+
+// Plane samples of one colour component of a picture, one element per sample
+// whatever the bit depth
+type Plane struct {
+	Pix    []uint16
+	Stride int
+	Width  int
+	Height int
+}
+
+// NewPlane allocate a plane of width x height samples
+func NewPlane(width, height int) *Plane {
+	return &Plane{Pix: make([]uint16, width*height), Stride: width, Width: width, Height: height}
+}
+
+// At sample at ( x, y )
+func (p *Plane) At(x, y int) uint16 {
+	return p.Pix[y*p.Stride+x]
+}
+
+// Set sample at ( x, y )
+func (p *Plane) Set(x, y int, v uint16) {
+	p.Pix[y*p.Stride+x] = v
+}
+
+// Field the top or bottom field of a frame plane, sharing its samples
+func (p *Plane) Field(bottom bool) *Plane {
+	f := &Plane{Stride: 2 * p.Stride, Width: p.Width, Height: p.Height / 2}
+	if bottom {
+		f.Pix = p.Pix[p.Stride:]
+	} else {
+		f.Pix = p.Pix
+	}
+	return f
+}
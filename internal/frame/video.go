@@ -1,8 +1,258 @@
 package frame
 
-import "image"
+import (
+	"image"
+	"time"
 
+	"github.com/LiveStudioSolution/h264decoder/internal"
+)
+
+// ChromaFormat chroma sampling of a frame, chroma_format_idc, Table 6-1
+type ChromaFormat uint8
+
+const (
+	Monochrome ChromaFormat = iota
+	Chroma420
+	Chroma422
+	Chroma444
+)
+
+// SubWidthC horizontal chroma subsampling factor, 0 for monochrome
+func (c ChromaFormat) SubWidthC() int {
+	switch c {
+	case Chroma420, Chroma422:
+		return 2
+	case Chroma444:
+		return 1
+	}
+	return 0
+}
+
+// SubHeightC vertical chroma subsampling factor, 0 for monochrome
+func (c ChromaFormat) SubHeightC() int {
+	switch c {
+	case Chroma420:
+		return 2
+	case Chroma422, Chroma444:
+		return 1
+	}
+	return 0
+}
+
+// Structure how the pictures of a frame were coded
+type Structure uint8
+
+const (
+	// FrameStructure a coded frame
+	FrameStructure Structure = iota
+	// FieldPair a complementary field pair, two coded fields
+	FieldPair
+	// TopField and BottomField a single field, the other field lines left blank
+	TopField
+	BottomField
+)
+
+// VideoPlane samples of one colour component of an output frame, one byte per
+// sample for bit depth 8, else two bytes little-endian
+type VideoPlane struct {
+	Pix []byte
+	// Stride bytes between vertically adjacent samples
+	Stride   int
+	Width    int
+	Height   int
+	BitDepth int
+}
+
+// NewVideoPlane allocate a plane of width x height samples of bitDepth bits
+func NewVideoPlane(width, height, bitDepth int) *VideoPlane {
+	stride := width * bytesPerSample(bitDepth)
+	return &VideoPlane{Pix: make([]byte, stride*height), Stride: stride, Width: width, Height: height, BitDepth: bitDepth}
+}
+
+func bytesPerSample(bitDepth int) int {
+	if bitDepth > 8 {
+		return 2
+	}
+	return 1
+}
+
+// At sample at ( x, y )
+func (p *VideoPlane) At(x, y int) uint16 {
+	if p.BitDepth <= 8 {
+		return uint16(p.Pix[y*p.Stride+x])
+	}
+	i := y*p.Stride + 2*x
+	return uint16(p.Pix[i]) | uint16(p.Pix[i+1])<<8
+}
+
+// Set sample at ( x, y )
+func (p *VideoPlane) Set(x, y int, v uint16) {
+	if p.BitDepth <= 8 {
+		p.Pix[y*p.Stride+x] = uint8(v)
+		return
+	}
+	i := y*p.Stride + 2*x
+	p.Pix[i], p.Pix[i+1] = uint8(v), uint8(v>>8)
+}
+
+// VideoFrame a decoded frame in output order
 type VideoFrame struct {
+	// Planes Y, Cb and Cr, chroma planes are nil for monochrome
+	Planes       [3]*VideoPlane
+	ChromaFormat ChromaFormat
+	BitDepthY    int
+	BitDepthC    int
+	// Crop window of the samples to display, in luma samples of the coded frame
+	Crop image.Rectangle
+
+	// Poc PicOrderCnt( ) of the frame, TopPoc and BottomPoc TopFieldOrderCnt and
+	// BottomFieldOrderCnt
+	Poc       int
+	TopPoc    int
+	BottomPoc int
+	FrameNum  int
+	Structure Structure
+	// KeyFrame an IDR picture, or a recovery point, decoding can start from
+	KeyFrame bool
+	// SPS sequence parameter set of the picture, its VUI parameters describing the
+	// colour of the frame, nil for a frame not output by the decoder
+	SPS *internal.SPS
+
+	// Timestamp presentation time relative to the first frame output, Duration the
+	// display duration, both 0 when the stream carries no timing information
+	Timestamp time.Duration
+	Duration  time.Duration
+}
+
+// NewVideoFrame copy the decoded planes Y, Cb and Cr of chroma format format into a
+// frame, cropped to the whole coded frame until Crop is set from the SPS frame
+// cropping window
+func NewVideoFrame(planes [3]*Plane, format ChromaFormat, bitDepthY, bitDepthC int) *VideoFrame {
+	f := &VideoFrame{ChromaFormat: format, BitDepthY: bitDepthY, BitDepthC: bitDepthC}
+	for c, p := range planes {
+		if p == nil || c > 0 && format == Monochrome {
+			continue
+		}
+		bitDepth := bitDepthY
+		if c > 0 {
+			bitDepth = bitDepthC
+		}
+		v := NewVideoPlane(p.Width, p.Height, bitDepth)
+		for y := 0; y < p.Height; y++ {
+			row := p.Pix[y*p.Stride : y*p.Stride+p.Width]
+			for x, s := range row {
+				v.Set(x, y, s)
+			}
+		}
+		f.Planes[c] = v
+	}
+	if y := f.Planes[0]; y != nil {
+		f.Crop = image.Rect(0, 0, y.Width, y.Height)
+	}
+	return f
+}
+
+// Bounds the crop window
+func (f *VideoFrame) Bounds() image.Rectangle {
+	return f.Crop
+}
+
+// YCbCr the frame as an image.YCbCr sharing its samples, false unless the frame
+// is 8 bits 4:2:0, 4:2:2 or 4:4:4 with chroma planes of the same stride
+func (f *VideoFrame) YCbCr() (*image.YCbCr, bool) {
+	var ratio image.YCbCrSubsampleRatio
+	switch f.ChromaFormat {
+	case Chroma420:
+		ratio = image.YCbCrSubsampleRatio420
+	case Chroma422:
+		ratio = image.YCbCrSubsampleRatio422
+	case Chroma444:
+		ratio = image.YCbCrSubsampleRatio444
+	default:
+		return nil, false
+	}
+	y, cb, cr := f.Planes[0], f.Planes[1], f.Planes[2]
+	if f.BitDepthY != 8 || f.BitDepthC != 8 || y == nil || cb == nil || cr == nil || cb.Stride != cr.Stride {
+		return nil, false
+	}
+	im := &image.YCbCr{
+		Y:              y.Pix,
+		Cb:             cb.Pix,
+		Cr:             cr.Pix,
+		YStride:        y.Stride,
+		CStride:        cb.Stride,
+		SubsampleRatio: ratio,
+		Rect:           image.Rect(0, 0, y.Width, y.Height),
+	}
+	return im.SubImage(f.Crop).(*image.YCbCr), true
+}
+
+// Gray the luma plane as an image.Gray, sharing its samples for bit depth 8, else
+// a copy scaled to 8 bits
+func (f *VideoFrame) Gray() *image.Gray {
+	y := f.Planes[0]
+	if f.BitDepthY == 8 {
+		im := &image.Gray{Pix: y.Pix, Stride: y.Stride, Rect: image.Rect(0, 0, y.Width, y.Height)}
+		return im.SubImage(f.Crop).(*image.Gray)
+	}
+	im := image.NewGray(f.Crop)
+	shift := f.BitDepthY - 8
+	for yy := f.Crop.Min.Y; yy < f.Crop.Max.Y; yy++ {
+		for x := f.Crop.Min.X; x < f.Crop.Max.X; x++ {
+			im.Pix[im.PixOffset(x, yy)] = uint8(y.At(x, yy) >> shift)
+		}
+	}
+	return im
+}
+
+// RGBA64 a copy of the frame converted to RGB by the full range BT.601 matrix of
+// image.YCbCr, gray for monochrome. Package colour converts as the VUI colour
+// description of SPS specifies.
+func (f *VideoFrame) RGBA64() *image.RGBA64 {
+	im := image.NewRGBA64(f.Crop)
+	subW, subH := f.ChromaFormat.SubWidthC(), f.ChromaFormat.SubHeightC()
+	maxY := 1<<f.BitDepthY - 1
+	for y := f.Crop.Min.Y; y < f.Crop.Max.Y; y++ {
+		for x := f.Crop.Min.X; x < f.Crop.Max.X; x++ {
+			// samples normalised to 16 bits
+			l := int64(f.Planes[0].At(x, y)) * 0xffff / int64(maxY)
+			r, g, b := l, l, l
+			if subW != 0 {
+				maxC, half := int64(1)<<f.BitDepthC-1, int64(1)<<(f.BitDepthC-1)
+				cb := (int64(f.Planes[1].At(x/subW, y/subH)) - half) * 0xffff / maxC
+				cr := (int64(f.Planes[2].At(x/subW, y/subH)) - half) * 0xffff / maxC
+				r = l + (91881*cr)>>16
+				g = l - (22554*cb+46802*cr)>>16
+				b = l + (116130*cb)>>16
+			}
+			i := im.PixOffset(x, y)
+			for c, v := range [4]int64{r, g, b, 0xffff} {
+				v = clip16(v)
+				im.Pix[i+2*c], im.Pix[i+2*c+1] = uint8(v>>8), uint8(v)
+			}
+		}
+	}
+	return im
+}
+
+// Image the frame as an image.Image, sharing its samples when possible, image.YCbCr
+// for 8 bits chroma, image.Gray for monochrome, else image.RGBA64
+func (f *VideoFrame) Image() image.Image {
+	if im, ok := f.YCbCr(); ok {
+		return im
+	}
+	if f.ChromaFormat == Monochrome {
+		return f.Gray()
+	}
+	return f.RGBA64()
+}
 
-	im image.Image
+func clip16(v int64) int64 {
+	switch {
+	case v < 0:
+		return 0
+	case v > 0xffff:
+		return 0xffff
+	}
+	return v
 }
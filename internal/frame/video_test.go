@@ -0,0 +1,175 @@
+package frame
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// testPlanes planes of a width x height frame of chroma format format, sample values
+// derived from their location
+func testPlanes(width, height int, format ChromaFormat, bitDepth int) [3]*Plane {
+	var planes [3]*Plane
+	mask := 1<<bitDepth - 1
+	for c := range planes {
+		w, h := width, height
+		if c > 0 {
+			if format == Monochrome {
+				break
+			}
+			w, h = width/format.SubWidthC(), height/format.SubHeightC()
+		}
+		p := NewPlane(w, h)
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				p.Set(x, y, uint16((37*x+11*y+97*c)&mask))
+			}
+		}
+		planes[c] = p
+	}
+	return planes
+}
+
+func TestVideoPlane(t *testing.T) {
+	for _, bitDepth := range []int{8, 10, 14} {
+		p := NewVideoPlane(3, 2, bitDepth)
+		v := uint16(1<<bitDepth - 2)
+		p.Set(2, 1, v)
+		if got := p.At(2, 1); got != v {
+			t.Errorf("bit depth %v: At() = %v, want %v", bitDepth, got, v)
+		}
+		if want := 3 * bytesPerSample(bitDepth); p.Stride != want {
+			t.Errorf("bit depth %v: Stride %v, want %v", bitDepth, p.Stride, want)
+		}
+	}
+	p := NewVideoPlane(1, 1, 10)
+	p.Set(0, 0, 0x3a5)
+	if p.Pix[0] != 0xa5 || p.Pix[1] != 0x03 {
+		t.Errorf("Pix % x, want little-endian a5 03", p.Pix)
+	}
+}
+
+func TestYCbCr(t *testing.T) {
+	tests := []struct {
+		format ChromaFormat
+		ratio  image.YCbCrSubsampleRatio
+	}{
+		{Chroma420, image.YCbCrSubsampleRatio420},
+		{Chroma422, image.YCbCrSubsampleRatio422},
+		{Chroma444, image.YCbCrSubsampleRatio444},
+	}
+	for _, tt := range tests {
+		planes := testPlanes(16, 16, tt.format, 8)
+		f := NewVideoFrame(planes, tt.format, 8, 8)
+		f.Crop = image.Rect(2, 2, 14, 12)
+		im, ok := f.YCbCr()
+		if !ok {
+			t.Errorf("%v: YCbCr() not available", tt.format)
+			continue
+		}
+		if im.Bounds() != f.Crop || im.SubsampleRatio != tt.ratio {
+			t.Errorf("%v: YCbCr() bounds %v ratio %v", tt.format, im.Bounds(), im.SubsampleRatio)
+		}
+		subW, subH := tt.format.SubWidthC(), tt.format.SubHeightC()
+		for _, pt := range []image.Point{{2, 2}, {7, 5}, {13, 11}} {
+			got := im.YCbCrAt(pt.X, pt.Y)
+			want := color.YCbCr{
+				Y:  uint8(planes[0].At(pt.X, pt.Y)),
+				Cb: uint8(planes[1].At(pt.X/subW, pt.Y/subH)),
+				Cr: uint8(planes[2].At(pt.X/subW, pt.Y/subH)),
+			}
+			if got != want {
+				t.Errorf("%v: YCbCrAt(%v) = %v, want %v", tt.format, pt, got, want)
+			}
+		}
+		// samples shared with the frame
+		f.Planes[0].Set(7, 5, 200)
+		if got := im.YCbCrAt(7, 5).Y; got != 200 {
+			t.Errorf("%v: YCbCr() copied the samples", tt.format)
+		}
+		if _, ok := f.Image().(*image.YCbCr); !ok {
+			t.Errorf("%v: Image() %T, want *image.YCbCr", tt.format, f.Image())
+		}
+	}
+
+	f := NewVideoFrame(testPlanes(16, 16, Chroma420, 10), Chroma420, 10, 10)
+	if _, ok := f.YCbCr(); ok {
+		t.Errorf("YCbCr() available for bit depth 10")
+	}
+	if _, ok := f.Image().(*image.RGBA64); !ok {
+		t.Errorf("Image() of bit depth 10 %T, want *image.RGBA64", f.Image())
+	}
+}
+
+func TestGray(t *testing.T) {
+	f := NewVideoFrame(testPlanes(8, 8, Monochrome, 8), Monochrome, 8, 8)
+	f.Crop = image.Rect(0, 0, 6, 8)
+	im, ok := f.Image().(*image.Gray)
+	if !ok {
+		t.Fatalf("Image() of monochrome %T, want *image.Gray", f.Image())
+	}
+	f.Planes[0].Set(3, 4, 123)
+	if im.Bounds() != f.Crop || im.GrayAt(3, 4).Y != 123 {
+		t.Errorf("Gray() bounds %v, not sharing the samples", im.Bounds())
+	}
+
+	planes := testPlanes(8, 8, Monochrome, 12)
+	planes[0].Set(1, 1, 4095)
+	planes[0].Set(2, 1, 0x810)
+	f = NewVideoFrame(planes, Monochrome, 12, 12)
+	im = f.Gray()
+	if got := im.GrayAt(1, 1).Y; got != 255 {
+		t.Errorf("GrayAt() of 4095 = %v, want 255", got)
+	}
+	if got := im.GrayAt(2, 1).Y; got != 0x81 {
+		t.Errorf("GrayAt() of 0x810 = %v, want 0x81", got)
+	}
+}
+
+func TestRGBA64(t *testing.T) {
+	// 8 bits converted alike image.YCbCr
+	f := NewVideoFrame(testPlanes(16, 16, Chroma420, 8), Chroma420, 8, 8)
+	ycbcr, _ := f.YCbCr()
+	rgba := f.RGBA64()
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 16; x++ {
+			want := color.RGBA64Model.Convert(ycbcr.At(x, y)).(color.RGBA64)
+			got := rgba.RGBA64At(x, y)
+			for c, d := range [3]int{int(got.R) - int(want.R), int(got.G) - int(want.G), int(got.B) - int(want.B)} {
+				if d < -0x180 || d > 0x180 {
+					t.Fatalf("RGBA64At(%v, %v) component %v = %v, want %v", x, y, c, got, want)
+				}
+			}
+		}
+	}
+
+	// 10 bits white and mid gray
+	planes := testPlanes(2, 2, Chroma444, 10)
+	planes[0].Set(0, 0, 1023)
+	planes[0].Set(1, 0, 512)
+	for _, c := range planes[1:] {
+		c.Set(0, 0, 512)
+		c.Set(1, 0, 512)
+	}
+	rgba = NewVideoFrame(planes, Chroma444, 10, 10).RGBA64()
+	if got := rgba.RGBA64At(0, 0); got.R != 0xffff || got.G < 0xff00 || got.B < 0xff00 || got.A != 0xffff {
+		t.Errorf("RGBA64At() of white = %v", got)
+	}
+	if got := rgba.RGBA64At(1, 0); got.R>>8 != 0x80 || got.G>>8 != 0x80 || got.B>>8 != 0x80 {
+		t.Errorf("RGBA64At() of mid gray = %v", got)
+	}
+}
+
+func TestRGBA64Monochrome(t *testing.T) {
+	planes := testPlanes(4, 2, Monochrome, 8)
+	planes[0].Set(1, 0, 255)
+	// no chroma bit depth for 4:0:0
+	rgba := NewVideoFrame(planes, Monochrome, 8, 0).RGBA64()
+	if got := rgba.RGBA64At(1, 0); got != (color.RGBA64{0xffff, 0xffff, 0xffff, 0xffff}) {
+		t.Errorf("RGBA64At() of white = %v", got)
+	}
+	want := uint16(planes[0].At(0, 1)) * 0x101
+	if got := rgba.RGBA64At(0, 1); got.R != want || got.G != want || got.B != want {
+		t.Errorf("RGBA64At() = %v, want gray %v", got, want)
+	}
+}
@@ -0,0 +1,159 @@
+// Package inter predict inter coded partitions from the samples of reference pictures,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.4.2 Decoding process for Inter prediction samples
+package inter
+
+import (
+	"fmt"
+
+	"github.com/LiveStudioSolution/h264decoder/internal/frame"
+)
+
+// Ref sample planes Y, Cb and Cr of a reference frame or field, chroma planes are nil
+// for ChromaArrayType 0. Fields are views of frame planes, see frame.Plane.Field.
+type Ref struct {
+	Planes [3]*frame.Plane
+	// Bottom the reference is a bottom field
+	Bottom bool
+}
+
+// Partition a macroblock or sub-macroblock partition and its motion data
+type Partition struct {
+	// X, Y luma location ( xAL, yAL ) of the upper-left sample of the partition in the
+	// current picture, or in the field of a field macroblock of an MBAFF frame
+	X, Y int
+	// W, H partWidth and partHeight in luma samples
+	W, H     int
+	PredFlag [2]bool
+	// Mv mvL0 and mvL1 in quarter luma samples
+	Mv  [2][2]int32
+	Ref [2]*Ref
+	// Field the current picture is a field or the macroblock a field macroblock, of
+	// parity Bottom
+	Field  bool
+	Bottom bool
+	// Weighting and Weights of Y, Cb and Cr, see ExplicitWeights and ImplicitWeights
+	Weighting WeightMode
+	Weights   [3]Weights
+}
+
+// Params sequence parameters the prediction depends on
+type Params struct {
+	ChromaArrayType int
+	BitDepthY       int
+	BitDepthC       int
+}
+
+// Predict derive the prediction samples of partition p and store them at its location
+// in the planes Y, Cb and Cr of dst
+func Predict(dst [3]*frame.Plane, p *Partition, params Params) error {
+	if !p.PredFlag[0] && !p.PredFlag[1] {
+		return fmt.Errorf("partition at ( %v, %v ) without prediction list", p.X, p.Y)
+	}
+	if p.W > 16 || p.H > 16 || p.X < 0 || p.Y < 0 || p.X+p.W > dst[0].Width || p.Y+p.H > dst[0].Height {
+		return fmt.Errorf("invalid partition %vx%v at ( %v, %v )", p.W, p.H, p.X, p.Y)
+	}
+	for l := range p.Ref {
+		if p.PredFlag[l] && (p.Ref[l] == nil || p.Ref[l].Planes[0] == nil) {
+			return fmt.Errorf("missing reference picture of list %v", l)
+		}
+	}
+	numComp := 3
+	if params.ChromaArrayType == 0 {
+		numComp = 1
+	}
+	var predLX [2][256]int32
+	for c := 0; c < numComp; c++ {
+		x, y, w, h := p.X, p.Y, p.W, p.H
+		bitDepth := params.BitDepthY
+		if c > 0 {
+			bitDepth = params.BitDepthC
+			if params.ChromaArrayType != 3 {
+				subHeightC := 1
+				if params.ChromaArrayType == 1 {
+					subHeightC = 2
+				}
+				x, w = x/2, w/2
+				y, h = y/subHeightC, h/subHeightC
+			}
+		}
+		for l := range p.Ref {
+			if !p.PredFlag[l] {
+				continue
+			}
+			ref := p.Ref[l].Planes[c]
+			if ref == nil {
+				return fmt.Errorf("missing plane %v of reference picture of list %v", c, l)
+			}
+			mv := p.Mv[l]
+			if c == 0 || params.ChromaArrayType == 3 {
+				Luma(predLX[l][:], ref, x+int(mv[0]>>2), y+int(mv[1]>>2), w, h, int(mv[0]&3), int(mv[1]&3), bitDepth)
+				continue
+			}
+			mvC := ChromaMv(mv, params.ChromaArrayType, p.Field, p.Bottom, p.Ref[l].Bottom)
+			if params.ChromaArrayType == 1 {
+				Chroma(predLX[l][:], ref, x+int(mvC[0]>>3), y+int(mvC[1]>>3), w, h, int(mvC[0]&7), int(mvC[1]&7))
+			} else {
+				Chroma(predLX[l][:], ref, x+int(mvC[0]>>3), y+int(mvC[1]>>2), w, h, int(mvC[0]&7), int(mvC[1]&3)<<1)
+			}
+		}
+		pred := predLX[0][:w*h]
+		if !p.PredFlag[0] {
+			pred = predLX[1][:w*h]
+		}
+		switch {
+		case p.Weighting == WeightExplicit,
+			p.Weighting == WeightImplicit && p.PredFlag[0] && p.PredFlag[1]:
+			Weighted(pred, predLX[0][:w*h], predLX[1][:w*h], p.PredFlag, &p.Weights[c], bitDepth)
+		case p.PredFlag[0] && p.PredFlag[1]:
+			Default(pred, predLX[0][:w*h], predLX[1][:w*h])
+		}
+		pl := dst[c]
+		for j := 0; j < h; j++ {
+			row := pl.Pix[(y+j)*pl.Stride+x : (y+j)*pl.Stride+x+w]
+			for i := range row {
+				row[i] = uint16(pred[j*w+i])
+			}
+		}
+	}
+	return nil
+}
+
+// ChromaMv chroma vector mvCLX of luma vector mv, in 1 / ( 4 * SubWidthC ) chroma
+// samples horizontally and 1 / ( 4 * SubHeightC ) vertically. For 4:2:0 fields the
+// vertical component is offset between fields of different parity, Table 8-10.
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.4.1.4 Derivation process for chroma motion vectors
+func ChromaMv(mv [2]int32, chromaArrayType int, field, currBottom, refBottom bool) [2]int32 {
+	if chromaArrayType != 1 || !field || currBottom == refBottom {
+		return mv
+	}
+	if currBottom {
+		return [2]int32{mv[0], mv[1] + 2}
+	}
+	return [2]int32{mv[0], mv[1] - 2}
+}
+
+// sample of pl at ( x, y ) with the coordinates clipped to the plane, which is the
+// same as reading a reference picture padded with its edge samples
+func sample(pl *frame.Plane, x, y int) int32 {
+	if x < 0 {
+		x = 0
+	} else if x >= pl.Width {
+		x = pl.Width - 1
+	}
+	if y < 0 {
+		y = 0
+	} else if y >= pl.Height {
+		y = pl.Height - 1
+	}
+	return int32(pl.Pix[y*pl.Stride+x])
+}
+
+func clip1(v int32, bitDepth int) int32 {
+	if v < 0 {
+		return 0
+	}
+	if max := int32(1)<<uint(bitDepth) - 1; v > max {
+		return max
+	}
+	return v
+}
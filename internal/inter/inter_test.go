@@ -0,0 +1,355 @@
+package inter
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/LiveStudioSolution/h264decoder/internal/frame"
+	"github.com/LiveStudioSolution/h264decoder/internal/slice"
+)
+
+func randPlane(rnd *rand.Rand, w, h, bitDepth int) *frame.Plane {
+	pl := frame.NewPlane(w, h)
+	for i := range pl.Pix {
+		pl.Pix[i] = uint16(rnd.Intn(1 << uint(bitDepth)))
+	}
+	return pl
+}
+
+// specLuma the luma sample at ( xInt, yInt ) and ( xFrac, yFrac ) with the sample names
+// of Figure 8-4, j computed from the vertical intermediate values
+func specLuma(ref *frame.Plane, xInt, yInt, xFrac, yFrac, bitDepth int) int32 {
+	s := func(x, y int) int32 { return sample(ref, xInt+x, yInt+y) }
+	c := func(v int32) int32 { return clip1(v, bitDepth) }
+	G, H, M := s(0, 0), s(1, 0), s(0, 1)
+	b1 := tap(s(-2, 0), s(-1, 0), G, H, s(2, 0), s(3, 0))
+	h1 := tap(s(0, -2), s(0, -1), G, M, s(0, 2), s(0, 3))
+	s1 := tap(s(-2, 1), s(-1, 1), M, s(1, 1), s(2, 1), s(3, 1))
+	m1 := tap(s(1, -2), s(1, -1), H, s(1, 1), s(1, 2), s(1, 3))
+	vert := func(x int) int32 { return tap(s(x, -2), s(x, -1), s(x, 0), s(x, 1), s(x, 2), s(x, 3)) }
+	j1 := tap(vert(-2), vert(-1), h1, m1, vert(2), vert(3))
+	b, h, sv, m := c((b1+16)>>5), c((h1+16)>>5), c((s1+16)>>5), c((m1+16)>>5)
+	j := c((j1 + 512) >> 10)
+	avg := func(a, b int32) int32 { return (a + b + 1) >> 1 }
+	table := [4][4]int32{
+		{G, avg(G, h), h, avg(M, h)},
+		{avg(G, b), avg(b, h), avg(h, j), avg(h, sv)},
+		{b, avg(b, j), j, avg(j, sv)},
+		{avg(H, b), avg(b, m), avg(j, m), avg(m, sv)},
+	}
+	return table[xFrac][yFrac]
+}
+
+func TestLuma(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for _, bitDepth := range []int{8, 10} {
+		ref := randPlane(rnd, 32, 24, bitDepth)
+		for n := 0; n < 200; n++ {
+			w, h := 4<<uint(rnd.Intn(3)), 4<<uint(rnd.Intn(3))
+			// locations inside, across and far outside the picture edges
+			xInt, yInt := rnd.Intn(60)-20, rnd.Intn(50)-20
+			for frac := 0; frac < 16; frac++ {
+				xFrac, yFrac := frac>>2, frac&3
+				pred := make([]int32, w*h)
+				Luma(pred, ref, xInt, yInt, w, h, xFrac, yFrac, bitDepth)
+				for y := 0; y < h; y++ {
+					for x := 0; x < w; x++ {
+						want := specLuma(ref, xInt+x, yInt+y, xFrac, yFrac, bitDepth)
+						if pred[y*w+x] != want {
+							t.Fatalf("Luma(%v, %v, %vx%v, %v, %v)[%v, %v] = %v, want %v", xInt, yInt, w, h, xFrac, yFrac, x, y, pred[y*w+x], want)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestLumaHalfSample(t *testing.T) {
+	ref := frame.NewPlane(8, 1)
+	for i, v := range []uint16{10, 20, 30, 40, 50, 60, 70, 80} {
+		ref.Pix[i] = v
+	}
+	pred := make([]int32, 4)
+	// b between 30 and 40: ( 10 - 100 + 600 + 800 - 250 + 60 + 16 ) >> 5
+	Luma(pred, ref, 2, 0, 4, 1, 2, 0, 8)
+	if want := []int32{35, 45, 55, 65}; !reflect.DeepEqual(pred, want) {
+		t.Errorf("Luma(half sample) = %v, want %v", pred, want)
+	}
+	// single row picture, vertical filters see the row repeated
+	Luma(pred, ref, 2, 0, 4, 1, 0, 2, 8)
+	if want := []int32{30, 40, 50, 60}; !reflect.DeepEqual(pred, want) {
+		t.Errorf("Luma(vertical half sample) = %v, want %v", pred, want)
+	}
+}
+
+func TestChroma(t *testing.T) {
+	ref := frame.NewPlane(2, 2)
+	copy(ref.Pix, []uint16{0, 8, 16, 24})
+	tests := []struct {
+		xInt, yInt, xFrac, yFrac int
+		want                     int32
+	}{
+		{0, 0, 0, 0, 0},
+		{0, 0, 4, 4, 12},
+		{0, 0, 2, 0, 2},
+		{0, 0, 0, 6, 12},
+		{0, 0, 7, 7, 21},
+		// outside the picture
+		{-5, 0, 3, 0, 0},
+		{1, 1, 5, 5, 24},
+		{-1, 0, 4, 0, 0},
+		{0, 0, 4, 0, 4},
+	}
+	for _, tt := range tests {
+		pred := make([]int32, 1)
+		Chroma(pred, ref, tt.xInt, tt.yInt, 1, 1, tt.xFrac, tt.yFrac)
+		if pred[0] != tt.want {
+			t.Errorf("Chroma(%v, %v, %v, %v) = %v, want %v", tt.xInt, tt.yInt, tt.xFrac, tt.yFrac, pred[0], tt.want)
+		}
+	}
+}
+
+func TestChromaMv(t *testing.T) {
+	tests := []struct {
+		chromaArrayType              int
+		field, currBottom, refBottom bool
+		want                         int32
+	}{
+		{1, false, false, true, 5},
+		{1, true, false, false, 5},
+		{1, true, true, false, 7},
+		{1, true, false, true, 3},
+		{2, true, true, false, 5},
+	}
+	for _, tt := range tests {
+		if got := ChromaMv([2]int32{-1, 5}, tt.chromaArrayType, tt.field, tt.currBottom, tt.refBottom); got != [2]int32{-1, tt.want} {
+			t.Errorf("ChromaMv(%+v) = %v, want %v", tt, got, tt.want)
+		}
+	}
+}
+
+func TestWeighted(t *testing.T) {
+	pred0 := []int32{0, 100, 255}
+	pred1 := []int32{255, 50, 0}
+	tests := []struct {
+		name     string
+		predFlag [2]bool
+		wt       Weights
+		want     []int32
+	}{
+		{"l0 logWD 0", [2]bool{true, false}, Weights{W0: 2, O0: -10}, []int32{0, 190, 255}},
+		{"l0 rounding", [2]bool{true, false}, Weights{LogWD: 2, W0: 3, O0: 1}, []int32{1, 76, 192}},
+		{"l1", [2]bool{false, true}, Weights{LogWD: 1, W1: 1, O1: 5, W0: 100}, []int32{133, 30, 5}},
+		{"bi", [2]bool{true, true}, Weights{LogWD: 5, W0: 48, W1: 16, O0: 3, O1: 4}, []int32{68, 92, 195}},
+		{"bi negative", [2]bool{true, true}, Weights{LogWD: 0, W0: -1, W1: 2}, []int32{255, 0, 0}},
+	}
+	for _, tt := range tests {
+		pred := make([]int32, 3)
+		Weighted(pred, pred0, pred1, tt.predFlag, &tt.wt, 8)
+		if !reflect.DeepEqual(pred, tt.want) {
+			t.Errorf("Weighted(%v) = %v, want %v", tt.name, pred, tt.want)
+		}
+	}
+	pred := make([]int32, 3)
+	Default(pred, pred0, pred1)
+	if want := []int32{128, 75, 128}; !reflect.DeepEqual(pred, want) {
+		t.Errorf("Default() = %v, want %v", pred, want)
+	}
+}
+
+func TestImplicitWeights(t *testing.T) {
+	tests := []struct {
+		currPoc, poc0, poc1 int
+		longTerm            bool
+		w0, w1              int32
+	}{
+		{4, 0, 8, false, 32, 32},
+		{2, 0, 8, false, 48, 16},
+		{6, 8, 0, false, 48, 16},
+		{4, 0, 8, true, 32, 32},
+		{4, 8, 8, false, 32, 32},
+		// DistScaleFactor >> 2 out of range
+		{40, 0, 8, false, 32, 32},
+		{-12, 0, 4, false, 32, 32},
+		{-2, 0, 4, false, 96, -32},
+	}
+	for _, tt := range tests {
+		wts := ImplicitWeights(tt.currPoc, tt.poc0, tt.poc1, tt.longTerm)
+		want := Weights{LogWD: 5, W0: tt.w0, W1: tt.w1}
+		if wts != [3]Weights{want, want, want} {
+			t.Errorf("ImplicitWeights(%v, %v, %v, %v) = %+v, want %+v", tt.currPoc, tt.poc0, tt.poc1, tt.longTerm, wts[0], want)
+		}
+	}
+}
+
+func TestDistScaleFactor(t *testing.T) {
+	tests := []struct{ currPoc, poc0, poc1, want int }{
+		{4, 0, 8, 128},
+		{2, 0, 8, 64},
+		{2, 0, 6, 85},
+		{3, 3, 3, 256},
+		{400, 0, 2, 1023},
+		{-400, 0, 2, -1024},
+	}
+	for _, tt := range tests {
+		if got := DistScaleFactor(tt.currPoc, tt.poc0, tt.poc1); got != tt.want {
+			t.Errorf("DistScaleFactor(%v, %v, %v) = %v, want %v", tt.currPoc, tt.poc0, tt.poc1, got, tt.want)
+		}
+	}
+}
+
+func TestExplicitWeights(t *testing.T) {
+	pwt := &slice.PredWeightTable{
+		LumaLog2WeightDenom:   6,
+		ChromaLog2WeightDenom: 4,
+		L0: []slice.PredWeight{
+			{LumaWeight: 64, ChromaWeight: [2]int{16, 16}},
+			{LumaWeightFlag: true, LumaWeight: 70, LumaOffset: -3, ChromaWeightFlag: true, ChromaWeight: [2]int{12, 20}, ChromaOffset: [2]int{1, -2}},
+		},
+		L1: []slice.PredWeight{
+			{LumaWeightFlag: true, LumaWeight: 30, LumaOffset: 5, ChromaWeight: [2]int{16, 16}},
+		},
+	}
+	wts := ExplicitWeights(pwt, [2]int{1, 0}, 8, 10)
+	want := [3]Weights{
+		{LogWD: 6, W0: 70, O0: -3, W1: 30, O1: 5},
+		{LogWD: 4, W0: 12, O0: 4, W1: 16},
+		{LogWD: 4, W0: 20, O0: -8, W1: 16},
+	}
+	if wts != want {
+		t.Errorf("ExplicitWeights() = %+v, want %+v", wts, want)
+	}
+	wts = ExplicitWeights(pwt, [2]int{0, -1}, 8, 8)
+	if wts[0] != (Weights{LogWD: 6, W0: 64}) || wts[1].W1 != 0 {
+		t.Errorf("ExplicitWeights(l0 only) = %+v", wts)
+	}
+}
+
+func testRef(rnd *rand.Rand, w, h int) *Ref {
+	return &Ref{Planes: [3]*frame.Plane{randPlane(rnd, w, h, 8), randPlane(rnd, w/2, h/2, 8), randPlane(rnd, w/2, h/2, 8)}}
+}
+
+func TestPredict(t *testing.T) {
+	rnd := rand.New(rand.NewSource(2))
+	params := Params{ChromaArrayType: 1, BitDepthY: 8, BitDepthC: 8}
+	ref0, ref1 := testRef(rnd, 32, 32), testRef(rnd, 32, 32)
+	dst := [3]*frame.Plane{frame.NewPlane(32, 32), frame.NewPlane(16, 16), frame.NewPlane(16, 16)}
+
+	p := &Partition{X: 8, Y: 4, W: 8, H: 16, PredFlag: [2]bool{true, false}, Mv: [2][2]int32{{-8, 12}}, Ref: [2]*Ref{ref0}}
+	if err := Predict(dst, p, params); err != nil {
+		t.Fatal(err)
+	}
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 8; x++ {
+			if got, want := dst[0].At(8+x, 4+y), ref0.Planes[0].At(6+x, 7+y); got != want {
+				t.Fatalf("Predict() luma[%v, %v] = %v, want %v", x, y, got, want)
+			}
+		}
+	}
+	// chroma vector ( -8, 12 ) in eighth samples: one sample left and one and a half below
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 4; x++ {
+			a, c := int32(ref0.Planes[1].At(3+x, 3+y)), int32(ref0.Planes[1].At(3+x, 4+y))
+			if got, want := int32(dst[1].At(4+x, 2+y)), (32*a+32*c+32)>>6; got != want {
+				t.Fatalf("Predict() cb[%v, %v] = %v, want %v", x, y, got, want)
+			}
+		}
+	}
+
+	p = &Partition{X: 0, Y: 0, W: 4, H: 4, PredFlag: [2]bool{true, true}, Mv: [2][2]int32{{1, 2}, {-3, 5}}, Ref: [2]*Ref{ref0, ref1}}
+	if err := Predict(dst, p, params); err != nil {
+		t.Fatal(err)
+	}
+	pred0, pred1 := make([]int32, 16), make([]int32, 16)
+	Luma(pred0, ref0.Planes[0], 0, 0, 4, 4, 1, 2, 8)
+	Luma(pred1, ref1.Planes[0], -1, 1, 4, 4, 1, 1, 8)
+	for i := range pred0 {
+		if got, want := int32(dst[0].At(i%4, i/4)), (pred0[i]+pred1[i]+1)>>1; got != want {
+			t.Fatalf("Predict(bi) luma[%v] = %v, want %v", i, got, want)
+		}
+	}
+
+	// implicit weights only apply to bi-prediction
+	p = &Partition{X: 16, Y: 16, W: 16, H: 16, PredFlag: [2]bool{false, true}, Ref: [2]*Ref{nil, ref1},
+		Weighting: WeightImplicit, Weights: ImplicitWeights(2, 0, 8, false)}
+	if err := Predict(dst, p, params); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := dst[2].At(10, 13), ref1.Planes[2].At(10, 13); got != want {
+		t.Errorf("Predict(implicit l1) cr = %v, want %v", got, want)
+	}
+	p.Weighting = WeightExplicit
+	p.Weights = [3]Weights{{W1: 1, O1: 255}, {W1: 0}, {W1: 0}}
+	if err := Predict(dst, p, params); err != nil {
+		t.Fatal(err)
+	}
+	if dst[0].At(20, 20) != 255 || dst[1].At(9, 9) != 0 {
+		t.Errorf("Predict(explicit l1) = %v %v, want 255 0", dst[0].At(20, 20), dst[1].At(9, 9))
+	}
+}
+
+func TestPredictField(t *testing.T) {
+	rnd := rand.New(rand.NewSource(3))
+	params := Params{ChromaArrayType: 1, BitDepthY: 8, BitDepthC: 8}
+	frm := testRef(rnd, 16, 32)
+	top := &Ref{Planes: [3]*frame.Plane{frm.Planes[0].Field(false), frm.Planes[1].Field(false), frm.Planes[2].Field(false)}}
+	dst := [3]*frame.Plane{frame.NewPlane(16, 32), frame.NewPlane(8, 16), frame.NewPlane(8, 16)}
+	field := [3]*frame.Plane{dst[0].Field(true), dst[1].Field(true), dst[2].Field(true)}
+	// bottom field predicted from the top field, chroma vector offset by 2
+	p := &Partition{W: 16, H: 16, PredFlag: [2]bool{true, false}, Mv: [2][2]int32{{0, 4}}, Ref: [2]*Ref{top}, Field: true, Bottom: true}
+	if err := Predict(field, p, params); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := dst[0].At(3, 1), frm.Planes[0].At(3, 2); got != want {
+		t.Errorf("Predict(field) luma = %v, want %v", got, want)
+	}
+	a, c := int32(frm.Planes[1].At(5, 0)), int32(frm.Planes[1].At(5, 2))
+	if got, want := int32(dst[1].At(5, 1)), (16*a+48*c+32)>>6; got != want {
+		t.Errorf("Predict(field) cb = %v, want %v", got, want)
+	}
+}
+
+func TestPredictErrors(t *testing.T) {
+	rnd := rand.New(rand.NewSource(4))
+	params := Params{ChromaArrayType: 1, BitDepthY: 8, BitDepthC: 8}
+	dst := [3]*frame.Plane{frame.NewPlane(16, 16), frame.NewPlane(8, 8), frame.NewPlane(8, 8)}
+	ref := testRef(rnd, 16, 16)
+	tests := []*Partition{
+		{W: 16, H: 16},
+		{W: 16, H: 16, PredFlag: [2]bool{false, true}, Ref: [2]*Ref{ref}},
+		{X: 8, W: 16, H: 16, PredFlag: [2]bool{true, false}, Ref: [2]*Ref{ref}},
+		{W: 16, H: 16, PredFlag: [2]bool{true, false}, Ref: [2]*Ref{{Planes: [3]*frame.Plane{ref.Planes[0]}}}},
+	}
+	for i, p := range tests {
+		if err := Predict(dst, p, params); err == nil {
+			t.Errorf("Predict(%v) succeeded", i)
+		}
+	}
+	// monochrome pictures have no chroma planes
+	p := &Partition{W: 16, H: 16, PredFlag: [2]bool{true, false}, Ref: [2]*Ref{{Planes: [3]*frame.Plane{ref.Planes[0]}}}}
+	if err := Predict([3]*frame.Plane{dst[0]}, p, Params{BitDepthY: 8}); err != nil {
+		t.Errorf("Predict(monochrome) = %v", err)
+	}
+}
+
+func BenchmarkLuma(b *testing.B) {
+	rnd := rand.New(rand.NewSource(5))
+	ref := randPlane(rnd, 64, 64, 8)
+	pred := make([]int32, 256)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Luma(pred, ref, 20, 20, 16, 16, i&3, i>>2&3, 8)
+	}
+}
+
+func BenchmarkChroma(b *testing.B) {
+	rnd := rand.New(rand.NewSource(6))
+	ref := randPlane(rnd, 32, 32, 8)
+	pred := make([]int32, 64)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Chroma(pred, ref, 10, 10, 8, 8, i&7, i>>3&7)
+	}
+}
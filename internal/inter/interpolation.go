@@ -0,0 +1,104 @@
+package inter
+
+import "github.com/LiveStudioSolution/h264decoder/internal/frame"
+
+// tap 6-tap filter ( 1, -5, 20, 20, -5, 1 ) of the half sample positions
+func tap(e, f, g, h, i, j int32) int32 {
+	return e - 5*f + 20*g + 20*h - 5*i + j
+}
+
+// Luma interpolate the w x h luma samples of ref at full sample location ( xInt, yInt )
+// and quarter sample offset ( xFrac, yFrac ) into pred in raster order. Samples outside
+// the picture are those of its nearest edge.
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.4.2.2.1 Luma sample interpolation process
+func Luma(pred []int32, ref *frame.Plane, xInt, yInt, w, h, xFrac, yFrac, bitDepth int) {
+	// window of the reference samples from ( xInt - 2, yInt - 2 ) used by the filters
+	const maxW = 16 + 5
+	ww, wh := w+5, h+5
+	var win [maxW * maxW]int32
+	for j := 0; j < wh; j++ {
+		for i := 0; i < ww; i++ {
+			win[j*ww+i] = sample(ref, xInt-2+i, yInt-2+j)
+		}
+	}
+	if xFrac == 0 && yFrac == 0 {
+		for y := 0; y < h; y++ {
+			copy(pred[y*w:y*w+w], win[(y+2)*ww+2:])
+		}
+		return
+	}
+	// at reports the full sample G at ( x, y ) of the window origin
+	at := func(x, y int) int32 { return win[(y+2)*ww+x+2] }
+	// b1 and h1 the intermediate values of the half samples right of and below ( x, y )
+	b1 := func(x, y int) int32 {
+		r := win[(y+2)*ww+x:]
+		return tap(r[0], r[1], r[2], r[3], r[4], r[5])
+	}
+	h1 := func(x, y int) int32 {
+		c := win[y*ww+x+2:]
+		return tap(c[0], c[ww], c[2*ww], c[3*ww], c[4*ww], c[5*ww])
+	}
+	b := func(x, y int) int32 { return clip1((b1(x, y)+16)>>5, bitDepth) }
+	hh := func(x, y int) int32 { return clip1((h1(x, y)+16)>>5, bitDepth) }
+	j := func(x, y int) int32 {
+		j1 := tap(b1(x, y-2), b1(x, y-1), b1(x, y), b1(x, y+1), b1(x, y+2), b1(x, y+3))
+		return clip1((j1+512)>>10, bitDepth)
+	}
+	avg := func(a, b int32) int32 { return (a + b + 1) >> 1 }
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var v int32
+			// Table 8-12, s is b of the row below and m is h of the column right
+			switch xFrac<<2 | yFrac {
+			case 0<<2 | 1:
+				v = avg(at(x, y), hh(x, y))
+			case 0<<2 | 2:
+				v = hh(x, y)
+			case 0<<2 | 3:
+				v = avg(at(x, y+1), hh(x, y))
+			case 1<<2 | 0:
+				v = avg(at(x, y), b(x, y))
+			case 1<<2 | 1:
+				v = avg(b(x, y), hh(x, y))
+			case 1<<2 | 2:
+				v = avg(hh(x, y), j(x, y))
+			case 1<<2 | 3:
+				v = avg(hh(x, y), b(x, y+1))
+			case 2<<2 | 0:
+				v = b(x, y)
+			case 2<<2 | 1:
+				v = avg(b(x, y), j(x, y))
+			case 2<<2 | 2:
+				v = j(x, y)
+			case 2<<2 | 3:
+				v = avg(j(x, y), b(x, y+1))
+			case 3<<2 | 0:
+				v = avg(at(x+1, y), b(x, y))
+			case 3<<2 | 1:
+				v = avg(b(x, y), hh(x+1, y))
+			case 3<<2 | 2:
+				v = avg(j(x, y), hh(x+1, y))
+			case 3<<2 | 3:
+				v = avg(hh(x+1, y), b(x, y+1))
+			}
+			pred[y*w+x] = v
+		}
+	}
+}
+
+// Chroma interpolate the w x h chroma samples of ref at full sample location
+// ( xInt, yInt ) and eighth sample offset ( xFrac, yFrac ) into pred in raster order,
+// for ChromaArrayType 1 and 2. Samples outside the picture are those of its nearest edge.
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.4.2.2.2 Chroma sample interpolation process
+func Chroma(pred []int32, ref *frame.Plane, xInt, yInt, w, h, xFrac, yFrac int) {
+	xF, yF := int32(xFrac), int32(yFrac)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			a := sample(ref, xInt+x, yInt+y)
+			b := sample(ref, xInt+x+1, yInt+y)
+			c := sample(ref, xInt+x, yInt+y+1)
+			d := sample(ref, xInt+x+1, yInt+y+1)
+			pred[y*w+x] = ((8-xF)*(8-yF)*a + xF*(8-yF)*b + (8-xF)*yF*c + xF*yF*d + 32) >> 6
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package inter
+
+import "github.com/LiveStudioSolution/h264decoder/internal/slice"
+
+// WeightMode weighted sample prediction of a partition, selected by weighted_pred_flag
+// in P and SP slices and weighted_bipred_idc in B slices
+type WeightMode uint8
+
+const (
+	WeightDefault WeightMode = iota
+	WeightExplicit
+	// WeightImplicit applies to bi-predicted partitions, the others use the default
+	WeightImplicit
+)
+
+// Weights variables logWD, w0, w1, o0 and o1 of the weighted prediction of one colour
+// component, the offsets already scaled to the bit depth
+type Weights struct {
+	LogWD  int
+	W0, W1 int32
+	O0, O1 int32
+}
+
+// Default average the predictions of both lists into pred,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.4.2.3.1 Default weighted sample prediction process
+func Default(pred, pred0, pred1 []int32) {
+	for i := range pred {
+		pred[i] = (pred0[i] + pred1[i] + 1) >> 1
+	}
+}
+
+// Weighted weight the predictions of the lists of predFlag into pred,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.4.2.3.2 Weighted sample prediction process
+func Weighted(pred, pred0, pred1 []int32, predFlag [2]bool, wt *Weights, bitDepth int) {
+	logWD := uint(wt.LogWD)
+	if predFlag[0] && predFlag[1] {
+		o := (wt.O0 + wt.O1 + 1) >> 1
+		round := int32(1) << logWD
+		for i := range pred {
+			pred[i] = clip1((pred0[i]*wt.W0+pred1[i]*wt.W1+round)>>(logWD+1)+o, bitDepth)
+		}
+		return
+	}
+	src, w, o := pred0, wt.W0, wt.O0
+	if predFlag[1] {
+		src, w, o = pred1, wt.W1, wt.O1
+	}
+	if logWD >= 1 {
+		round := int32(1) << (logWD - 1)
+		for i := range pred {
+			pred[i] = clip1((src[i]*w+round)>>logWD+o, bitDepth)
+		}
+		return
+	}
+	for i := range pred {
+		pred[i] = clip1(src[i]*w+o, bitDepth)
+	}
+}
+
+// ExplicitWeights weights of Y, Cb and Cr from the pred_weight_table of the slice for
+// the reference indices refIdxL0WP and refIdxL1WP, being refIdxLX >> 1 for field
+// macroblocks of MBAFF frames, a negative index for a list not used,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.4.3 Derivation process for prediction weights
+func ExplicitWeights(pwt *slice.PredWeightTable, refIdxWP [2]int, bitDepthY, bitDepthC int) [3]Weights {
+	var wts [3]Weights
+	wts[0].LogWD = int(pwt.LumaLog2WeightDenom)
+	wts[1].LogWD = int(pwt.ChromaLog2WeightDenom)
+	wts[2].LogWD = int(pwt.ChromaLog2WeightDenom)
+	for l, table := range [2][]slice.PredWeight{pwt.L0, pwt.L1} {
+		idx := refIdxWP[l]
+		if idx < 0 || idx >= len(table) {
+			continue
+		}
+		pw := &table[idx]
+		w := [3]int32{int32(pw.LumaWeight), int32(pw.ChromaWeight[0]), int32(pw.ChromaWeight[1])}
+		o := [3]int32{
+			int32(pw.LumaOffset) << uint(bitDepthY-8),
+			int32(pw.ChromaOffset[0]) << uint(bitDepthC-8),
+			int32(pw.ChromaOffset[1]) << uint(bitDepthC-8),
+		}
+		for c := range wts {
+			if l == 0 {
+				wts[c].W0, wts[c].O0 = w[c], o[c]
+			} else {
+				wts[c].W1, wts[c].O1 = w[c], o[c]
+			}
+		}
+	}
+	return wts
+}
+
+// ImplicitWeights weights of bi-predicted partitions of B slices with
+// weighted_bipred_idc 2 from the picture order counts of currPicOrField, pic0 and
+// pic1, the same for all colour components. longTerm reports that pic0 or pic1 is a
+// long-term reference,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.4.3 Derivation process for prediction weights
+func ImplicitWeights(currPoc, poc0, poc1 int, longTerm bool) [3]Weights {
+	w0, w1 := int32(32), int32(32)
+	if poc1 != poc0 && !longTerm {
+		dsf := int32(DistScaleFactor(currPoc, poc0, poc1) >> 2)
+		if dsf >= -64 && dsf <= 128 {
+			w0, w1 = 64-dsf, dsf
+		}
+	}
+	wt := Weights{LogWD: 5, W0: w0, W1: w1}
+	return [3]Weights{wt, wt, wt}
+}
+
+// DistScaleFactor scale of temporal distances from the picture order counts of
+// currPicOrField, pic0 and pic1, 8.4.1.2.3. With equal counts of pic0 and pic1 it is 256,
+// the scale of a vector unchanged.
+func DistScaleFactor(currPoc, poc0, poc1 int) int {
+	td := clip3(-128, 127, poc1-poc0)
+	if td == 0 {
+		return 256
+	}
+	tb := clip3(-128, 127, currPoc-poc0)
+	tx := (16384 + abs(td/2)) / td
+	return clip3(-1024, 1023, (tb*tx+32)>>6)
+}
+
+func clip3(x, y, z int) int {
+	if z < x {
+		return x
+	}
+	if z > y {
+		return y
+	}
+	return z
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
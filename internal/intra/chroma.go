@@ -0,0 +1,90 @@
+package intra
+
+import (
+	"fmt"
+
+	"github.com/LiveStudioSolution/h264decoder/internal/frame"
+)
+
+// ChromaMode intra_chroma_pred_mode, Table 8-5
+type ChromaMode uint8
+
+const (
+	ChromaDC ChromaMode = iota
+	ChromaHorizontal
+	ChromaVertical
+	ChromaPlane
+)
+
+func (m ChromaMode) String() string {
+	if m <= ChromaPlane {
+		return [...]string{"DC", "Horizontal", "Vertical", "Plane"}[m]
+	}
+	return fmt.Sprintf("ChromaMode:%d", m)
+}
+
+// Chroma predict the w x h chroma block of a macroblock at ( x, y ) of the Cb or Cr
+// plane pl in mode, w and h being MbWidthC and MbHeightC of ChromaArrayType 1 or 2.
+// With ChromaArrayType 3 chroma is predicted as luma.
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.3.4 Intra prediction process for chroma samples
+func Chroma(pl *frame.Plane, x, y, w, h int, mode ChromaMode, avail Avail, bitDepth int) error {
+	if w != 8 || h != 8 && h != 16 {
+		return fmt.Errorf("invalid chroma block %vx%v", w, h)
+	}
+	r := loadRefs(pl, x, y, w, h, false, avail)
+	var pred [128]int32
+	switch mode {
+	case ChromaDC:
+		for yO := 0; yO < h; yO += 4 {
+			for xO := 0; xO < w; xO += 4 {
+				dc := chromaDC(r, xO, yO, bitDepth)
+				for j := yO; j < yO+4; j++ {
+					for i := xO; i < xO+4; i++ {
+						pred[j*w+i] = dc
+					}
+				}
+			}
+		}
+	case ChromaHorizontal:
+		if err := r.require(mode, true, false, false); err != nil {
+			return err
+		}
+		for i := range pred[:w*h] {
+			pred[i] = r.left[i/w]
+		}
+	case ChromaVertical:
+		if err := r.require(mode, false, true, false); err != nil {
+			return err
+		}
+		for i := range pred[:w*h] {
+			pred[i] = r.top[i%w]
+		}
+	case ChromaPlane:
+		if err := r.require(mode, true, true, true); err != nil {
+			return err
+		}
+		predPlane(pred[:], r, w, h, bitDepth)
+	default:
+		return fmt.Errorf("invalid intra_chroma_pred_mode %v", mode)
+	}
+	store(pl, x, y, w, h, pred[:])
+	return nil
+}
+
+// chromaDC DC prediction of the 4x4 chroma block at ( xO, yO ), 8.3.4.1 to 8.3.4.3:
+// blocks on the top row prefer the samples above and those of the left column the
+// samples on the left, the others use both
+func chromaDC(r *refs, xO, yO, bitDepth int) int32 {
+	left, top := r.avail.Left, r.avail.Top
+	switch {
+	case xO > 0 && yO == 0:
+		if top {
+			left = false
+		}
+	case xO == 0 && yO > 0:
+		if left {
+			top = false
+		}
+	}
+	return predDC(r, xO, yO, 4, 4, left, top, bitDepth)
+}
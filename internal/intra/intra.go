@@ -0,0 +1,132 @@
+// Package intra predict blocks of a picture plane from the neighbouring samples
+// already constructed, T-REC-H.264-201402-S!!PDF-E.pdf 8.3 Intra prediction process
+package intra
+
+import (
+	"fmt"
+
+	"github.com/LiveStudioSolution/h264decoder/internal/frame"
+)
+
+// Avail availability of the neighbouring samples of a block for intra prediction:
+// left (A), above (B), above-right (C) and above-left (D). A neighbour is not available
+// outside the picture or the slice, not yet decoded, or inter coded with
+// constrained_intra_pred_flag, see Available.
+type Avail struct {
+	Left     bool
+	Top      bool
+	TopRight bool
+	TopLeft  bool
+}
+
+// Available report whether the samples of neighbouring macroblock mbAddrN can be used
+// for intra prediction of the current macroblock, 8.3.1.2, 8.3.2.2, 8.3.3 and 8.3.4.
+// available is the macroblock address availability of 6.4.8.
+func Available(available, interN, siN, currSI, constrainedIntraPred bool) bool {
+	if !available {
+		return false
+	}
+	if constrainedIntraPred && (interN || siN && !currSI) {
+		return false
+	}
+	return true
+}
+
+// DerivePredMode Intra4x4PredMode or Intra8x8PredMode of a block from the modes of
+// its neighbouring blocks A and B, 8.3.1.1 and 8.3.2.1. A negative mode of a neighbour
+// sets dcPredModePredictedFlag, the caller passes DC for neighbours not coded in
+// Intra_4x4 or Intra_8x8 prediction mode.
+func DerivePredMode(modeA, modeB int, prevIntraPredModeFlag bool, remIntraPredMode uint8) Mode {
+	predMode := DC
+	if modeA >= 0 && modeB >= 0 {
+		predMode = Mode(modeA)
+		if modeB < modeA {
+			predMode = Mode(modeB)
+		}
+	}
+	if prevIntraPredModeFlag {
+		return predMode
+	}
+	if Mode(remIntraPredMode) < predMode {
+		return Mode(remIntraPredMode)
+	}
+	return Mode(remIntraPredMode) + 1
+}
+
+// refs neighbouring samples p[ x, -1 ], p[ -1, y ] and p[ -1, -1 ] of a block
+type refs struct {
+	top     [16]int32
+	left    [16]int32
+	topLeft int32
+	avail   Avail
+}
+
+// loadRefs read the available neighbouring samples of the w x h block at ( x, y ).
+// With topRight the w samples above-right are read too, substituted with p[ w-1, -1 ]
+// when not available, 8.3.1.2 and 8.3.2.2.
+func loadRefs(pl *frame.Plane, x, y, w, h int, topRight bool, avail Avail) *refs {
+	r := &refs{avail: avail}
+	if avail.Top {
+		row := pl.Pix[(y-1)*pl.Stride+x:]
+		n := w
+		if topRight && avail.TopRight {
+			n = 2 * w
+		}
+		for i := 0; i < n; i++ {
+			r.top[i] = int32(row[i])
+		}
+		if topRight {
+			for i := n; i < 2*w; i++ {
+				r.top[i] = r.top[w-1]
+			}
+		}
+	}
+	if avail.Left {
+		for i := 0; i < h; i++ {
+			r.left[i] = int32(pl.Pix[(y+i)*pl.Stride+x-1])
+		}
+	}
+	if avail.TopLeft {
+		r.topLeft = int32(pl.Pix[(y-1)*pl.Stride+x-1])
+	}
+	return r
+}
+
+// p neighbouring sample p[ x, y ] with x or y equal to -1
+func (r *refs) p(x, y int) int32 {
+	if y >= 0 {
+		return r.left[y]
+	}
+	if x >= 0 {
+		return r.top[x]
+	}
+	return r.topLeft
+}
+
+// require check the neighbours a prediction mode depends on are available
+func (r *refs) require(mode fmt.Stringer, left, top, topLeft bool) error {
+	if left && !r.avail.Left || top && !r.avail.Top || topLeft && !r.avail.TopLeft {
+		return fmt.Errorf("intra prediction mode %v with neighbouring samples not available", mode)
+	}
+	return nil
+}
+
+// store write predicted block pred of width w in the plane at ( x, y )
+func store(pl *frame.Plane, x, y, w, h int, pred []int32) {
+	for j := 0; j < h; j++ {
+		row := pl.Pix[(y+j)*pl.Stride+x:]
+		for i := 0; i < w; i++ {
+			row[i] = uint16(pred[j*w+i])
+		}
+	}
+}
+
+func clip1(v int32, bitDepth int) int32 {
+	if v < 0 {
+		return 0
+	}
+	if max := int32(1)<<uint(bitDepth) - 1; v > max {
+		return max
+	}
+	return v
+}
@@ -0,0 +1,286 @@
+package intra
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/LiveStudioSolution/h264decoder/internal/frame"
+)
+
+var (
+	testTop     = []uint16{10, 20, 30, 40, 50, 60, 70, 80, 90, 100, 110, 120, 130, 140, 150, 160}
+	testLeft    = []uint16{15, 25, 35, 45, 55, 65, 75, 85, 95, 105, 115, 125, 135, 145, 155, 165}
+	testTopLeft = uint16(5)
+)
+
+// testPlane plane with the block at ( 16, 16 ) surrounded by testTop, testLeft and
+// testTopLeft, and 1 in the samples above-right beyond testTop
+func testPlane() *frame.Plane {
+	pl := frame.NewPlane(48, 48)
+	for i := range pl.Pix {
+		pl.Pix[i] = 1
+	}
+	copy(pl.Pix[15*pl.Stride+16:], testTop)
+	for y, v := range testLeft {
+		pl.Set(15, 16+y, v)
+	}
+	pl.Set(15, 15, testTopLeft)
+	return pl
+}
+
+func block(pl *frame.Plane, x, y, w, h int) []int32 {
+	b := make([]int32, 0, w*h)
+	for j := y; j < y+h; j++ {
+		for i := x; i < x+w; i++ {
+			b = append(b, int32(pl.At(i, j)))
+		}
+	}
+	return b
+}
+
+func fill(n int, v int32) []int32 {
+	b := make([]int32, n)
+	for i := range b {
+		b[i] = v
+	}
+	return b
+}
+
+func equal(want []int32) func(got []int32) bool {
+	return func(got []int32) bool {
+		return reflect.DeepEqual(got, want)
+	}
+}
+
+var availAll = Avail{Left: true, Top: true, TopRight: true, TopLeft: true}
+
+func TestIntra4x4(t *testing.T) {
+	tests := []struct {
+		mode  Mode
+		avail Avail
+		want  []int32
+	}{
+		{Vertical, availAll, []int32{10, 20, 30, 40, 10, 20, 30, 40, 10, 20, 30, 40, 10, 20, 30, 40}},
+		{Horizontal, availAll, []int32{15, 15, 15, 15, 25, 25, 25, 25, 35, 35, 35, 35, 45, 45, 45, 45}},
+		{DC, availAll, fill(16, 28)},
+		{DC, Avail{Left: true}, fill(16, 30)},
+		{DC, Avail{Top: true}, fill(16, 25)},
+		{DC, Avail{}, fill(16, 128)},
+		{DiagonalDownLeft, availAll, []int32{20, 30, 40, 50, 30, 40, 50, 60, 40, 50, 60, 70, 50, 60, 70, 78}},
+		// p[ x, -1 ] x = 4..7 substituted by p[ 3, -1 ]
+		{DiagonalDownLeft, Avail{Top: true}, []int32{20, 30, 38, 40, 30, 38, 40, 40, 38, 40, 40, 40, 40, 40, 40, 40}},
+		{DiagonalDownRight, availAll, []int32{9, 11, 20, 30, 15, 9, 11, 20, 25, 15, 9, 11, 35, 25, 15, 9}},
+		{VerticalRight, availAll, []int32{8, 15, 25, 35, 9, 11, 20, 30, 15, 8, 15, 25, 25, 9, 11, 20}},
+		{HorizontalDown, availAll, []int32{10, 9, 11, 20, 20, 15, 10, 9, 30, 25, 20, 15, 40, 35, 30, 25}},
+		{VerticalLeft, availAll, []int32{15, 25, 35, 45, 20, 30, 40, 50, 25, 35, 45, 55, 30, 40, 50, 60}},
+		{HorizontalUp, availAll, []int32{20, 25, 30, 35, 30, 35, 40, 43, 40, 43, 45, 45, 45, 45, 45, 45}},
+	}
+	for _, tt := range tests {
+		pl := testPlane()
+		if err := Intra4x4(pl, 16, 16, tt.mode, tt.avail, 8); err != nil {
+			t.Errorf("Intra4x4(%v, %+v) error = %v", tt.mode, tt.avail, err)
+			continue
+		}
+		if got := block(pl, 16, 16, 4, 4); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Intra4x4(%v, %+v) = %v, want %v", tt.mode, tt.avail, got, tt.want)
+		}
+	}
+}
+
+func TestIntra4x4Unavailable(t *testing.T) {
+	tests := []struct {
+		mode  Mode
+		avail Avail
+	}{
+		{Vertical, Avail{Left: true, TopLeft: true}},
+		{Horizontal, Avail{Top: true, TopRight: true}},
+		{DiagonalDownRight, Avail{Left: true, Top: true}},
+		{HorizontalUp, Avail{Top: true}},
+		{Mode(9), availAll},
+	}
+	for _, tt := range tests {
+		if err := Intra4x4(testPlane(), 16, 16, tt.mode, tt.avail, 8); err == nil {
+			t.Errorf("Intra4x4(%v, %+v) error = nil, want error", tt.mode, tt.avail)
+		}
+	}
+}
+
+func TestIntra8x8(t *testing.T) {
+	ddr := []int32{
+		11, 13, 20, 30, 40, 50, 60, 70,
+		16, 11, 13, 20, 30, 40, 50, 60,
+		25, 16, 11, 13, 20, 30, 40, 50,
+		35, 25, 16, 11, 13, 20, 30, 40,
+		45, 35, 25, 16, 11, 13, 20, 30,
+		55, 45, 35, 25, 16, 11, 13, 20,
+		65, 55, 45, 35, 25, 16, 11, 13,
+		75, 65, 55, 45, 35, 25, 16, 11,
+	}
+	vertical := make([]int32, 0, 64)
+	for y := 0; y < 8; y++ {
+		vertical = append(vertical, 11, 20, 30, 40, 50, 60, 70, 80)
+	}
+	// no p[ -1, -1 ]: p'[ 0, -1 ] = ( 3 * p[ 0, -1 ] + p[ 1, -1 ] + 2 ) >> 2
+	verticalNoD := append([]int32(nil), vertical...)
+	for y := 0; y < 8; y++ {
+		verticalNoD[8*y] = 13
+	}
+	tests := []struct {
+		mode  Mode
+		avail Avail
+		want  []int32
+	}{
+		{Vertical, availAll, vertical},
+		{Vertical, Avail{Top: true, TopRight: true, Left: true}, verticalNoD},
+		{DC, availAll, fill(64, 47)},
+		{DiagonalDownRight, availAll, ddr},
+	}
+	for _, tt := range tests {
+		pl := testPlane()
+		if err := Intra8x8(pl, 16, 16, tt.mode, tt.avail, 8); err != nil {
+			t.Errorf("Intra8x8(%v, %+v) error = %v", tt.mode, tt.avail, err)
+			continue
+		}
+		if got := block(pl, 16, 16, 8, 8); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Intra8x8(%v, %+v) = %v, want %v", tt.mode, tt.avail, got, tt.want)
+		}
+	}
+}
+
+func TestRefsFilter(t *testing.T) {
+	r := loadRefs(testPlane(), 16, 16, 8, 8, true, availAll)
+	r.filter()
+	wantTop := [16]int32{11, 20, 30, 40, 50, 60, 70, 80, 90, 100, 110, 120, 130, 140, 150, 158}
+	wantLeft := [8]int32{15, 25, 35, 45, 55, 65, 75, 83}
+	if r.top != wantTop || r.topLeft != 9 {
+		t.Errorf("filter() top = %v, topLeft = %v, want %v, 9", r.top, r.topLeft, wantTop)
+	}
+	var left [8]int32
+	copy(left[:], r.left[:8])
+	if left != wantLeft {
+		t.Errorf("filter() left = %v, want %v", left, wantLeft)
+	}
+}
+
+func TestIntra16x16(t *testing.T) {
+	pl := testPlane()
+	if err := Intra16x16(pl, 16, 16, Plane16x16, availAll, 8); err != nil {
+		t.Fatalf("Intra16x16() error = %v", err)
+	}
+	got := block(pl, 16, 16, 16, 16)
+	wantRow0 := []int32{24, 33, 43, 53, 63, 73, 83, 93, 103, 112, 122, 132, 142, 152, 162, 172}
+	wantRow15 := []int32{173, 183, 193, 203, 213, 223, 232, 242, 252, 255, 255, 255, 255, 255, 255, 255}
+	if !reflect.DeepEqual(got[:16], wantRow0) || !reflect.DeepEqual(got[240:], wantRow15) {
+		t.Errorf("Intra16x16(Plane) rows 0 and 15 = %v %v, want %v %v", got[:16], got[240:], wantRow0, wantRow15)
+	}
+
+	pl = testPlane()
+	if err := Intra16x16(pl, 16, 16, DC16x16, Avail{Top: true}, 8); err != nil {
+		t.Fatalf("Intra16x16() error = %v", err)
+	}
+	if got := block(pl, 16, 16, 16, 16); !reflect.DeepEqual(got, fill(256, 85)) {
+		t.Errorf("Intra16x16(DC) = %v, want 85", got)
+	}
+	if err := Intra16x16(testPlane(), 16, 16, Plane16x16, Avail{Top: true, Left: true}, 8); err == nil {
+		t.Errorf("Intra16x16(Plane) without p[ -1, -1 ] error = nil")
+	}
+}
+
+func TestChroma(t *testing.T) {
+	// 4:2:0 DC: blocks 0 and 3 use both sides, block 1 the above samples, block 2 the left ones
+	dc420 := []int32{
+		28, 28, 28, 28, 65, 65, 65, 65,
+		28, 28, 28, 28, 65, 65, 65, 65,
+		28, 28, 28, 28, 65, 65, 65, 65,
+		28, 28, 28, 28, 65, 65, 65, 65,
+		70, 70, 70, 70, 68, 68, 68, 68,
+		70, 70, 70, 70, 68, 68, 68, 68,
+		70, 70, 70, 70, 68, 68, 68, 68,
+		70, 70, 70, 70, 68, 68, 68, 68,
+	}
+	// only the above samples: the left column blocks fall back to them
+	dc420Top := []int32{
+		25, 25, 25, 25, 65, 65, 65, 65,
+		25, 25, 25, 25, 65, 65, 65, 65,
+		25, 25, 25, 25, 65, 65, 65, 65,
+		25, 25, 25, 25, 65, 65, 65, 65,
+		25, 25, 25, 25, 65, 65, 65, 65,
+		25, 25, 25, 25, 65, 65, 65, 65,
+		25, 25, 25, 25, 65, 65, 65, 65,
+		25, 25, 25, 25, 65, 65, 65, 65,
+	}
+	tests := []struct {
+		name     string
+		h        int
+		mode     ChromaMode
+		avail    Avail
+		bitDepth int
+		check    func(got []int32) bool
+	}{
+		{"dc 4:2:0", 8, ChromaDC, availAll, 8, equal(dc420)},
+		{"dc 4:2:0 above", 8, ChromaDC, Avail{Top: true}, 8, equal(dc420Top)},
+		{"dc 4:2:2 none", 16, ChromaDC, Avail{}, 10, equal(fill(128, 512))},
+		{"plane 4:2:2 column 0", 16, ChromaPlane, availAll, 8, func(got []int32) bool {
+			var col []int32
+			for y := 0; y < 16; y++ {
+				col = append(col, got[8*y])
+			}
+			return reflect.DeepEqual(col, []int32{24, 34, 44, 54, 64, 74, 84, 94, 104, 114, 124, 134, 143, 153, 163, 173})
+		}},
+		{"plane 4:2:2 row 0", 16, ChromaPlane, availAll, 8, func(got []int32) bool {
+			return reflect.DeepEqual(got[:8], []int32{24, 33, 43, 53, 62, 72, 82, 91})
+		}},
+	}
+	for _, tt := range tests {
+		pl := testPlane()
+		if err := Chroma(pl, 16, 16, 8, tt.h, tt.mode, tt.avail, tt.bitDepth); err != nil {
+			t.Errorf("%v: Chroma() error = %v", tt.name, err)
+			continue
+		}
+		got := block(pl, 16, 16, 8, tt.h)
+		if !tt.check(got) {
+			t.Errorf("%v: Chroma() = %v", tt.name, got)
+		}
+	}
+}
+
+func TestAvailable(t *testing.T) {
+	tests := []struct {
+		available, interN, siN, currSI, constrained bool
+		want                                        bool
+	}{
+		{false, false, false, false, false, false},
+		{true, true, false, false, false, true},
+		{true, true, false, false, true, false},
+		{true, false, true, false, true, false},
+		{true, false, true, true, true, true},
+		{true, false, true, false, false, true},
+	}
+	for _, tt := range tests {
+		if got := Available(tt.available, tt.interN, tt.siN, tt.currSI, tt.constrained); got != tt.want {
+			t.Errorf("Available(%+v) = %v, want %v", tt, got, tt.want)
+		}
+	}
+}
+
+func TestDerivePredMode(t *testing.T) {
+	tests := []struct {
+		modeA, modeB int
+		prevFlag     bool
+		rem          uint8
+		want         Mode
+	}{
+		{-1, 3, true, 0, DC},
+		{5, 3, true, 0, DiagonalDownLeft},
+		{5, 3, false, 2, DC},
+		{5, 3, false, 3, DiagonalDownRight},
+		{8, 8, false, 7, VerticalLeft},
+		{7, 8, false, 7, HorizontalUp},
+		{2, -1, false, 1, Horizontal},
+	}
+	for _, tt := range tests {
+		if got := DerivePredMode(tt.modeA, tt.modeB, tt.prevFlag, tt.rem); got != tt.want {
+			t.Errorf("DerivePredMode(%v, %v, %v, %v) = %v, want %v", tt.modeA, tt.modeB, tt.prevFlag, tt.rem, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package intra
+
+import (
+	"fmt"
+
+	"github.com/LiveStudioSolution/h264decoder/internal/frame"
+)
+
+// Mode Intra4x4PredMode or Intra8x8PredMode, Table 8-2 and Table 8-3
+type Mode uint8
+
+const (
+	Vertical Mode = iota
+	Horizontal
+	DC
+	DiagonalDownLeft
+	DiagonalDownRight
+	VerticalRight
+	HorizontalDown
+	VerticalLeft
+	HorizontalUp
+)
+
+var modeNames = [...]string{"Vertical", "Horizontal", "DC", "Diagonal_Down_Left", "Diagonal_Down_Right",
+	"Vertical_Right", "Horizontal_Down", "Vertical_Left", "Horizontal_Up"}
+
+func (m Mode) String() string {
+	if int(m) < len(modeNames) {
+		return modeNames[m]
+	}
+	return fmt.Sprintf("Mode:%d", m)
+}
+
+// Mode16x16 Intra16x16PredMode, Table 8-4
+type Mode16x16 uint8
+
+const (
+	Vertical16x16 Mode16x16 = iota
+	Horizontal16x16
+	DC16x16
+	Plane16x16
+)
+
+func (m Mode16x16) String() string {
+	if m <= Plane16x16 {
+		return [...]string{"Vertical", "Horizontal", "DC", "Plane"}[m]
+	}
+	return fmt.Sprintf("Mode16x16:%d", m)
+}
+
+// Intra4x4 predict the 4x4 block at ( x, y ) of pl in mode,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.3.1.2 Intra_4x4 sample prediction
+func Intra4x4(pl *frame.Plane, x, y int, mode Mode, avail Avail, bitDepth int) error {
+	r := loadRefs(pl, x, y, 4, 4, true, avail)
+	var pred [16]int32
+	if err := predNxN(pred[:], 4, mode, r, bitDepth); err != nil {
+		return err
+	}
+	store(pl, x, y, 4, 4, pred[:])
+	return nil
+}
+
+// Intra8x8 predict the 8x8 block at ( x, y ) of pl in mode from the filtered
+// neighbouring samples, T-REC-H.264-201402-S!!PDF-E.pdf 8.3.2.2 Intra_8x8 sample prediction
+func Intra8x8(pl *frame.Plane, x, y int, mode Mode, avail Avail, bitDepth int) error {
+	r := loadRefs(pl, x, y, 8, 8, true, avail)
+	r.filter()
+	var pred [64]int32
+	if err := predNxN(pred[:], 8, mode, r, bitDepth); err != nil {
+		return err
+	}
+	store(pl, x, y, 8, 8, pred[:])
+	return nil
+}
+
+// filter reference sample filtering process for Intra_8x8 sample prediction, 8.3.2.2.1.
+// The above-right samples have been substituted so all 16 above samples are
+// available with the above ones.
+func (r *refs) filter() {
+	f := *r
+	if r.avail.Top {
+		if r.avail.TopLeft {
+			f.top[0] = (r.topLeft + 2*r.top[0] + r.top[1] + 2) >> 2
+		} else {
+			f.top[0] = (3*r.top[0] + r.top[1] + 2) >> 2
+		}
+		for x := 1; x < 15; x++ {
+			f.top[x] = (r.top[x-1] + 2*r.top[x] + r.top[x+1] + 2) >> 2
+		}
+		f.top[15] = (r.top[14] + 3*r.top[15] + 2) >> 2
+	}
+	if r.avail.TopLeft {
+		switch {
+		case r.avail.Top && r.avail.Left:
+			f.topLeft = (r.top[0] + 2*r.topLeft + r.left[0] + 2) >> 2
+		case r.avail.Top:
+			f.topLeft = (3*r.topLeft + r.top[0] + 2) >> 2
+		case r.avail.Left:
+			f.topLeft = (3*r.topLeft + r.left[0] + 2) >> 2
+		}
+	}
+	if r.avail.Left {
+		if r.avail.TopLeft {
+			f.left[0] = (r.topLeft + 2*r.left[0] + r.left[1] + 2) >> 2
+		} else {
+			f.left[0] = (3*r.left[0] + r.left[1] + 2) >> 2
+		}
+		for y := 1; y < 7; y++ {
+			f.left[y] = (r.left[y-1] + 2*r.left[y] + r.left[y+1] + 2) >> 2
+		}
+		f.left[7] = (r.left[6] + 3*r.left[7] + 2) >> 2
+	}
+	*r = f
+}
+
+// predNxN Intra_4x4 and Intra_8x8 prediction of an n x n block in raster order, the
+// equations of 8.3.1.2.x and 8.3.2.2.x only differ by the block size
+func predNxN(pred []int32, n int, mode Mode, r *refs, bitDepth int) error {
+	var err error
+	switch mode {
+	case Vertical:
+		err = r.require(mode, false, true, false)
+	case Horizontal, HorizontalUp:
+		err = r.require(mode, true, false, false)
+	case DiagonalDownLeft, VerticalLeft:
+		err = r.require(mode, false, true, false)
+	case DiagonalDownRight, VerticalRight, HorizontalDown:
+		err = r.require(mode, true, true, true)
+	case DC:
+	default:
+		err = fmt.Errorf("invalid intra prediction mode %v", mode)
+	}
+	if err != nil {
+		return err
+	}
+	if mode == DC {
+		dc := predDC(r, 0, 0, n, n, r.avail.Left, r.avail.Top, bitDepth)
+		for i := range pred[:n*n] {
+			pred[i] = dc
+		}
+		return nil
+	}
+	p := r.p
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			var v int32
+			switch mode {
+			case Vertical:
+				v = p(x, -1)
+			case Horizontal:
+				v = p(-1, y)
+			case DiagonalDownLeft:
+				if x == n-1 && y == n-1 {
+					v = (p(2*n-2, -1) + 3*p(2*n-1, -1) + 2) >> 2
+				} else {
+					v = (p(x+y, -1) + 2*p(x+y+1, -1) + p(x+y+2, -1) + 2) >> 2
+				}
+			case DiagonalDownRight:
+				switch {
+				case x > y:
+					v = (p(x-y-2, -1) + 2*p(x-y-1, -1) + p(x-y, -1) + 2) >> 2
+				case x < y:
+					v = (p(-1, y-x-2) + 2*p(-1, y-x-1) + p(-1, y-x) + 2) >> 2
+				default:
+					v = (p(0, -1) + 2*p(-1, -1) + p(-1, 0) + 2) >> 2
+				}
+			case VerticalRight:
+				zVR := 2*x - y
+				switch {
+				case zVR >= 0 && zVR%2 == 0:
+					v = (p(x-(y>>1)-1, -1) + p(x-(y>>1), -1) + 1) >> 1
+				case zVR >= 0:
+					v = (p(x-(y>>1)-2, -1) + 2*p(x-(y>>1)-1, -1) + p(x-(y>>1), -1) + 2) >> 2
+				case zVR == -1:
+					v = (p(-1, 0) + 2*p(-1, -1) + p(0, -1) + 2) >> 2
+				default:
+					v = (p(-1, y-2*x-1) + 2*p(-1, y-2*x-2) + p(-1, y-2*x-3) + 2) >> 2
+				}
+			case HorizontalDown:
+				zHD := 2*y - x
+				switch {
+				case zHD >= 0 && zHD%2 == 0:
+					v = (p(-1, y-(x>>1)-1) + p(-1, y-(x>>1)) + 1) >> 1
+				case zHD >= 0:
+					v = (p(-1, y-(x>>1)-2) + 2*p(-1, y-(x>>1)-1) + p(-1, y-(x>>1)) + 2) >> 2
+				case zHD == -1:
+					v = (p(-1, 0) + 2*p(-1, -1) + p(0, -1) + 2) >> 2
+				default:
+					v = (p(x-2*y-1, -1) + 2*p(x-2*y-2, -1) + p(x-2*y-3, -1) + 2) >> 2
+				}
+			case VerticalLeft:
+				if y%2 == 0 {
+					v = (p(x+(y>>1), -1) + p(x+(y>>1)+1, -1) + 1) >> 1
+				} else {
+					v = (p(x+(y>>1), -1) + 2*p(x+(y>>1)+1, -1) + p(x+(y>>1)+2, -1) + 2) >> 2
+				}
+			case HorizontalUp:
+				zHU := x + 2*y
+				switch {
+				case zHU < 2*n-3 && zHU%2 == 0:
+					v = (p(-1, y+(x>>1)) + p(-1, y+(x>>1)+1) + 1) >> 1
+				case zHU < 2*n-3:
+					v = (p(-1, y+(x>>1)) + 2*p(-1, y+(x>>1)+1) + p(-1, y+(x>>1)+2) + 2) >> 2
+				case zHU == 2*n-3:
+					v = (p(-1, n-2) + 3*p(-1, n-1) + 2) >> 2
+				default:
+					v = p(-1, n-1)
+				}
+			}
+			pred[y*n+x] = v
+		}
+	}
+	return nil
+}
+
+// predDC mean of the w above samples from xO and the h left samples from yO that are
+// used, 1 << ( bitDepth - 1 ) when none is
+func predDC(r *refs, xO, yO, w, h int, left, top bool, bitDepth int) int32 {
+	var sum, n int32
+	if top {
+		for x := xO; x < xO+w; x++ {
+			sum += r.top[x]
+		}
+		n += int32(w)
+	}
+	if left {
+		for y := yO; y < yO+h; y++ {
+			sum += r.left[y]
+		}
+		n += int32(h)
+	}
+	if n == 0 {
+		return 1 << uint(bitDepth-1)
+	}
+	return (sum + n/2) / n
+}
+
+// Intra16x16 predict the 16x16 luma macroblock at ( x, y ) of pl in mode,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.3.3 Intra_16x16 prediction process for luma samples
+func Intra16x16(pl *frame.Plane, x, y int, mode Mode16x16, avail Avail, bitDepth int) error {
+	r := loadRefs(pl, x, y, 16, 16, false, avail)
+	var pred [256]int32
+	switch mode {
+	case Vertical16x16:
+		if err := r.require(mode, false, true, false); err != nil {
+			return err
+		}
+		for i := range pred {
+			pred[i] = r.top[i%16]
+		}
+	case Horizontal16x16:
+		if err := r.require(mode, true, false, false); err != nil {
+			return err
+		}
+		for i := range pred {
+			pred[i] = r.left[i/16]
+		}
+	case DC16x16:
+		dc := predDC(r, 0, 0, 16, 16, avail.Left, avail.Top, bitDepth)
+		for i := range pred {
+			pred[i] = dc
+		}
+	case Plane16x16:
+		if err := r.require(mode, true, true, true); err != nil {
+			return err
+		}
+		predPlane(pred[:], r, 16, 16, bitDepth)
+	default:
+		return fmt.Errorf("invalid Intra16x16PredMode %v", mode)
+	}
+	store(pl, x, y, 16, 16, pred[:])
+	return nil
+}
+
+// predPlane plane prediction of a w x h block, (8-116) to (8-121) for luma and (8-141)
+// to (8-146) for chroma. xCF and yCF are 4 and the multipliers of H and V 5 for
+// dimensions of 16 samples, as in luma, and they are 0 and 34 for 8 samples.
+func predPlane(pred []int32, r *refs, w, h int, bitDepth int) {
+	p := r.p
+	var hh, vv int32
+	for x := 0; x <= w/2-1; x++ {
+		hh += int32(x+1) * (p(w/2+x, -1) - p(w/2-2-x, -1))
+	}
+	for y := 0; y <= h/2-1; y++ {
+		vv += int32(y+1) * (p(-1, h/2+y) - p(-1, h/2-2-y))
+	}
+	mul := func(n int) int32 {
+		if n == 16 {
+			return 5
+		}
+		return 34
+	}
+	a := 16 * (p(-1, h-1) + p(w-1, -1))
+	b := (mul(w)*hh + 32) >> 6
+	c := (mul(h)*vv + 32) >> 6
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			pred[y*w+x] = clip1((a+b*int32(x-w/2+1)+c*int32(y-h/2+1)+16)>>5, bitDepth)
+		}
+	}
+}
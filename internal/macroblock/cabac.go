@@ -0,0 +1,264 @@
+package macroblock
+
+import (
+	"github.com/LiveStudioSolution/h264decoder/internal/cabac"
+	"github.com/LiveStudioSolution/h264decoder/internal/slice"
+)
+
+// cabacDecoder macroblock layer syntax elements coded with CABAC, ctxIdxInc
+// derived from the neighbouring macroblocks, T-REC-H.264-201402-S!!PDF-E.pdf 9.3.3.1.1
+type cabacDecoder struct {
+	p *Parser
+	d *cabac.Decoder
+}
+
+// mbNeighbours neighbouring macroblocks A and B of 6.4.11.1, nil when not available
+func (d *cabacDecoder) mbNeighbours(mb *Macroblock) [2]*Macroblock {
+	var nbs [2]*Macroblock
+	for n := range nbs {
+		if addr := d.p.pic.MbNeighbour(mb.Addr, n); addr >= 0 {
+			nbs[n] = &d.p.pic.Mbs[addr]
+		}
+	}
+	return nbs
+}
+
+// condTermSum condTermFlagA + condTermFlagB of the available neighbours satisfying cond
+func (d *cabacDecoder) condTermSum(mb *Macroblock, cond func(nb *Macroblock) bool) int {
+	inc := 0
+	for _, nb := range d.mbNeighbours(mb) {
+		if nb != nil && cond(nb) {
+			inc++
+		}
+	}
+	return inc
+}
+
+// mbSkipFlag 9.3.3.1.1.1
+func (d *cabacDecoder) mbSkipFlag(mb *Macroblock) (bool, error) {
+	return d.d.MbSkipFlag(d.condTermSum(mb, func(nb *Macroblock) bool {
+		return !nb.MbType.IsSkip()
+	}))
+}
+
+// mbFieldDecodingFlag 9.3.3.1.1.2, neighbours are the left and above macroblock pairs
+func (d *cabacDecoder) mbFieldDecodingFlag(mb *Macroblock) (bool, error) {
+	inc := 0
+	for _, n := range []int{NeighbourA, NeighbourB} {
+		if addr := d.p.pic.MbAddrNeighbour(mb.Addr, n); addr >= 0 && d.p.pic.Mbs[addr].FieldDecodingFlag {
+			inc++
+		}
+	}
+	return d.d.MbFieldDecodingFlag(inc)
+}
+
+// mbType 9.3.3.1.1.3
+func (d *cabacDecoder) mbType(mb *Macroblock) (uint, error) {
+	notINxN := func(nb *Macroblock) bool {
+		return nb.MbType != MbINxN
+	}
+	switch d.p.h.SliceType {
+	case slice.SliceI:
+		return d.d.MbType(d.condTermSum(mb, notINxN), 0)
+	case slice.SliceSI:
+		inc := d.condTermSum(mb, func(nb *Macroblock) bool {
+			return nb.MbType != MbSI
+		})
+		return d.d.MbType(inc, d.condTermSum(mb, notINxN))
+	case slice.SliceB:
+		return d.d.MbType(d.condTermSum(mb, func(nb *Macroblock) bool {
+			return nb.MbType != MbBSkip && nb.MbType != MbBDirect16x16
+		}), 0)
+	}
+	return d.d.MbType(0, 0)
+}
+
+// pcm read the samples of an I_PCM macroblock and initialise the decoding engine, 9.3.1.2
+func (d *cabacDecoder) pcm(mb *Macroblock) error {
+	if err := d.p.readPcm(d.d.Reader(), mb); err != nil {
+		return err
+	}
+	return d.d.InitEngine()
+}
+
+// transformSize8x8Flag 9.3.3.1.1.10
+func (d *cabacDecoder) transformSize8x8Flag(mb *Macroblock) (bool, error) {
+	return d.d.TransformSize8x8Flag(d.condTermSum(mb, func(nb *Macroblock) bool {
+		return nb.TransformSize8x8Flag
+	}))
+}
+
+// codedBlockPattern 9.3.3.1.1.4, the bits of the 8x8 blocks left and above the current
+// macroblock are gathered from the neighbouring 8x8 blocks, which in MBAFF frames may
+// lie in both macroblocks of the left pair
+func (d *cabacDecoder) codedBlockPattern(mb *Macroblock) (uint, error) {
+	pic := d.p.pic
+	neighbourCbp := func(addr int) uint {
+		if addr < 0 {
+			return cabac.NeighbourCbp(false, false, false, 0)
+		}
+		nb := &pic.Mbs[addr]
+		cbp := uint(nb.CodedBlockPatternLuma) | uint(nb.CodedBlockPatternChroma)<<4
+		return cabac.NeighbourCbp(true, nb.MbType.IsSkip(), nb.MbType == MbIPCM, cbp)
+	}
+	var cbpA, cbpB uint
+	for _, b8 := range []int{0, 2} {
+		addr, blkIdxN := pic.Luma8x8Neighbour(mb.Addr, b8, NeighbourA)
+		cbpA |= (neighbourCbp(addr) >> uint(blkIdxN) & 1) << uint(b8+1)
+	}
+	for _, b8 := range []int{0, 1} {
+		addr, blkIdxN := pic.Luma8x8Neighbour(mb.Addr, b8, NeighbourB)
+		cbpB |= (neighbourCbp(addr) >> uint(blkIdxN) & 1) << uint(b8+2)
+	}
+	cbpA |= neighbourCbp(pic.MbNeighbour(mb.Addr, NeighbourA)) &^ 0x0f
+	cbpB |= neighbourCbp(pic.MbNeighbour(mb.Addr, NeighbourB)) &^ 0x0f
+	return d.d.CodedBlockPattern(cbpA, cbpB, d.p.chromaArrayType)
+}
+
+// mbQpDelta 9.3.3.1.1.5, from the previous macroblock of the slice in decoding order
+func (d *cabacDecoder) mbQpDelta(mb *Macroblock) (int, error) {
+	inc := 0
+	if prev := d.p.prev; prev != nil && !prev.MbType.IsSkip() && prev.MbType != MbIPCM && prev.MbQpDelta != 0 &&
+		(prev.MbType.IsIntra16x16() || prev.CodedBlockPatternLuma != 0 || prev.CodedBlockPatternChroma != 0) {
+		inc = 1
+	}
+	return d.d.MbQpDelta(inc)
+}
+
+func (d *cabacDecoder) prevIntraPredModeFlag() (bool, error) {
+	return d.d.PrevIntraPredModeFlag()
+}
+
+func (d *cabacDecoder) remIntraPredMode() (uint, error) {
+	return d.d.RemIntraPredMode()
+}
+
+// intraChromaPredMode 9.3.3.1.1.8
+func (d *cabacDecoder) intraChromaPredMode(mb *Macroblock) (uint, error) {
+	return d.d.IntraChromaPredMode(d.condTermSum(mb, func(nb *Macroblock) bool {
+		return nb.IsIntra() && nb.MbType != MbIPCM && nb.IntraChromaPredMode != 0
+	}))
+}
+
+func (d *cabacDecoder) subMbType() (uint, error) {
+	return d.d.SubMbType()
+}
+
+// partNeighbours neighbouring partitions A and B of the partition with upper-left luma
+// sample ( x, y ) and the 4x4 block in raster order of the neighbour covering them,
+// macroblock nil when not available or not inter predicted, 6.4.11.7
+func (d *cabacDecoder) partNeighbours(mb *Macroblock, x, y int) ([2]*Macroblock, [2]int) {
+	var nbs [2]*Macroblock
+	var blks [2]int
+	for n := range nbs {
+		addr, xW, yW := d.p.pic.Location(mb.Addr, x+neighbourLocations[n][0], y+neighbourLocations[n][1], true)
+		if addr < 0 {
+			continue
+		}
+		if nb := &d.p.pic.Mbs[addr]; !nb.MbType.IsSkip() && !nb.IsIntra() {
+			nbs[n], blks[n] = nb, 4*(yW/4)+xW/4
+		}
+	}
+	return nbs, blks
+}
+
+// refIdx 9.3.3.1.1.6, RefIdx of neighbours not predicted from the list is -1
+func (d *cabacDecoder) refIdx(mb *Macroblock, list, x, y, maxRefIdx int) (int, error) {
+	nbs, blks := d.partNeighbours(mb, x, y)
+	inc := 0
+	for n, nb := range nbs {
+		if nb == nil {
+			continue
+		}
+		refIdxZero := int8(0)
+		if d.p.h.MbaffFrameFlag && !mb.FieldDecodingFlag && nb.FieldDecodingFlag {
+			refIdxZero = 1
+		}
+		if nb.RefIdx[list][blks[n]/8*2+blks[n]%4/2] > refIdxZero {
+			inc += 1 << uint(n)
+		}
+	}
+	v, err := d.d.RefIdx(inc)
+	return int(v), err
+}
+
+// mvd 9.3.3.1.1.7, the vertical component of neighbours is scaled between frame and
+// field macroblocks of MBAFF frames
+func (d *cabacDecoder) mvd(mb *Macroblock, list, compIdx, x, y int) (int, error) {
+	nbs, blks := d.partNeighbours(mb, x, y)
+	absMvdSum := 0
+	for n, nb := range nbs {
+		if nb == nil {
+			continue
+		}
+		absMvdComp := int(nb.Mvd[list][blks[n]][compIdx])
+		if absMvdComp < 0 {
+			absMvdComp = -absMvdComp
+		}
+		if compIdx == 1 && d.p.h.MbaffFrameFlag {
+			if !mb.FieldDecodingFlag && nb.FieldDecodingFlag {
+				absMvdComp *= 2
+			} else if mb.FieldDecodingFlag && !nb.FieldDecodingFlag {
+				absMvdComp /= 2
+			}
+		}
+		absMvdSum += absMvdComp
+	}
+	return d.d.Mvd(compIdx, absMvdSum)
+}
+
+// residualBlock parse residual_block_cabac, 8x8 blocks have coded_block_flag only
+// when ChromaArrayType is 3
+func (d *cabacDecoder) residualBlock(mb *Macroblock, cat, c, blkIdx int, coeffLevel []int32, startIdx, endIdx, maxNumCoeff int) (int, error) {
+	inc := -1
+	switch cat {
+	case cabac.CatLuma8x8, cabac.CatCb8x8, cabac.CatCr8x8:
+		if d.p.chromaArrayType == 3 {
+			inc = d.codedBlockFlagInc(mb, cat, c, blkIdx)
+		}
+	default:
+		inc = d.codedBlockFlagInc(mb, cat, c, blkIdx)
+	}
+	return d.d.ResidualBlock(cat, inc, mb.FieldDecodingFlag, coeffLevel, startIdx, endIdx, maxNumCoeff)
+}
+
+// codedBlockFlagInc ctxIdxInc of coded_block_flag, T-REC-H.264-201402-S!!PDF-E.pdf 9.3.3.1.1.9.
+// coded_block_flag of transBlockN is read from CodedDC and TotalCoeff of the neighbour,
+// which are zero for blocks not coded by coded_block_pattern or skipped macroblocks.
+func (d *cabacDecoder) codedBlockFlagInc(mb *Macroblock, cat, c, blkIdx int) int {
+	pic := d.p.pic
+	inc := 0
+	for n := NeighbourA; n <= NeighbourB; n++ {
+		var addr, blkIdxN int
+		switch cat {
+		case cabac.CatLumaDC, cabac.CatCbDC, cabac.CatCrDC, cabac.CatChromaDC:
+			addr = pic.MbNeighbour(mb.Addr, n)
+		case cabac.CatChromaAC:
+			addr, blkIdxN = pic.Chroma4x4Neighbour(mb.Addr, blkIdx, n)
+		case cabac.CatLuma8x8, cabac.CatCb8x8, cabac.CatCr8x8:
+			addr, blkIdxN = pic.Luma8x8Neighbour(mb.Addr, blkIdx, n)
+		default:
+			addr, blkIdxN = pic.Luma4x4Neighbour(mb.Addr, blkIdx, n)
+		}
+		var condTermFlag bool
+		if addr < 0 {
+			condTermFlag = mb.IsIntra()
+		} else {
+			nb := &pic.Mbs[addr]
+			switch cat {
+			case cabac.CatLumaDC, cabac.CatCbDC, cabac.CatCrDC:
+				condTermFlag = nb.MbType == MbIPCM || nb.MbType.IsIntra16x16() && nb.CodedDC[c]
+			case cabac.CatChromaDC:
+				condTermFlag = nb.MbType == MbIPCM || nb.CodedDC[c]
+			case cabac.CatLuma8x8, cabac.CatCb8x8, cabac.CatCr8x8:
+				condTermFlag = nb.MbType == MbIPCM || nb.TransformSize8x8Flag && nb.TotalCoeff[c][4*blkIdxN] != 0
+			default:
+				condTermFlag = nb.MbType == MbIPCM || nb.TotalCoeff[c][blkIdxN] != 0
+			}
+		}
+		if condTermFlag {
+			inc += 1 << uint(n)
+		}
+	}
+	return inc
+}
@@ -0,0 +1,116 @@
+package macroblock
+
+import (
+	"fmt"
+
+	"github.com/LiveStudioSolution/h264decoder/internal/cabac"
+	"github.com/LiveStudioSolution/h264decoder/internal/cavlc"
+	"github.com/LiveStudioSolution/h264decoder/internal/rbr"
+)
+
+// cavlcDecoder macroblock layer syntax elements coded with Exp-Golomb and CAVLC
+type cavlcDecoder struct {
+	p *Parser
+}
+
+func (d *cavlcDecoder) mbFieldDecodingFlag(mb *Macroblock) (bool, error) {
+	return d.p.br.Read1()
+}
+
+func (d *cavlcDecoder) mbType(mb *Macroblock) (uint, error) {
+	return rbr.DecUe(d.p.br)
+}
+
+func (d *cavlcDecoder) pcm(mb *Macroblock) error {
+	return d.p.readPcm(d.p.br, mb)
+}
+
+func (d *cavlcDecoder) transformSize8x8Flag(mb *Macroblock) (bool, error) {
+	return d.p.br.Read1()
+}
+
+func (d *cavlcDecoder) codedBlockPattern(mb *Macroblock) (uint, error) {
+	cbp, err := rbr.DecMe(d.p.br, d.p.chromaArrayType)
+	if err != nil {
+		return 0, err
+	}
+	switch mb.MbPartPredMode(0) {
+	case PredIntra4x4, PredIntra8x8:
+		return uint(cbp.Intra44), nil
+	}
+	return uint(cbp.Inter), nil
+}
+
+func (d *cavlcDecoder) mbQpDelta(mb *Macroblock) (int, error) {
+	return rbr.DecSe(d.p.br)
+}
+
+func (d *cavlcDecoder) prevIntraPredModeFlag() (bool, error) {
+	return d.p.br.Read1()
+}
+
+func (d *cavlcDecoder) remIntraPredMode() (uint, error) {
+	v, err := d.p.br.Read8(3)
+	return uint(v), err
+}
+
+func (d *cavlcDecoder) intraChromaPredMode(mb *Macroblock) (uint, error) {
+	return rbr.DecUe(d.p.br)
+}
+
+func (d *cavlcDecoder) subMbType() (uint, error) {
+	return rbr.DecUe(d.p.br)
+}
+
+// refIdx te(v) with range maxRefIdx, 9.1
+func (d *cavlcDecoder) refIdx(mb *Macroblock, list, x, y, maxRefIdx int) (int, error) {
+	if maxRefIdx == 1 {
+		b, err := d.p.br.Read1()
+		return 1 - boolToInt(b), err
+	}
+	v, err := rbr.DecUe(d.p.br)
+	if err != nil {
+		return 0, err
+	}
+	if v > uint(maxRefIdx) {
+		return 0, fmt.Errorf("ref_idx %v exceeds %v", v, maxRefIdx)
+	}
+	return int(v), nil
+}
+
+func (d *cavlcDecoder) mvd(mb *Macroblock, list, compIdx, x, y int) (int, error) {
+	return rbr.DecSe(d.p.br)
+}
+
+func (d *cavlcDecoder) residualBlock(mb *Macroblock, cat, c, blkIdx int, coeffLevel []int32, startIdx, endIdx, maxNumCoeff int) (int, error) {
+	var nC int
+	switch cat {
+	case cabac.CatChromaDC:
+		nC = cavlc.NCChromaDC420
+		if d.p.chromaArrayType == 2 {
+			nC = cavlc.NCChromaDC422
+		}
+	case cabac.CatChromaAC:
+		nC = d.nC(mb, c, blkIdx, d.p.pic.Chroma4x4Neighbour)
+	default:
+		nC = d.nC(mb, c, blkIdx, d.p.pic.Luma4x4Neighbour)
+	}
+	return cavlc.ResidualBlock(d.p.br, nC, coeffLevel, startIdx, endIdx, maxNumCoeff)
+}
+
+// nC of 4x4 block blkIdx of component c from the total coefficients of its
+// neighbours A and B, T-REC-H.264-201402-S!!PDF-E.pdf 9.2.1. Skipped macroblocks
+// have none and I_PCM ones 16 in TotalCoeff.
+func (d *cavlcDecoder) nC(mb *Macroblock, c, blkIdx int, neighbour func(curr, blkIdx, n int) (int, int)) int {
+	var available [2]bool
+	var total [2]int
+	for n := NeighbourA; n <= NeighbourB; n++ {
+		mbAddrN, blkIdxN := neighbour(mb.Addr, blkIdx, n)
+		if mbAddrN < 0 {
+			continue
+		}
+		available[n] = true
+		total[n] = int(d.p.pic.Mbs[mbAddrN].TotalCoeff[c][blkIdxN])
+	}
+	return cavlc.NC(available[0], available[1], total[0], total[1])
+}
@@ -0,0 +1,157 @@
+package macroblock
+
+// Macroblock syntax elements of one macroblock_layer and the values derived while
+// parsing it, indexed by colour component 0 luma, 1 Cb and 2 Cr where relevant
+// T-REC-H.264-201402-S!!PDF-E.pdf 7.3.5 Macroblock layer syntax
+type Macroblock struct {
+	Addr int
+	// SliceNum of the slice the macroblock belongs to, -1 until decoded
+	SliceNum int
+	MbType   MbType
+
+	FieldDecodingFlag    bool
+	TransformSize8x8Flag bool
+
+	// prev_intra4x4_pred_mode_flag and rem_intra4x4_pred_mode by luma4x4BlkIdx,
+	// or the Intra_8x8 ones by luma8x8BlkIdx
+	PrevIntraPredModeFlag [16]bool
+	RemIntraPredMode      [16]uint8
+	IntraChromaPredMode   uint8
+
+	SubMbType [4]SubMbType
+	// RefIdx ref_idx_l0 and ref_idx_l1 by 8x8 quadrant in raster order, 0 when
+	// inferred and -1 when the partition does not use the list or is direct predicted
+	RefIdx [2][4]int8
+	// Mvd mvd_l0 and mvd_l1 by 4x4 block in raster order, 0 when not present
+	Mvd [2][16][2]int32
+
+	CodedBlockPatternLuma   uint8
+	CodedBlockPatternChroma uint8
+	MbQpDelta               int
+	QPY                     int
+
+	// Levels transform coefficient levels in scan order, 4x4 block luma4x4BlkIdx at
+	// [16*luma4x4BlkIdx:], with Intra16x16ACLevel and the chroma AC levels at index 1..15,
+	// and 8x8 block luma8x8BlkIdx at [64*luma8x8BlkIdx:]. Chroma 4x4 blocks are indexed by
+	// chroma4x4BlkIdx. For I_PCM the samples in raster order.
+	Levels [3][256]int32
+	// DCLevels Intra16x16DCLevel of each component, or ChromaDCLevel of Cb and Cr
+	DCLevels [3][16]int32
+	// TotalCoeff non-zero levels of 4x4 blocks, for 8x8 blocks decoded with CABAC the
+	// count of the 8x8 block is set to its four 4x4 blocks. 16 for I_PCM.
+	TotalCoeff [3][16]uint8
+	// CodedDC coded_block_flag of the DC blocks indexed as DCLevels
+	CodedDC [3]bool
+}
+
+// IsIntra report whether the macroblock is coded in an intra prediction mode
+func (mb *Macroblock) IsIntra() bool {
+	return mb.MbType.IsIntra()
+}
+
+// MbPartPredMode MbPartPredMode( mb_type, mbPartIdx ) with I_NxN resolved to
+// Intra_4x4 or Intra_8x8 by transform_size_8x8_flag
+func (mb *Macroblock) MbPartPredMode(mbPartIdx int) PredMode {
+	if mb.MbType == MbINxN && mb.TransformSize8x8Flag {
+		return PredIntra8x8
+	}
+	return mb.MbType.MbPartPredMode(mbPartIdx)
+}
+
+// PartPredMode prediction mode of the macroblock or sub-macroblock partition of
+// an inter macroblock covering 8x8 quadrant q
+func (mb *Macroblock) PartPredMode(q int) PredMode {
+	if mb.MbType.NumMbPart() == 4 {
+		return mb.SubMbType[q].SubMbPredMode()
+	}
+	if mb.MbType.NumMbPart() != 2 {
+		return mb.MbType.MbPartPredMode(0)
+	}
+	if mb.MbType.MbPartWidth() == 16 {
+		return mb.MbType.MbPartPredMode(q / 2)
+	}
+	return mb.MbType.MbPartPredMode(q % 2)
+}
+
+// Level4x4 coefficient levels of 4x4 block blkIdx of component c
+func (mb *Macroblock) Level4x4(c, blkIdx int) []int32 {
+	return mb.Levels[c][16*blkIdx : 16*blkIdx+16]
+}
+
+// Level8x8 coefficient levels of 8x8 block blkIdx of component c
+func (mb *Macroblock) Level8x8(c, blkIdx int) []int32 {
+	return mb.Levels[c][64*blkIdx : 64*blkIdx+64]
+}
+
+// reset clear the syntax elements of a macroblock about to be parsed
+func (mb *Macroblock) reset(sliceNum int) {
+	*mb = Macroblock{Addr: mb.Addr, SliceNum: sliceNum, FieldDecodingFlag: mb.FieldDecodingFlag}
+	for l := range mb.RefIdx {
+		for q := range mb.RefIdx[l] {
+			mb.RefIdx[l][q] = -1
+		}
+	}
+}
+
+// luma4x4BlkIdxAt index of the 4x4 luma block covering luma location ( x, y ),
+// T-REC-H.264-201402-S!!PDF-E.pdf 6.4.13.1
+func luma4x4BlkIdxAt(x, y int) int {
+	return 8*(y/8) + 4*(x/8) + 2*(y%8/4) + x%8/4
+}
+
+// luma8x8BlkIdxAt index of the 8x8 luma block covering luma location ( x, y ), 6.4.13.3
+func luma8x8BlkIdxAt(x, y int) int {
+	return 2*(y/8) + x/8
+}
+
+// luma4x4BlkPos upper-left location of 4x4 luma block blkIdx, 6.4.3
+func luma4x4BlkPos(blkIdx int) (int, int) {
+	return blkIdx/4%2*8 + blkIdx%2*4, blkIdx/8*8 + blkIdx%4/2*4
+}
+
+// neighbourLocations ( xD, yD ) of neighbours A and B, Table 6-2
+var neighbourLocations = [2][2]int{{-1, 0}, {0, -1}}
+
+// MbNeighbour mbAddrN of neighbouring macroblock A or B of curr, -1 when not available,
+// T-REC-H.264-201402-S!!PDF-E.pdf 6.4.11.1 Derivation process for neighbouring macroblocks
+func (p *Picture) MbNeighbour(curr, n int) int {
+	mbAddrN, _, _ := p.Location(curr, neighbourLocations[n][0], neighbourLocations[n][1], true)
+	return mbAddrN
+}
+
+// Luma8x8Neighbour mbAddrN and luma8x8BlkIdxN of neighbour A or B of 8x8 luma block
+// blkIdx, mbAddrN is -1 when not available. Also used for Cb and Cr when ChromaArrayType is 3.
+// T-REC-H.264-201402-S!!PDF-E.pdf 6.4.11.2 Derivation process for neighbouring 8x8 luma block
+func (p *Picture) Luma8x8Neighbour(curr, blkIdx, n int) (int, int) {
+	xN := blkIdx%2*8 + neighbourLocations[n][0]
+	yN := blkIdx/2*8 + neighbourLocations[n][1]
+	mbAddrN, xW, yW := p.Location(curr, xN, yN, true)
+	if mbAddrN < 0 {
+		return -1, 0
+	}
+	return mbAddrN, luma8x8BlkIdxAt(xW, yW)
+}
+
+// Luma4x4Neighbour mbAddrN and luma4x4BlkIdxN of neighbour A or B of 4x4 luma block
+// blkIdx, mbAddrN is -1 when not available. Also used for Cb and Cr when ChromaArrayType is 3.
+// T-REC-H.264-201402-S!!PDF-E.pdf 6.4.11.4 Derivation process for neighbouring 4x4 luma blocks
+func (p *Picture) Luma4x4Neighbour(curr, blkIdx, n int) (int, int) {
+	x, y := luma4x4BlkPos(blkIdx)
+	mbAddrN, xW, yW := p.Location(curr, x+neighbourLocations[n][0], y+neighbourLocations[n][1], true)
+	if mbAddrN < 0 {
+		return -1, 0
+	}
+	return mbAddrN, luma4x4BlkIdxAt(xW, yW)
+}
+
+// Chroma4x4Neighbour mbAddrN and chroma4x4BlkIdxN of neighbour A or B of 4x4 chroma
+// block blkIdx when ChromaArrayType is 1 or 2, mbAddrN is -1 when not available.
+// T-REC-H.264-201402-S!!PDF-E.pdf 6.4.11.5 Derivation process for neighbouring 4x4 chroma blocks
+func (p *Picture) Chroma4x4Neighbour(curr, blkIdx, n int) (int, int) {
+	x, y := blkIdx%2*4, blkIdx/2*4
+	mbAddrN, xW, yW := p.Location(curr, x+neighbourLocations[n][0], y+neighbourLocations[n][1], false)
+	if mbAddrN < 0 {
+		return -1, 0
+	}
+	return mbAddrN, 2*(yW/4) + xW/4
+}
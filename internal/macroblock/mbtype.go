@@ -0,0 +1,295 @@
+// Package macroblock parse the macroblock layer of slice data into Macroblock,
+// over either CAVLC or CABAC entropy decoding
+// T-REC-H.264-201402-S!!PDF-E.pdf 7.3.4 Slice data syntax and 7.3.5 Macroblock layer syntax
+package macroblock
+
+import (
+	"fmt"
+
+	"github.com/LiveStudioSolution/h264decoder/internal/slice"
+)
+
+// PredMode prediction mode of a macroblock or sub-macroblock partition
+type PredMode uint8
+
+const (
+	PredNA PredMode = iota
+	PredIntra4x4
+	PredIntra8x8
+	PredIntra16x16
+	PredL0
+	PredL1
+	PredBi
+	PredDirect
+)
+
+func (m PredMode) String() string {
+	switch m {
+	case PredIntra4x4:
+		return "Intra_4x4"
+	case PredIntra8x8:
+		return "Intra_8x8"
+	case PredIntra16x16:
+		return "Intra_16x16"
+	case PredL0:
+		return "Pred_L0"
+	case PredL1:
+		return "Pred_L1"
+	case PredBi:
+		return "BiPred"
+	case PredDirect:
+		return "Direct"
+	}
+	return "na"
+}
+
+// MbType macroblock type of any slice type. I macroblock types keep their mb_type
+// value of Table 7-11, the ones of SI, P and B slices follow.
+type MbType uint8
+
+const (
+	MbINxN MbType = 0
+	// MbI16x16 first of the 24 I_16x16_<predMode>_<cbpChroma>_<cbpLuma> types
+	MbI16x16 MbType = 1
+	MbIPCM   MbType = 25
+	MbSI     MbType = 26
+	// MbPL016x16 P_L0_16x16, first of the P types of Table 7-13
+	MbPL016x16 MbType = 27
+	MbP8x8     MbType = 30
+	MbP8x8Ref0 MbType = 31
+	MbPSkip    MbType = 32
+	// MbBDirect16x16 B_Direct_16x16, first of the B types of Table 7-14
+	MbBDirect16x16 MbType = 33
+	MbB8x8         MbType = 55
+	MbBSkip        MbType = 56
+)
+
+type mbTypeInfo struct {
+	name       string
+	numMbPart  int
+	predMode   [2]PredMode
+	partWidth  int
+	partHeight int
+}
+
+// mbTypeInfos T-REC-H.264-201402-S!!PDF-E.pdf Table 7-11, 7-12, 7-13 and 7-14
+var mbTypeInfos = [MbBSkip + 1]mbTypeInfo{
+	MbINxN:  {"I_NxN", 1, [2]PredMode{PredIntra4x4}, 16, 16},
+	MbIPCM:  {"I_PCM", 1, [2]PredMode{}, 16, 16},
+	MbSI:    {"SI", 1, [2]PredMode{PredIntra4x4}, 16, 16},
+	27:      {"P_L0_16x16", 1, [2]PredMode{PredL0}, 16, 16},
+	28:      {"P_L0_L0_16x8", 2, [2]PredMode{PredL0, PredL0}, 16, 8},
+	29:      {"P_L0_L0_8x16", 2, [2]PredMode{PredL0, PredL0}, 8, 16},
+	MbP8x8:  {"P_8x8", 4, [2]PredMode{}, 8, 8},
+	31:      {"P_8x8ref0", 4, [2]PredMode{}, 8, 8},
+	MbPSkip: {"P_Skip", 1, [2]PredMode{PredL0}, 16, 16},
+	33:      {"B_Direct_16x16", 0, [2]PredMode{PredDirect}, 8, 8},
+	34:      {"B_L0_16x16", 1, [2]PredMode{PredL0}, 16, 16},
+	35:      {"B_L1_16x16", 1, [2]PredMode{PredL1}, 16, 16},
+	36:      {"B_Bi_16x16", 1, [2]PredMode{PredBi}, 16, 16},
+	37:      {"B_L0_L0_16x8", 2, [2]PredMode{PredL0, PredL0}, 16, 8},
+	38:      {"B_L0_L0_8x16", 2, [2]PredMode{PredL0, PredL0}, 8, 16},
+	39:      {"B_L1_L1_16x8", 2, [2]PredMode{PredL1, PredL1}, 16, 8},
+	40:      {"B_L1_L1_8x16", 2, [2]PredMode{PredL1, PredL1}, 8, 16},
+	41:      {"B_L0_L1_16x8", 2, [2]PredMode{PredL0, PredL1}, 16, 8},
+	42:      {"B_L0_L1_8x16", 2, [2]PredMode{PredL0, PredL1}, 8, 16},
+	43:      {"B_L1_L0_16x8", 2, [2]PredMode{PredL1, PredL0}, 16, 8},
+	44:      {"B_L1_L0_8x16", 2, [2]PredMode{PredL1, PredL0}, 8, 16},
+	45:      {"B_L0_Bi_16x8", 2, [2]PredMode{PredL0, PredBi}, 16, 8},
+	46:      {"B_L0_Bi_8x16", 2, [2]PredMode{PredL0, PredBi}, 8, 16},
+	47:      {"B_L1_Bi_16x8", 2, [2]PredMode{PredL1, PredBi}, 16, 8},
+	48:      {"B_L1_Bi_8x16", 2, [2]PredMode{PredL1, PredBi}, 8, 16},
+	49:      {"B_Bi_L0_16x8", 2, [2]PredMode{PredBi, PredL0}, 16, 8},
+	50:      {"B_Bi_L0_8x16", 2, [2]PredMode{PredBi, PredL0}, 8, 16},
+	51:      {"B_Bi_L1_16x8", 2, [2]PredMode{PredBi, PredL1}, 16, 8},
+	52:      {"B_Bi_L1_8x16", 2, [2]PredMode{PredBi, PredL1}, 8, 16},
+	53:      {"B_Bi_Bi_16x8", 2, [2]PredMode{PredBi, PredBi}, 16, 8},
+	54:      {"B_Bi_Bi_8x16", 2, [2]PredMode{PredBi, PredBi}, 8, 16},
+	MbB8x8:  {"B_8x8", 4, [2]PredMode{}, 8, 8},
+	MbBSkip: {"B_Skip", 0, [2]PredMode{PredDirect}, 8, 8},
+}
+
+func init() {
+	for t := MbI16x16; t < MbIPCM; t++ {
+		mbTypeInfos[t] = mbTypeInfo{
+			name:       fmt.Sprintf("I_16x16_%d_%d_%d", t.Intra16x16PredMode(), t.CodedBlockPatternChroma(), t.CodedBlockPatternLuma()),
+			numMbPart:  1,
+			predMode:   [2]PredMode{PredIntra16x16},
+			partWidth:  16,
+			partHeight: 16,
+		}
+	}
+}
+
+// mbTypeOf map mb_type of a slice of sliceType to MbType, intra macroblock types
+// of SI, P and B slices are offset by 1, 5 and 23
+func mbTypeOf(sliceType slice.SliceType, mbType uint) (MbType, error) {
+	switch sliceType {
+	case slice.SliceI:
+		if mbType <= uint(MbIPCM) {
+			return MbType(mbType), nil
+		}
+	case slice.SliceSI:
+		if mbType == 0 {
+			return MbSI, nil
+		}
+		if mbType <= 1+uint(MbIPCM) {
+			return MbType(mbType - 1), nil
+		}
+	case slice.SliceP, slice.SliceSP:
+		if mbType < 5 {
+			return MbPL016x16 + MbType(mbType), nil
+		}
+		if mbType <= 5+uint(MbIPCM) {
+			return MbType(mbType - 5), nil
+		}
+	case slice.SliceB:
+		if mbType < 23 {
+			return MbBDirect16x16 + MbType(mbType), nil
+		}
+		if mbType <= 23+uint(MbIPCM) {
+			return MbType(mbType - 23), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid mb_type %v in %v slice", mbType, sliceType)
+}
+
+func (t MbType) String() string {
+	if int(t) < len(mbTypeInfos) {
+		return mbTypeInfos[t].name
+	}
+	return fmt.Sprintf("MbType:%d", t)
+}
+
+// IsIntra report whether t is an I or SI macroblock type
+func (t MbType) IsIntra() bool {
+	return t <= MbSI
+}
+
+// IsSkip report whether t is P_Skip or B_Skip
+func (t MbType) IsSkip() bool {
+	return t == MbPSkip || t == MbBSkip
+}
+
+// IsIntra16x16 report whether t is one of the I_16x16 types
+func (t MbType) IsIntra16x16() bool {
+	return t >= MbI16x16 && t < MbIPCM
+}
+
+// NumMbPart NumMbPart( mb_type ), 0 for B_Skip and B_Direct_16x16
+func (t MbType) NumMbPart() int {
+	return mbTypeInfos[t].numMbPart
+}
+
+// MbPartPredMode MbPartPredMode( mb_type, mbPartIdx ), Intra_4x4 for I_NxN which is
+// Intra_8x8 when transform_size_8x8_flag is set, see Macroblock.MbPartPredMode
+func (t MbType) MbPartPredMode(mbPartIdx int) PredMode {
+	return mbTypeInfos[t].predMode[mbPartIdx]
+}
+
+// MbPartWidth MbPartWidth( mb_type )
+func (t MbType) MbPartWidth() int {
+	return mbTypeInfos[t].partWidth
+}
+
+// MbPartHeight MbPartHeight( mb_type )
+func (t MbType) MbPartHeight() int {
+	return mbTypeInfos[t].partHeight
+}
+
+// Intra16x16PredMode Intra16x16PredMode of I_16x16 types
+func (t MbType) Intra16x16PredMode() int {
+	return int(t-MbI16x16) % 4
+}
+
+// CodedBlockPatternChroma CodedBlockPatternChroma of I_16x16 types
+func (t MbType) CodedBlockPatternChroma() uint8 {
+	return uint8(t-MbI16x16) / 4 % 3
+}
+
+// CodedBlockPatternLuma CodedBlockPatternLuma of I_16x16 types
+func (t MbType) CodedBlockPatternLuma() uint8 {
+	if t >= MbI16x16+12 {
+		return 15
+	}
+	return 0
+}
+
+// SubMbType sub-macroblock type of P and B slices, P types keep their sub_mb_type
+// value of Table 7-17 and B types of Table 7-18 follow
+type SubMbType uint8
+
+const (
+	// SubPL08x8 P_L0_8x8, first of the P sub-macroblock types
+	SubPL08x8 SubMbType = 0
+	// SubBDirect8x8 B_Direct_8x8, first of the B sub-macroblock types
+	SubBDirect8x8 SubMbType = 4
+)
+
+type subMbTypeInfo struct {
+	name         string
+	numSubMbPart int
+	predMode     PredMode
+	partWidth    int
+	partHeight   int
+}
+
+// subMbTypeInfos T-REC-H.264-201402-S!!PDF-E.pdf Table 7-17 and 7-18
+var subMbTypeInfos = [17]subMbTypeInfo{
+	{"P_L0_8x8", 1, PredL0, 8, 8},
+	{"P_L0_8x4", 2, PredL0, 8, 4},
+	{"P_L0_4x8", 2, PredL0, 4, 8},
+	{"P_L0_4x4", 4, PredL0, 4, 4},
+	{"B_Direct_8x8", 4, PredDirect, 4, 4},
+	{"B_L0_8x8", 1, PredL0, 8, 8},
+	{"B_L1_8x8", 1, PredL1, 8, 8},
+	{"B_Bi_8x8", 1, PredBi, 8, 8},
+	{"B_L0_8x4", 2, PredL0, 8, 4},
+	{"B_L0_4x8", 2, PredL0, 4, 8},
+	{"B_L1_8x4", 2, PredL1, 8, 4},
+	{"B_L1_4x8", 2, PredL1, 4, 8},
+	{"B_Bi_8x4", 2, PredBi, 8, 4},
+	{"B_Bi_4x8", 2, PredBi, 4, 8},
+	{"B_L0_4x4", 4, PredL0, 4, 4},
+	{"B_L1_4x4", 4, PredL1, 4, 4},
+	{"B_Bi_4x4", 4, PredBi, 4, 4},
+}
+
+// subMbTypeOf map sub_mb_type of a slice of sliceType to SubMbType
+func subMbTypeOf(sliceType slice.SliceType, subMbType uint) (SubMbType, error) {
+	if sliceType == slice.SliceB {
+		if subMbType < 13 {
+			return SubBDirect8x8 + SubMbType(subMbType), nil
+		}
+	} else if subMbType < 4 {
+		return SubMbType(subMbType), nil
+	}
+	return 0, fmt.Errorf("invalid sub_mb_type %v in %v slice", subMbType, sliceType)
+}
+
+func (t SubMbType) String() string {
+	if int(t) < len(subMbTypeInfos) {
+		return subMbTypeInfos[t].name
+	}
+	return fmt.Sprintf("SubMbType:%d", t)
+}
+
+// NumSubMbPart NumSubMbPart( sub_mb_type )
+func (t SubMbType) NumSubMbPart() int {
+	return subMbTypeInfos[t].numSubMbPart
+}
+
+// SubMbPredMode SubMbPredMode( sub_mb_type )
+func (t SubMbType) SubMbPredMode() PredMode {
+	return subMbTypeInfos[t].predMode
+}
+
+// SubMbPartWidth SubMbPartWidth( sub_mb_type )
+func (t SubMbType) SubMbPartWidth() int {
+	return subMbTypeInfos[t].partWidth
+}
+
+// SubMbPartHeight SubMbPartHeight( sub_mb_type )
+func (t SubMbType) SubMbPartHeight() int {
+	return subMbTypeInfos[t].partHeight
+}
@@ -0,0 +1,231 @@
+package macroblock
+
+import "github.com/LiveStudioSolution/h264decoder/internal/slice"
+
+// neighbouring macroblocks, blocks or partitions left, above, above-right and
+// above-left of the current one
+const (
+	NeighbourA = iota
+	NeighbourB
+	NeighbourC
+	NeighbourD
+)
+
+// Picture macroblocks of the picture being decoded indexed by macroblock address,
+// shared by the slices of the picture for the neighbour derivations of 6.4
+type Picture struct {
+	Mbs            []Macroblock
+	WidthInMbs     int
+	MbaffFrameFlag bool
+	// MbWidthC and MbHeightC, 0 when ChromaArrayType is 0
+	MbWidthC  int
+	MbHeightC int
+}
+
+// NewPicture allocate the macroblocks of the picture slice h belongs to,
+// all of them not yet decoded
+func NewPicture(h *slice.Header) *Picture {
+	p := &Picture{
+		Mbs:            make([]Macroblock, h.PicSizeInMbs),
+		WidthInMbs:     int(h.SPS.PicWidthInMbs()),
+		MbaffFrameFlag: h.MbaffFrameFlag,
+	}
+	if h.SPS.ChromaArrayType() != 0 {
+		p.MbWidthC = 16 / h.SPS.SubWidthC()
+		p.MbHeightC = 16 / h.SPS.SubHeightC()
+	}
+	for i := range p.Mbs {
+		p.Mbs[i].Addr = i
+		p.Mbs[i].SliceNum = -1
+	}
+	return p
+}
+
+// available report whether macroblock mbAddr is available to macroblock curr,
+// T-REC-H.264-201402-S!!PDF-E.pdf 6.4.8 Derivation process of the availability for macroblock addresses
+func (p *Picture) available(curr, mbAddr int) bool {
+	return mbAddr >= 0 && mbAddr <= curr && p.Mbs[mbAddr].SliceNum == p.Mbs[curr].SliceNum
+}
+
+// MbAddrNeighbour mbAddrN of neighbour n of macroblock curr, -1 when not available.
+// In MBAFF frames it is the top macroblock of the neighbouring macroblock pair.
+// T-REC-H.264-201402-S!!PDF-E.pdf 6.4.9 and 6.4.10 Derivation process for neighbouring macroblock addresses and their availability
+func (p *Picture) MbAddrNeighbour(curr, n int) int {
+	w := p.WidthInMbs
+	addr, col := curr, curr%w
+	if p.MbaffFrameFlag {
+		addr, col = curr/2, curr/2%w
+	}
+	switch n {
+	case NeighbourA:
+		if col == 0 {
+			return -1
+		}
+		addr--
+	case NeighbourB:
+		addr -= w
+	case NeighbourC:
+		if col == w-1 {
+			return -1
+		}
+		addr -= w - 1
+	case NeighbourD:
+		if col == 0 {
+			return -1
+		}
+		addr -= w + 1
+	}
+	if p.MbaffFrameFlag {
+		addr *= 2
+	}
+	if !p.available(curr, addr) {
+		return -1
+	}
+	return addr
+}
+
+// Location mbAddrN of the macroblock covering location ( xN, yN ) relative to the
+// upper-left corner of macroblock curr, and the location ( xW, yW ) relative to
+// mbAddrN. mbAddrN is -1 when not available. The location is a luma one or a
+// chroma one relative to the MbWidthC x MbHeightC chroma block.
+// T-REC-H.264-201402-S!!PDF-E.pdf 6.4.12 Derivation process for neighbouring locations
+func (p *Picture) Location(curr, xN, yN int, luma bool) (mbAddrN, xW, yW int) {
+	maxW, maxH := 16, 16
+	if !luma {
+		maxW, maxH = p.MbWidthC, p.MbHeightC
+	}
+	if yN > maxH-1 || (xN > maxW-1 && yN >= 0) {
+		return -1, 0, 0
+	}
+	yM := yN
+	if !p.MbaffFrameFlag {
+		mbAddrN = curr
+		switch {
+		case xN < 0 && yN < 0:
+			mbAddrN = p.MbAddrNeighbour(curr, NeighbourD)
+		case xN < 0:
+			mbAddrN = p.MbAddrNeighbour(curr, NeighbourA)
+		case xN < maxW && yN < 0:
+			mbAddrN = p.MbAddrNeighbour(curr, NeighbourB)
+		case yN < 0:
+			mbAddrN = p.MbAddrNeighbour(curr, NeighbourC)
+		}
+	} else {
+		mbAddrN, yM = p.locationMbaff(curr, xN, yN, maxW, maxH)
+	}
+	if mbAddrN < 0 {
+		return -1, 0, 0
+	}
+	return mbAddrN, (xN + maxW) % maxW, (yM + maxH) % maxH
+}
+
+// locationMbaff mbAddrN and yM of location ( xN, yN ) in MBAFF frames,
+// T-REC-H.264-201402-S!!PDF-E.pdf 6.4.12.2 Table 6-4
+func (p *Picture) locationMbaff(curr, xN, yN, maxW, maxH int) (int, int) {
+	currMbFrameFlag := !p.Mbs[curr].FieldDecodingFlag
+	mbIsTopMbFlag := curr%2 == 0
+	frame := func(mbAddrX int) bool {
+		return !p.Mbs[mbAddrX].FieldDecodingFlag
+	}
+	switch {
+	case xN < 0 && yN < 0:
+		if currMbFrameFlag {
+			if mbIsTopMbFlag {
+				return p.pairBottom(curr, NeighbourD), yN
+			}
+			mbAddrX := p.MbAddrNeighbour(curr, NeighbourA)
+			if mbAddrX < 0 || frame(mbAddrX) {
+				return mbAddrX, yN
+			}
+			return mbAddrX + 1, (yN + maxH) >> 1
+		}
+		mbAddrX := p.MbAddrNeighbour(curr, NeighbourD)
+		if mbAddrX < 0 {
+			return -1, 0
+		}
+		if !mbIsTopMbFlag {
+			return mbAddrX + 1, yN
+		}
+		if frame(mbAddrX) {
+			return mbAddrX + 1, 2 * yN
+		}
+		return mbAddrX, yN
+
+	case xN < 0:
+		mbAddrX := p.MbAddrNeighbour(curr, NeighbourA)
+		if mbAddrX < 0 {
+			return -1, 0
+		}
+		xFrame := frame(mbAddrX)
+		switch {
+		case currMbFrameFlag && mbIsTopMbFlag && xFrame:
+			return mbAddrX, yN
+		case currMbFrameFlag && mbIsTopMbFlag:
+			return mbAddrX + yN%2, yN >> 1
+		case currMbFrameFlag && xFrame:
+			return mbAddrX + 1, yN
+		case currMbFrameFlag:
+			return mbAddrX + yN%2, (yN + maxH) >> 1
+		case mbIsTopMbFlag && xFrame:
+			if yN < maxH/2 {
+				return mbAddrX, yN << 1
+			}
+			return mbAddrX + 1, yN<<1 - maxH
+		case mbIsTopMbFlag:
+			return mbAddrX, yN
+		case xFrame:
+			if yN < maxH/2 {
+				return mbAddrX, yN<<1 + 1
+			}
+			return mbAddrX + 1, yN<<1 + 1 - maxH
+		}
+		return mbAddrX + 1, yN
+
+	case xN < maxW:
+		if currMbFrameFlag {
+			if mbIsTopMbFlag {
+				return p.pairBottom(curr, NeighbourB), yN
+			}
+			return curr - 1, yN
+		}
+		mbAddrX := p.MbAddrNeighbour(curr, NeighbourB)
+		if mbAddrX < 0 {
+			return -1, 0
+		}
+		if !mbIsTopMbFlag {
+			return mbAddrX + 1, yN
+		}
+		if frame(mbAddrX) {
+			return mbAddrX + 1, 2 * yN
+		}
+		return mbAddrX, yN
+	}
+
+	// xN > maxW - 1 and yN < 0
+	if currMbFrameFlag {
+		if mbIsTopMbFlag {
+			return p.pairBottom(curr, NeighbourC), yN
+		}
+		return -1, 0
+	}
+	mbAddrX := p.MbAddrNeighbour(curr, NeighbourC)
+	if mbAddrX < 0 {
+		return -1, 0
+	}
+	if !mbIsTopMbFlag {
+		return mbAddrX + 1, yN
+	}
+	if frame(mbAddrX) {
+		return mbAddrX + 1, 2 * yN
+	}
+	return mbAddrX, yN
+}
+
+// pairBottom bottom macroblock of neighbouring macroblock pair n, -1 when not available
+func (p *Picture) pairBottom(curr, n int) int {
+	mbAddrX := p.MbAddrNeighbour(curr, n)
+	if mbAddrX < 0 {
+		return -1
+	}
+	return mbAddrX + 1
+}
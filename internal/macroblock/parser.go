@@ -0,0 +1,672 @@
+package macroblock
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/LiveStudioSolution/h264decoder/internal"
+	"github.com/LiveStudioSolution/h264decoder/internal/cabac"
+	"github.com/LiveStudioSolution/h264decoder/internal/rbr"
+	"github.com/LiveStudioSolution/h264decoder/internal/slice"
+)
+
+// entropyDecoder parse the syntax elements of the macroblock layer coded with
+// either CAVLC or CABAC, the neighbouring macroblocks giving nC and ctxIdxInc
+type entropyDecoder interface {
+	mbFieldDecodingFlag(mb *Macroblock) (bool, error)
+	mbType(mb *Macroblock) (uint, error)
+	// pcm read pcm_alignment_zero_bit and the samples of an I_PCM macroblock
+	pcm(mb *Macroblock) error
+	transformSize8x8Flag(mb *Macroblock) (bool, error)
+	codedBlockPattern(mb *Macroblock) (uint, error)
+	mbQpDelta(mb *Macroblock) (int, error)
+	prevIntraPredModeFlag() (bool, error)
+	remIntraPredMode() (uint, error)
+	intraChromaPredMode(mb *Macroblock) (uint, error)
+	subMbType() (uint, error)
+	// refIdx ref_idx_lX of the partition with upper-left luma sample ( x, y ), at most maxRefIdx
+	refIdx(mb *Macroblock, list, x, y, maxRefIdx int) (int, error)
+	// mvd component compIdx of mvd_lX of the partition with upper-left luma sample ( x, y )
+	mvd(mb *Macroblock, list, compIdx, x, y int) (int, error)
+	// residualBlock parse block blkIdx of ctxBlockCat cat and colour component c, return
+	// the count of non-zero levels
+	residualBlock(mb *Macroblock, cat, c, blkIdx int, coeffLevel []int32, startIdx, endIdx, maxNumCoeff int) (int, error)
+}
+
+// residualCats ctxBlockCat of the DC, AC, 4x4 and 8x8 blocks of each colour component
+var residualCats = [3][4]int{
+	{cabac.CatLumaDC, cabac.CatLumaAC, cabac.CatLuma4x4, cabac.CatLuma8x8},
+	{cabac.CatCbDC, cabac.CatCbAC, cabac.CatCb4x4, cabac.CatCb8x8},
+	{cabac.CatCrDC, cabac.CatCrAC, cabac.CatCr4x4, cabac.CatCr8x8},
+}
+
+// Parser parse the macroblocks of one slice in decoding order
+// T-REC-H.264-201402-S!!PDF-E.pdf 7.3.4 Slice data syntax
+type Parser struct {
+	h   *slice.Header
+	sps *internal.SPS
+	pps *internal.PPS
+	pic *Picture
+	br  *rbr.Reader
+
+	ed    entropyDecoder
+	cabac *cabacDecoder
+
+	sliceNum        int
+	chromaArrayType uint
+	curr            int
+	moreData        bool
+	// prev previous macroblock of the slice in decoding order, nil for the first one
+	prev *Macroblock
+	qpy  int
+
+	// skipRun macroblocks left of the last mb_skip_run, runRead whether mb_skip_run
+	// was read for the next macroblock
+	skipRun int
+	runRead bool
+
+	// bottom macroblock values parsed ahead when the top macroblock of an MBAFF
+	// pair is skipped, as its mb_field_decoding_flag applies to both
+	pending          bool
+	pendingSkip      bool
+	pendingFieldFlag bool
+}
+
+// NewParser return a parser of the slice data of slice h, rbsp the rbsp of its nal unit.
+// Macroblocks are stored in pic, sliceNum tells the slices of the picture apart.
+func NewParser(pic *Picture, h *slice.Header, rbsp []byte, sliceNum int) (*Parser, error) {
+	if h.PPS.NumSliceGroupsMinus1 > 0 {
+		return nil, fmt.Errorf("slice groups not supported")
+	}
+	if h.NalUnitType >= internal.NaluSliceDpa && h.NalUnitType <= internal.NaluSliceDpc {
+		return nil, fmt.Errorf("slice data partitioning not supported")
+	}
+	if len(pic.Mbs) != int(h.PicSizeInMbs) {
+		return nil, fmt.Errorf("picture of %v macroblocks, slice of %v", len(pic.Mbs), h.PicSizeInMbs)
+	}
+	p := &Parser{
+		h:               h,
+		sps:             h.SPS,
+		pps:             h.PPS,
+		pic:             pic,
+		br:              rbr.NewReader(rbsp),
+		sliceNum:        sliceNum,
+		chromaArrayType: h.SPS.ChromaArrayType(),
+		curr:            int(h.FirstMbInSlice) * (1 + boolToInt(h.MbaffFrameFlag)),
+		moreData:        true,
+		qpy:             h.SliceQPY,
+	}
+	if err := p.br.Skip(uint(h.BitSize)); err != nil {
+		return nil, err
+	}
+	if !h.PPS.EntropyCodingModeFlag {
+		p.ed = &cavlcDecoder{p: p}
+		return p, nil
+	}
+	for !p.br.ByteAligned() {
+		if b, err := p.br.Read1(); err != nil || !b {
+			return nil, fmt.Errorf("cabac_alignment_one_bit %v, %v", b, err)
+		}
+	}
+	d, err := cabac.NewDecoder(p.br, h.SliceType, h.CabacInitIdc, h.SliceQPY)
+	if err != nil {
+		return nil, err
+	}
+	p.cabac = &cabacDecoder{p: p, d: d}
+	p.ed = p.cabac
+	return p, nil
+}
+
+// Next parse the next macroblock of the slice, io.EOF after the last one
+func (p *Parser) Next() (*Macroblock, error) {
+	if !p.moreData {
+		return nil, io.EOF
+	}
+	if p.curr >= len(p.pic.Mbs) {
+		return nil, fmt.Errorf("slice data beyond macroblock %v", len(p.pic.Mbs))
+	}
+	mb := &p.pic.Mbs[p.curr]
+	if err := p.next(mb); err != nil {
+		return nil, fmt.Errorf("macroblock %v: %v", p.curr, err)
+	}
+	p.prev = mb
+	p.qpy = mb.QPY
+	p.curr++
+	return mb, nil
+}
+
+func (p *Parser) next(mb *Macroblock) error {
+	mbaff := p.h.MbaffFrameFlag
+	top := p.curr%2 == 0
+	if !mbaff || top {
+		mb.reset(p.sliceNum)
+		mb.FieldDecodingFlag = p.h.FieldPicFlag
+		if mbaff {
+			mb.FieldDecodingFlag = p.inferFieldDecodingFlag()
+			bottom := &p.pic.Mbs[p.curr+1]
+			bottom.reset(p.sliceNum)
+			bottom.FieldDecodingFlag = mb.FieldDecodingFlag
+		}
+	}
+
+	skipped, err := p.mbSkip(mb)
+	if err != nil {
+		return err
+	}
+	mb.QPY = p.qpy
+	if skipped {
+		mb.MbType = MbPSkip
+		if p.h.SliceType == slice.SliceB {
+			mb.MbType = MbBSkip
+		}
+		if mbaff && top {
+			if err := p.parseAhead(mb); err != nil {
+				return err
+			}
+		}
+	} else {
+		// the bottom macroblock of a pair carries the flag when the top one is skipped,
+		// in which case it was parsed ahead
+		if mbaff && (top || p.pending) {
+			if p.pending {
+				mb.FieldDecodingFlag = p.pendingFieldFlag
+			} else if mb.FieldDecodingFlag, err = p.ed.mbFieldDecodingFlag(mb); err != nil {
+				return fmt.Errorf("mb_field_decoding_flag: %v", err)
+			}
+			if top {
+				p.pic.Mbs[p.curr+1].FieldDecodingFlag = mb.FieldDecodingFlag
+			}
+		}
+		if err := p.macroblockLayer(mb); err != nil {
+			return err
+		}
+	}
+	if !top {
+		p.pending = false
+	}
+	return p.moreDataFlag(skipped)
+}
+
+// inferFieldDecodingFlag mb_field_decoding_flag of a macroblock pair without one,
+// the one of the left or above pair of the same slice, T-REC-H.264-201402-S!!PDF-E.pdf 7.4.4
+func (p *Parser) inferFieldDecodingFlag() bool {
+	for _, n := range []int{NeighbourA, NeighbourB} {
+		if addr := p.pic.MbAddrNeighbour(p.curr, n); addr >= 0 {
+			return p.pic.Mbs[addr].FieldDecodingFlag
+		}
+	}
+	return false
+}
+
+// mbSkip parse mb_skip_run or mb_skip_flag, report whether the current macroblock is skipped
+func (p *Parser) mbSkip(mb *Macroblock) (bool, error) {
+	if p.h.SliceType.IsIntra() {
+		return false, nil
+	}
+	if p.cabac != nil {
+		if p.pending {
+			return p.pendingSkip, nil
+		}
+		skipped, err := p.cabac.mbSkipFlag(mb)
+		if err != nil {
+			return false, fmt.Errorf("mb_skip_flag: %v", err)
+		}
+		return skipped, nil
+	}
+	if !p.runRead {
+		run, err := rbr.DecUe(p.br)
+		if err != nil {
+			return false, fmt.Errorf("mb_skip_run: %v", err)
+		}
+		if run > uint(len(p.pic.Mbs)-p.curr) {
+			return false, fmt.Errorf("invalid mb_skip_run %v", run)
+		}
+		p.skipRun, p.runRead = int(run), true
+	}
+	if p.skipRun == 0 {
+		p.runRead = false
+		return false, nil
+	}
+	p.skipRun--
+	return true, nil
+}
+
+// parseAhead parse the skip state and mb_field_decoding_flag of the bottom macroblock
+// of a pair whose top macroblock is skipped, the flag being the one of the pair
+func (p *Parser) parseAhead(top *Macroblock) error {
+	bottom := &p.pic.Mbs[p.curr+1]
+	if p.cabac == nil {
+		if p.skipRun > 0 || !p.br.MoreRBSPData() {
+			return nil
+		}
+		b, err := p.br.Read1()
+		if err != nil {
+			return fmt.Errorf("mb_field_decoding_flag: %v", err)
+		}
+		p.pending, p.pendingFieldFlag = true, b
+	} else {
+		skipped, err := p.cabac.mbSkipFlag(bottom)
+		if err != nil {
+			return fmt.Errorf("mb_skip_flag: %v", err)
+		}
+		p.pending, p.pendingSkip, p.pendingFieldFlag = true, skipped, top.FieldDecodingFlag
+		if !skipped {
+			if p.pendingFieldFlag, err = p.cabac.mbFieldDecodingFlag(bottom); err != nil {
+				return fmt.Errorf("mb_field_decoding_flag: %v", err)
+			}
+		}
+	}
+	top.FieldDecodingFlag = p.pendingFieldFlag
+	bottom.FieldDecodingFlag = p.pendingFieldFlag
+	return nil
+}
+
+// moreDataFlag update moreDataFlag after a macroblock
+func (p *Parser) moreDataFlag(skipped bool) error {
+	if p.cabac == nil {
+		if skipped && p.skipRun > 0 {
+			return nil
+		}
+		p.moreData = p.br.MoreRBSPData()
+		return nil
+	}
+	if p.h.MbaffFrameFlag && p.curr%2 == 0 {
+		return nil
+	}
+	end, err := p.cabac.d.EndOfSliceFlag()
+	if err != nil {
+		return fmt.Errorf("end_of_slice_flag: %v", err)
+	}
+	p.moreData = !end
+	return nil
+}
+
+// macroblockLayer T-REC-H.264-201402-S!!PDF-E.pdf 7.3.5 Macroblock layer syntax
+func (p *Parser) macroblockLayer(mb *Macroblock) error {
+	v, err := p.ed.mbType(mb)
+	if err != nil {
+		return fmt.Errorf("mb_type: %v", err)
+	}
+	if mb.MbType, err = mbTypeOf(p.h.SliceType, v); err != nil {
+		return err
+	}
+	if mb.MbType == MbIPCM {
+		if err := p.ed.pcm(mb); err != nil {
+			return fmt.Errorf("pcm samples: %v", err)
+		}
+		for c := range mb.TotalCoeff {
+			for blk := range mb.TotalCoeff[c] {
+				mb.TotalCoeff[c][blk] = 16
+			}
+			mb.CodedDC[c] = true
+		}
+		return nil
+	}
+
+	noSubMbPartSizeLessThan8x8Flag := true
+	if mb.MbType.NumMbPart() == 4 {
+		if err := p.subMbPred(mb); err != nil {
+			return err
+		}
+		for _, t := range mb.SubMbType {
+			if t != SubBDirect8x8 {
+				if t.NumSubMbPart() > 1 {
+					noSubMbPartSizeLessThan8x8Flag = false
+				}
+			} else if !p.sps.Direct8X8InferenceFlag {
+				noSubMbPartSizeLessThan8x8Flag = false
+			}
+		}
+	} else {
+		if p.pps.Transform8X8ModeFlag && mb.MbType == MbINxN {
+			if mb.TransformSize8x8Flag, err = p.ed.transformSize8x8Flag(mb); err != nil {
+				return fmt.Errorf("transform_size_8x8_flag: %v", err)
+			}
+		}
+		if err := p.mbPred(mb); err != nil {
+			return err
+		}
+	}
+
+	if mb.MbType.IsIntra16x16() {
+		mb.CodedBlockPatternLuma = mb.MbType.CodedBlockPatternLuma()
+		mb.CodedBlockPatternChroma = mb.MbType.CodedBlockPatternChroma()
+	} else {
+		cbp, err := p.ed.codedBlockPattern(mb)
+		if err != nil {
+			return fmt.Errorf("coded_block_pattern: %v", err)
+		}
+		mb.CodedBlockPatternLuma, mb.CodedBlockPatternChroma = uint8(cbp&15), uint8(cbp>>4)
+		if mb.CodedBlockPatternChroma > 2 {
+			return fmt.Errorf("invalid coded_block_pattern %v", cbp)
+		}
+		if mb.CodedBlockPatternLuma > 0 && p.pps.Transform8X8ModeFlag && mb.MbType != MbINxN &&
+			noSubMbPartSizeLessThan8x8Flag && (mb.MbType != MbBDirect16x16 || p.sps.Direct8X8InferenceFlag) {
+			if mb.TransformSize8x8Flag, err = p.ed.transformSize8x8Flag(mb); err != nil {
+				return fmt.Errorf("transform_size_8x8_flag: %v", err)
+			}
+		}
+	}
+
+	if mb.CodedBlockPatternLuma == 0 && mb.CodedBlockPatternChroma == 0 && !mb.MbType.IsIntra16x16() {
+		return nil
+	}
+	if mb.MbQpDelta, err = p.ed.mbQpDelta(mb); err != nil {
+		return fmt.Errorf("mb_qp_delta: %v", err)
+	}
+	qpBdOffsetY := 6 * int(p.sps.BitDepthLumaMinus8)
+	if mb.MbQpDelta < -(26+qpBdOffsetY/2) || mb.MbQpDelta > 25+qpBdOffsetY/2 {
+		return fmt.Errorf("invalid mb_qp_delta %v", mb.MbQpDelta)
+	}
+	// T-REC-H.264-201402-S!!PDF-E.pdf 7.4.5 (7-37)
+	mb.QPY = (p.qpy+mb.MbQpDelta+52+2*qpBdOffsetY)%(52+qpBdOffsetY) - qpBdOffsetY
+	return p.residual(mb, 0, 15)
+}
+
+// mbPred T-REC-H.264-201402-S!!PDF-E.pdf 7.3.5.1 Macroblock prediction syntax
+func (p *Parser) mbPred(mb *Macroblock) error {
+	mode := mb.MbPartPredMode(0)
+	switch mode {
+	case PredIntra4x4, PredIntra8x8, PredIntra16x16:
+		n := 0
+		switch mode {
+		case PredIntra4x4:
+			n = 16
+		case PredIntra8x8:
+			n = 4
+		}
+		for i := 0; i < n; i++ {
+			flag, err := p.ed.prevIntraPredModeFlag()
+			if err != nil {
+				return fmt.Errorf("prev_intra_pred_mode_flag: %v", err)
+			}
+			mb.PrevIntraPredModeFlag[i] = flag
+			if flag {
+				continue
+			}
+			rem, err := p.ed.remIntraPredMode()
+			if err != nil {
+				return fmt.Errorf("rem_intra_pred_mode: %v", err)
+			}
+			mb.RemIntraPredMode[i] = uint8(rem)
+		}
+		if p.chromaArrayType == 1 || p.chromaArrayType == 2 {
+			v, err := p.ed.intraChromaPredMode(mb)
+			if err != nil {
+				return fmt.Errorf("intra_chroma_pred_mode: %v", err)
+			}
+			if v > 3 {
+				return fmt.Errorf("invalid intra_chroma_pred_mode %v", v)
+			}
+			mb.IntraChromaPredMode = uint8(v)
+		}
+		return nil
+	case PredDirect:
+		return nil
+	}
+
+	numMbPart := mb.MbType.NumMbPart()
+	w, h := mb.MbType.MbPartWidth(), mb.MbType.MbPartHeight()
+	for list := 0; list < 2; list++ {
+		for mbPartIdx := 0; mbPartIdx < numMbPart; mbPartIdx++ {
+			if !usesList(mb.MbType.MbPartPredMode(mbPartIdx), list) {
+				continue
+			}
+			x, y := mbPartIdx%(16/w)*w, mbPartIdx/(16/w)*h
+			refIdx, err := p.refIdx(mb, list, x, y, false)
+			if err != nil {
+				return err
+			}
+			for q := 0; q < 4; q++ {
+				if qx, qy := q%2*8, q/2*8; qx >= x && qx < x+w && qy >= y && qy < y+h {
+					mb.RefIdx[list][q] = int8(refIdx)
+				}
+			}
+		}
+	}
+	for list := 0; list < 2; list++ {
+		for mbPartIdx := 0; mbPartIdx < numMbPart; mbPartIdx++ {
+			if !usesList(mb.MbType.MbPartPredMode(mbPartIdx), list) {
+				continue
+			}
+			x, y := mbPartIdx%(16/w)*w, mbPartIdx/(16/w)*h
+			if err := p.mvd(mb, list, x, y, w, h); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// subMbPred T-REC-H.264-201402-S!!PDF-E.pdf 7.3.5.2 Sub-macroblock prediction syntax
+func (p *Parser) subMbPred(mb *Macroblock) error {
+	for i := range mb.SubMbType {
+		v, err := p.ed.subMbType()
+		if err != nil {
+			return fmt.Errorf("sub_mb_type: %v", err)
+		}
+		if mb.SubMbType[i], err = subMbTypeOf(p.h.SliceType, v); err != nil {
+			return err
+		}
+	}
+	for list := 0; list < 2; list++ {
+		for q, t := range mb.SubMbType {
+			if !usesList(t.SubMbPredMode(), list) {
+				continue
+			}
+			refIdx, err := p.refIdx(mb, list, q%2*8, q/2*8, mb.MbType == MbP8x8Ref0)
+			if err != nil {
+				return err
+			}
+			mb.RefIdx[list][q] = int8(refIdx)
+		}
+	}
+	for list := 0; list < 2; list++ {
+		for q, t := range mb.SubMbType {
+			if !usesList(t.SubMbPredMode(), list) {
+				continue
+			}
+			w, h := t.SubMbPartWidth(), t.SubMbPartHeight()
+			for i := 0; i < t.NumSubMbPart(); i++ {
+				x, y := q%2*8+i%(8/w)*w, q/2*8+i/(8/w)*h
+				if err := p.mvd(mb, list, x, y, w, h); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// usesList report whether prediction mode mode predicts from list 0 or 1,
+// direct predicted partitions have no ref_idx nor mvd
+func usesList(mode PredMode, list int) bool {
+	return mode == PredBi || (list == 0 && mode == PredL0) || (list == 1 && mode == PredL1)
+}
+
+// refIdx parse ref_idx_lX of the partition at ( x, y ) or infer it to 0 when not present
+func (p *Parser) refIdx(mb *Macroblock, list, x, y int, ref0 bool) (int, error) {
+	numRefIdxActiveMinus1 := int(p.h.NumRefIdxL0ActiveMinus1)
+	if list == 1 {
+		numRefIdxActiveMinus1 = int(p.h.NumRefIdxL1ActiveMinus1)
+	}
+	if ref0 || (numRefIdxActiveMinus1 == 0 && mb.FieldDecodingFlag == p.h.FieldPicFlag) {
+		return 0, nil
+	}
+	// a field macroblock of an MBAFF frame refers to the fields of the reference frames
+	maxRefIdx := numRefIdxActiveMinus1
+	if p.h.MbaffFrameFlag && mb.FieldDecodingFlag {
+		maxRefIdx = 2*numRefIdxActiveMinus1 + 1
+	}
+	refIdx, err := p.ed.refIdx(mb, list, x, y, maxRefIdx)
+	if err != nil {
+		return 0, fmt.Errorf("ref_idx_l%v: %v", list, err)
+	}
+	if refIdx < 0 || refIdx > maxRefIdx {
+		return 0, fmt.Errorf("invalid ref_idx_l%v %v", list, refIdx)
+	}
+	return refIdx, nil
+}
+
+// mvd parse mvd_lX of the w x h partition at ( x, y ), set to the 4x4 blocks it covers
+func (p *Parser) mvd(mb *Macroblock, list, x, y, w, h int) error {
+	var mvd [2]int32
+	for compIdx := range mvd {
+		v, err := p.ed.mvd(mb, list, compIdx, x, y)
+		if err != nil {
+			return fmt.Errorf("mvd_l%v: %v", list, err)
+		}
+		mvd[compIdx] = int32(v)
+	}
+	for by := y / 4; by < (y+h)/4; by++ {
+		for bx := x / 4; bx < (x+w)/4; bx++ {
+			mb.Mvd[list][4*by+bx] = mvd
+		}
+	}
+	return nil
+}
+
+// residual T-REC-H.264-201402-S!!PDF-E.pdf 7.3.5.3 Residual data syntax
+func (p *Parser) residual(mb *Macroblock, startIdx, endIdx int) error {
+	if err := p.residualLuma(mb, 0, startIdx, endIdx); err != nil {
+		return err
+	}
+	switch p.chromaArrayType {
+	case 1, 2:
+		numC8x8 := 4 / (p.sps.SubWidthC() * p.sps.SubHeightC())
+		for c := 1; c <= 2; c++ {
+			if mb.CodedBlockPatternChroma&3 == 0 || startIdx != 0 {
+				continue
+			}
+			n, err := p.ed.residualBlock(mb, cabac.CatChromaDC, c, 0, mb.DCLevels[c][:4*numC8x8], 0, 4*numC8x8-1, 4*numC8x8)
+			if err != nil {
+				return fmt.Errorf("chroma DC %v: %v", c, err)
+			}
+			mb.CodedDC[c] = n > 0
+		}
+		if mb.CodedBlockPatternChroma&2 == 0 {
+			return nil
+		}
+		for c := 1; c <= 2; c++ {
+			for blk := 0; blk < 4*numC8x8; blk++ {
+				n, err := p.ed.residualBlock(mb, cabac.CatChromaAC, c, blk, mb.Levels[c][16*blk+1:16*blk+16], imax(0, startIdx-1), endIdx-1, 15)
+				if err != nil {
+					return fmt.Errorf("chroma AC %v block %v: %v", c, blk, err)
+				}
+				mb.TotalCoeff[c][blk] = uint8(n)
+			}
+		}
+	case 3:
+		for c := 1; c <= 2; c++ {
+			if err := p.residualLuma(mb, c, startIdx, endIdx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// residualLuma T-REC-H.264-201402-S!!PDF-E.pdf 7.3.5.3.1 Residual luma syntax, for
+// luma, or Cb and Cr when ChromaArrayType is 3
+func (p *Parser) residualLuma(mb *Macroblock, c, startIdx, endIdx int) error {
+	cats := residualCats[c]
+	intra16x16 := mb.MbType.IsIntra16x16()
+	if startIdx == 0 && intra16x16 {
+		n, err := p.ed.residualBlock(mb, cats[0], c, 0, mb.DCLevels[c][:], 0, 15, 16)
+		if err != nil {
+			return fmt.Errorf("component %v DC: %v", c, err)
+		}
+		mb.CodedDC[c] = n > 0
+	}
+	for b8 := 0; b8 < 4; b8++ {
+		if mb.CodedBlockPatternLuma&(1<<b8) == 0 {
+			continue
+		}
+		if mb.TransformSize8x8Flag && p.cabac != nil {
+			n, err := p.ed.residualBlock(mb, cats[3], c, b8, mb.Level8x8(c, b8), 4*startIdx, 4*endIdx+3, 64)
+			if err != nil {
+				return fmt.Errorf("component %v 8x8 block %v: %v", c, b8, err)
+			}
+			for blk := 4 * b8; blk < 4*b8+4; blk++ {
+				mb.TotalCoeff[c][blk] = uint8(n)
+			}
+			continue
+		}
+		var level4x4 [4][16]int32
+		for b4 := 0; b4 < 4; b4++ {
+			blk := 4*b8 + b4
+			var n int
+			var err error
+			if intra16x16 {
+				n, err = p.ed.residualBlock(mb, cats[1], c, blk, level4x4[b4][1:], imax(0, startIdx-1), endIdx-1, 15)
+			} else {
+				n, err = p.ed.residualBlock(mb, cats[2], c, blk, level4x4[b4][:], startIdx, endIdx, 16)
+			}
+			if err != nil {
+				return fmt.Errorf("component %v block %v: %v", c, blk, err)
+			}
+			mb.TotalCoeff[c][blk] = uint8(n)
+		}
+		if mb.TransformSize8x8Flag {
+			// the 4x4 blocks of CAVLC interleave into the 8x8 block
+			level8x8 := mb.Level8x8(c, b8)
+			for b4 := range level4x4 {
+				for i, v := range level4x4[b4] {
+					level8x8[4*i+b4] = v
+				}
+			}
+			continue
+		}
+		for b4 := range level4x4 {
+			copy(mb.Level4x4(c, 4*b8+b4), level4x4[b4][:])
+		}
+	}
+	return nil
+}
+
+// readPcm read pcm_alignment_zero_bit and the pcm samples of an I_PCM macroblock
+// into Levels, T-REC-H.264-201402-S!!PDF-E.pdf 7.3.5
+func (p *Parser) readPcm(br *rbr.Reader, mb *Macroblock) error {
+	for !br.ByteAligned() {
+		b, err := br.Read1()
+		if err != nil {
+			return err
+		}
+		if b {
+			return fmt.Errorf("pcm_alignment_zero_bit is 1")
+		}
+	}
+	bitDepth := p.sps.BitDepthY()
+	for i := 0; i < 256; i++ {
+		v, err := br.Read16(bitDepth)
+		if err != nil {
+			return err
+		}
+		mb.Levels[0][i] = int32(v)
+	}
+	bitDepth = p.sps.BitDepthC()
+	for c := 1; c <= 2; c++ {
+		for i := 0; i < p.pic.MbWidthC*p.pic.MbHeightC; i++ {
+			v, err := br.Read16(bitDepth)
+			if err != nil {
+				return err
+			}
+			mb.Levels[c][i] = int32(v)
+		}
+	}
+	return nil
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func imax(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,196 @@
+package macroblock
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/LiveStudioSolution/h264decoder/internal"
+	"github.com/LiveStudioSolution/h264decoder/internal/slice"
+)
+
+func TestMbTypeOf(t *testing.T) {
+	tests := []struct {
+		sliceType slice.SliceType
+		raw       uint
+		want      string
+		wantErr   bool
+	}{
+		{slice.SliceI, 0, "I_NxN", false},
+		{slice.SliceI, 1, "I_16x16_0_0_0", false},
+		{slice.SliceI, 25, "I_PCM", false},
+		{slice.SliceI, 26, "", true},
+		{slice.SliceSI, 0, "SI", false},
+		{slice.SliceSI, 1, "I_NxN", false},
+		{slice.SliceP, 0, "P_L0_16x16", false},
+		{slice.SliceP, 4, "P_8x8ref0", false},
+		{slice.SliceP, 5, "I_NxN", false},
+		{slice.SliceP, 31, "", true},
+		{slice.SliceB, 0, "B_Direct_16x16", false},
+		{slice.SliceB, 22, "B_8x8", false},
+		{slice.SliceB, 23, "I_NxN", false},
+	}
+	for _, tt := range tests {
+		got, err := mbTypeOf(tt.sliceType, tt.raw)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("mbTypeOf(%v, %v) error = %v, wantErr %v", tt.sliceType, tt.raw, err, tt.wantErr)
+			continue
+		}
+		if err == nil && got.String() != tt.want {
+			t.Errorf("mbTypeOf(%v, %v) = %v, want %v", tt.sliceType, tt.raw, got, tt.want)
+		}
+	}
+}
+
+func testPicture(widthInMbs, heightInMbs int, mbaff bool) *Picture {
+	p := &Picture{
+		Mbs:            make([]Macroblock, widthInMbs*heightInMbs),
+		WidthInMbs:     widthInMbs,
+		MbaffFrameFlag: mbaff,
+		MbWidthC:       8,
+		MbHeightC:      8,
+	}
+	for i := range p.Mbs {
+		p.Mbs[i].Addr = i
+	}
+	return p
+}
+
+func TestLocation(t *testing.T) {
+	p := testPicture(3, 3, false)
+	tests := []struct {
+		curr, xN, yN int
+		luma         bool
+		want         [3]int
+	}{
+		{4, -1, 0, true, [3]int{3, 15, 0}},
+		{4, 0, -1, true, [3]int{1, 0, 15}},
+		{4, 16, -1, true, [3]int{2, 0, 15}},
+		{4, -1, -1, true, [3]int{0, 15, 15}},
+		{4, 5, 6, true, [3]int{4, 5, 6}},
+		{4, 16, 0, true, [3]int{-1, 0, 0}},
+		{4, -1, 3, false, [3]int{3, 7, 3}},
+		{3, -1, 0, true, [3]int{-1, 0, 0}},
+		{5, 16, -1, true, [3]int{-1, 0, 0}},
+		{1, 0, -1, true, [3]int{-1, 0, 0}},
+	}
+	for _, tt := range tests {
+		addr, xW, yW := p.Location(tt.curr, tt.xN, tt.yN, tt.luma)
+		if got := [3]int{addr, xW, yW}; got != tt.want {
+			t.Errorf("Location(%v, %v, %v) = %v, want %v", tt.curr, tt.xN, tt.yN, got, tt.want)
+		}
+	}
+	p.Mbs[3].SliceNum = 1
+	if addr, _, _ := p.Location(4, -1, 0, true); addr != -1 {
+		t.Errorf("Location() of macroblock in other slice = %v, want -1", addr)
+	}
+}
+
+func TestLocationMbaff(t *testing.T) {
+	// two macroblock pairs side by side on the second pair row, left pair 4/5, current 6/7
+	p := testPicture(2, 4, true)
+	tests := []struct {
+		name             string
+		curr             int
+		currField, field bool
+		xN, yN           int
+		want             [3]int
+	}{
+		{"frame top, frame left", 6, false, false, -1, 5, [3]int{4, 15, 5}},
+		{"frame top, field left", 6, false, true, -1, 5, [3]int{5, 15, 2}},
+		{"frame bottom, field left", 7, false, true, -1, 4, [3]int{4, 15, 10}},
+		{"field top, frame left", 6, true, false, -1, 9, [3]int{5, 15, 2}},
+		{"field bottom, frame left", 7, true, false, -1, 3, [3]int{4, 15, 7}},
+		{"frame bottom, above", 7, false, false, 3, -1, [3]int{6, 3, 15}},
+		{"frame top, above", 6, false, false, 3, -1, [3]int{3, 3, 15}},
+		{"field top, frame above", 6, true, false, 3, -1, [3]int{3, 3, 14}},
+		{"field bottom, above", 7, true, true, 3, -1, [3]int{3, 3, 15}},
+		{"frame bottom, above-right", 7, false, false, 16, -1, [3]int{-1, 0, 0}},
+		{"frame bottom, field above-left", 7, false, true, -1, -1, [3]int{5, 15, 7}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := range p.Mbs {
+				p.Mbs[i].FieldDecodingFlag = tt.field
+			}
+			p.Mbs[6].FieldDecodingFlag = tt.currField
+			p.Mbs[7].FieldDecodingFlag = tt.currField
+			addr, xW, yW := p.Location(tt.curr, tt.xN, tt.yN, true)
+			if got := [3]int{addr, xW, yW}; got != tt.want {
+				t.Errorf("Location(%v, %v, %v) = %v, want %v", tt.curr, tt.xN, tt.yN, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParserSample(t *testing.T) {
+	f, err := os.Open("../../docs/videosamples/txjg.h264")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer f.Close()
+	bs := internal.NewBitStream(f)
+	ps := internal.NewParameterSetStore()
+	slices, intra := 0, 0
+	for {
+		nl, err := bs.NextNalu()
+		if err != nil {
+			t.Fatalf("NextNalu() error = %v", err)
+		}
+		if nl == nil {
+			break
+		}
+		switch nl.Type() {
+		case internal.NaluSps:
+			_, err = ps.PutSps(nl.Rbsp())
+		case internal.NaluPps:
+			_, err = ps.PutPps(nl.Rbsp())
+		case internal.NaluSlice, internal.NaluSliceIdr:
+			var n, in int
+			n, in, err = parseSlice(nl, ps)
+			if err == nil && n != 0 {
+				t.Errorf("slice %v: %v macroblocks not parsed", slices, n)
+			}
+			intra += in
+			slices++
+		}
+		if err != nil {
+			t.Fatalf("slice %v: %v", slices, err)
+		}
+	}
+	if slices != 150 || intra == 0 {
+		t.Errorf("parsed %v slices, %v intra macroblocks", slices, intra)
+	}
+}
+
+// parseSlice parse the macroblocks of a single slice picture, returning the count of
+// macroblocks left unparsed and of intra ones
+func parseSlice(nl *internal.Nalu, ps *internal.ParameterSetStore) (int, int, error) {
+	h, err := slice.ParseHeader(nl, ps)
+	if err != nil {
+		return 0, 0, err
+	}
+	pic := NewPicture(h)
+	p, err := NewParser(pic, h, nl.Rbsp(), 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	left, intra := len(pic.Mbs), 0
+	for {
+		mb, err := p.Next()
+		if err == io.EOF {
+			return left, intra, nil
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+		if mb.IsIntra() {
+			intra++
+		}
+		if !mb.MbType.IsSkip() && (mb.QPY < -6*int(h.SPS.BitDepthLumaMinus8) || mb.QPY > 51) {
+			return 0, 0, fmt.Errorf("macroblock %v QPY %v out of range", mb.Addr, mb.QPY)
+		}
+		left--
+	}
+}
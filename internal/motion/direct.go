@@ -0,0 +1,333 @@
+package motion
+
+import (
+	"fmt"
+
+	"github.com/LiveStudioSolution/h264decoder/internal/inter"
+)
+
+// vertMvScale conversion of the vertical vector of the co-located partition
+type vertMvScale uint8
+
+const (
+	oneToOne vertMvScale = iota
+	frmToFld
+	fldToFrm
+)
+
+// direct derivation of the direct predicted quadrants of a B_Skip, B_Direct_16x16 or
+// B_8x8 macroblock, 8.4.1.2
+type direct struct {
+	s      *Slice
+	mbAddr int
+	// spatial reference indices and vector predictions, the same for all the quadrants
+	refIdx     [2]int
+	mvp        [2][2]int32
+	directZero bool
+}
+
+func (s *Slice) newDirect(mbAddr int) *direct {
+	d := &direct{s: s, mbAddr: mbAddr}
+	if s.DirectSpatialMvPredFlag {
+		d.spatialPred()
+	}
+	return d
+}
+
+// derive the motion of quadrant q, each of its 4x4 blocks or only its corner block with
+// direct_8x8_inference_flag
+func (d *direct) derive(q int) error {
+	for blk := 0; blk < 4; blk++ {
+		luma4x4BlkIdx := 4*q + blk
+		if d.s.Direct8x8InferenceFlag {
+			luma4x4BlkIdx = 5 * q
+		}
+		col, err := d.s.colocated(d.mbAddr, luma4x4BlkIdx)
+		if err != nil {
+			return err
+		}
+		var refIdx [2]int
+		var mv [2][2]int32
+		if d.s.DirectSpatialMvPredFlag {
+			refIdx, mv = d.spatial(col)
+		} else if refIdx, mv, err = d.temporal(col); err != nil {
+			return err
+		}
+		x, y := q%2*8+blk%2*4, q/2*8+blk/2*4
+		for l := range refIdx {
+			if refIdx[l] >= 0 {
+				d.s.store(d.mbAddr, l, x, y, 4, 4, refIdx[l], mv[l])
+			}
+		}
+	}
+	return nil
+}
+
+// spatialPred reference indices and vector predictions of spatial direct prediction
+// from the neighbouring partitions of the macroblock
+func (d *direct) spatialPred() {
+	for l := range d.refIdx {
+		nb := d.s.neighbours(d.mbAddr, 0, 0, 0, 0, 16, l)
+		d.refIdx[l] = minPositive(nb[0].refIdx, minPositive(nb[1].refIdx, nb[2].refIdx))
+		if d.refIdx[l] >= 0 {
+			d.mvp[l] = mvPred(nb, d.refIdx[l], 8, 8, 0)
+		}
+	}
+	if d.refIdx[0] < 0 && d.refIdx[1] < 0 {
+		d.refIdx = [2]int{0, 0}
+		d.directZero = true
+	}
+}
+
+func minPositive(x, y int) int {
+	if x >= 0 && y >= 0 {
+		if x < y {
+			return x
+		}
+		return y
+	}
+	if x > y {
+		return x
+	}
+	return y
+}
+
+// spatial motion of a 4x4 block with co-located partition col,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.4.1.2.2 Derivation process for spatial direct luma motion vector and reference index prediction mode
+func (d *direct) spatial(col *colocated) ([2]int, [2][2]int32) {
+	colZero := !d.s.Refs[1][0].LongTerm && col.refIdx == 0 &&
+		col.mv[0] >= -1 && col.mv[0] <= 1 && col.mv[1] >= -1 && col.mv[1] <= 1
+	var mv [2][2]int32
+	for l, refIdx := range d.refIdx {
+		if d.directZero || refIdx < 0 || refIdx == 0 && colZero {
+			continue
+		}
+		mv[l] = d.mvp[l]
+	}
+	return d.refIdx, mv
+}
+
+// temporal motion of a 4x4 block with co-located partition col,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.4.1.2.3 Derivation process for temporal direct luma motion vector and reference index prediction mode
+func (d *direct) temporal(col *colocated) ([2]int, [2][2]int32, error) {
+	s := d.s
+	curr := &s.Motion.Mbs[d.mbAddr]
+	refIdxL0 := 0
+	if col.refIdx >= 0 {
+		var err error
+		if refIdxL0, err = s.mapColToList0(d.mbAddr, col); err != nil {
+			return [2]int{}, [2][2]int32{}, err
+		}
+	}
+	mvCol := col.mv
+	switch col.vertMvScale {
+	case frmToFld:
+		mvCol[1] /= 2
+	case fldToFrm:
+		mvCol[1] *= 2
+	}
+
+	var currPoc, poc0, poc1 int
+	var ref0 *Ref
+	if !s.FieldPicFlag && curr.Field {
+		mbParity := parity(d.mbAddr%2 == 1)
+		refParity := mbParity
+		if refIdxL0%2 != 0 {
+			refParity = parity(mbParity == TopField)
+		}
+		if refIdxL0/2 >= len(s.Refs[0]) {
+			return [2]int{}, [2][2]int32{}, fmt.Errorf("invalid refIdxL0 %v of temporal direct", refIdxL0)
+		}
+		ref0 = s.Refs[0][refIdxL0/2]
+		currPoc = s.TopPoc
+		if mbParity == BottomField {
+			currPoc = s.BottomPoc
+		}
+		poc0, poc1 = ref0.fieldPoc(refParity), s.Refs[1][0].fieldPoc(mbParity)
+	} else {
+		if refIdxL0 >= len(s.Refs[0]) {
+			return [2]int{}, [2][2]int32{}, fmt.Errorf("invalid refIdxL0 %v of temporal direct", refIdxL0)
+		}
+		ref0 = s.Refs[0][refIdxL0]
+		currPoc = s.currPoc()
+		poc0, poc1 = ref0.Poc(), s.Refs[1][0].Poc()
+	}
+
+	var mv [2][2]int32
+	if ref0.LongTerm || poc1 == poc0 {
+		mv[0] = mvCol
+	} else {
+		dsf := int32(inter.DistScaleFactor(currPoc, poc0, poc1))
+		for c := range mvCol {
+			mv[0][c] = (dsf*mvCol[c] + 128) >> 8
+			mv[1][c] = mv[0][c] - mvCol[c]
+		}
+	}
+	return [2]int{refIdxL0, 0}, mv, nil
+}
+
+// currPoc PicOrderCnt( CurrPic )
+func (s *Slice) currPoc() int {
+	switch {
+	case s.FieldPicFlag && s.BottomFieldFlag:
+		return s.BottomPoc
+	case s.FieldPicFlag || s.TopPoc < s.BottomPoc:
+		return s.TopPoc
+	}
+	return s.BottomPoc
+}
+
+// mapColToList0 MapColToList0( refIdxCol ), the lowest index of RefPicList0 referring
+// to the reference picture of the co-located partition, or the frame containing it
+func (s *Slice) mapColToList0(mbAddr int, col *colocated) (int, error) {
+	if col.ref == nil {
+		return 0, fmt.Errorf("co-located reference index %v without reference picture", col.refIdx)
+	}
+	want := col.refStructure
+	if col.vertMvScale == frmToFld {
+		want = parity(s.BottomFieldFlag)
+	}
+	for i, ref := range s.Refs[0] {
+		if ref.ID != col.ref.ID || s.FieldPicFlag && ref.Structure != want {
+			continue
+		}
+		if s.FieldPicFlag || !s.Motion.Mbs[mbAddr].Field {
+			return i, nil
+		}
+		// field macroblock of a frame, index of the list of fields
+		if col.vertMvScale == oneToOne && col.refStructure != parity(mbAddr%2 == 1) {
+			return i<<1 + 1, nil
+		}
+		return i << 1, nil
+	}
+	return 0, fmt.Errorf("reference picture %v of the co-located partition not in RefPicList0", col.ref.ID)
+}
+
+// colocated co-located 4x4 sub-macroblock partition of a direct predicted block
+type colocated struct {
+	mv     [2]int32
+	refIdx int
+	// ref and refStructure reference picture refPicCol of the partition, a frame or one
+	// of its fields
+	ref          *Ref
+	refStructure Structure
+	vertMvScale  vertMvScale
+}
+
+// colocated motion of the co-located partition of 4x4 block luma4x4BlkIdx of macroblock
+// mbAddr, in the picture colPic of Table 8-6 and at the location of Table 8-8,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.4.1.2.1 Derivation process for the co-located 4x4 sub-macroblock partitions
+func (s *Slice) colocated(mbAddr, luma4x4BlkIdx int) (*colocated, error) {
+	if len(s.Refs[1]) == 0 {
+		return nil, fmt.Errorf("direct prediction with an empty RefPicList1")
+	}
+	ref1 := s.Refs[1][0]
+	currField := s.Motion.Mbs[mbAddr].Field
+	currPoc := s.currPoc()
+	topAbsDiffPoc, bottomAbsDiffPoc := abs(ref1.TopPoc-currPoc), abs(ref1.BottomPoc-currPoc)
+
+	var colPic *Picture
+	switch {
+	case ref1.Frame != nil:
+		colPic = ref1.Frame
+	case s.FieldPicFlag:
+		if ref1.Structure == Frame {
+			return nil, fmt.Errorf("frame in RefPicList1 of a field")
+		}
+		colPic = ref1.Fields[ref1.Structure-TopField]
+	case currField:
+		colPic = ref1.Fields[mbAddr&1]
+	case topAbsDiffPoc < bottomAbsDiffPoc:
+		colPic = ref1.Fields[0]
+	default:
+		colPic = ref1.Fields[1]
+	}
+	if colPic == nil {
+		return nil, fmt.Errorf("co-located picture without motion")
+	}
+
+	xCol := luma4x4BlkIdx/4%2*8 + luma4x4BlkIdx%2*4
+	yCol := luma4x4BlkIdx/8*8 + luma4x4BlkIdx%4/2*4
+	w := s.Motion.WidthInMbs
+	mbAddrCol, yM, scale := mbAddr, yCol, oneToOne
+	colFld := colPic.Structure != Frame
+	switch {
+	case s.FieldPicFlag && colFld:
+	case s.FieldPicFlag && !colPic.MbaffFrameFlag:
+		mbAddrCol = 2*w*(mbAddr/w) + mbAddr%w + w*(yCol/8)
+		yM, scale = 2*yCol%16, frmToFld
+	case s.FieldPicFlag:
+		if colPic.Mbs[2*mbAddr].Field {
+			mbAddrCol = 2*mbAddr + boolToInt(s.BottomFieldFlag)
+		} else {
+			mbAddrCol = 2*mbAddr + yCol/8
+			yM, scale = 2*yCol%16, frmToFld
+		}
+	case !s.Motion.MbaffFrameFlag && colFld:
+		mbAddrCol = w*(mbAddr/(2*w)) + mbAddr%w
+		yM, scale = 8*(mbAddr/w%2)+4*(yCol/8), fldToFrm
+	case !s.Motion.MbaffFrameFlag:
+	case colFld:
+		mbAddrCol = mbAddr / 2
+		if !currField {
+			yM, scale = 8*(mbAddr%2)+4*(yCol/8), fldToFrm
+		}
+	case !currField && colPic.Mbs[mbAddr].Field:
+		mbAddrCol = 2*(mbAddr/2) + boolToInt(topAbsDiffPoc >= bottomAbsDiffPoc)
+		yM, scale = 8*(mbAddr%2)+4*(yCol/8), fldToFrm
+	case currField && !colPic.Mbs[mbAddr].Field:
+		mbAddrCol = 2*(mbAddr/2) + yCol/8
+		yM, scale = 2*yCol%16, frmToFld
+	}
+	if mbAddrCol >= len(colPic.Mbs) {
+		return nil, fmt.Errorf("co-located macroblock %v out of picture", mbAddrCol)
+	}
+
+	col := &colocated{refIdx: -1, vertMvScale: scale}
+	mCol := &colPic.Mbs[mbAddrCol]
+	q, blk := 2*(yM/8)+xCol/8, 4*(yM/4)+xCol/4
+	l := 0
+	if mCol.Intra || !mCol.PredFlag(0, q) && !mCol.PredFlag(1, q) {
+		return col, nil
+	}
+	if !mCol.PredFlag(0, q) {
+		l = 1
+	}
+	col.mv, col.refIdx = mCol.Mv[l][blk], int(mCol.RefIdx[l][q])
+	if mCol.Refs == nil {
+		return col, nil
+	}
+	refs := mCol.Refs[l]
+	idx := col.refIdx
+	if colPic.Structure == Frame && mCol.Field {
+		// field macroblock of an MBAFF frame, refIdxCol indexes the fields of the frames
+		idx >>= 1
+	}
+	if idx >= len(refs) {
+		return nil, fmt.Errorf("invalid co-located reference index %v", col.refIdx)
+	}
+	col.ref = refs[idx]
+	col.refStructure = col.ref.Structure
+	if colPic.Structure == Frame && mCol.Field {
+		colParity := parity(mbAddrCol%2 == 1)
+		col.refStructure = colParity
+		if col.refIdx%2 != 0 {
+			col.refStructure = parity(colParity == TopField)
+		}
+	}
+	return col, nil
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
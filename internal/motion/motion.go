@@ -0,0 +1,230 @@
+// Package motion derive the motion vectors and reference indices of inter coded
+// macroblocks into a motion field of the picture, from the motion of the neighbouring
+// partitions and of the co-located picture for direct prediction,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.4.1 Derivation process for motion vector components and reference indices
+package motion
+
+import (
+	"fmt"
+
+	"github.com/LiveStudioSolution/h264decoder/internal/macroblock"
+	"github.com/LiveStudioSolution/h264decoder/internal/slice"
+)
+
+// Structure of a picture, a frame or one of its fields
+type Structure uint8
+
+const (
+	Frame Structure = iota
+	TopField
+	BottomField
+)
+
+// parity field structure of parity bottom
+func parity(bottom bool) Structure {
+	if bottom {
+		return BottomField
+	}
+	return TopField
+}
+
+// Ref reference picture of a reference picture list
+type Ref struct {
+	// ID identify the frame or complementary field pair the reference belongs to, the
+	// same for references to the frame and to each of its fields
+	ID int
+	// Structure frame for a frame or complementary field pair, else the field referenced
+	Structure Structure
+	// TopPoc and BottomPoc TopFieldOrderCnt and BottomFieldOrderCnt of the frame or pair
+	TopPoc    int
+	BottomPoc int
+	LongTerm  bool
+	// Frame motion of a reference decoded as a frame, Fields the motion of the top and
+	// bottom fields of one decoded as fields
+	Frame  *Picture
+	Fields [2]*Picture
+}
+
+// Poc PicOrderCnt( ) of the reference, 8.2.1
+func (r *Ref) Poc() int {
+	switch r.Structure {
+	case TopField:
+		return r.TopPoc
+	case BottomField:
+		return r.BottomPoc
+	}
+	if r.BottomPoc < r.TopPoc {
+		return r.BottomPoc
+	}
+	return r.TopPoc
+}
+
+// fieldPoc PicOrderCnt( ) of the field of parity s of the reference
+func (r *Ref) fieldPoc(s Structure) int {
+	if s == BottomField {
+		return r.BottomPoc
+	}
+	return r.TopPoc
+}
+
+// RefLists RefPicList0 and RefPicList1 of a slice
+type RefLists [2][]*Ref
+
+// Mb motion of one macroblock
+type Mb struct {
+	Intra bool
+	// Field macroblock of a field picture or field macroblock of an MBAFF frame
+	Field bool
+	// RefIdx refIdxL0 and refIdxL1 by 8x8 quadrant in raster order, -1 when the list is
+	// not used
+	RefIdx [2][4]int8
+	// Mv mvL0 and mvL1 by 4x4 block in raster order
+	Mv [2][16][2]int32
+	// Refs reference picture lists of the slice of the macroblock
+	Refs *RefLists
+}
+
+// PredFlag predFlagL0 or predFlagL1 of 8x8 quadrant q
+func (m *Mb) PredFlag(list, q int) bool {
+	return m.RefIdx[list][q] >= 0
+}
+
+// Picture motion field of a decoded frame or field, by macroblock address
+type Picture struct {
+	Structure      Structure
+	MbaffFrameFlag bool
+	WidthInMbs     int
+	Mbs            []Mb
+}
+
+// NewPicture allocate the motion field of a frame or field of sizeInMbs macroblocks
+func NewPicture(structure Structure, mbaffFrameFlag bool, widthInMbs, sizeInMbs int) *Picture {
+	return &Picture{
+		Structure:      structure,
+		MbaffFrameFlag: mbaffFrameFlag,
+		WidthInMbs:     widthInMbs,
+		Mbs:            make([]Mb, sizeInMbs),
+	}
+}
+
+// Slice the slice being decoded, what the derivations of its macroblocks depend on
+type Slice struct {
+	// Syntax macroblocks of the current picture as parsed, for mb_type, sub_mb_type,
+	// ref_idx, mvd and the neighbour derivations
+	Syntax *macroblock.Picture
+	// Motion of the current picture, filled by Derive
+	Motion    *Picture
+	Refs      RefLists
+	SliceType slice.SliceType
+
+	FieldPicFlag            bool
+	BottomFieldFlag         bool
+	DirectSpatialMvPredFlag bool
+	Direct8x8InferenceFlag  bool
+	// TopPoc and BottomPoc TopFieldOrderCnt and BottomFieldOrderCnt of the current
+	// picture, only the one of its parity for a field
+	TopPoc    int
+	BottomPoc int
+}
+
+// Derive the motion vectors and reference indices of macroblock mbAddr, parsed into
+// s.Syntax, and store them into s.Motion
+func (s *Slice) Derive(mbAddr int) error {
+	mb := &s.Syntax.Mbs[mbAddr]
+	m := &s.Motion.Mbs[mbAddr]
+	*m = Mb{Field: s.FieldPicFlag || mb.FieldDecodingFlag, Refs: &s.Refs}
+	for l := range m.RefIdx {
+		m.RefIdx[l] = [4]int8{-1, -1, -1, -1}
+	}
+	if mb.IsIntra() {
+		m.Intra = true
+		return nil
+	}
+	switch mb.MbType {
+	case macroblock.MbPSkip:
+		return s.pSkip(mbAddr)
+	case macroblock.MbBSkip, macroblock.MbBDirect16x16:
+		d := s.newDirect(mbAddr)
+		for q := 0; q < 4; q++ {
+			if err := d.derive(q); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if mb.MbType.NumMbPart() == 4 {
+		var d *direct
+		for q, t := range mb.SubMbType {
+			if t == macroblock.SubBDirect8x8 {
+				if d == nil {
+					d = s.newDirect(mbAddr)
+				}
+				if err := d.derive(q); err != nil {
+					return err
+				}
+				continue
+			}
+			w, h := t.SubMbPartWidth(), t.SubMbPartHeight()
+			for i := 0; i < t.NumSubMbPart(); i++ {
+				x, y := q%2*8+i%(8/w)*w, q/2*8+i/(8/w)*h
+				if err := s.partition(mbAddr, q, i, x, y, w, h, t.SubMbPredMode()); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	w, h := mb.MbType.MbPartWidth(), mb.MbType.MbPartHeight()
+	for i := 0; i < mb.MbType.NumMbPart(); i++ {
+		x, y := i%(16/w)*w, i/(16/w)*h
+		if err := s.partition(mbAddr, i, 0, x, y, w, h, mb.MbType.MbPartPredMode(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// partition derive the motion of the w x h partition at ( x, y ) of macroblock mbAddr
+// from ref_idx_lX and mvd_lX, mvLX = mvpLX + mvd_lX
+func (s *Slice) partition(mbAddr, mbPartIdx, subMbPartIdx, x, y, w, h int, mode macroblock.PredMode) error {
+	mb := &s.Syntax.Mbs[mbAddr]
+	for l := 0; l < 2; l++ {
+		if mode != macroblock.PredBi && mode != macroblock.PredL0+macroblock.PredMode(l) {
+			continue
+		}
+		refIdx := int(mb.RefIdx[l][2*(y/8)+x/8])
+		if refIdx < 0 {
+			return fmt.Errorf("macroblock %v partition %v without ref_idx_l%v", mbAddr, mbPartIdx, l)
+		}
+		nb := s.neighbours(mbAddr, mbPartIdx, subMbPartIdx, x, y, w, l)
+		mvp := mvPred(nb, refIdx, mb.MbType.MbPartWidth(), mb.MbType.MbPartHeight(), mbPartIdx)
+		mvd := mb.Mvd[l][4*(y/4)+x/4]
+		s.store(mbAddr, l, x, y, w, h, refIdx, [2]int32{mvp[0] + mvd[0], mvp[1] + mvd[1]})
+	}
+	return nil
+}
+
+// store the motion of list l of the w x h partition at ( x, y ) of macroblock mbAddr
+func (s *Slice) store(mbAddr, l, x, y, w, h, refIdx int, mv [2]int32) {
+	m := &s.Motion.Mbs[mbAddr]
+	for by := y / 4; by < (y+h)/4; by++ {
+		for bx := x / 4; bx < (x+w)/4; bx++ {
+			m.Mv[l][4*by+bx] = mv
+			m.RefIdx[l][2*(by/2)+bx/2] = int8(refIdx)
+		}
+	}
+}
+
+// pSkip motion of a P_Skip macroblock,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.4.1.1 Derivation process for luma motion vectors for skipped macroblocks in P and SP slices
+func (s *Slice) pSkip(mbAddr int) error {
+	nb := s.neighbours(mbAddr, 0, 0, 0, 0, 16, 0)
+	a, b := nb[0], nb[1]
+	var mv [2]int32
+	if a.available && b.available &&
+		!(a.refIdx == 0 && a.mv == [2]int32{}) && !(b.refIdx == 0 && b.mv == [2]int32{}) {
+		mv = mvPred(nb, 0, 16, 16, 0)
+	}
+	s.store(mbAddr, 0, 0, 0, 16, 16, 0, mv)
+	return nil
+}
@@ -0,0 +1,382 @@
+package motion
+
+import (
+	"testing"
+
+	"github.com/LiveStudioSolution/h264decoder/internal/macroblock"
+	"github.com/LiveStudioSolution/h264decoder/internal/slice"
+)
+
+// testSlice slice of a w x h macroblocks frame, all macroblocks in the slice
+func testSlice(w, h int, sliceType slice.SliceType) *Slice {
+	syn := &macroblock.Picture{Mbs: make([]macroblock.Macroblock, w*h), WidthInMbs: w}
+	for i := range syn.Mbs {
+		syn.Mbs[i].Addr = i
+	}
+	return &Slice{Syntax: syn, Motion: NewPicture(Frame, false, w, w*h), SliceType: sliceType}
+}
+
+// interMb motion of a macroblock of one 16x16 partition
+func interMb(refIdx [2]int, mv [2][2]int32) Mb {
+	var m Mb
+	for l := range refIdx {
+		for q := range m.RefIdx[l] {
+			m.RefIdx[l][q] = int8(refIdx[l])
+		}
+		for blk := range m.Mv[l] {
+			m.Mv[l][blk] = mv[l]
+		}
+	}
+	return m
+}
+
+func intraMb() Mb {
+	return interMb([2]int{-1, -1}, [2][2]int32{})
+}
+
+// l0 motion of list 0 only
+func l0(refIdx int, mv [2]int32) Mb {
+	return interMb([2]int{refIdx, -1}, [2][2]int32{mv})
+}
+
+// setSyntax mb_type, ref_idx_l0 and mvd_l0 of a P macroblock of one partition per quadrant
+// or 16x16 partition
+func setSyntax(s *Slice, mbAddr int, t macroblock.MbType, refIdx int, mvd [2]int32) *macroblock.Macroblock {
+	mb := &s.Syntax.Mbs[mbAddr]
+	mb.MbType = t
+	for q := range mb.RefIdx[0] {
+		mb.RefIdx[0][q] = int8(refIdx)
+		mb.RefIdx[1][q] = -1
+	}
+	for blk := range mb.Mvd[0] {
+		mb.Mvd[0][blk] = mvd
+	}
+	return mb
+}
+
+func TestMedian(t *testing.T) {
+	tests := []struct {
+		x, y, z, want int32
+	}{
+		{1, 2, 3, 2}, {3, 2, 1, 2}, {2, 3, 1, 2}, {-5, 7, 0, 0}, {4, 4, -1, 4},
+	}
+	for _, tt := range tests {
+		if got := median(tt.x, tt.y, tt.z); got != tt.want {
+			t.Errorf("median(%v, %v, %v) = %v, want %v", tt.x, tt.y, tt.z, got, tt.want)
+		}
+	}
+}
+
+func TestMvPred16x16(t *testing.T) {
+	tests := []struct {
+		name string
+		curr int
+		// a, b, c and d motion of macroblocks 3, 1, 2 and 0 of a 3x3 picture
+		mbs    map[int]Mb
+		refIdx int
+		want   [2]int32
+	}{
+		{"median", 4, map[int]Mb{3: l0(0, [2]int32{1, 2}), 1: l0(0, [2]int32{5, -3}), 2: l0(0, [2]int32{3, 7}), 0: intraMb()}, 0, [2]int32{3, 2}},
+		{"one reference index matches", 4, map[int]Mb{3: l0(1, [2]int32{1, 2}), 1: l0(0, [2]int32{5, -3}), 2: l0(1, [2]int32{3, 7}), 0: intraMb()}, 0, [2]int32{5, -3}},
+		{"intra neighbours", 4, map[int]Mb{3: intraMb(), 1: l0(0, [2]int32{5, -3}), 2: intraMb(), 0: intraMb()}, 0, [2]int32{5, -3}},
+		{"no match", 4, map[int]Mb{3: l0(1, [2]int32{1, 2}), 1: l0(2, [2]int32{5, -3}), 2: intraMb(), 0: intraMb()}, 0, [2]int32{1, 0}},
+		// C of macroblock 5 is outside the picture, D is macroblock 1
+		{"C replaced by D", 5, map[int]Mb{4: l0(0, [2]int32{1, 2}), 2: l0(0, [2]int32{5, -3}), 1: l0(0, [2]int32{3, 7})}, 0, [2]int32{3, 2}},
+		// top row, B and C not available
+		{"only A available", 1, map[int]Mb{0: l0(1, [2]int32{-6, 9})}, 0, [2]int32{-6, 9}},
+	}
+	for _, tt := range tests {
+		s := testSlice(3, 3, slice.SliceP)
+		for addr, m := range tt.mbs {
+			s.Motion.Mbs[addr] = m
+		}
+		setSyntax(s, tt.curr, macroblock.MbPL016x16, tt.refIdx, [2]int32{1, -1})
+		if err := s.Derive(tt.curr); err != nil {
+			t.Fatalf("%v: %v", tt.name, err)
+		}
+		want := [2]int32{tt.want[0] + 1, tt.want[1] - 1}
+		m := &s.Motion.Mbs[tt.curr]
+		if m.Mv[0][0] != want || m.Mv[0][15] != want || m.RefIdx[0] != [4]int8{0, 0, 0, 0} || m.RefIdx[1] != [4]int8{-1, -1, -1, -1} {
+			t.Errorf("%v: Derive() = %v %v, want %v", tt.name, m.Mv[0][0], m.RefIdx, want)
+		}
+	}
+}
+
+func TestMvPredDirectional(t *testing.T) {
+	a, b, c := l0(0, [2]int32{1, 1}), l0(0, [2]int32{2, 2}), l0(0, [2]int32{3, 3})
+	tests := []struct {
+		name     string
+		t        macroblock.MbType
+		a, b, c  Mb
+		want0    [2]int32
+		want1    [2]int32
+		partBlk1 int
+	}{
+		// 16x8: the upper partition predicted from B, the lower one from A
+		{"16x8", macroblock.MbPL016x16 + 1, a, b, c, [2]int32{2, 2}, [2]int32{1, 1}, 8},
+		// 8x16: the left partition predicted from A, the right one from C
+		{"8x16", macroblock.MbPL016x16 + 2, a, b, c, [2]int32{1, 1}, [2]int32{3, 3}, 2},
+		// reference index not matching, median of A, B and C, the lower partition having
+		// the upper one as B and C replaced by D
+		{"16x8 median", macroblock.MbPL016x16 + 1, a, l0(1, [2]int32{2, 2}), c, [2]int32{2, 2}, [2]int32{1, 1}, 8},
+	}
+	for _, tt := range tests {
+		s := testSlice(3, 3, slice.SliceP)
+		s.Motion.Mbs[0], s.Motion.Mbs[1], s.Motion.Mbs[2], s.Motion.Mbs[3] = intraMb(), tt.b, tt.c, tt.a
+		setSyntax(s, 4, tt.t, 0, [2]int32{})
+		if err := s.Derive(4); err != nil {
+			t.Fatal(err)
+		}
+		m := &s.Motion.Mbs[4]
+		if m.Mv[0][0] != tt.want0 || m.Mv[0][tt.partBlk1] != tt.want1 {
+			t.Errorf("%v: Derive() = %v %v, want %v %v", tt.name, m.Mv[0][0], m.Mv[0][tt.partBlk1], tt.want0, tt.want1)
+		}
+	}
+}
+
+func TestMvPredSubPartitions(t *testing.T) {
+	s := testSlice(3, 3, slice.SliceP)
+	for i := 0; i < 4; i++ {
+		s.Motion.Mbs[i] = intraMb()
+	}
+	mb := setSyntax(s, 4, macroblock.MbP8x8, 0, [2]int32{})
+	mb.SubMbType = [4]macroblock.SubMbType{3, 0, 0, 0}
+	// mvd of the 4x4 sub-macroblock partitions of quadrant 0 in raster order
+	mb.Mvd[0][0], mb.Mvd[0][1], mb.Mvd[0][4], mb.Mvd[0][5] = [2]int32{8, 0}, [2]int32{1, 2}, [2]int32{0, 4}, [2]int32{-2, 0}
+	if err := s.Derive(4); err != nil {
+		t.Fatal(err)
+	}
+	m := &s.Motion.Mbs[4]
+	m0 := [2]int32{8, 0}
+	// A only
+	m1 := [2]int32{8 + 1, 0 + 2}
+	// median of the intra A, sub-partition 0 as B and 1 as C
+	m2 := [2]int32{median(0, 8, 9) + 0, median(0, 0, 2) + 4}
+	// C not yet decoded, median of A, B and D
+	m3 := [2]int32{median(m2[0], m1[0], m0[0]) - 2, median(m2[1], m1[1], m0[1])}
+	if got := [4][2]int32{m.Mv[0][0], m.Mv[0][1], m.Mv[0][4], m.Mv[0][5]}; got != [4][2]int32{m0, m1, m2, m3} {
+		t.Errorf("Derive() = %v, want %v", got, [4][2]int32{m0, m1, m2, m3})
+	}
+}
+
+func TestPSkip(t *testing.T) {
+	tests := []struct {
+		name string
+		curr int
+		mbs  map[int]Mb
+		want [2]int32
+	}{
+		{"A not available", 3, map[int]Mb{0: l0(0, [2]int32{4, 4}), 1: l0(0, [2]int32{4, 4})}, [2]int32{}},
+		{"B not available", 1, map[int]Mb{0: l0(0, [2]int32{4, 4})}, [2]int32{}},
+		{"A zero motion", 4, map[int]Mb{3: l0(0, [2]int32{}), 1: l0(0, [2]int32{4, 4}), 2: l0(0, [2]int32{4, 4})}, [2]int32{}},
+		{"B zero motion", 4, map[int]Mb{3: l0(0, [2]int32{4, 4}), 1: l0(0, [2]int32{}), 2: l0(0, [2]int32{4, 4})}, [2]int32{}},
+		{"A zero motion of reference 1", 4, map[int]Mb{3: l0(1, [2]int32{}), 1: l0(0, [2]int32{4, 4}), 2: l0(0, [2]int32{4, 6})}, [2]int32{4, 4}},
+		{"intra A", 4, map[int]Mb{3: intraMb(), 1: l0(0, [2]int32{4, 4}), 2: l0(0, [2]int32{4, 6})}, [2]int32{4, 4}},
+		{"median", 4, map[int]Mb{3: l0(0, [2]int32{1, 9}), 1: l0(0, [2]int32{4, 4}), 2: l0(0, [2]int32{-4, 6})}, [2]int32{1, 6}},
+	}
+	for _, tt := range tests {
+		s := testSlice(3, 3, slice.SliceP)
+		for i := range s.Motion.Mbs {
+			s.Motion.Mbs[i] = intraMb()
+		}
+		for addr, m := range tt.mbs {
+			s.Motion.Mbs[addr] = m
+		}
+		s.Syntax.Mbs[tt.curr].MbType = macroblock.MbPSkip
+		if err := s.Derive(tt.curr); err != nil {
+			t.Fatal(err)
+		}
+		m := &s.Motion.Mbs[tt.curr]
+		if m.Mv[0][0] != tt.want || m.RefIdx[0][3] != 0 || m.PredFlag(1, 0) {
+			t.Errorf("%v: Derive() = %v %v, want %v", tt.name, m.Mv[0][0], m.RefIdx, tt.want)
+		}
+	}
+}
+
+func TestMvPredMbaff(t *testing.T) {
+	s := testSlice(2, 2, slice.SliceP)
+	s.Syntax.MbaffFrameFlag = true
+	s.Motion.MbaffFrameFlag = true
+	// left pair of field macroblocks, current pair of frame macroblocks
+	s.Syntax.Mbs[0].FieldDecodingFlag, s.Syntax.Mbs[1].FieldDecodingFlag = true, true
+	s.Motion.Mbs[0] = l0(2, [2]int32{4, 3})
+	s.Motion.Mbs[1] = l0(3, [2]int32{5, -5})
+	s.Motion.Mbs[0].Field, s.Motion.Mbs[1].Field = true, true
+	setSyntax(s, 2, macroblock.MbPL016x16, 1, [2]int32{})
+	if err := s.Derive(2); err != nil {
+		t.Fatal(err)
+	}
+	// A of the top frame macroblock is the top field macroblock, refIdx 2 / 2 and mv[ 1 ] * 2
+	if got := s.Motion.Mbs[2].Mv[0][0]; got != [2]int32{4, 6} {
+		t.Errorf("Derive(frame macroblock) = %v, want [4 6]", got)
+	}
+
+	// field macroblock with a frame neighbour, refIdx * 2 and mv[ 1 ] / 2
+	s.Syntax.Mbs[0].FieldDecodingFlag, s.Syntax.Mbs[1].FieldDecodingFlag = false, false
+	s.Syntax.Mbs[2].FieldDecodingFlag, s.Syntax.Mbs[3].FieldDecodingFlag = true, true
+	s.Motion.Mbs[0] = l0(1, [2]int32{4, -3})
+	s.Motion.Mbs[1] = l0(1, [2]int32{5, 7})
+	setSyntax(s, 2, macroblock.MbPL016x16, 2, [2]int32{})
+	if err := s.Derive(2); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Motion.Mbs[2].Mv[0][0]; got != [2]int32{4, -1} {
+		t.Errorf("Derive(field macroblock) = %v, want [4 -1]", got)
+	}
+}
+
+// testRefs frames of picture order count pocs, decoded as frames of w x h macroblocks
+func testRefs(w, h int, pocs ...int) []*Ref {
+	refs := make([]*Ref, len(pocs))
+	for i, poc := range pocs {
+		refs[i] = &Ref{ID: i, TopPoc: poc, BottomPoc: poc, Frame: NewPicture(Frame, false, w, w*h)}
+		for j := range refs[i].Frame.Mbs {
+			refs[i].Frame.Mbs[j] = intraMb()
+		}
+	}
+	return refs
+}
+
+func TestSpatialDirect(t *testing.T) {
+	refs := testRefs(3, 3, 0, 8)
+	s := testSlice(3, 3, slice.SliceB)
+	s.DirectSpatialMvPredFlag, s.Direct8x8InferenceFlag = true, true
+	s.TopPoc, s.BottomPoc = 4, 4
+	s.Refs = RefLists{{refs[0], refs[1]}, {refs[1], refs[0]}}
+	for i := range s.Motion.Mbs {
+		s.Motion.Mbs[i] = intraMb()
+	}
+	s.Motion.Mbs[3] = l0(1, [2]int32{4, 4})
+	s.Motion.Mbs[1] = interMb([2]int{0, 0}, [2][2]int32{{8, 0}, {-2, -2}})
+	// co-located: quadrant 0 without motion, 3 with motion, 1 and 2 intra
+	col := &refs[1].Frame.Mbs[4]
+	*col = interMb([2]int{0, -1}, [2][2]int32{{3, 0}})
+	col.Mv[0][0] = [2]int32{1, -1}
+	col.RefIdx[0][1], col.RefIdx[0][2] = -1, -1
+	s.Syntax.Mbs[4].MbType = macroblock.MbBSkip
+	if err := s.Derive(4); err != nil {
+		t.Fatal(err)
+	}
+	m := &s.Motion.Mbs[4]
+	if m.RefIdx != [2][4]int8{{0, 0, 0, 0}, {0, 0, 0, 0}} {
+		t.Errorf("Derive() refIdx = %v, want all 0", m.RefIdx)
+	}
+	want := [2][4][2]int32{
+		{{0, 0}, {8, 0}, {8, 0}, {8, 0}},
+		{{0, 0}, {-2, -2}, {-2, -2}, {-2, -2}},
+	}
+	for l := range want {
+		for q, blk := range [4]int{0, 2, 8, 10} {
+			if m.Mv[l][blk] != want[l][q] || m.Mv[l][blk+5] != want[l][q] {
+				t.Errorf("Derive() mvL%v quadrant %v = %v, want %v", l, q, m.Mv[l][blk], want[l][q])
+			}
+		}
+	}
+
+	// neighbours without motion, both lists from reference 0 with zero vectors
+	s.Motion.Mbs[3], s.Motion.Mbs[1] = intraMb(), intraMb()
+	if err := s.Derive(4); err != nil {
+		t.Fatal(err)
+	}
+	if m.RefIdx != [2][4]int8{{0, 0, 0, 0}, {0, 0, 0, 0}} || m.Mv[0][15] != [2]int32{} || m.Mv[1][15] != [2]int32{} {
+		t.Errorf("Derive(directZeroPredictionFlag) = %v %v %v", m.RefIdx, m.Mv[0][15], m.Mv[1][15])
+	}
+
+	// only list 1 from the neighbours
+	s.Motion.Mbs[1] = interMb([2]int{-1, 1}, [2][2]int32{{}, {6, 2}})
+	if err := s.Derive(4); err != nil {
+		t.Fatal(err)
+	}
+	if m.RefIdx != [2][4]int8{{-1, -1, -1, -1}, {1, 1, 1, 1}} || m.Mv[1][0] != [2]int32{6, 2} {
+		t.Errorf("Derive(list 1 only) = %v %v", m.RefIdx, m.Mv[1][0])
+	}
+}
+
+func TestTemporalDirect(t *testing.T) {
+	refs := testRefs(3, 3, 0, 2, 8)
+	s := testSlice(3, 3, slice.SliceB)
+	s.Direct8x8InferenceFlag = true
+	s.TopPoc, s.BottomPoc = 4, 4
+	s.Refs = RefLists{{refs[0], refs[1]}, {refs[2]}}
+	colRefs := &RefLists{{refs[1]}, {refs[0]}}
+	col := &refs[2].Frame.Mbs[4]
+	// quadrant 0 intra like, 1 list 0, 2 list 1, 3 list 0 long-term in the current lists
+	*col = interMb([2]int{0, 0}, [2][2]int32{{8, -4}, {-6, 2}})
+	col.Refs = colRefs
+	col.RefIdx[0][0], col.RefIdx[1][0] = -1, -1
+	col.RefIdx[0][2] = -1
+	s.Syntax.Mbs[4].MbType = macroblock.MbBDirect16x16
+	if err := s.Derive(4); err != nil {
+		t.Fatal(err)
+	}
+	m := &s.Motion.Mbs[4]
+	// refIdxL0 of quadrant 1 maps refs[ 1 ] to 1, of quadrant 2 refs[ 0 ] from list 1 to 0
+	if m.RefIdx != [2][4]int8{{0, 1, 0, 1}, {0, 0, 0, 0}} {
+		t.Errorf("Derive() refIdx = %v", m.RefIdx)
+	}
+	// DistScaleFactor 85 from POC 2 to 8, 128 from POC 0 to 8
+	want := [4][2][2]int32{
+		{{0, 0}, {0, 0}},
+		{{3, -1}, {-5, 3}},
+		{{-3, 1}, {3, -1}},
+		{{3, -1}, {-5, 3}},
+	}
+	for q, blk := range [4]int{0, 2, 8, 10} {
+		if got := [2][2]int32{m.Mv[0][blk], m.Mv[1][blk]}; got != want[q] {
+			t.Errorf("Derive() quadrant %v = %v, want %v", q, got, want[q])
+		}
+	}
+
+	refs[1].LongTerm = true
+	if err := s.Derive(4); err != nil {
+		t.Fatal(err)
+	}
+	if got := [2][2]int32{m.Mv[0][2], m.Mv[1][2]}; got != [2][2]int32{{8, -4}, {0, 0}} {
+		t.Errorf("Derive(long-term) = %v", got)
+	}
+
+	// reference of the co-located partition missing from RefPicList0
+	s.Refs[0] = s.Refs[0][:1]
+	if err := s.Derive(4); err == nil {
+		t.Errorf("Derive(missing reference) succeeded")
+	}
+}
+
+func TestTemporalDirectField(t *testing.T) {
+	// a top field of a picture one macroblock wide, co-located in a frame decoded as a frame
+	refs := testRefs(1, 4, 0, 8)
+	top0 := &Ref{ID: refs[0].ID, Structure: TopField, TopPoc: 0, BottomPoc: 1, Frame: refs[0].Frame}
+	bottom0 := &Ref{ID: refs[0].ID, Structure: BottomField, TopPoc: 0, BottomPoc: 1, Frame: refs[0].Frame}
+	top1 := &Ref{ID: refs[1].ID, Structure: TopField, TopPoc: 8, BottomPoc: 9, Frame: refs[1].Frame}
+	s := testSlice(1, 2, slice.SliceB)
+	s.Motion.Structure = TopField
+	s.FieldPicFlag, s.Direct8x8InferenceFlag = true, true
+	s.TopPoc = 4
+	s.Refs = RefLists{{bottom0, top0}, {top1}}
+	// field macroblock 1 covers frame macroblocks 2 and 3, its quadrant 2 corner 3
+	col := &refs[1].Frame.Mbs[3]
+	*col = interMb([2]int{0, -1}, [2][2]int32{{2, 6}})
+	col.Refs = &RefLists{{refs[0]}}
+	s.Syntax.Mbs[1].MbType = macroblock.MbBSkip
+	if err := s.Derive(1); err != nil {
+		t.Fatal(err)
+	}
+	m := &s.Motion.Mbs[1]
+	// the top field of refs[ 0 ], mvCol[ 1 ] / 2 and DistScaleFactor 128
+	if m.RefIdx[0][2] != 1 || m.Mv[0][8] != [2]int32{1, 2} || m.Mv[1][8] != [2]int32{-1, -1} {
+		t.Errorf("Derive() = %v %v %v", m.RefIdx, m.Mv[0][8], m.Mv[1][8])
+	}
+}
+
+func TestDeriveErrors(t *testing.T) {
+	s := testSlice(1, 1, slice.SliceB)
+	s.Syntax.Mbs[0].MbType = macroblock.MbBSkip
+	if err := s.Derive(0); err == nil {
+		t.Errorf("Derive(empty RefPicList1) succeeded")
+	}
+	s = testSlice(1, 1, slice.SliceP)
+	setSyntax(s, 0, macroblock.MbPL016x16, -1, [2]int32{})
+	if err := s.Derive(0); err == nil {
+		t.Errorf("Derive(missing ref_idx_l0) succeeded")
+	}
+}
@@ -0,0 +1,123 @@
+package motion
+
+import "github.com/LiveStudioSolution/h264decoder/internal/macroblock"
+
+// neighbour motion data mvLXN and refIdxLXN of a neighbouring partition, refIdx is -1
+// for partitions not available, intra coded or not using the list
+type neighbour struct {
+	available bool
+	refIdx    int
+	mv        [2]int32
+}
+
+// neighbours motion data of list l of the neighbouring partitions A, B and C of the
+// partition at ( x, y ), D replacing C when not available, with the vertical vectors
+// and reference indices of frame and field macroblocks converted to those of the
+// current one,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.4.1.3.2 Derivation process for motion data of neighbouring partitions
+func (s *Slice) neighbours(mbAddr, mbPartIdx, subMbPartIdx, x, y, predPartWidth, l int) [3]neighbour {
+	nb := [3]neighbour{
+		s.neighbour(mbAddr, mbPartIdx, subMbPartIdx, x-1, y, l),
+		s.neighbour(mbAddr, mbPartIdx, subMbPartIdx, x, y-1, l),
+		s.neighbour(mbAddr, mbPartIdx, subMbPartIdx, x+predPartWidth, y-1, l),
+	}
+	if !nb[2].available {
+		nb[2] = s.neighbour(mbAddr, mbPartIdx, subMbPartIdx, x-1, y-1, l)
+	}
+	return nb
+}
+
+// neighbour motion data of list l of the partition covering luma location ( xN, yN )
+// relative to macroblock mbAddr, 6.4.11.7. Partitions of the current macroblock
+// following partition mbPartIdx\subMbPartIdx are not yet decoded, not available.
+func (s *Slice) neighbour(mbAddr, mbPartIdx, subMbPartIdx, xN, yN, l int) neighbour {
+	mbAddrN, xW, yW := s.Syntax.Location(mbAddr, xN, yN, true)
+	if mbAddrN < 0 {
+		return neighbour{refIdx: -1}
+	}
+	if mbAddrN == mbAddr && later(&s.Syntax.Mbs[mbAddr], mbPartIdx, subMbPartIdx, xW, yW) {
+		return neighbour{refIdx: -1}
+	}
+	mN := &s.Motion.Mbs[mbAddrN]
+	refIdx := int(mN.RefIdx[l][2*(yW/8)+xW/8])
+	if mN.Intra || refIdx < 0 {
+		return neighbour{available: true, refIdx: -1}
+	}
+	mv := mN.Mv[l][4*(yW/4)+xW/4]
+	curr := &s.Motion.Mbs[mbAddr]
+	switch {
+	case curr.Field && !mN.Field:
+		mv[1] /= 2
+		refIdx *= 2
+	case !curr.Field && mN.Field:
+		mv[1] *= 2
+		refIdx /= 2
+	}
+	return neighbour{available: true, refIdx: refIdx, mv: mv}
+}
+
+// later report whether the partition covering ( x, y ) of mb follows partition
+// mbPartIdx\subMbPartIdx in decoding order
+func later(mb *macroblock.Macroblock, mbPartIdx, subMbPartIdx, x, y int) bool {
+	if mb.MbType.NumMbPart() != 4 {
+		w, h := mb.MbType.MbPartWidth(), mb.MbType.MbPartHeight()
+		return (16/w)*(y/h)+x/w > mbPartIdx
+	}
+	if q := 2*(y/8) + x/8; q != mbPartIdx {
+		return q > mbPartIdx
+	}
+	t := mb.SubMbType[mbPartIdx]
+	w, h := t.SubMbPartWidth(), t.SubMbPartHeight()
+	return (8/w)*(y%8/h)+x%8/w > subMbPartIdx
+}
+
+// mvPred mvpLX of a partition of refIdxLX from the neighbouring partitions, with the
+// directional prediction of 16x8 and 8x16 partitions, partWidth and partHeight being
+// MbPartWidth( mb_type ) and MbPartHeight( mb_type ),
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.4.1.3 Derivation process for luma motion vector prediction
+func mvPred(nb [3]neighbour, refIdx, partWidth, partHeight, mbPartIdx int) [2]int32 {
+	a, b, c := nb[0], nb[1], nb[2]
+	switch {
+	case partWidth == 16 && partHeight == 8 && mbPartIdx == 0 && b.refIdx == refIdx:
+		return b.mv
+	case partWidth == 16 && partHeight == 8 && mbPartIdx == 1 && a.refIdx == refIdx:
+		return a.mv
+	case partWidth == 8 && partHeight == 16 && mbPartIdx == 0 && a.refIdx == refIdx:
+		return a.mv
+	case partWidth == 8 && partHeight == 16 && mbPartIdx == 1 && c.refIdx == refIdx:
+		return c.mv
+	}
+	return medianPred(nb, refIdx)
+}
+
+// medianPred T-REC-H.264-201402-S!!PDF-E.pdf 8.4.1.3.1 Derivation process for median luma motion vector prediction
+func medianPred(nb [3]neighbour, refIdx int) [2]int32 {
+	a, b, c := nb[0], nb[1], nb[2]
+	if !b.available && !c.available && a.available {
+		b, c = a, a
+	}
+	match, n := neighbour{}, 0
+	for _, v := range [3]neighbour{a, b, c} {
+		if v.refIdx == refIdx {
+			match = v
+			n++
+		}
+	}
+	if n == 1 {
+		return match.mv
+	}
+	return [2]int32{median(a.mv[0], b.mv[0], c.mv[0]), median(a.mv[1], b.mv[1], c.mv[1])}
+}
+
+func median(x, y, z int32) int32 {
+	if x > y {
+		x, y = y, x
+	}
+	if y > z {
+		y = z
+	}
+	if x > y {
+		return x
+	}
+	return y
+}
@@ -38,6 +38,20 @@ const (
 	NaluEostream NaluType = 11
 	// Filler data
 	NaluFiller NaluType = 12
+	// Sequence parameter set extension
+	NaluSpsExt NaluType = 13
+	// Prefix NAL unit, carry svc or mvc header extension of the following slice
+	NaluPrefix NaluType = 14
+	// Subset sequence parameter set
+	NaluSubsetSps NaluType = 15
+	// Depth parameter set
+	NaluDps NaluType = 16
+	// Coded slice of an auxiliary coded picture without partitioning
+	NaluSliceAux NaluType = 19
+	// Coded slice extension, svc or mvc
+	NaluSliceExt NaluType = 20
+	// Coded slice extension for a depth view component or a 3D-AVC texture view component
+	NaluSliceExtDepth NaluType = 21
 )
 
 func (nt NaluType) String() string {
@@ -68,16 +82,94 @@ func (nt NaluType) String() string {
 		return "NaluEostream"
 	case NaluFiller:
 		return "NaluFiller"
+	case NaluSpsExt:
+		return "NaluSpsExt"
+	case NaluPrefix:
+		return "NaluPrefix"
+	case NaluSubsetSps:
+		return "NaluSubsetSps"
+	case NaluDps:
+		return "NaluDps"
+	case NaluSliceAux:
+		return "NaluSliceAux"
+	case NaluSliceExt:
+		return "NaluSliceExt"
+	case NaluSliceExtDepth:
+		return "NaluSliceExtDepth"
+	}
+	if nt.IsReserved() {
+		return fmt.Sprintf("NaluReserved:%d", nt)
 	}
 	return fmt.Sprintf("NaluUnspecified:%d", nt)
 }
 
+// IsReserved return true for nal_unit_type reserved by Table 7-1, 17..18 and 22..23
+func (nt NaluType) IsReserved() bool {
+	return nt == 17 || nt == 18 || nt == 22 || nt == 23
+}
+
+// IsUnspecified return true for nal_unit_type 0 and 24..31
+func (nt NaluType) IsUnspecified() bool {
+	return nt == NaluUnspecified || nt >= 24
+}
+
+// HasHeaderExtension return true when nal unit header carry an svc, mvc or
+// 3D-AVC extension, 7.3.1
+func (nt NaluType) HasHeaderExtension() bool {
+	return nt == NaluPrefix || nt == NaluSliceExt || nt == NaluSliceExtDepth
+}
+
+// NaluHeaderSvcExtension nal_unit_header_svc_extension
+// T-REC-H.264-201402-S!!PDF-E.pdf G.7.3.1.1
+type NaluHeaderSvcExtension struct {
+	IdrFlag              bool
+	PriorityId           uint8
+	NoInterLayerPredFlag bool
+	DependencyId         uint8
+	QualityId            uint8
+	TemporalId           uint8
+	UseRefBasePicFlag    bool
+	DiscardableFlag      bool
+	OutputFlag           bool
+}
+
+// NaluHeaderMvcExtension nal_unit_header_mvc_extension
+// T-REC-H.264-201402-S!!PDF-E.pdf H.7.3.1.1
+type NaluHeaderMvcExtension struct {
+	NonIdrFlag    bool
+	PriorityId    uint8
+	ViewId        uint16
+	TemporalId    uint8
+	AnchorPicFlag bool
+	InterViewFlag bool
+}
+
+// NaluHeader3davcExtension nal_unit_header_3davc_extension
+// T-REC-H.264-201402-S!!PDF-E.pdf J.7.3.1.1
+type NaluHeader3davcExtension struct {
+	ViewIdx       uint8
+	DepthFlag     bool
+	NonIdrFlag    bool
+	TemporalId    uint8
+	AnchorPicFlag bool
+	InterViewFlag bool
+}
+
 // Nalu  of h264 codec
 type Nalu struct {
 	rbsp   []byte
 	refIdc uint8
 	uType  NaluType
 	br     bitreader.BitReader
+
+	// nalUnitHeaderBytes, 1 plus the size of header extension
+	headerSize int
+	svcExt     *NaluHeaderSvcExtension
+	mvcExt     *NaluHeaderMvcExtension
+	avc3dExt   *NaluHeader3davcExtension
+
+	// rbsp byte index in front of which an emulation_prevention_three_byte was removed
+	epb []int
 }
 
 //NewNalu  create new Nalu
@@ -91,15 +183,24 @@ func (nl *Nalu) Load(data []byte) error {
 	if data == nil || len(data) < 1 {
 		return fmt.Errorf("invalid nalu data")
 	}
-	nl.rbsp = data[1:]
 	nl.br = bitreader.NewReader(bytes.NewBuffer(data))
-	return nl.parse()
+	if err := nl.parse(); err != nil {
+		return err
+	}
+	if len(data) < nl.headerSize {
+		return fmt.Errorf("nalu %v truncated header extension", nl.uType)
+	}
+	nl.rbsp, nl.epb = EBSPToRBSP(data[nl.headerSize:])
+	// end_of_seq_rbsp and end_of_stream_rbsp are empty
+	if len(nl.rbsp) < 1 && nl.uType != NaluEoseq && nl.uType != NaluEostream {
+		return fmt.Errorf("nalu invalid rbr size 0")
+	}
+	return nil
 }
 
+// parse nal_unit header, types unknown to the decoder are kept for caller to skip
+// T-REC-H.264-201402-S!!PDF-E.pdf 7.3.1 NAL unit syntax
 func (nl *Nalu) parse() error {
-	if len(nl.rbsp) < 1 {
-		return fmt.Errorf("nalu invalid rbr size 0")
-	}
 	// parse type
 	// forbidden_zero_bit
 	t, err := nl.br.Read1()
@@ -115,10 +216,123 @@ func (nl *Nalu) parse() error {
 		return err
 	}
 	nt, err := nl.br.Read8(5)
+	if err != nil {
+		return err
+	}
 	nl.uType = NaluType(nt)
-	if nl.uType > NaluFiller {
-		return fmt.Errorf("invalid nalu type %v", nl.uType)
+	nl.headerSize = 1
+	if !nl.uType.HasHeaderExtension() {
+		return nil
 	}
+	// svc_extension_flag, or avc_3d_extension_flag for NaluSliceExtDepth
+	extFlag, err := nl.br.Read1()
+	if err != nil {
+		return err
+	}
+	switch {
+	case extFlag && nl.uType == NaluSliceExtDepth:
+		nl.headerSize += 2
+		return nl.parse3davcExtension()
+	case extFlag:
+		nl.headerSize += 3
+		return nl.parseSvcExtension()
+	}
+	nl.headerSize += 3
+	return nl.parseMvcExtension()
+}
+
+func (nl *Nalu) parseSvcExtension() error {
+	br := nl.br
+	ext := &NaluHeaderSvcExtension{}
+	var err error
+	if ext.IdrFlag, err = br.Read1(); err != nil {
+		return err
+	}
+	if ext.PriorityId, err = br.Read8(6); err != nil {
+		return err
+	}
+	if ext.NoInterLayerPredFlag, err = br.Read1(); err != nil {
+		return err
+	}
+	if ext.DependencyId, err = br.Read8(3); err != nil {
+		return err
+	}
+	if ext.QualityId, err = br.Read8(4); err != nil {
+		return err
+	}
+	if ext.TemporalId, err = br.Read8(3); err != nil {
+		return err
+	}
+	if ext.UseRefBasePicFlag, err = br.Read1(); err != nil {
+		return err
+	}
+	if ext.DiscardableFlag, err = br.Read1(); err != nil {
+		return err
+	}
+	if ext.OutputFlag, err = br.Read1(); err != nil {
+		return err
+	}
+	// reserved_three_2bits
+	if err = br.Skip(2); err != nil {
+		return err
+	}
+	nl.svcExt = ext
+	return nil
+}
+
+func (nl *Nalu) parseMvcExtension() error {
+	br := nl.br
+	ext := &NaluHeaderMvcExtension{}
+	var err error
+	if ext.NonIdrFlag, err = br.Read1(); err != nil {
+		return err
+	}
+	if ext.PriorityId, err = br.Read8(6); err != nil {
+		return err
+	}
+	if ext.ViewId, err = br.Read16(10); err != nil {
+		return err
+	}
+	if ext.TemporalId, err = br.Read8(3); err != nil {
+		return err
+	}
+	if ext.AnchorPicFlag, err = br.Read1(); err != nil {
+		return err
+	}
+	if ext.InterViewFlag, err = br.Read1(); err != nil {
+		return err
+	}
+	// reserved_one_bit
+	if err = br.Skip(1); err != nil {
+		return err
+	}
+	nl.mvcExt = ext
+	return nil
+}
+
+func (nl *Nalu) parse3davcExtension() error {
+	br := nl.br
+	ext := &NaluHeader3davcExtension{}
+	var err error
+	if ext.ViewIdx, err = br.Read8(8); err != nil {
+		return err
+	}
+	if ext.DepthFlag, err = br.Read1(); err != nil {
+		return err
+	}
+	if ext.NonIdrFlag, err = br.Read1(); err != nil {
+		return err
+	}
+	if ext.TemporalId, err = br.Read8(3); err != nil {
+		return err
+	}
+	if ext.AnchorPicFlag, err = br.Read1(); err != nil {
+		return err
+	}
+	if ext.InterViewFlag, err = br.Read1(); err != nil {
+		return err
+	}
+	nl.avc3dExt = ext
 	return nil
 }
 
@@ -127,7 +341,56 @@ func (nl *Nalu) Type() NaluType {
 	return nl.uType
 }
 
+// RefIdc return nal_ref_idc, 0 means nalu is not used for reference
+func (nl *Nalu) RefIdc() uint8 {
+	return nl.refIdc
+}
+
+// Rbsp return nalu rbsp bytes following the nalu header and its extension, emulation prevention bytes removed
+func (nl *Nalu) Rbsp() []byte {
+	return nl.rbsp
+}
+
+// HeaderSize return nal unit header bytes count, 1 plus the header extension
+func (nl *Nalu) HeaderSize() int {
+	return nl.headerSize
+}
+
+// SvcExtension return nal_unit_header_svc_extension, nil if absent
+func (nl *Nalu) SvcExtension() *NaluHeaderSvcExtension {
+	return nl.svcExt
+}
+
+// MvcExtension return nal_unit_header_mvc_extension, nil if absent
+func (nl *Nalu) MvcExtension() *NaluHeaderMvcExtension {
+	return nl.mvcExt
+}
+
+// Avc3dExtension return nal_unit_header_3davc_extension, nil if absent
+func (nl *Nalu) Avc3dExtension() *NaluHeader3davcExtension {
+	return nl.avc3dExt
+}
+
 // RbspSize  return nalu rbr bytes count
 func (nl *Nalu) RbspSize() int {
 	return len(nl.rbsp)
 }
+
+// EmulationPreventionBytes return count of emulation_prevention_three_byte removed from nalu
+func (nl *Nalu) EmulationPreventionBytes() int {
+	return len(nl.epb)
+}
+
+// NaluByteOffset map a bit offset in rbsp to the byte offset in the nal unit,
+// nalu header byte included, for diagnostics
+func (nl *Nalu) NaluByteOffset(rbspBitOffset int) int {
+	rbspByte := rbspBitOffset / 8
+	removed := 0
+	for _, pos := range nl.epb {
+		if pos > rbspByte {
+			break
+		}
+		removed++
+	}
+	return nl.headerSize + rbspByte + removed
+}
@@ -0,0 +1,98 @@
+package internal
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestNaluHeaderExtension(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       []byte
+		wantType   NaluType
+		headerSize int
+		svc        *NaluHeaderSvcExtension
+		mvc        *NaluHeaderMvcExtension
+		avc3d      *NaluHeader3davcExtension
+		rbsp       []byte
+		wantErr    bool
+	}{
+		{
+			name:       "prefix svc",
+			data:       []byte{0x6E, 0xC5, 0xA3, 0x8F, 0x80},
+			wantType:   NaluPrefix,
+			headerSize: 4,
+			svc: &NaluHeaderSvcExtension{IdrFlag: true, PriorityId: 5, NoInterLayerPredFlag: true,
+				DependencyId: 2, QualityId: 3, TemporalId: 4, DiscardableFlag: true, OutputFlag: true},
+			rbsp: []byte{0x80},
+		},
+		{
+			name:       "slice extension mvc",
+			data:       []byte{0x54, 0x40, 0x01, 0x57, 0x88, 0x00, 0x00, 0x03, 0x01},
+			wantType:   NaluSliceExt,
+			headerSize: 4,
+			mvc:        &NaluHeaderMvcExtension{NonIdrFlag: true, ViewId: 5, TemporalId: 2, AnchorPicFlag: true, InterViewFlag: true},
+			rbsp:       []byte{0x88, 0x00, 0x00, 0x01},
+		},
+		{
+			name:       "slice extension 3d-avc",
+			data:       []byte{0x35, 0x81, 0xC5, 0x80},
+			wantType:   NaluSliceExtDepth,
+			headerSize: 3,
+			avc3d:      &NaluHeader3davcExtension{ViewIdx: 3, DepthFlag: true, TemporalId: 1, InterViewFlag: true},
+			rbsp:       []byte{0x80},
+		},
+		{name: "subset sps", data: []byte{0x6F, 0x64}, wantType: NaluSubsetSps, headerSize: 1, rbsp: []byte{0x64}},
+		{name: "reserved", data: []byte{0x11, 0x80}, wantType: 17, headerSize: 1, rbsp: []byte{0x80}},
+		{name: "unspecified", data: []byte{0x1E, 0x80}, wantType: 30, headerSize: 1, rbsp: []byte{0x80}},
+		{name: "end of sequence", data: []byte{0x0A}, wantType: NaluEoseq, headerSize: 1, rbsp: []byte{}},
+		{name: "truncated extension", data: []byte{0x6E, 0xC5}, wantErr: true},
+		{name: "empty slice", data: []byte{0x65}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nl := NewNalu()
+			err := nl.Load(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if nl.Type() != tt.wantType || nl.HeaderSize() != tt.headerSize {
+				t.Errorf("Type(), HeaderSize() = %v, %v, want %v, %v", nl.Type(), nl.HeaderSize(), tt.wantType, tt.headerSize)
+			}
+			if !reflect.DeepEqual(nl.SvcExtension(), tt.svc) {
+				t.Errorf("SvcExtension() = %+v, want %+v", nl.SvcExtension(), tt.svc)
+			}
+			if !reflect.DeepEqual(nl.MvcExtension(), tt.mvc) {
+				t.Errorf("MvcExtension() = %+v, want %+v", nl.MvcExtension(), tt.mvc)
+			}
+			if !reflect.DeepEqual(nl.Avc3dExtension(), tt.avc3d) {
+				t.Errorf("Avc3dExtension() = %+v, want %+v", nl.Avc3dExtension(), tt.avc3d)
+			}
+			if !bytes.Equal(nl.Rbsp(), tt.rbsp) {
+				t.Errorf("Rbsp() = %x, want %x", nl.Rbsp(), tt.rbsp)
+			}
+		})
+	}
+}
+
+func TestNaluTypeString(t *testing.T) {
+	tests := []struct {
+		nt   NaluType
+		want string
+	}{
+		{NaluSliceIdr, "NaluSliceIdr"},
+		{NaluPrefix, "NaluPrefix"},
+		{NaluSliceExtDepth, "NaluSliceExtDepth"},
+		{18, "NaluReserved:18"},
+		{24, "NaluUnspecified:24"},
+	}
+	for _, tt := range tests {
+		if got := tt.nt.String(); got != tt.want {
+			t.Errorf("NaluType(%d).String() = %v, want %v", tt.nt, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+)
+
+const (
+	maxSpsCount = 32
+	maxPpsCount = 256
+)
+
+// ParameterSetChange describe a parameter set replaced by one with the same id
+// but different content
+type ParameterSetChange struct {
+	// NaluSps or NaluPps
+	Type NaluType
+	Id   uint
+	// Active is true when the replaced parameter set was the active one,
+	// which happens at stream switching
+	Active bool
+}
+
+func (c ParameterSetChange) String() string {
+	return fmt.Sprintf("%v id %v replaced, active = %v", c.Type, c.Id, c.Active)
+}
+
+// MissingParameterSetError error activating the pps of a slice which was not received,
+// or whose sps was not received, as happens for the slices before the first parameter
+// sets of a stream joined in the middle
+type MissingParameterSetError struct {
+	// NaluPps when the pps is missing, NaluSps when the sps it refers to is
+	Type  NaluType
+	PpsId uint
+	SpsId uint
+}
+
+func (e MissingParameterSetError) Error() string {
+	if e.Type == NaluSps {
+		return fmt.Sprintf("pps %v refers to missing sps %v", e.PpsId, e.SpsId)
+	}
+	return fmt.Sprintf("pps %v not received", e.PpsId)
+}
+
+// ParameterSetStore hold all received sps and pps by id, and activate them
+// when referred by slices
+// T-REC-H.264-201402-S!!PDF-E.pdf 7.4.1.2.1 Order of sequence and picture parameter set RBSPs and their activation
+type ParameterSetStore struct {
+	sps     [maxSpsCount]*SPS
+	spsRbsp [maxSpsCount][]byte
+	pps     [maxPpsCount]*PPS
+	ppsRbsp [maxPpsCount][]byte
+
+	activeSps *SPS
+	activePps *PPS
+
+	// OnChange is called when a parameter set is replaced with different content
+	OnChange func(change ParameterSetChange)
+}
+
+// NewParameterSetStore return an empty ParameterSetStore
+func NewParameterSetStore() *ParameterSetStore {
+	return &ParameterSetStore{}
+}
+
+// PutSps parse and store a sequence parameter set.
+// A sps with the same id and content as the stored one is ignored.
+func (s *ParameterSetStore) PutSps(rbsp []byte) (*SPS, error) {
+	sps, err := ParseSpsFromRBSP(rbsp)
+	if err != nil {
+		return nil, err
+	}
+	if sps.Id >= maxSpsCount {
+		return nil, fmt.Errorf("invalid seq_parameter_set_id %v", sps.Id)
+	}
+	rbsp = trimTrailingZero(rbsp)
+	if old := s.sps[sps.Id]; old != nil {
+		if bytes.Equal(s.spsRbsp[sps.Id], rbsp) {
+			return old, nil
+		}
+		s.notify(ParameterSetChange{Type: NaluSps, Id: sps.Id, Active: old == s.activeSps})
+	}
+	s.sps[sps.Id] = sps
+	s.spsRbsp[sps.Id] = rbsp
+	return sps, nil
+}
+
+// PutPps parse and store a picture parameter set against the stored sps it refers to.
+// A pps with the same id and content as the stored one is ignored.
+func (s *ParameterSetStore) PutPps(rbsp []byte) (*PPS, error) {
+	pps, err := ParsePpsFromRBSPWithSps(rbsp, s.Sps)
+	if err != nil {
+		return nil, err
+	}
+	if pps.Id >= maxPpsCount {
+		return nil, fmt.Errorf("invalid pic_parameter_set_id %v", pps.Id)
+	}
+	if pps.SeqParameterSetId >= maxSpsCount {
+		return nil, fmt.Errorf("invalid seq_parameter_set_id %v in pps %v", pps.SeqParameterSetId, pps.Id)
+	}
+	rbsp = trimTrailingZero(rbsp)
+	if old := s.pps[pps.Id]; old != nil {
+		if bytes.Equal(s.ppsRbsp[pps.Id], rbsp) {
+			return old, nil
+		}
+		s.notify(ParameterSetChange{Type: NaluPps, Id: pps.Id, Active: old == s.activePps})
+	}
+	s.pps[pps.Id] = pps
+	s.ppsRbsp[pps.Id] = rbsp
+	return pps, nil
+}
+
+// Sps return the stored sps of id, nil if not received
+func (s *ParameterSetStore) Sps(id uint) *SPS {
+	if id >= maxSpsCount {
+		return nil
+	}
+	return s.sps[id]
+}
+
+// Pps return the stored pps of id, nil if not received
+func (s *ParameterSetStore) Pps(id uint) *PPS {
+	if id >= maxPpsCount {
+		return nil
+	}
+	return s.pps[id]
+}
+
+// ActiveSps return the active sps, nil before first activation
+func (s *ParameterSetStore) ActiveSps() *SPS {
+	return s.activeSps
+}
+
+// ActivePps return the active pps, nil before first activation
+func (s *ParameterSetStore) ActivePps() *PPS {
+	return s.activePps
+}
+
+// Activate activate the pps referred by a slice and the sps referred by that pps.
+// A different sps may only be activated by an idr picture, the active sps
+// stays active for the whole coded video sequence.
+func (s *ParameterSetStore) Activate(ppsId uint, idr bool) (*SPS, *PPS, error) {
+	pps := s.Pps(ppsId)
+	if pps == nil {
+		return nil, nil, MissingParameterSetError{Type: NaluPps, PpsId: ppsId}
+	}
+	sps := s.Sps(pps.SeqParameterSetId)
+	if sps == nil {
+		return nil, nil, MissingParameterSetError{Type: NaluSps, PpsId: ppsId, SpsId: pps.SeqParameterSetId}
+	}
+	if s.activeSps != nil && sps != s.activeSps && !idr {
+		return nil, nil, fmt.Errorf("sps %v activated by non idr picture", sps.Id)
+	}
+	if pps.Sps() != sps {
+		// sps was received or replaced after the pps, parse pps again against it
+		var err error
+		if pps, err = ParsePpsFromRBSPWithSps(s.ppsRbsp[ppsId], s.Sps); err != nil {
+			return nil, nil, err
+		}
+		s.pps[ppsId] = pps
+	}
+	s.activeSps = sps
+	s.activePps = pps
+	return sps, pps, nil
+}
+
+func (s *ParameterSetStore) notify(change ParameterSetChange) {
+	if s.OnChange != nil {
+		s.OnChange(change)
+	}
+}
+
+// trimTrailingZero drop trailing_zero_8bits left by the byte stream splitter
+func trimTrailingZero(rbsp []byte) []byte {
+	end := len(rbsp)
+	for end > 0 && rbsp[end-1] == 0 {
+		end--
+	}
+	return rbsp[:end]
+}
@@ -0,0 +1,139 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/LiveStudioSolution/h264decoder/internal/rbr"
+)
+
+// testSpsRbsp build a baseline profile sps rbsp
+func testSpsRbsp(id uint, widthInMbs uint) []byte {
+	w := rbr.NewWriter()
+	w.WriteBits(66, 8)   // profile_idc
+	w.WriteBits(0xC0, 8) // constraint_set flags, reserved_zero_2bits
+	w.WriteBits(30, 8)   // level_idc
+	w.WriteUe(id)        // seq_parameter_set_id
+	w.WriteUe(0)         // log2_max_frame_num_minus4
+	w.WriteUe(2)         // pic_order_cnt_type
+	w.WriteUe(1)         // max_num_ref_frames
+	w.WriteFlag(false)   // gaps_in_frame_num_value_allowed_flag
+	w.WriteUe(widthInMbs - 1)
+	w.WriteUe(8)       // pic_height_in_map_units_minus1
+	w.WriteFlag(true)  // frame_mbs_only_flag
+	w.WriteFlag(true)  // direct_8x8_inference_flag
+	w.WriteFlag(false) // frame_cropping_flag
+	w.WriteFlag(false) // vui_parameters_present_flag
+	w.WriteTrailingBits()
+	return w.Bytes()
+}
+
+// testPpsRbsp build a cavlc pps rbsp
+func testPpsRbsp(id, spsId uint, qp int) []byte {
+	w := rbr.NewWriter()
+	w.WriteUe(id)
+	w.WriteUe(spsId)
+	w.WriteFlag(false) // entropy_coding_mode_flag
+	w.WriteFlag(false) // bottom_field_pic_order_in_frame_present_flag
+	w.WriteUe(0)       // num_slice_groups_minus1
+	w.WriteUe(0)       // num_ref_idx_l0_default_active_minus1
+	w.WriteUe(0)       // num_ref_idx_l1_default_active_minus1
+	w.WriteFlag(false) // weighted_pred_flag
+	w.WriteBits(0, 2)  // weighted_bipred_idc
+	w.WriteSe(qp - 26) // pic_init_qp_minus26
+	w.WriteSe(0)       // pic_init_qs_minus26
+	w.WriteSe(0)       // chroma_qp_index_offset
+	w.WriteFlag(true)  // deblocking_filter_control_present_flag
+	w.WriteFlag(false) // constrained_intra_pred_flag
+	w.WriteFlag(false) // redundant_pic_cnt_present_flag
+	w.WriteTrailingBits()
+	return w.Bytes()
+}
+
+func TestParameterSetStore(t *testing.T) {
+	var changes []ParameterSetChange
+	s := NewParameterSetStore()
+	s.OnChange = func(c ParameterSetChange) {
+		changes = append(changes, c)
+	}
+	mustPutSps := func(rbsp []byte) *SPS {
+		sps, err := s.PutSps(rbsp)
+		if err != nil {
+			t.Fatalf("PutSps() error = %v", err)
+		}
+		return sps
+	}
+	mustPutPps := func(rbsp []byte) *PPS {
+		pps, err := s.PutPps(rbsp)
+		if err != nil {
+			t.Fatalf("PutPps() error = %v", err)
+		}
+		return pps
+	}
+
+	sps0 := mustPutSps(testSpsRbsp(0, 20))
+	sps3 := mustPutSps(testSpsRbsp(3, 40))
+	pps0 := mustPutPps(testPpsRbsp(0, 0, 26))
+	pps7 := mustPutPps(testPpsRbsp(7, 3, 30))
+	mustPutPps(testPpsRbsp(9, 5, 30))
+
+	if s.Sps(3) != sps3 || s.Pps(7) != pps7 || s.Sps(1) != nil || s.Pps(300) != nil {
+		t.Fatalf("lookup by id returned wrong parameter set")
+	}
+	if s.ActiveSps() != nil || s.ActivePps() != nil {
+		t.Fatalf("parameter sets active before any slice")
+	}
+
+	if _, _, err := s.Activate(1, true); err != (MissingParameterSetError{Type: NaluPps, PpsId: 1}) {
+		t.Errorf("Activate() of missing pps error = %v", err)
+	}
+	if _, _, err := s.Activate(9, true); err != (MissingParameterSetError{Type: NaluSps, PpsId: 9, SpsId: 5}) {
+		t.Errorf("Activate() of pps with missing sps error = %v", err)
+	}
+
+	sps, pps, err := s.Activate(0, true)
+	if err != nil || sps != sps0 || pps != pps0 {
+		t.Fatalf("Activate(0) = %v, %v, %v", sps, pps, err)
+	}
+	// switching pps inside the sequence is allowed, switching sps is not
+	if _, _, err = s.Activate(0, false); err != nil {
+		t.Errorf("Activate(0) again error = %v", err)
+	}
+	if _, _, err = s.Activate(7, false); err == nil {
+		t.Errorf("Activate() of a new sps by non idr picture succeeded")
+	}
+	if sps, pps, err = s.Activate(7, true); err != nil || sps != sps3 || pps != pps7 {
+		t.Fatalf("Activate(7) = %v, %v, %v", sps, pps, err)
+	}
+
+	// same content is not a replacement
+	if got := mustPutSps(testSpsRbsp(3, 40)); got != sps3 {
+		t.Errorf("PutSps() with same content replaced stored sps")
+	}
+	if got := mustPutPps(append(testPpsRbsp(7, 3, 30), 0, 0)); got != pps7 {
+		t.Errorf("PutPps() with trailing zero bytes replaced stored pps")
+	}
+	if len(changes) != 0 {
+		t.Errorf("changes = %v, want none", changes)
+	}
+
+	// different content replaces and is reported
+	mustPutPps(testPpsRbsp(0, 0, 20))
+	newSps3 := mustPutSps(testSpsRbsp(3, 80))
+	mustPutPps(testPpsRbsp(7, 3, 22))
+	want := []ParameterSetChange{
+		{Type: NaluPps, Id: 0, Active: false},
+		{Type: NaluSps, Id: 3, Active: true},
+		{Type: NaluPps, Id: 7, Active: true},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("changes = %v, want %v", changes, want)
+	}
+	// replaced active sps content needs an idr picture to become active
+	if _, _, err = s.Activate(7, false); err == nil {
+		t.Errorf("Activate() of replaced sps by non idr picture succeeded")
+	}
+	if sps, _, err = s.Activate(7, true); err != nil || sps != newSps3 || sps.PicWidthInMbsMinus1 != 79 {
+		t.Errorf("Activate(7) = %v, %v", sps, err)
+	}
+}
@@ -0,0 +1,172 @@
+// Package poc derive the picture order counts of the pictures of a coded video
+// sequence, ordering them for output,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.2.1 Decoding process for picture order count
+package poc
+
+import (
+	"fmt"
+
+	"github.com/LiveStudioSolution/h264decoder/internal/slice"
+)
+
+// Counter picture order count state carried from picture to picture in decoding order.
+// The zero value is ready for use, the first picture being an IDR picture.
+type Counter struct {
+	// prevPicOrderCntMsb and prevPicOrderCntLsb of the previous reference picture,
+	// pic_order_cnt_type 0
+	prevPicOrderCntMsb int
+	prevPicOrderCntLsb int
+	// prevFrameNumOffset and prevFrameNum of the previous picture, pic_order_cnt_type 1 and 2
+	prevFrameNumOffset int
+	prevFrameNum       int
+}
+
+// Derive TopFieldOrderCnt and BottomFieldOrderCnt of the picture of slice h, only the
+// one of its parity being meaningful for a field, and update the counter for the next
+// picture. It is invoked once per picture, before the decoded reference picture
+// marking. The order counts of a picture including a memory_management_control_operation
+// equal to 5 are the ones used for its decoding, before their reset to the picture itself.
+// The "non-existing" frames inferred for gaps in frame_num need not be counted, they
+// leave FrameNumOffset of the following picture unchanged.
+func (c *Counter) Derive(h *slice.Header) (top, bottom int, err error) {
+	sps := h.SPS
+	if sps == nil {
+		return 0, 0, fmt.Errorf("slice header without sequence parameter set")
+	}
+	switch sps.PicOrderCntType {
+	case 0:
+		top, bottom = c.type0(h)
+	case 1:
+		if top, bottom, err = c.type1(h); err != nil {
+			return 0, 0, err
+		}
+	case 2:
+		top, bottom = c.type2(h)
+	default:
+		return 0, 0, fmt.Errorf("invalid pic_order_cnt_type %v", sps.PicOrderCntType)
+	}
+	c.update(h, top, bottom)
+	return top, bottom, nil
+}
+
+// type0 T-REC-H.264-201402-S!!PDF-E.pdf 8.2.1.1 Decoding process for picture order count type 0
+func (c *Counter) type0(h *slice.Header) (top, bottom int) {
+	if h.IdrPicFlag {
+		c.prevPicOrderCntMsb, c.prevPicOrderCntLsb = 0, 0
+	}
+	maxLsb := int(h.SPS.MaxPicOrderCntLsb())
+	lsb := int(h.PicOrderCntLsb)
+	msb := c.prevPicOrderCntMsb
+	switch {
+	case lsb < c.prevPicOrderCntLsb && c.prevPicOrderCntLsb-lsb >= maxLsb/2:
+		msb += maxLsb
+	case lsb > c.prevPicOrderCntLsb && lsb-c.prevPicOrderCntLsb > maxLsb/2:
+		msb -= maxLsb
+	}
+	if h.NalRefIdc != 0 {
+		c.prevPicOrderCntMsb, c.prevPicOrderCntLsb = msb, lsb
+	}
+	switch {
+	case !h.FieldPicFlag:
+		top = msb + lsb
+		bottom = top + h.DeltaPicOrderCntBottom
+	case h.BottomFieldFlag:
+		bottom = msb + lsb
+	default:
+		top = msb + lsb
+	}
+	return top, bottom
+}
+
+// frameNumOffset FrameNumOffset of the picture of slice h, pic_order_cnt_type 1 and 2
+func (c *Counter) frameNumOffset(h *slice.Header) int {
+	switch {
+	case h.IdrPicFlag:
+		return 0
+	case c.prevFrameNum > int(h.FrameNum):
+		return c.prevFrameNumOffset + int(h.SPS.MaxFrameNum())
+	}
+	return c.prevFrameNumOffset
+}
+
+// type1 T-REC-H.264-201402-S!!PDF-E.pdf 8.2.1.2 Decoding process for picture order count type 1
+func (c *Counter) type1(h *slice.Header) (top, bottom int, err error) {
+	sps := h.SPS
+	cycle := int(sps.NumRefFramesInPicOrderCntCycle)
+	if len(sps.OffsetForRefFrame) != cycle {
+		return 0, 0, fmt.Errorf("%v offset_for_ref_frame for num_ref_frames_in_pic_order_cnt_cycle %v", len(sps.OffsetForRefFrame), cycle)
+	}
+	offset := c.frameNumOffset(h)
+	absFrameNum := 0
+	if cycle != 0 {
+		absFrameNum = offset + int(h.FrameNum)
+	}
+	if h.NalRefIdc == 0 && absFrameNum > 0 {
+		absFrameNum--
+	}
+
+	expected := 0
+	if absFrameNum > 0 {
+		expectedDeltaPerCycle := 0
+		for _, o := range sps.OffsetForRefFrame {
+			expectedDeltaPerCycle += o
+		}
+		expected = (absFrameNum - 1) / cycle * expectedDeltaPerCycle
+		for _, o := range sps.OffsetForRefFrame[:(absFrameNum-1)%cycle+1] {
+			expected += o
+		}
+	}
+	if h.NalRefIdc == 0 {
+		expected += sps.OffsetForNonRefPic
+	}
+
+	switch {
+	case !h.FieldPicFlag:
+		top = expected + h.DeltaPicOrderCnt[0]
+		bottom = top + sps.OffsetForTopToBottomField + h.DeltaPicOrderCnt[1]
+	case h.BottomFieldFlag:
+		bottom = expected + sps.OffsetForTopToBottomField + h.DeltaPicOrderCnt[0]
+	default:
+		top = expected + h.DeltaPicOrderCnt[0]
+	}
+	c.prevFrameNumOffset = offset
+	return top, bottom, nil
+}
+
+// type2 T-REC-H.264-201402-S!!PDF-E.pdf 8.2.1.3 Decoding process for picture order count type 2
+func (c *Counter) type2(h *slice.Header) (top, bottom int) {
+	offset := c.frameNumOffset(h)
+	temp := 0
+	switch {
+	case h.IdrPicFlag:
+	case h.NalRefIdc == 0:
+		temp = 2*(offset+int(h.FrameNum)) - 1
+	default:
+		temp = 2 * (offset + int(h.FrameNum))
+	}
+	c.prevFrameNumOffset = offset
+	return temp, temp
+}
+
+// update the counter after the picture of slice h of order counts top and bottom,
+// whose memory_management_control_operation equal to 5 resets the order counts and
+// frame_num of the following pictures
+func (c *Counter) update(h *slice.Header, top, bottom int) {
+	c.prevFrameNum = int(h.FrameNum)
+	if !h.HasMmco5() {
+		return
+	}
+	c.prevFrameNumOffset, c.prevFrameNum = 0, 0
+	c.prevPicOrderCntMsb, c.prevPicOrderCntLsb = 0, 0
+	// TopFieldOrderCnt after the reset to tempPicOrderCnt, 0 for a top field
+	if !h.FieldPicFlag {
+		c.prevPicOrderCntLsb = top - imin(top, bottom)
+	}
+}
+
+func imin(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,153 @@
+package poc
+
+import (
+	"testing"
+
+	"github.com/LiveStudioSolution/h264decoder/internal"
+	"github.com/LiveStudioSolution/h264decoder/internal/slice"
+)
+
+// picture of a test sequence and its expected order counts
+type step struct {
+	name        string
+	h           slice.Header
+	top, bottom int
+}
+
+// testHeader slice header of a frame, a reference one for ref, of frame_num frameNum
+func testHeader(idr, ref bool, frameNum uint) slice.Header {
+	h := slice.Header{IdrPicFlag: idr, FrameNum: frameNum}
+	if ref {
+		h.NalRefIdc = 1
+	}
+	return h
+}
+
+// field slice header h of a field of parity bottom
+func field(h slice.Header, bottom bool) slice.Header {
+	h.FieldPicFlag, h.BottomFieldFlag = true, bottom
+	return h
+}
+
+// mmco5 slice header h including a memory_management_control_operation equal to 5
+func mmco5(h slice.Header) slice.Header {
+	h.DecRefPicMarking.AdaptiveRefPicMarkingModeFlag = true
+	h.DecRefPicMarking.Operations = []slice.MemoryManagementOperation{{MemoryManagementControlOperation: 5}}
+	return h
+}
+
+func lsb(h slice.Header, lsb uint, deltaBottom int) slice.Header {
+	h.PicOrderCntLsb, h.DeltaPicOrderCntBottom = lsb, deltaBottom
+	return h
+}
+
+func delta(h slice.Header, d0, d1 int) slice.Header {
+	h.DeltaPicOrderCnt = [2]int{d0, d1}
+	return h
+}
+
+func run(t *testing.T, sps *internal.SPS, c *Counter, steps []step) {
+	t.Helper()
+	for _, s := range steps {
+		h := s.h
+		h.SPS = sps
+		top, bottom, err := c.Derive(&h)
+		if err != nil {
+			t.Fatalf("%v: Derive() error %v", s.name, err)
+		}
+		// the order count of the other parity of a field is not meaningful
+		if h.FieldPicFlag && h.BottomFieldFlag {
+			top = s.top
+		} else if h.FieldPicFlag {
+			bottom = s.bottom
+		}
+		if top != s.top || bottom != s.bottom {
+			t.Errorf("%v: Derive() = %v, %v, want %v, %v", s.name, top, bottom, s.top, s.bottom)
+		}
+	}
+}
+
+func TestType0(t *testing.T) {
+	// MaxPicOrderCntLsb 16
+	sps := &internal.SPS{PicOrderCntType: 0}
+	run(t, sps, &Counter{}, []step{
+		{"IDR", lsb(testHeader(true, true, 0), 0, 1), 0, 1},
+		{"P", lsb(testHeader(false, true, 1), 8, 0), 8, 8},
+		{"P", lsb(testHeader(false, true, 2), 14, 0), 14, 14},
+		{"P lsb wrapped", lsb(testHeader(false, true, 3), 4, 0), 20, 20},
+		{"B", lsb(testHeader(false, false, 4), 2, 0), 18, 18},
+		// relative to the previous reference picture, not to the B picture
+		{"P", lsb(testHeader(false, true, 4), 12, 0), 28, 28},
+		{"top field", field(lsb(testHeader(false, true, 5), 0, 0), false), 32, 0},
+		{"bottom field", field(lsb(testHeader(false, true, 5), 1, 0), true), 0, 33},
+		{"B lsb wrapped backwards", lsb(testHeader(false, false, 6), 15, 0), 31, 31},
+		{"mmco 5", mmco5(lsb(testHeader(false, true, 6), 3, 2)), 35, 37},
+		{"after mmco 5", lsb(testHeader(false, true, 1), 2, 0), 2, 2},
+		{"mmco 5 of a bottom field", mmco5(field(lsb(testHeader(false, true, 2), 6, 0), true)), 0, 6},
+		{"after mmco 5", lsb(testHeader(false, true, 1), 2, 0), 2, 2},
+		{"IDR", lsb(testHeader(true, true, 0), 0, 0), 0, 0},
+	})
+}
+
+func TestType1(t *testing.T) {
+	// MaxFrameNum 16
+	sps := &internal.SPS{
+		PicOrderCntType:                1,
+		OffsetForNonRefPic:             -2,
+		OffsetForTopToBottomField:      1,
+		NumRefFramesInPicOrderCntCycle: 2,
+		OffsetForRefFrame:              []int{3, 5},
+	}
+	run(t, sps, &Counter{}, []step{
+		{"IDR", testHeader(true, true, 0), 0, 1},
+		{"P", testHeader(false, true, 1), 3, 4},
+		{"B", testHeader(false, false, 2), 1, 2},
+		{"P", testHeader(false, true, 2), 8, 9},
+		{"P of delta_pic_order_cnt", delta(testHeader(false, true, 3), 1, -2), 12, 11},
+		{"top field", field(testHeader(false, true, 4), false), 16, 0},
+		{"bottom field", field(delta(testHeader(false, true, 4), 1, 0), true), 0, 18},
+		{"mmco 5", mmco5(testHeader(false, true, 5)), 19, 20},
+		{"after mmco 5", testHeader(false, true, 1), 3, 4},
+	})
+
+	// frame_num wrapped, FrameNumOffset MaxFrameNum, absFrameNum 16
+	run(t, sps, &Counter{prevFrameNum: 15}, []step{
+		{"frame_num wrapped", testHeader(false, true, 0), 64, 65},
+	})
+}
+
+func TestType2(t *testing.T) {
+	sps := &internal.SPS{PicOrderCntType: 2}
+	run(t, sps, &Counter{}, []step{
+		{"IDR", testHeader(true, true, 0), 0, 0},
+		{"P", testHeader(false, true, 1), 2, 2},
+		{"non-reference", testHeader(false, false, 2), 3, 3},
+		{"P", testHeader(false, true, 2), 4, 4},
+		{"top field", field(testHeader(false, true, 3), false), 6, 0},
+		{"bottom field", field(testHeader(false, true, 3), true), 0, 6},
+		{"mmco 5", mmco5(testHeader(false, true, 4)), 8, 8},
+		{"after mmco 5", testHeader(false, true, 1), 2, 2},
+	})
+	run(t, sps, &Counter{prevFrameNum: 15, prevFrameNumOffset: 16}, []step{
+		{"frame_num wrapped", testHeader(false, true, 0), 64, 64},
+	})
+}
+
+func TestDeriveErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		sps  *internal.SPS
+	}{
+		{"no SPS", nil},
+		{"pic_order_cnt_type 3", &internal.SPS{PicOrderCntType: 3}},
+		{"offset_for_ref_frame missing", &internal.SPS{PicOrderCntType: 1, NumRefFramesInPicOrderCntCycle: 2, OffsetForRefFrame: []int{1}}},
+	}
+	for _, tt := range tests {
+		h := testHeader(true, true, 0)
+		h.SPS = tt.sps
+		var c Counter
+		if _, _, err := c.Derive(&h); err == nil {
+			t.Errorf("%v: Derive() succeeded", tt.name)
+		}
+	}
+}
@@ -1,10 +1,8 @@
 package internal
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
-	"github.com/32bitkid/bitreader"
 	"github.com/LiveStudioSolution/h264decoder/internal/rbr"
 )
 
@@ -41,12 +39,27 @@ type PPS struct {
 	PicScalingMatrixPresentFlag bool   `json:"pic_scaling_matrix_present_flag"`
 	PicScalingListPresentFlag   []bool `json:"pic_scaling_list_present_flag"`
 	SecondChromaQpIndexOffset   int    `json:"second_chroma_qp_index_offset"`
+	// resolved picture level scaling matrices, the sps ones when not present
+	ScalingMatrices ScalingMatrices `json:"-"`
 
-	br bitreader.BitReader
+	br *rbr.Reader
+	// sps the pps was parsed against, nil when it was not received
+	sps *SPS
 }
 
+// SpsLookup return the sps of seq_parameter_set_id, nil when not received
+type SpsLookup func(id uint) *SPS
+
+// Load parse pps without its sps, 4:2:0 chroma format and fall-back rule A
+// are assumed for the pic scaling lists
 func (pps *PPS) Load(rbsp []byte) error {
-	pps.br = bitreader.NewReader(bytes.NewReader(rbsp))
+	return pps.LoadWithSps(rbsp, nil)
+}
+
+// LoadWithSps parse pps, the sps it refers to is looked up to parse the pic
+// scaling lists
+func (pps *PPS) LoadWithSps(rbsp []byte, lookup SpsLookup) error {
+	pps.br = rbr.NewReader(rbsp)
 	br := pps.br
 	var err error
 	if pps.Id, err = rbr.DecUe(br); err != nil {
@@ -142,16 +155,74 @@ func (pps *PPS) Load(rbsp []byte) error {
 	if pps.RedundantPicCntPresentFlag, err = br.Read1(); err != nil {
 		return err
 	}
-	// todo MoreRBSPDatao
-	//if ! rbr.MoreRBSPData(br) {
-	//	return nil
-	//}
+
+	pps.sps = nil
+	if lookup != nil {
+		pps.sps = lookup(pps.SeqParameterSetId)
+	}
+	if pps.sps != nil {
+		pps.ScalingMatrices = pps.sps.ScalingMatrices
+	} else {
+		pps.ScalingMatrices = FlatScalingMatrices()
+	}
+	pps.SecondChromaQpIndexOffset = pps.ChromaQpIndexOffset
+	if !rbr.MoreRBSPData(br) {
+		return nil
+	}
+	return pps.parseExtension()
+}
+
+// T-REC-H.264-201402-S!!PDF-E.pdf 7.3.2.2 transform_8x8_mode_flag to second_chroma_qp_index_offset
+func (pps *PPS) parseExtension() error {
+	br := pps.br
+	var err error
+	if pps.Transform8X8ModeFlag, err = br.Read1(); err != nil {
+		return err
+	}
+	if pps.PicScalingMatrixPresentFlag, err = br.Read1(); err != nil {
+		return err
+	}
+	if pps.PicScalingMatrixPresentFlag {
+		chromaFormatIdc := uint(1)
+		// fall-back rule B refers to the sequence level lists when they are present
+		var fallback *ScalingMatrices
+		if pps.sps != nil {
+			chromaFormatIdc = pps.sps.ChromaFormatIdc
+			if pps.sps.SeqScalingMatrixPresentFlag {
+				fallback = &pps.sps.ScalingMatrices
+			}
+		}
+		count := 6
+		if pps.Transform8X8ModeFlag {
+			if chromaFormatIdc == 3 {
+				count += 6
+			} else {
+				count += 2
+			}
+		}
+		if pps.ScalingMatrices, pps.PicScalingListPresentFlag, err = parseScalingMatrices(br, count, fallback); err != nil {
+			return err
+		}
+	}
+	if pps.SecondChromaQpIndexOffset, err = rbr.DecSe(br); err != nil {
+		return err
+	}
 	return nil
 }
 
+// Sps return the sps the pps was parsed against, nil if it was parsed without one
+func (pps *PPS) Sps() *SPS {
+	return pps.sps
+}
+
 func ParsePpsFromRBSP(rbsp []byte) (*PPS, error) {
+	return ParsePpsFromRBSPWithSps(rbsp, nil)
+}
+
+// ParsePpsFromRBSPWithSps parse pps, looking up the sps it refers to
+func ParsePpsFromRBSPWithSps(rbsp []byte, lookup SpsLookup) (*PPS, error) {
 	pps := &PPS{}
-	if err := pps.Load(rbsp); err != nil {
+	if err := pps.LoadWithSps(rbsp, lookup); err != nil {
 		return nil, err
 	}
 	return pps, nil
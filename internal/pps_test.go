@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/LiveStudioSolution/h264decoder/internal/rbr"
+)
+
+func TestPpsParseFromRBSP(t *testing.T) {
+	// pps of docs/videosamples/txjg.h264, no extension
+	pps, err := ParsePpsFromRBSP([]byte{0xCE, 0x3C, 0x80, 0x00})
+	if err != nil {
+		t.Fatalf("ParsePpsFromRBSP() error = %v", err)
+	}
+	if pps.Transform8X8ModeFlag || pps.PicScalingMatrixPresentFlag || !pps.DeblockingFilterControlPresentFlag {
+		t.Errorf("ParsePpsFromRBSP() = %v", pps)
+	}
+	if pps.ScalingMatrices != FlatScalingMatrices() {
+		t.Errorf("ScalingMatrices is not flat without scaling lists")
+	}
+}
+
+// testHighPpsRbsp build a high profile pps rbsp with extension
+func testHighPpsRbsp(chromaQpOffset, secondChromaQpOffset int, scalingLists bool) []byte {
+	w := rbr.NewWriter()
+	w.WriteUe(2)       // pic_parameter_set_id
+	w.WriteUe(1)       // seq_parameter_set_id
+	w.WriteFlag(true)  // entropy_coding_mode_flag
+	w.WriteFlag(false) // bottom_field_pic_order_in_frame_present_flag
+	w.WriteUe(0)       // num_slice_groups_minus1
+	w.WriteUe(2)       // num_ref_idx_l0_default_active_minus1
+	w.WriteUe(0)       // num_ref_idx_l1_default_active_minus1
+	w.WriteFlag(true)  // weighted_pred_flag
+	w.WriteBits(2, 2)  // weighted_bipred_idc
+	w.WriteSe(0)       // pic_init_qp_minus26
+	w.WriteSe(0)       // pic_init_qs_minus26
+	w.WriteSe(chromaQpOffset)
+	w.WriteFlag(true)  // deblocking_filter_control_present_flag
+	w.WriteFlag(false) // constrained_intra_pred_flag
+	w.WriteFlag(false) // redundant_pic_cnt_present_flag
+	w.WriteFlag(true)  // transform_8x8_mode_flag
+	w.WriteFlag(scalingLists)
+	if scalingLists {
+		w.WriteFlag(false) // list 0, fall back
+		w.WriteFlag(true)  // list 1, flat 16
+		w.WriteSe(8)
+		w.WriteSe(-16)
+		for i := 2; i < 8; i++ {
+			w.WriteFlag(false)
+		}
+	}
+	w.WriteSe(secondChromaQpOffset)
+	w.WriteTrailingBits()
+	return w.Bytes()
+}
+
+func TestPpsExtension(t *testing.T) {
+	pps, err := ParsePpsFromRBSP(testHighPpsRbsp(-2, 3, false))
+	if err != nil {
+		t.Fatalf("ParsePpsFromRBSP() error = %v", err)
+	}
+	if !pps.Transform8X8ModeFlag || pps.PicScalingMatrixPresentFlag || pps.ChromaQpIndexOffset != -2 || pps.SecondChromaQpIndexOffset != 3 {
+		t.Errorf("ParsePpsFromRBSP() = %v", pps)
+	}
+
+	// second_chroma_qp_index_offset is inferred without extension
+	pps, err = ParsePpsFromRBSP(testPpsRbsp(0, 0, 30))
+	if err != nil {
+		t.Fatalf("ParsePpsFromRBSP() error = %v", err)
+	}
+	if pps.Transform8X8ModeFlag || pps.SecondChromaQpIndexOffset != pps.ChromaQpIndexOffset {
+		t.Errorf("ParsePpsFromRBSP() = %v", pps)
+	}
+
+	// fall-back rule A without sequence level scaling lists
+	pps, err = ParsePpsFromRBSP(testHighPpsRbsp(0, 0, true))
+	if err != nil {
+		t.Fatalf("ParsePpsFromRBSP() error = %v", err)
+	}
+	sm := pps.ScalingMatrices
+	if sm.Matrix4x4[0][0] != default4x4Intra[0] || sm.Matrix4x4[1] != FlatScalingMatrices().Matrix4x4[1] || sm.Matrix4x4[2] != sm.Matrix4x4[1] {
+		t.Errorf("Matrix4x4 = %v", sm.Matrix4x4)
+	}
+	if sm.Matrix4x4[3][0] != default4x4Inter[0] || sm.Matrix8x8[0][0] != default8x8Intra[0] || sm.Matrix8x8[1][0] != default8x8Inter[0] {
+		t.Errorf("Matrix4x4[3] = %v, Matrix8x8[0..1] = %v", sm.Matrix4x4[3], sm.Matrix8x8[:2])
+	}
+
+	// fall-back rule B refers to sequence level scaling lists
+	sps := &SPS{Id: 1, ChromaFormatIdc: 1, SeqScalingMatrixPresentFlag: true, ScalingMatrices: FlatScalingMatrices()}
+	sps.ScalingMatrices.Matrix4x4[0][0] = 99
+	sps.ScalingMatrices.Matrix4x4[3][0] = 98
+	sps.ScalingMatrices.Matrix8x8[0][0] = 97
+	pps, err = ParsePpsFromRBSPWithSps(testHighPpsRbsp(0, 0, true), func(id uint) *SPS {
+		if id == sps.Id {
+			return sps
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ParsePpsFromRBSPWithSps() error = %v", err)
+	}
+	sm = pps.ScalingMatrices
+	if pps.Sps() != sps || sm.Matrix4x4[0][0] != 99 || sm.Matrix4x4[3][0] != 98 || sm.Matrix8x8[0][0] != 97 || sm.Matrix4x4[1][0] != 16 {
+		t.Errorf("ScalingMatrices = %v", sm)
+	}
+}
@@ -1,8 +1,6 @@
 package rbr
 
-import (
-	"github.com/32bitkid/bitreader"
-)
+import "fmt"
 
 type CodedBlock struct {
 	Intra44 uint32
@@ -84,11 +82,11 @@ func init() {
 
 }
 
-func DecUe(br bitreader.BitReader) (uint, error) {
+func DecUe(br BitReader) (uint, error) {
 	return readCodeNum(br)
 }
 
-func readCodeNum(br bitreader.BitReader) (uint, error) {
+func readCodeNum(br BitReader) (uint, error) {
 	var err error
 	leadingZeroBits := -1
 	for b := false; !b; leadingZeroBits++ {
@@ -109,7 +107,7 @@ func readCodeNum(br bitreader.BitReader) (uint, error) {
 	return uint(codeNum), nil
 }
 
-func DecSe(br bitreader.BitReader) (int, error) {
+func DecSe(br BitReader) (int, error) {
 	codeNum, err := DecUe(br)
 	if err != nil {
 		return 0, err
@@ -124,12 +122,28 @@ func DecSe(br bitreader.BitReader) (int, error) {
 	return s * int(v), nil
 }
 
-func DecMe(br bitreader.BitReader) (CodedBlock, error) {
+// codedBlockPatternMap03 codeNum to coded_block_pattern for ChromaArrayType 0 or 3,
+// T-REC-H.264-201402-S!!PDF-E.pdf Table 9-4 (b)
+var codedBlockPatternMap03 = []CodedBlock{
+	{15, 0}, {0, 1}, {7, 2}, {11, 4}, {13, 8}, {14, 3}, {3, 5}, {5, 10},
+	{10, 12}, {12, 15}, {1, 7}, {2, 11}, {4, 13}, {8, 14}, {6, 6}, {9, 9},
+}
+
+// DecMe parse coded_block_pattern me(v) of a macroblock, the table of Table 9-4
+// depends on chromaArrayType
+func DecMe(br BitReader, chromaArrayType uint) (CodedBlock, error) {
 	uv, err := DecUe(br)
 	if err != nil {
 		return CodedBlock{0, 0}, err
 	}
-	return codedBlockPatternMap[uv], nil
+	table := codedBlockPatternMap
+	if chromaArrayType == 0 || chromaArrayType == 3 {
+		table = codedBlockPatternMap03
+	}
+	if uv >= uint(len(table)) {
+		return CodedBlock{0, 0}, fmt.Errorf("invalid coded_block_pattern codeNum %v", uv)
+	}
+	return table[uv], nil
 }
 
 //func DecTe(br bitreader.BitReader)(uint,error) {
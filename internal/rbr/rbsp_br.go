@@ -1,29 +1,30 @@
 package rbr
 
 import (
-	"fmt"
-	"github.com/32bitkid/bitreader"
+	"math/bits"
 )
 
-var errNotImplemented = fmt.Errorf("not implemented")
+// MoreRBSPData more_rbsp_data() of 7.2, report whether there is more data in
+// the rbsp before rbsp_trailing_bits
+func MoreRBSPData(r *Reader) bool {
+	return r.MoreRBSPData()
+}
 
-func MoreRBSPData(br bitreader.BitReader) bool {
-	if _, err := br.Peek1(); err != nil {
-		return false
-	}
-		
-	panic(errNotImplemented)
-	return false
+// MoreRBSPData report whether there is more data before rbsp_trailing_bits,
+// trailing cabac_zero_word bytes are ignored
+func (r *Reader) MoreRBSPData() bool {
+	stop := lastSetBitOffset(r.data)
+	return stop >= 0 && r.pos < stop
 }
 
-func lastSetBitOffset(br bitreader.BitReader) int {
-	lSetBitOffset := 0
-	currBitOffset := 0
-	for isSet, err := br.Peek1(); err != nil; isSet, err = br.Peek1() {
-		currBitOffset +=1
-		if isSet {
-			lSetBitOffset = currBitOffset
-		}
+// lastSetBitOffset return bit offset of the rbsp_stop_one_bit, -1 if data has no set bit
+func lastSetBitOffset(data []byte) int {
+	last := len(data) - 1
+	for last >= 0 && data[last] == 0 {
+		last--
+	}
+	if last < 0 {
+		return -1
 	}
-	return lSetBitOffset
+	return last*8 + 7 - bits.TrailingZeros8(data[last])
 }
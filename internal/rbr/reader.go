@@ -0,0 +1,116 @@
+package rbr
+
+import (
+	"io"
+)
+
+// BitReader bit reader consumed by the exp-golomb decoders,
+// both bitreader.BitReader and *Reader implement it
+type BitReader interface {
+	Read1() (bool, error)
+	Read32(bits uint) (uint32, error)
+}
+
+// Reader bit reader over rbsp bytes which keeps track of its bit position
+type Reader struct {
+	data []byte
+	pos  int
+}
+
+// NewReader return a Reader positioned at the first bit of rbsp
+func NewReader(rbsp []byte) *Reader {
+	return &Reader{data: rbsp}
+}
+
+// BitPos return count of bits already read
+func (r *Reader) BitPos() int {
+	return r.pos
+}
+
+// BitsLeft return count of bits not read yet
+func (r *Reader) BitsLeft() int {
+	return len(r.data)*8 - r.pos
+}
+
+// ByteAligned report whether reader is positioned at a byte boundary
+func (r *Reader) ByteAligned() bool {
+	return r.pos&7 == 0
+}
+
+// Data return the underlying rbsp bytes
+func (r *Reader) Data() []byte {
+	return r.data
+}
+
+func (r *Reader) peek(bits uint) (uint32, error) {
+	if bits > 32 {
+		return 0, io.ErrShortBuffer
+	}
+	if int(bits) > r.BitsLeft() {
+		return 0, io.EOF
+	}
+	var v uint32
+	pos := r.pos
+	for n := bits; n > 0; {
+		b := r.data[pos>>3]
+		off := uint(pos & 7)
+		avail := 8 - off
+		take := avail
+		if take > n {
+			take = n
+		}
+		v = v<<take | uint32(b>>(avail-take))&(1<<take-1)
+		pos += int(take)
+		n -= take
+	}
+	return v, nil
+}
+
+// Peek1 return next bit without consuming it
+func (r *Reader) Peek1() (bool, error) {
+	v, err := r.peek(1)
+	return v == 1, err
+}
+
+// Peek32 return next bits, at most 32, without consuming them
+func (r *Reader) Peek32(bits uint) (uint32, error) {
+	return r.peek(bits)
+}
+
+// Read1 read one bit
+func (r *Reader) Read1() (bool, error) {
+	v, err := r.Read32(1)
+	return v == 1, err
+}
+
+// Read8 read bits, at most 8
+func (r *Reader) Read8(bits uint) (uint8, error) {
+	v, err := r.Read32(bits)
+	return uint8(v), err
+}
+
+// Read16 read bits, at most 16
+func (r *Reader) Read16(bits uint) (uint16, error) {
+	v, err := r.Read32(bits)
+	return uint16(v), err
+}
+
+// Read32 read bits, at most 32
+func (r *Reader) Read32(bits uint) (uint32, error) {
+	v, err := r.peek(bits)
+	if err != nil {
+		return 0, err
+	}
+	r.pos += int(bits)
+	return v, nil
+}
+
+// Skip skip bits
+func (r *Reader) Skip(bits uint) error {
+	if int(bits) > r.BitsLeft() {
+		r.pos = len(r.data) * 8
+		return io.EOF
+	}
+	r.pos += int(bits)
+	return nil
+}
@@ -0,0 +1,85 @@
+package rbr
+
+import (
+	"io"
+	"testing"
+)
+
+func TestReaderWriter(t *testing.T) {
+	w := NewWriter()
+	w.WriteBits(0x5, 3)
+	w.WriteUe(0)
+	w.WriteUe(7)
+	w.WriteSe(-3)
+	w.WriteSe(4)
+	w.WriteFlag(true)
+	w.WriteBits(0xABCDE, 20)
+	w.WriteTrailingBits()
+
+	r := NewReader(w.Bytes())
+	if v, err := r.Read8(3); err != nil || v != 0x5 {
+		t.Fatalf("Read8(3) = %v, %v, want 5", v, err)
+	}
+	if v, err := DecUe(r); err != nil || v != 0 {
+		t.Fatalf("DecUe() = %v, %v, want 0", v, err)
+	}
+	if v, err := DecUe(r); err != nil || v != 7 {
+		t.Fatalf("DecUe() = %v, %v, want 7", v, err)
+	}
+	if v, err := DecSe(r); err != nil || v != -3 {
+		t.Fatalf("DecSe() = %v, %v, want -3", v, err)
+	}
+	if v, err := DecSe(r); err != nil || v != 4 {
+		t.Fatalf("DecSe() = %v, %v, want 4", v, err)
+	}
+	if v, err := r.Read1(); err != nil || !v {
+		t.Fatalf("Read1() = %v, %v, want true", v, err)
+	}
+	if v, err := r.Peek32(20); err != nil || v != 0xABCDE {
+		t.Fatalf("Peek32(20) = %x, %v, want abcde", v, err)
+	}
+	if v, err := r.Read32(20); err != nil || v != 0xABCDE {
+		t.Fatalf("Read32(20) = %x, %v, want abcde", v, err)
+	}
+	if r.BitPos() != w.BitPos()-r.BitsLeft() {
+		t.Errorf("BitPos() = %v, BitsLeft() = %v, written %v", r.BitPos(), r.BitsLeft(), w.BitPos())
+	}
+	if err := r.Skip(uint(r.BitsLeft())); err != nil {
+		t.Fatalf("Skip() error = %v", err)
+	}
+	if !r.ByteAligned() {
+		t.Errorf("ByteAligned() = false at end of data")
+	}
+	if _, err := r.Read1(); err != io.EOF {
+		t.Errorf("Read1() past end error = %v, want EOF", err)
+	}
+}
+
+func TestMoreRBSPData(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		skip uint
+		want bool
+	}{
+		{"empty", []byte{}, 0, false},
+		{"only trailing bits", []byte{0x80}, 0, false},
+		{"data before trailing bits", []byte{0xC0}, 0, true},
+		{"at stop bit", []byte{0xC0}, 1, false},
+		{"stop bit in last byte", []byte{0xFF, 0x01}, 14, true},
+		{"stop bit in last byte reached", []byte{0xFF, 0x01}, 15, false},
+		{"cabac zero words", []byte{0xA0, 0x00, 0x00, 0x00}, 2, false},
+		{"zero data bits before stop", []byte{0x00, 0x80}, 7, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewReader(tt.data)
+			if err := r.Skip(tt.skip); err != nil {
+				t.Fatalf("Skip() error = %v", err)
+			}
+			if got := MoreRBSPData(r); got != tt.want {
+				t.Errorf("MoreRBSPData() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package rbr
+
+// Writer bit writer producing rbsp bytes, the counterpart of Reader
+type Writer struct {
+	data []byte
+	bits int
+}
+
+// NewWriter return an empty Writer
+func NewWriter() *Writer {
+	return &Writer{}
+}
+
+// WriteBits write the low n bits of v, msb first
+func (w *Writer) WriteBits(v uint64, n uint) {
+	for i := int(n) - 1; i >= 0; i-- {
+		if w.bits&7 == 0 {
+			w.data = append(w.data, 0)
+		}
+		if v>>uint(i)&1 == 1 {
+			w.data[len(w.data)-1] |= 0x80 >> uint(w.bits&7)
+		}
+		w.bits++
+	}
+}
+
+// WriteFlag write one bit
+func (w *Writer) WriteFlag(b bool) {
+	if b {
+		w.WriteBits(1, 1)
+	} else {
+		w.WriteBits(0, 1)
+	}
+}
+
+// WriteUe write ue(v) exp-golomb code
+func (w *Writer) WriteUe(v uint) {
+	codeNum := uint64(v) + 1
+	leadingZeroBits := uint(0)
+	for codeNum>>(leadingZeroBits+1) != 0 {
+		leadingZeroBits++
+	}
+	w.WriteBits(0, leadingZeroBits)
+	w.WriteBits(codeNum, leadingZeroBits+1)
+}
+
+// WriteSe write se(v) exp-golomb code
+func (w *Writer) WriteSe(v int) {
+	if v > 0 {
+		w.WriteUe(uint(2*v - 1))
+	} else {
+		w.WriteUe(uint(-2 * v))
+	}
+}
+
+// WriteTrailingBits write rbsp_trailing_bits, rbsp_stop_one_bit and alignment zero bits
+func (w *Writer) WriteTrailingBits() {
+	w.WriteBits(1, 1)
+	for w.bits&7 != 0 {
+		w.WriteBits(0, 1)
+	}
+}
+
+// BitPos return count of bits written
+func (w *Writer) BitPos() int {
+	return w.bits
+}
+
+// Bytes return the written bytes, a partial last byte is zero padded
+func (w *Writer) Bytes() []byte {
+	return w.data
+}
@@ -0,0 +1,52 @@
+package internal
+
+// emulation prevention, T-REC-H.264-201402-S!!PDF-E.pdf 7.3.1 NAL unit syntax
+// and 7.4.1 NAL unit semantics
+const emulationPreventionThreeByte = 0x03
+
+// EBSPToRBSP remove emulation_prevention_three_byte from ebsp.
+// It returns a new rbsp slice, ebsp is never modified, and the rbsp byte index
+// in front of which each emulation prevention byte was removed.
+func EBSPToRBSP(ebsp []byte) ([]byte, []int) {
+	rbsp := make([]byte, 0, len(ebsp))
+	var epb []int
+	zeros := 0
+	for _, b := range ebsp {
+		if zeros >= 2 && b == emulationPreventionThreeByte {
+			epb = append(epb, len(rbsp))
+			zeros = 0
+			continue
+		}
+		rbsp = append(rbsp, b)
+		if b == 0 {
+			zeros++
+		} else {
+			zeros = 0
+		}
+	}
+	return rbsp, epb
+}
+
+// RBSPToEBSP insert emulation_prevention_three_byte into rbsp so that no
+// 0x000000, 0x000001, 0x000002 or 0x000003 sequence appears in the result
+func RBSPToEBSP(rbsp []byte) []byte {
+	ebsp := make([]byte, 0, len(rbsp)+len(rbsp)/64)
+	zeros := 0
+	for _, b := range rbsp {
+		if zeros >= 2 && b <= emulationPreventionThreeByte {
+			ebsp = append(ebsp, emulationPreventionThreeByte)
+			zeros = 0
+		}
+		ebsp = append(ebsp, b)
+		if b == 0 {
+			zeros++
+		} else {
+			zeros = 0
+		}
+	}
+	// rbsp ending with cabac_zero_word, 7.4.1
+	if len(rbsp) > 0 && rbsp[len(rbsp)-1] == 0 {
+		ebsp = append(ebsp, emulationPreventionThreeByte)
+	}
+	return ebsp
+}
@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestEBSPToRBSP(t *testing.T) {
+	tests := []struct {
+		name    string
+		ebsp    []byte
+		want    []byte
+		wantEpb []int
+	}{
+		{"empty", []byte{}, []byte{}, nil},
+		{"no emulation", []byte{0x42, 0x00, 0x01, 0x00, 0x00, 0x7D}, []byte{0x42, 0x00, 0x01, 0x00, 0x00, 0x7D}, nil},
+		{"000003 00", []byte{0x00, 0x00, 0x03, 0x00}, []byte{0x00, 0x00, 0x00}, []int{2}},
+		{"000003 01", []byte{0x11, 0x00, 0x00, 0x03, 0x01}, []byte{0x11, 0x00, 0x00, 0x01}, []int{3}},
+		{"000003 03", []byte{0x00, 0x00, 0x03, 0x03, 0xFF}, []byte{0x00, 0x00, 0x03, 0xFF}, []int{2}},
+		{"trailing 000003", []byte{0xAB, 0x00, 0x00, 0x03}, []byte{0xAB, 0x00, 0x00}, []int{3}},
+		{"back to back", []byte{0x00, 0x00, 0x03, 0x00, 0x00, 0x03, 0x00}, []byte{0x00, 0x00, 0x00, 0x00, 0x00}, []int{2, 4}},
+		{"zero run restarts after epb", []byte{0x00, 0x00, 0x03, 0x00, 0x03}, []byte{0x00, 0x00, 0x00, 0x03}, []int{2}},
+		{"single zero", []byte{0x00, 0x03, 0x00, 0x03}, []byte{0x00, 0x03, 0x00, 0x03}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, epb := EBSPToRBSP(tt.ebsp)
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("EBSPToRBSP() rbsp = %x, want %x", got, tt.want)
+			}
+			if !reflect.DeepEqual(epb, tt.wantEpb) {
+				t.Errorf("EBSPToRBSP() epb = %v, want %v", epb, tt.wantEpb)
+			}
+		})
+	}
+}
+
+func TestRBSPToEBSP(t *testing.T) {
+	tests := []struct {
+		name string
+		rbsp []byte
+		want []byte
+	}{
+		{"empty", []byte{}, []byte{}},
+		{"no emulation", []byte{0x00, 0x04, 0x00, 0x00, 0x04}, []byte{0x00, 0x04, 0x00, 0x00, 0x04}},
+		{"start code", []byte{0x00, 0x00, 0x01}, []byte{0x00, 0x00, 0x03, 0x01}},
+		{"three zeros", []byte{0x00, 0x00, 0x00, 0x01}, []byte{0x00, 0x00, 0x03, 0x00, 0x01}},
+		{"literal 03", []byte{0x00, 0x00, 0x03}, []byte{0x00, 0x00, 0x03, 0x03}},
+		{"cabac zero word", []byte{0x80, 0x00, 0x00}, []byte{0x80, 0x00, 0x00, 0x03}},
+		{"long zero run", []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, []byte{0x00, 0x00, 0x03, 0x00, 0x00, 0x03, 0x00, 0x00, 0x03}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RBSPToEBSP(tt.rbsp)
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("RBSPToEBSP() = %x, want %x", got, tt.want)
+			}
+			for i := 0; i+2 < len(got); i++ {
+				if got[i] == 0 && got[i+1] == 0 && got[i+2] < 3 {
+					t.Errorf("RBSPToEBSP() = %x contains start code emulation at %v", got, i)
+				}
+			}
+			back, _ := EBSPToRBSP(got)
+			if !bytes.Equal(back, tt.rbsp) {
+				t.Errorf("EBSPToRBSP(RBSPToEBSP()) = %x, want %x", back, tt.rbsp)
+			}
+		})
+	}
+}
+
+func TestNaluByteOffset(t *testing.T) {
+	// header 0x67, ebsp 11 00 00 03 01 22 00 00 03 00 33
+	data := []byte{0x67, 0x11, 0x00, 0x00, 0x03, 0x01, 0x22, 0x00, 0x00, 0x03, 0x00, 0x33}
+	nl := NewNalu()
+	if err := nl.Load(data); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if want := []byte{0x11, 0x00, 0x00, 0x01, 0x22, 0x00, 0x00, 0x00, 0x33}; !bytes.Equal(nl.Rbsp(), want) {
+		t.Fatalf("Rbsp() = %x, want %x", nl.Rbsp(), want)
+	}
+	if nl.EmulationPreventionBytes() != 2 {
+		t.Errorf("EmulationPreventionBytes() = %v, want 2", nl.EmulationPreventionBytes())
+	}
+	tests := []struct {
+		bitOffset int
+		want      int
+	}{
+		{0, 1},
+		{7, 1},
+		{16, 3},
+		{24, 5},
+		{39, 6},
+		{56, 10},
+		{64, 11},
+	}
+	for _, tt := range tests {
+		if got := nl.NaluByteOffset(tt.bitOffset); got != tt.want {
+			t.Errorf("NaluByteOffset(%v) = %v, want %v", tt.bitOffset, got, tt.want)
+		}
+		if rbspByte := tt.bitOffset / 8; data[nl.NaluByteOffset(tt.bitOffset)] != nl.Rbsp()[rbspByte] {
+			t.Errorf("NaluByteOffset(%v) points at %x, want %x", tt.bitOffset, data[nl.NaluByteOffset(tt.bitOffset)], nl.Rbsp()[rbspByte])
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package reflist
+
+import (
+	"sort"
+
+	"github.com/LiveStudioSolution/h264decoder/internal/dpb"
+	"github.com/LiveStudioSolution/h264decoder/internal/motion"
+)
+
+// initP initial RefPicList0 of a P or SP slice,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.2.4.2.1 Initialisation process for the reference picture list for P and SP slices in frames
+// and 8.2.4.2.2 Initialisation process for the reference picture list for P and SP slices in fields
+func initP(frames []*dpb.Frame, s *Slice) []*Ref {
+	h := s.Header
+	frameNum, maxFrameNum := int(h.FrameNum), int(h.SPS.MaxFrameNum())
+	parity := s.parity()
+	var shortTerm, longTerm []*dpb.Frame
+	for _, f := range frames {
+		if parity < 0 && f.IsShortTermFrame() || parity >= 0 && hasField(f, dpb.ShortTerm) {
+			shortTerm = append(shortTerm, f)
+		}
+		if parity < 0 && f.IsLongTermFrame() || parity >= 0 && hasField(f, dpb.LongTerm) {
+			longTerm = append(longTerm, f)
+		}
+	}
+	// descending PicNum, or FrameNumWrap of fields, and ascending LongTermPicNum, or
+	// LongTermFrameIdx of fields, alike for a given parity
+	sort.SliceStable(shortTerm, func(i, j int) bool {
+		return shortTerm[i].FrameNumWrap(frameNum, maxFrameNum) > shortTerm[j].FrameNumWrap(frameNum, maxFrameNum)
+	})
+	sort.SliceStable(longTerm, func(i, j int) bool {
+		return longTerm[i].LongTermFrameIdx < longTerm[j].LongTermFrameIdx
+	})
+	if parity < 0 {
+		return append(frameRefs(shortTerm, false), frameRefs(longTerm, true)...)
+	}
+	return append(alternate(shortTerm, dpb.ShortTerm, parity), alternate(longTerm, dpb.LongTerm, parity)...)
+}
+
+// initB initial RefPicList0 and RefPicList1 of a B slice,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.2.4.2.3 Initialisation process for reference picture lists for B slices in frames
+// and 8.2.4.2.4 Initialisation process for reference picture lists for B slices in fields
+func initB(frames []*dpb.Frame, s *Slice) [2][]*Ref {
+	parity := s.parity()
+	poc := s.poc()
+	// "non-existing" frames have no PicOrderCnt( ) with pic_order_cnt_type 0
+	skipNonExisting := s.Header.SPS.PicOrderCntType == 0
+	var before, after, longTerm []*dpb.Frame
+	for _, f := range frames {
+		if f.NonExisting && skipNonExisting {
+			continue
+		}
+		if parity < 0 && f.IsShortTermFrame() || parity >= 0 && hasField(f, dpb.ShortTerm) {
+			// PicOrderCnt( ) of the first field alone for the second field of the pair
+			if shortTermPoc(f) <= poc {
+				before = append(before, f)
+			} else {
+				after = append(after, f)
+			}
+		}
+		if parity < 0 && f.IsLongTermFrame() || parity >= 0 && hasField(f, dpb.LongTerm) {
+			longTerm = append(longTerm, f)
+		}
+	}
+	sort.SliceStable(before, func(i, j int) bool { return shortTermPoc(before[i]) > shortTermPoc(before[j]) })
+	sort.SliceStable(after, func(i, j int) bool { return shortTermPoc(after[i]) < shortTermPoc(after[j]) })
+	sort.SliceStable(longTerm, func(i, j int) bool { return longTerm[i].LongTermFrameIdx < longTerm[j].LongTermFrameIdx })
+	shortTerm := [2][]*dpb.Frame{
+		append(append([]*dpb.Frame{}, before...), after...),
+		append(append([]*dpb.Frame{}, after...), before...),
+	}
+
+	var lists [2][]*Ref
+	for list := range lists {
+		if parity < 0 {
+			lists[list] = append(frameRefs(shortTerm[list], false), frameRefs(longTerm, true)...)
+		} else {
+			lists[list] = append(alternate(shortTerm[list], dpb.ShortTerm, parity), alternate(longTerm, dpb.LongTerm, parity)...)
+		}
+	}
+	if len(lists[1]) > 1 && equal(lists[0], lists[1]) {
+		lists[1][0], lists[1][1] = lists[1][1], lists[1][0]
+	}
+	return lists
+}
+
+// hasField report whether a decoded field of f is marked m
+func hasField(f *dpb.Frame, m dpb.Marking) bool {
+	return f.Decoded[0] && f.Marking[0] == m || f.Decoded[1] && f.Marking[1] == m
+}
+
+// shortTermPoc PicOrderCnt( ) of the reference entry f of the short-term lists, of
+// its decoded fields marked as used for short-term reference, which are both fields
+// of a short-term reference frame
+func shortTermPoc(f *dpb.Frame) int {
+	top := f.Decoded[0] && f.Marking[0] == dpb.ShortTerm
+	bottom := f.Decoded[1] && f.Marking[1] == dpb.ShortTerm
+	switch {
+	case top && !bottom:
+		return f.Poc[0]
+	case bottom && !top:
+		return f.Poc[1]
+	}
+	return f.PicOrderCnt()
+}
+
+// frameRefs references to frames, long-term ones for longTerm
+func frameRefs(frames []*dpb.Frame, longTerm bool) []*Ref {
+	refs := make([]*Ref, 0, len(frames))
+	for _, f := range frames {
+		refs = append(refs, &Ref{Frame: f, Structure: motion.Frame, LongTerm: longTerm})
+	}
+	return refs
+}
+
+// alternate the fields marked m of frames, alternating between parities starting
+// with the one of the current field parity, the fields left of one parity following
+// in order when the other one runs out,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.2.4.2.5 Initialisation process for reference picture lists in fields
+func alternate(frames []*dpb.Frame, m dpb.Marking, parity int) []*Ref {
+	var refs []*Ref
+	var next [2]int
+	// field next field of parity p, nil when there is none left
+	field := func(p int) *Ref {
+		for ; next[p] < len(frames); next[p]++ {
+			f := frames[next[p]]
+			if f.Decoded[p] && f.Marking[p] == m {
+				next[p]++
+				return &Ref{Frame: f, Structure: fieldStructure(p), LongTerm: m == dpb.LongTerm}
+			}
+		}
+		return nil
+	}
+	p := parity
+	for {
+		r := field(p)
+		if r == nil {
+			for r = field(1 - p); r != nil; r = field(1 - p) {
+				refs = append(refs, r)
+			}
+			return refs
+		}
+		refs = append(refs, r)
+		p = 1 - p
+	}
+}
+
+func fieldStructure(parity int) motion.Structure {
+	if parity == 1 {
+		return motion.BottomField
+	}
+	return motion.TopField
+}
+
+// equal report whether the lists a and b hold the same references
+func equal(a, b []*Ref) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !same(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
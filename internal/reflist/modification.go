@@ -0,0 +1,139 @@
+package reflist
+
+import (
+	"fmt"
+
+	"github.com/LiveStudioSolution/h264decoder/internal/dpb"
+	"github.com/LiveStudioSolution/h264decoder/internal/motion"
+	"github.com/LiveStudioSolution/h264decoder/internal/slice"
+)
+
+// modify apply the ref_pic_list_modification commands mods to RefPicListX entries of
+// list X, num_ref_idx_lX_active_minus1 + 2 entries long,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.2.4.3 Modification process for reference picture lists
+// and H.8.2.2 Modification process for reference picture lists
+func modify(frames []*dpb.Frame, s *Slice, list int, entries []*Ref, mods []slice.RefPicListModification) error {
+	h := s.Header
+	parity := s.parity()
+	frameNum := int(h.FrameNum)
+	maxFrameNum := int(h.SPS.MaxFrameNum())
+	currPicNum, maxPicNum := frameNum, maxFrameNum
+	if parity >= 0 {
+		currPicNum, maxPicNum = 2*frameNum+1, 2*maxFrameNum
+	}
+	interView := s.InterView[list]
+	maxViewIdx := len(interView)
+
+	refIdx := 0
+	picNumPred, picViewIdxPred := currPicNum, -1
+	for _, mod := range mods {
+		if refIdx >= len(entries)-1 {
+			return fmt.Errorf("more ref_pic_list_modification commands than references")
+		}
+		var r *Ref
+		switch mod.ModificationOfPicNumsIdc {
+		case 0, 1:
+			// 8.2.4.3.1 Modification process of reference picture lists for short-term reference pictures
+			absDiff := int(mod.AbsDiffPicNumMinus1) + 1
+			if absDiff > maxPicNum {
+				return fmt.Errorf("abs_diff_pic_num_minus1 %v above MaxPicNum %v", mod.AbsDiffPicNumMinus1, maxPicNum)
+			}
+			picNumNoWrap := picNumPred + absDiff
+			if mod.ModificationOfPicNumsIdc == 0 {
+				if picNumNoWrap = picNumPred - absDiff; picNumNoWrap < 0 {
+					picNumNoWrap += maxPicNum
+				}
+			} else if picNumNoWrap >= maxPicNum {
+				picNumNoWrap -= maxPicNum
+			}
+			picNumPred = picNumNoWrap
+			picNum := picNumNoWrap
+			if picNum > currPicNum {
+				picNum -= maxPicNum
+			}
+			if r = shortTerm(frames, picNum, parity, frameNum, maxFrameNum); r == nil {
+				return fmt.Errorf("no short-term reference picture of picNum %v", picNum)
+			}
+		case 2:
+			// 8.2.4.3.2 Modification process of reference picture lists for long-term reference pictures
+			if r = longTerm(frames, int(mod.LongTermPicNum), parity); r == nil {
+				return fmt.Errorf("no long-term reference picture of LongTermPicNum %v", mod.LongTermPicNum)
+			}
+		case 4, 5:
+			// H.8.2.2.3 Modification process for reference picture lists for inter-view reference components
+			absDiff := int(mod.AbsDiffViewIdxMinus1) + 1
+			if maxViewIdx == 0 || absDiff > maxViewIdx {
+				return fmt.Errorf("abs_diff_view_idx_minus1 %v of %v inter-view references", mod.AbsDiffViewIdxMinus1, maxViewIdx)
+			}
+			picViewIdx := picViewIdxPred + absDiff
+			if mod.ModificationOfPicNumsIdc == 4 {
+				if picViewIdx = picViewIdxPred - absDiff; picViewIdx < 0 {
+					picViewIdx += maxViewIdx
+				}
+			} else if picViewIdx >= maxViewIdx {
+				picViewIdx -= maxViewIdx
+			}
+			picViewIdxPred = picViewIdx
+			r = interView[picViewIdx]
+		default:
+			return fmt.Errorf("invalid modification_of_pic_nums_idc %v", mod.ModificationOfPicNumsIdc)
+		}
+		refIdx = insert(entries, refIdx, r)
+	}
+	return nil
+}
+
+// insert r at refIdx of entries, shifting the following ones and removing the later
+// duplicate of r, and return the next refIdx
+func insert(entries []*Ref, refIdx int, r *Ref) int {
+	copy(entries[refIdx+1:], entries[refIdx:len(entries)-1])
+	entries[refIdx] = r
+	refIdx++
+	n := refIdx
+	for c := refIdx; c < len(entries); c++ {
+		if !same(entries[c], r) {
+			entries[n] = entries[c]
+			n++
+		}
+	}
+	for ; n < len(entries); n++ {
+		entries[n] = nil
+	}
+	return refIdx
+}
+
+// shortTerm the short-term reference frame, or field, of PicNum picNum
+func shortTerm(frames []*dpb.Frame, picNum, currParity, currFrameNum, maxFrameNum int) *Ref {
+	for _, f := range frames {
+		if currParity < 0 {
+			if f.IsShortTermFrame() && f.PicNum(0, currParity, currFrameNum, maxFrameNum) == picNum {
+				return &Ref{Frame: f, Structure: motion.Frame}
+			}
+			continue
+		}
+		for p, m := range f.Marking {
+			if f.Decoded[p] && m == dpb.ShortTerm && f.PicNum(p, currParity, currFrameNum, maxFrameNum) == picNum {
+				return &Ref{Frame: f, Structure: fieldStructure(p)}
+			}
+		}
+	}
+	return nil
+}
+
+// longTerm the long-term reference frame, or field, of LongTermPicNum longTermPicNum
+func longTerm(frames []*dpb.Frame, longTermPicNum, currParity int) *Ref {
+	for _, f := range frames {
+		if currParity < 0 {
+			if f.IsLongTermFrame() && f.LongTermPicNum(0, currParity) == longTermPicNum {
+				return &Ref{Frame: f, Structure: motion.Frame, LongTerm: true}
+			}
+			continue
+		}
+		for p, m := range f.Marking {
+			if f.Decoded[p] && m == dpb.LongTerm && f.LongTermPicNum(p, currParity) == longTermPicNum {
+				return &Ref{Frame: f, Structure: fieldStructure(p), LongTerm: true}
+			}
+		}
+	}
+	return nil
+}
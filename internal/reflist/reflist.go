@@ -0,0 +1,164 @@
+// Package reflist construct the reference picture lists RefPicList0 and RefPicList1 of
+// a slice from the reference pictures of the DPB,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.2.4 Decoding process for reference picture lists construction
+package reflist
+
+import (
+	"fmt"
+
+	"github.com/LiveStudioSolution/h264decoder/internal/dpb"
+	"github.com/LiveStudioSolution/h264decoder/internal/inter"
+	"github.com/LiveStudioSolution/h264decoder/internal/motion"
+	"github.com/LiveStudioSolution/h264decoder/internal/slice"
+)
+
+// Ref entry of a reference picture list, a frame or complementary field pair of the
+// DPB, or one of its fields
+type Ref struct {
+	Frame *dpb.Frame
+	// Structure motion.Frame for a frame or complementary field pair, else the field referenced
+	Structure motion.Structure
+	LongTerm  bool
+	// InterView inter-view reference component of another view of the access unit, H.8.2.1
+	InterView bool
+}
+
+// same report whether a and b refer to the same picture
+func same(a, b *Ref) bool {
+	return a != nil && b != nil && a.Frame == b.Frame && a.Structure == b.Structure
+}
+
+// Motion the reference as seen by the motion vector derivation
+func (r *Ref) Motion() *motion.Ref {
+	f := r.Frame
+	return &motion.Ref{
+		ID:        f.ID,
+		Structure: r.Structure,
+		TopPoc:    f.Poc[0],
+		BottomPoc: f.Poc[1],
+		LongTerm:  r.LongTerm,
+		Frame:     f.Motion,
+		Fields:    f.FieldMotion,
+	}
+}
+
+// Samples the planes of the reference as seen by the inter prediction, views of the
+// frame planes for a field
+func (r *Ref) Samples() *inter.Ref {
+	if r.Structure == motion.Frame {
+		return &inter.Ref{Planes: r.Frame.Planes}
+	}
+	bottom := r.Structure == motion.BottomField
+	ref := &inter.Ref{Bottom: bottom}
+	for c, p := range r.Frame.Planes {
+		if p != nil {
+			ref.Planes[c] = p.Field(bottom)
+		}
+	}
+	return ref
+}
+
+// Lists RefPicList0 and RefPicList1 of num_ref_idx_l0_active_minus1 + 1 and
+// num_ref_idx_l1_active_minus1 + 1 entries, nil for "no reference picture"
+type Lists [2][]*Ref
+
+// Motion the lists as seen by the motion vector derivation, each cut before its first
+// "no reference picture" entry
+func (l *Lists) Motion() motion.RefLists {
+	var refs motion.RefLists
+	for list, entries := range l {
+		for _, r := range entries {
+			if r == nil {
+				break
+			}
+			refs[list] = append(refs[list], r.Motion())
+		}
+	}
+	return refs
+}
+
+// Slice the current slice the lists are constructed for
+type Slice struct {
+	Header *slice.Header
+	// TopPoc and BottomPoc TopFieldOrderCnt and BottomFieldOrderCnt of the current
+	// picture, only the one of its parity for a field
+	TopPoc    int
+	BottomPoc int
+	// InterView inter-view reference components of RefPicList0 and RefPicList1 in the
+	// order of the anchor or non-anchor references of the MVC sequence parameter set
+	// extension, appended to the initial lists and indexed by modification_of_pic_nums_idc
+	// 4 and 5. Empty for a base view.
+	InterView [2][]*Ref
+}
+
+// parity 0 for a top field, 1 for a bottom field, -1 for a frame
+func (s *Slice) parity() int {
+	switch {
+	case !s.Header.FieldPicFlag:
+		return -1
+	case s.Header.BottomFieldFlag:
+		return 1
+	}
+	return 0
+}
+
+// poc PicOrderCnt( CurrPic )
+func (s *Slice) poc() int {
+	switch s.parity() {
+	case 0:
+		return s.TopPoc
+	case 1:
+		return s.BottomPoc
+	}
+	return imin(s.TopPoc, s.BottomPoc)
+}
+
+// Build construct the reference picture lists of slice s, RefPicList0 of P and SP
+// slices and both lists of B slices, from the frame buffers of the DPB holding the
+// reference pictures, including the first field of the current frame for a second
+// field. The lists of I and SI slices are empty.
+func Build(frames []*dpb.Frame, s *Slice) (Lists, error) {
+	var lists Lists
+	h := s.Header
+	var n [2]int
+	switch h.SliceType {
+	case slice.SliceP, slice.SliceSP:
+		n[0] = int(h.NumRefIdxL0ActiveMinus1) + 1
+	case slice.SliceB:
+		n = [2]int{int(h.NumRefIdxL0ActiveMinus1) + 1, int(h.NumRefIdxL1ActiveMinus1) + 1}
+	default:
+		return lists, nil
+	}
+
+	var initial [2][]*Ref
+	if h.SliceType == slice.SliceB {
+		initial = initB(frames, s)
+	} else {
+		initial[0] = initP(frames, s)
+	}
+	mods := [2][]slice.RefPicListModification{h.RefPicListModificationL0, h.RefPicListModificationL1}
+	for list := range lists {
+		if n[list] == 0 {
+			continue
+		}
+		entries := append(initial[list], s.InterView[list]...)
+		// num_ref_idx_lX_active_minus1 + 1 entries plus one held during the modification
+		lists[list] = make([]*Ref, n[list]+1)
+		copy(lists[list][:n[list]], entries)
+		if err := modify(frames, s, list, lists[list], mods[list]); err != nil {
+			return lists, fmt.Errorf("RefPicList%v: %v", list, err)
+		}
+		lists[list] = lists[list][:n[list]]
+		if lists[list][0] == nil {
+			return lists, fmt.Errorf("RefPicList%v without reference picture", list)
+		}
+	}
+	return lists, nil
+}
+
+func imin(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
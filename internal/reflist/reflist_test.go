@@ -0,0 +1,243 @@
+package reflist
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/LiveStudioSolution/h264decoder/internal"
+	"github.com/LiveStudioSolution/h264decoder/internal/dpb"
+	"github.com/LiveStudioSolution/h264decoder/internal/frame"
+	"github.com/LiveStudioSolution/h264decoder/internal/motion"
+	"github.com/LiveStudioSolution/h264decoder/internal/slice"
+)
+
+// testFrame decoded frame of frame_num frameNum and TopFieldOrderCnt poc, both fields marked m
+func testFrame(id, frameNum, poc int, m dpb.Marking) *dpb.Frame {
+	f := &dpb.Frame{ID: id, FrameNum: frameNum, Decoded: [2]bool{true, true}, Marking: [2]dpb.Marking{m, m}, Poc: [2]int{poc, poc + 1}}
+	if m == dpb.LongTerm {
+		f.LongTermFrameIdx = frameNum
+	}
+	return f
+}
+
+// testField frame buffer holding the field of parity bottom only
+func testField(id, frameNum, poc int, m dpb.Marking, bottom bool) *dpb.Frame {
+	f := testFrame(id, frameNum, poc, m)
+	p := boolToInt(bottom)
+	f.Decoded[1-p], f.Marking[1-p] = false, dpb.Unused
+	return f
+}
+
+// testSlice slice of type t and frame_num frameNum, of TopFieldOrderCnt poc, with n0 and
+// n1 active references
+func testSlice(t slice.SliceType, frameNum uint, poc int, n0, n1 uint, mods ...slice.RefPicListModification) *Slice {
+	h := &slice.Header{
+		SliceType:               t,
+		FrameNum:                frameNum,
+		NumRefIdxL0ActiveMinus1: n0 - 1,
+		NumRefIdxL1ActiveMinus1: n1 - 1,
+		SPS:                     &internal.SPS{},
+	}
+	if len(mods) > 0 {
+		h.RefPicListModificationFlagL0 = true
+		h.RefPicListModificationL0 = mods
+	}
+	return &Slice{Header: h, TopPoc: poc, BottomPoc: poc + 1}
+}
+
+// field slice s of a field of parity bottom
+func field(s *Slice, bottom bool) *Slice {
+	s.Header.FieldPicFlag, s.Header.BottomFieldFlag = true, bottom
+	return s
+}
+
+// names short names of the references of list, f, t and b for frames, top and bottom
+// fields followed by the frame ID and L for long-term, v for inter-view, - for none
+func names(list []*Ref) string {
+	var s []string
+	for _, r := range list {
+		switch {
+		case r == nil:
+			s = append(s, "-")
+			continue
+		case r.InterView:
+			s = append(s, fmt.Sprintf("v%v", r.Frame.ID))
+			continue
+		}
+		name := fmt.Sprintf("%v%v", "ftb"[r.Structure:r.Structure+1], r.Frame.ID)
+		if r.LongTerm {
+			name += "L"
+		}
+		s = append(s, name)
+	}
+	return strings.Join(s, " ")
+}
+
+func mod(idc, arg uint) slice.RefPicListModification {
+	m := slice.RefPicListModification{ModificationOfPicNumsIdc: idc}
+	switch idc {
+	case 0, 1:
+		m.AbsDiffPicNumMinus1 = arg
+	case 2:
+		m.LongTermPicNum = arg
+	default:
+		m.AbsDiffViewIdxMinus1 = arg
+	}
+	return m
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func TestBuild(t *testing.T) {
+	short, long := dpb.ShortTerm, dpb.LongTerm
+	pFrames := []*dpb.Frame{
+		testFrame(1, 1, 2, short), testFrame(2, 2, 4, short), testFrame(3, 3, 6, short),
+		testFrame(4, 1, 0, long), testFrame(5, 0, 8, long),
+		// neither complete nor marked, not used by frames
+		testField(6, 3, 7, short, true), testFrame(7, 0, 10, dpb.Unused),
+	}
+	bFrames := []*dpb.Frame{
+		testFrame(1, 0, 0, short), testFrame(2, 1, 4, short), testFrame(3, 2, 8, short),
+		testFrame(4, 3, 12, short), testFrame(5, 0, 2, long),
+	}
+	halfLong := testFrame(2, 1, 8, short)
+	halfLong.Poc[1], halfLong.Marking[1] = 3, long
+	nonExisting := testFrame(6, 4, 0, short)
+	nonExisting.NonExisting = true
+	fields := []*dpb.Frame{
+		testFrame(1, 1, 0, short), testField(2, 2, 8, short, false), testFrame(3, 0, 4, long),
+	}
+
+	tests := []struct {
+		name   string
+		frames []*dpb.Frame
+		s      *Slice
+		l0, l1 string
+	}{
+		{"P frame", pFrames, testSlice(slice.SliceP, 4, 10, 6, 1), "f3 f2 f1 f5L f4L -", ""},
+		{"P frame truncated", pFrames, testSlice(slice.SliceSP, 4, 10, 2, 1), "f3 f2", ""},
+		{"P frame_num wrapped", []*dpb.Frame{testFrame(1, 14, 0, short), testFrame(2, 15, 2, short), testFrame(3, 0, 4, short)},
+			testSlice(slice.SliceP, 1, 6, 3, 1), "f3 f2 f1", ""},
+		{"I", pFrames, testSlice(slice.SliceI, 4, 10, 6, 1), "", ""},
+		{"P top field", fields, field(testSlice(slice.SliceP, 3, 10, 5, 1), false), "t2 b1 t1 t3L b3L", ""},
+		{"P second field", append(fields[:1:1], testField(6, 3, 10, short, false)),
+			field(testSlice(slice.SliceP, 3, 10, 3, 1), true), "b1 t6 t1", ""},
+		{"B frame", bFrames, testSlice(slice.SliceB, 4, 6, 5, 5), "f2 f1 f3 f4 f5L", "f3 f4 f2 f1 f5L"},
+		{"B frame lists swapped", []*dpb.Frame{bFrames[0], bFrames[4]}, testSlice(slice.SliceB, 4, 6, 2, 2), "f1 f5L", "f5L f1"},
+		{"B non-existing", append(bFrames[:2:2], nonExisting), testSlice(slice.SliceB, 5, 6, 2, 2), "f2 f1", "f1 f2"},
+		{"B second field", []*dpb.Frame{testFrame(1, 0, 0, short), testFrame(2, 1, 8, short), testField(3, 2, 6, short, false)},
+			field(testSlice(slice.SliceB, 2, 6, 5, 5), true), "b1 t3 b2 t1 t2", "b2 t2 b1 t3 t1"},
+		{"B field of short-term top field", []*dpb.Frame{testFrame(1, 0, 4, short), halfLong},
+			field(testSlice(slice.SliceB, 2, 6, 4, 4), false), "t1 b1 t2 b2L", "t2 b1 t1 b2L"},
+	}
+	for _, tt := range tests {
+		lists, err := Build(tt.frames, tt.s)
+		if err != nil {
+			t.Errorf("%v: Build() error %v", tt.name, err)
+			continue
+		}
+		if got := names(lists[0]); got != tt.l0 {
+			t.Errorf("%v: RefPicList0 %v, want %v", tt.name, got, tt.l0)
+		}
+		if got := names(lists[1]); got != tt.l1 {
+			t.Errorf("%v: RefPicList1 %v, want %v", tt.name, got, tt.l1)
+		}
+	}
+}
+
+func TestModification(t *testing.T) {
+	short, long := dpb.ShortTerm, dpb.LongTerm
+	frames := []*dpb.Frame{testFrame(1, 1, 2, short), testFrame(2, 2, 4, short), testFrame(3, 3, 6, short), testFrame(5, 0, 8, long)}
+	interView := []*Ref{
+		{Frame: &dpb.Frame{ID: 10}, InterView: true},
+		{Frame: &dpb.Frame{ID: 11}, InterView: true},
+	}
+	tests := []struct {
+		name      string
+		frames    []*dpb.Frame
+		s         *Slice
+		interView []*Ref
+		want      string
+	}{
+		// picNum 1 then 2
+		{"short-term", frames, testSlice(slice.SliceP, 4, 10, 3, 1, mod(0, 2), mod(1, 0)), nil, "f1 f2 f3"},
+		{"long-term", frames, testSlice(slice.SliceP, 4, 10, 4, 1, mod(2, 0), mod(0, 2)), nil, "f5L f1 f3 f2"},
+		// the reference shifted past the list end dropped
+		{"truncated", frames, testSlice(slice.SliceP, 4, 10, 2, 1, mod(0, 2)), nil, "f1 f3"},
+		// picNumNoWrap 15, picNum -1
+		{"wrapped", []*dpb.Frame{testFrame(1, 15, 0, short), testFrame(2, 0, 2, short)},
+			testSlice(slice.SliceP, 1, 4, 2, 1, mod(0, 1)), nil, "f1 f2"},
+		{"wrapped upwards", []*dpb.Frame{testFrame(1, 15, 0, short), testFrame(2, 0, 2, short)},
+			testSlice(slice.SliceP, 1, 4, 2, 1, mod(1, 13)), nil, "f1 f2"},
+		// CurrPicNum 5, PicNum 2 of the bottom field
+		{"field", frames[:1], field(testSlice(slice.SliceP, 2, 10, 2, 1, mod(0, 2)), false), nil, "b1 t1"},
+		// LongTermPicNum 1 of the top field
+		{"long-term field", frames[3:], field(testSlice(slice.SliceP, 2, 10, 2, 1, mod(2, 1)), false), nil, "t5L b5L"},
+		{"padded", frames[:1], testSlice(slice.SliceP, 4, 10, 3, 1, mod(0, 2)), nil, "f1 - -"},
+		{"inter-view", frames[:1], testSlice(slice.SliceP, 4, 10, 3, 1, mod(5, 1), mod(4, 0)), interView, "v11 v10 f1"},
+		{"inter-view wrapped", frames[:1], testSlice(slice.SliceP, 4, 10, 3, 1, mod(4, 0)), interView, "v10 f1 v11"},
+	}
+	for _, tt := range tests {
+		tt.s.InterView[0] = tt.interView
+		lists, err := Build(tt.frames, tt.s)
+		if err != nil {
+			t.Errorf("%v: Build() error %v", tt.name, err)
+			continue
+		}
+		if got := names(lists[0]); got != tt.want {
+			t.Errorf("%v: RefPicList0 %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	frames := []*dpb.Frame{testFrame(1, 1, 2, dpb.ShortTerm)}
+	tests := []struct {
+		name   string
+		frames []*dpb.Frame
+		s      *Slice
+	}{
+		{"no reference", nil, testSlice(slice.SliceP, 2, 4, 1, 1)},
+		{"no RefPicList1 reference", nil, testSlice(slice.SliceB, 2, 4, 1, 1)},
+		{"no picNum", frames, testSlice(slice.SliceP, 2, 4, 1, 1, mod(0, 1))},
+		{"no LongTermPicNum", frames, testSlice(slice.SliceP, 2, 4, 1, 1, mod(2, 0))},
+		{"too many commands", frames, testSlice(slice.SliceP, 2, 4, 1, 1, mod(0, 0), mod(0, 0))},
+		{"no inter-view reference", frames, testSlice(slice.SliceP, 2, 4, 1, 1, mod(4, 0))},
+		{"invalid idc", frames, testSlice(slice.SliceP, 2, 4, 1, 1, mod(6, 0))},
+	}
+	for _, tt := range tests {
+		if _, err := Build(tt.frames, tt.s); err == nil {
+			t.Errorf("%v: Build() succeeded", tt.name)
+		}
+	}
+}
+
+func TestRefConversions(t *testing.T) {
+	f := testFrame(1, 0, 4, dpb.ShortTerm)
+	f.Planes = [3]*frame.Plane{frame.NewPlane(16, 16)}
+	f.FieldMotion[1] = &motion.Picture{Structure: motion.BottomField}
+	f.Planes[0].Set(0, 1, 7)
+	lists := Lists{{{Frame: f, Structure: motion.BottomField}, nil}}
+
+	refs := lists.Motion()
+	if len(refs[0]) != 1 || len(refs[1]) != 0 {
+		t.Fatalf("Motion() %v and %v references, want 1 and 0", len(refs[0]), len(refs[1]))
+	}
+	if m := refs[0][0]; m.ID != 1 || m.Structure != motion.BottomField || m.Poc() != 5 || m.Fields[1] != f.FieldMotion[1] {
+		t.Errorf("Motion() = %+v", m)
+	}
+
+	s := lists[0][0].Samples()
+	if !s.Bottom || s.Planes[0].Height != 8 || s.Planes[0].At(0, 0) != 7 || s.Planes[1] != nil {
+		t.Errorf("Samples() of a bottom field = %+v", s)
+	}
+	if s := (&Ref{Frame: f}).Samples(); s.Bottom || s.Planes[0] != f.Planes[0] {
+		t.Errorf("Samples() of a frame = %+v", s)
+	}
+}
@@ -0,0 +1,179 @@
+package internal
+
+import (
+	"github.com/LiveStudioSolution/h264decoder/internal/rbr"
+)
+
+// T-REC-H.264-201402-S!!PDF-E.pdf Table 7-3 – Specification of default scaling lists Default_4x4_Intra and Default_4x4_Inter
+var (
+	default4x4Intra = [16]uint8{6, 13, 13, 20, 20, 20, 28, 28, 28, 28, 32, 32, 32, 37, 37, 42}
+	default4x4Inter = [16]uint8{10, 14, 14, 20, 20, 20, 24, 24, 24, 24, 27, 27, 27, 30, 30, 34}
+)
+
+// T-REC-H.264-201402-S!!PDF-E.pdf Table 7-4 – Specification of default scaling lists Default_8x8_Intra and Default_8x8_Inter
+var (
+	default8x8Intra = [64]uint8{
+		6, 10, 10, 13, 11, 13, 16, 16, 16, 16, 18, 18, 18, 18, 18, 23,
+		23, 23, 23, 23, 23, 25, 25, 25, 25, 25, 25, 25, 27, 27, 27, 27,
+		27, 27, 27, 27, 29, 29, 29, 29, 29, 29, 29, 31, 31, 31, 31, 31,
+		31, 33, 33, 33, 33, 33, 36, 36, 36, 36, 38, 38, 38, 40, 40, 42,
+	}
+	default8x8Inter = [64]uint8{
+		9, 13, 13, 15, 13, 15, 17, 17, 17, 17, 19, 19, 19, 19, 19, 21,
+		21, 21, 21, 21, 21, 22, 22, 22, 22, 22, 22, 22, 24, 24, 24, 24,
+		24, 24, 24, 24, 25, 25, 25, 25, 25, 25, 25, 27, 27, 27, 27, 27,
+		27, 28, 28, 28, 28, 28, 30, 30, 30, 30, 32, 32, 32, 33, 33, 35,
+	}
+)
+
+// ZigZag4x4 raster index of each 4x4 zig-zag scan position, Table 8-13
+var ZigZag4x4 = [16]int{0, 1, 4, 8, 5, 2, 3, 6, 9, 12, 13, 10, 7, 11, 14, 15}
+
+// ZigZag8x8 raster index of each 8x8 zig-zag scan position, Table 8-14
+var ZigZag8x8 = [64]int{
+	0, 1, 8, 16, 9, 2, 3, 10, 17, 24, 32, 25, 18, 11, 4, 5,
+	12, 19, 26, 33, 40, 48, 41, 34, 27, 20, 13, 6, 7, 14, 21, 28,
+	35, 42, 49, 56, 57, 50, 43, 36, 29, 22, 15, 23, 30, 37, 44, 51,
+	58, 59, 52, 45, 38, 31, 39, 46, 53, 60, 61, 54, 47, 55, 62, 63,
+}
+
+// ScalingMatrices resolved scaling matrices in raster order, the weightScale4x4
+// and weightScale8x8 of 8.5.9. Index i of Matrix4x4 is Intra Y, Cb, Cr then
+// Inter Y, Cb, Cr; Matrix8x8 is Intra Y, Inter Y, Intra Cb, Inter Cb, Intra Cr, Inter Cr.
+type ScalingMatrices struct {
+	Matrix4x4 [6][16]uint8
+	Matrix8x8 [6][64]uint8
+}
+
+// FlatScalingMatrices return Flat_4x4_16 and Flat_8x8_16 matrices
+func FlatScalingMatrices() ScalingMatrices {
+	var sm ScalingMatrices
+	for i := range sm.Matrix4x4 {
+		for j := range sm.Matrix4x4[i] {
+			sm.Matrix4x4[i][j] = 16
+		}
+	}
+	for i := range sm.Matrix8x8 {
+		for j := range sm.Matrix8x8[i] {
+			sm.Matrix8x8[i][j] = 16
+		}
+	}
+	return sm
+}
+
+// parseScalingList parse scaling_list() syntax into list, in zig-zag scan order.
+// It reports whether the default scaling matrix should be used instead.
+// T-REC-H.264-201402-S!!PDF-E.pdf 7.3.2.1.1.1 Scaling list syntax
+func parseScalingList(br rbr.BitReader, list []uint8) (bool, error) {
+	lastScale, nextScale := 8, 8
+	useDefault := false
+	for j := range list {
+		if nextScale != 0 {
+			deltaScale, err := rbr.DecSe(br)
+			if err != nil {
+				return false, err
+			}
+			nextScale = (lastScale + deltaScale + 256) % 256
+			useDefault = j == 0 && nextScale == 0
+		}
+		if nextScale != 0 {
+			list[j] = uint8(nextScale)
+		} else {
+			list[j] = uint8(lastScale)
+		}
+		lastScale = int(list[j])
+	}
+	return useDefault, nil
+}
+
+// parseScalingMatrices parse count scaling lists, applying the fall-back rule
+// of Table 7-2 for lists which are not present. fallback holds the matrices of
+// fall-back rule B, nil selects fall-back rule A.
+func parseScalingMatrices(br rbr.BitReader, count int, fallback *ScalingMatrices) (ScalingMatrices, []bool, error) {
+	var sm ScalingMatrices
+	present := make([]bool, count)
+	var lists4x4 [6][16]uint8
+	var lists8x8 [6][64]uint8
+	for i := 0; i < count; i++ {
+		var err error
+		if present[i], err = br.Read1(); err != nil {
+			return sm, nil, err
+		}
+		useDefault := false
+		if i < 6 {
+			if present[i] {
+				if useDefault, err = parseScalingList(br, lists4x4[i][:]); err != nil {
+					return sm, nil, err
+				}
+			}
+			switch {
+			case present[i] && useDefault:
+				lists4x4[i] = defaultList4x4(i)
+			case !present[i] && (i == 0 || i == 3):
+				if fallback != nil {
+					sm.Matrix4x4[i] = fallback.Matrix4x4[i]
+					continue
+				}
+				lists4x4[i] = defaultList4x4(i)
+			case !present[i]:
+				sm.Matrix4x4[i] = sm.Matrix4x4[i-1]
+				continue
+			}
+			for j, pos := range ZigZag4x4 {
+				sm.Matrix4x4[i][pos] = lists4x4[i][j]
+			}
+			continue
+		}
+		i8 := i - 6
+		if present[i] {
+			if useDefault, err = parseScalingList(br, lists8x8[i8][:]); err != nil {
+				return sm, nil, err
+			}
+		}
+		switch {
+		case present[i] && useDefault:
+			lists8x8[i8] = defaultList8x8(i8)
+		case !present[i] && (i8 == 0 || i8 == 1):
+			if fallback != nil {
+				sm.Matrix8x8[i8] = fallback.Matrix8x8[i8]
+				continue
+			}
+			lists8x8[i8] = defaultList8x8(i8)
+		case !present[i]:
+			sm.Matrix8x8[i8] = sm.Matrix8x8[i8-2]
+			continue
+		}
+		for j, pos := range ZigZag8x8 {
+			sm.Matrix8x8[i8][pos] = lists8x8[i8][j]
+		}
+	}
+	// 4:2:0 and 4:2:2 only carry the luma 8x8 lists, chroma ones follow rule A
+	for i8 := count - 6; i8 < 6; i8++ {
+		if i8 < 2 {
+			if fallback != nil {
+				sm.Matrix8x8[i8] = fallback.Matrix8x8[i8]
+			} else {
+				for j, pos := range ZigZag8x8 {
+					sm.Matrix8x8[i8][pos] = defaultList8x8(i8)[j]
+				}
+			}
+			continue
+		}
+		sm.Matrix8x8[i8] = sm.Matrix8x8[i8-2]
+	}
+	return sm, present, nil
+}
+
+func defaultList4x4(i int) [16]uint8 {
+	if i < 3 {
+		return default4x4Intra
+	}
+	return default4x4Inter
+}
+
+func defaultList8x8(i8 int) [64]uint8 {
+	if i8%2 == 0 {
+		return default8x8Intra
+	}
+	return default8x8Inter
+}
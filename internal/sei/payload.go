@@ -0,0 +1,352 @@
+package sei
+
+import (
+	"fmt"
+
+	"github.com/LiveStudioSolution/h264decoder/internal"
+	"github.com/LiveStudioSolution/h264decoder/internal/rbr"
+)
+
+// BufferingPeriod buffering_period, initial cpb removal delays per SchedSelIdx
+// T-REC-H.264-201402-S!!PDF-E.pdf D.1.2 Buffering period SEI message syntax
+type BufferingPeriod struct {
+	SeqParameterSetId uint
+
+	NalInitialCpbRemovalDelay       []uint32
+	NalInitialCpbRemovalDelayOffset []uint32
+	VclInitialCpbRemovalDelay       []uint32
+	VclInitialCpbRemovalDelayOffset []uint32
+}
+
+// ClockTimestamp clock timestamp of pic_timing, fields not present are zero
+type ClockTimestamp struct {
+	CtType             uint8
+	NuitFieldBasedFlag bool
+	CountingType       uint8
+	FullTimestampFlag  bool
+	DiscontinuityFlag  bool
+	CntDroppedFlag     bool
+	NFrames            uint8
+	SecondsFlag        bool
+	SecondsValue       uint8
+	MinutesFlag        bool
+	MinutesValue       uint8
+	HoursFlag          bool
+	HoursValue         uint8
+	TimeOffset         int32
+}
+
+// PicTiming pic_timing, ClockTimestamps has NumClockTS entries, nil for
+// clock_timestamp_flag equal to 0
+// T-REC-H.264-201402-S!!PDF-E.pdf D.1.3 Picture timing SEI message syntax
+type PicTiming struct {
+	CpbDpbDelaysPresent bool
+	CpbRemovalDelay     uint32
+	DpbOutputDelay      uint32
+
+	PicStructPresent bool
+	PicStruct        uint8
+	ClockTimestamps  []*ClockTimestamp
+}
+
+// RecoveryPoint recovery_point
+// T-REC-H.264-201402-S!!PDF-E.pdf D.1.8 Recovery point SEI message syntax
+type RecoveryPoint struct {
+	RecoveryFrameCnt      uint
+	ExactMatchFlag        bool
+	BrokenLinkFlag        bool
+	ChangingSliceGroupIdc uint8
+}
+
+// UserDataRegistered user_data_registered_itu_t_t35, Payload starts after country code
+// T-REC-H.264-201402-S!!PDF-E.pdf D.1.6 User data registered by Rec. ITU-T T.35 SEI message syntax
+type UserDataRegistered struct {
+	CountryCode          uint8
+	CountryCodeExtension uint8
+	Payload              []byte
+}
+
+// UserDataUnregistered user_data_unregistered
+// T-REC-H.264-201402-S!!PDF-E.pdf D.1.7 User data unregistered SEI message syntax
+type UserDataUnregistered struct {
+	UUID    [16]byte
+	Payload []byte
+}
+
+// MasteringDisplayColourVolume mastering_display_colour_volume, primaries in
+// 0.00002 units, luminance in 0.0001 cd/m2
+// T-REC-H.264-201610-S!!PDF-E.pdf Mastering display colour volume SEI message syntax
+type MasteringDisplayColourVolume struct {
+	DisplayPrimariesX            [3]uint16
+	DisplayPrimariesY            [3]uint16
+	WhitePointX                  uint16
+	WhitePointY                  uint16
+	MaxDisplayMasteringLuminance uint32
+	MinDisplayMasteringLuminance uint32
+}
+
+// ContentLightLevelInfo content_light_level_info, in cd/m2
+// T-REC-H.264-201610-S!!PDF-E.pdf Content light level information SEI message syntax
+type ContentLightLevelInfo struct {
+	MaxContentLightLevel    uint16
+	MaxPicAverageLightLevel uint16
+}
+
+// NumClockTS return clock timestamp count of pic_struct, Table D-1
+func NumClockTS(picStruct uint8) int {
+	switch picStruct {
+	case 0, 1, 2:
+		return 1
+	case 3, 4, 7:
+		return 2
+	case 5, 6, 8:
+		return 3
+	}
+	return 0
+}
+
+// hrd return hrd parameters of sps, shared by nal and vcl hrd
+func hrd(sps *internal.SPS) (*internal.HrdParameters, bool, bool) {
+	vui := &sps.VuiParams
+	if !sps.VuiParametersPresentFlag {
+		return &vui.HrdParameters, false, false
+	}
+	return &vui.HrdParameters, vui.NalHrdParametersPresentFlag, vui.VclHrdParametersPresentFlag
+}
+
+func parseBufferingPeriod(data []byte, ps ParameterSets) (*BufferingPeriod, error) {
+	br := rbr.NewReader(data)
+	bp := &BufferingPeriod{}
+	var err error
+	if bp.SeqParameterSetId, err = rbr.DecUe(br); err != nil {
+		return nil, err
+	}
+	var sps *internal.SPS
+	if ps != nil {
+		sps = ps.Sps(bp.SeqParameterSetId)
+	}
+	if sps == nil {
+		// delays can not be parsed without sps, keep its id
+		return bp, nil
+	}
+	h, nal, vcl := hrd(sps)
+	bits := uint(h.InitialCpbRemovalDelayLengthMinus1) + 1
+	readDelays := func() ([]uint32, []uint32, error) {
+		delay := make([]uint32, h.CpbCntMinus1+1)
+		offset := make([]uint32, h.CpbCntMinus1+1)
+		for i := range delay {
+			if delay[i], err = br.Read32(bits); err != nil {
+				return nil, nil, err
+			}
+			if offset[i], err = br.Read32(bits); err != nil {
+				return nil, nil, err
+			}
+		}
+		return delay, offset, nil
+	}
+	if nal {
+		if bp.NalInitialCpbRemovalDelay, bp.NalInitialCpbRemovalDelayOffset, err = readDelays(); err != nil {
+			return bp, err
+		}
+	}
+	if vcl {
+		if bp.VclInitialCpbRemovalDelay, bp.VclInitialCpbRemovalDelayOffset, err = readDelays(); err != nil {
+			return bp, err
+		}
+	}
+	return bp, nil
+}
+
+func parsePicTiming(data []byte, sps *internal.SPS) (*PicTiming, error) {
+	br := rbr.NewReader(data)
+	pt := &PicTiming{}
+	h, nal, vcl := hrd(sps)
+	var err error
+	if nal || vcl {
+		pt.CpbDpbDelaysPresent = true
+		if pt.CpbRemovalDelay, err = br.Read32(uint(h.CpbRemovalDelayLengthMinus1) + 1); err != nil {
+			return nil, err
+		}
+		if pt.DpbOutputDelay, err = br.Read32(uint(h.DpbOutputDelayLengthMinus1) + 1); err != nil {
+			return nil, err
+		}
+	}
+	if !sps.VuiParametersPresentFlag || !sps.VuiParams.PicStructPresentFlag {
+		return pt, nil
+	}
+	pt.PicStructPresent = true
+	if pt.PicStruct, err = br.Read8(4); err != nil {
+		return nil, err
+	}
+	if pt.PicStruct > 8 {
+		return nil, fmt.Errorf("invalid pic_struct %v", pt.PicStruct)
+	}
+	pt.ClockTimestamps = make([]*ClockTimestamp, NumClockTS(pt.PicStruct))
+	for i := range pt.ClockTimestamps {
+		flag, err := br.Read1()
+		if err != nil {
+			return nil, err
+		}
+		if flag {
+			if pt.ClockTimestamps[i], err = parseClockTimestamp(br, uint(h.TimeOffsetLengths)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return pt, nil
+}
+
+func parseClockTimestamp(br *rbr.Reader, timeOffsetLength uint) (*ClockTimestamp, error) {
+	ct := &ClockTimestamp{}
+	var err error
+	if ct.CtType, err = br.Read8(2); err != nil {
+		return nil, err
+	}
+	if ct.NuitFieldBasedFlag, err = br.Read1(); err != nil {
+		return nil, err
+	}
+	if ct.CountingType, err = br.Read8(5); err != nil {
+		return nil, err
+	}
+	if ct.FullTimestampFlag, err = br.Read1(); err != nil {
+		return nil, err
+	}
+	if ct.DiscontinuityFlag, err = br.Read1(); err != nil {
+		return nil, err
+	}
+	if ct.CntDroppedFlag, err = br.Read1(); err != nil {
+		return nil, err
+	}
+	if ct.NFrames, err = br.Read8(8); err != nil {
+		return nil, err
+	}
+	if ct.FullTimestampFlag {
+		ct.SecondsFlag, ct.MinutesFlag, ct.HoursFlag = true, true, true
+		if ct.SecondsValue, err = br.Read8(6); err != nil {
+			return nil, err
+		}
+		if ct.MinutesValue, err = br.Read8(6); err != nil {
+			return nil, err
+		}
+		if ct.HoursValue, err = br.Read8(5); err != nil {
+			return nil, err
+		}
+	} else {
+		if ct.SecondsFlag, err = br.Read1(); err != nil {
+			return nil, err
+		}
+		if ct.SecondsFlag {
+			if ct.SecondsValue, err = br.Read8(6); err != nil {
+				return nil, err
+			}
+			if ct.MinutesFlag, err = br.Read1(); err != nil {
+				return nil, err
+			}
+		}
+		if ct.MinutesFlag {
+			if ct.MinutesValue, err = br.Read8(6); err != nil {
+				return nil, err
+			}
+			if ct.HoursFlag, err = br.Read1(); err != nil {
+				return nil, err
+			}
+		}
+		if ct.HoursFlag {
+			if ct.HoursValue, err = br.Read8(5); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if timeOffsetLength > 0 {
+		v, err := br.Read32(timeOffsetLength)
+		if err != nil {
+			return nil, err
+		}
+		// i(v), two's complement of timeOffsetLength bits
+		ct.TimeOffset = int32(v<<(32-timeOffsetLength)) >> (32 - timeOffsetLength)
+	}
+	return ct, nil
+}
+
+func parseRecoveryPoint(data []byte) (*RecoveryPoint, error) {
+	br := rbr.NewReader(data)
+	rp := &RecoveryPoint{}
+	var err error
+	if rp.RecoveryFrameCnt, err = rbr.DecUe(br); err != nil {
+		return nil, err
+	}
+	if rp.ExactMatchFlag, err = br.Read1(); err != nil {
+		return nil, err
+	}
+	if rp.BrokenLinkFlag, err = br.Read1(); err != nil {
+		return nil, err
+	}
+	if rp.ChangingSliceGroupIdc, err = br.Read8(2); err != nil {
+		return nil, err
+	}
+	return rp, nil
+}
+
+func parseUserDataRegistered(data []byte) (*UserDataRegistered, error) {
+	if len(data) < 1 {
+		return nil, fmt.Errorf("missing itu_t_t35_country_code")
+	}
+	ud := &UserDataRegistered{CountryCode: data[0], Payload: data[1:]}
+	if ud.CountryCode == 0xFF {
+		if len(data) < 2 {
+			return nil, fmt.Errorf("missing itu_t_t35_country_code_extension_byte")
+		}
+		ud.CountryCodeExtension = data[1]
+		ud.Payload = data[2:]
+	}
+	return ud, nil
+}
+
+func parseUserDataUnregistered(data []byte) (*UserDataUnregistered, error) {
+	if len(data) < 16 {
+		return nil, fmt.Errorf("missing uuid_iso_iec_11578")
+	}
+	ud := &UserDataUnregistered{Payload: data[16:]}
+	copy(ud.UUID[:], data)
+	return ud, nil
+}
+
+func parseMasteringDisplayColourVolume(data []byte) (*MasteringDisplayColourVolume, error) {
+	br := rbr.NewReader(data)
+	md := &MasteringDisplayColourVolume{}
+	var err error
+	for c := 0; c < 3; c++ {
+		if md.DisplayPrimariesX[c], err = br.Read16(16); err != nil {
+			return nil, err
+		}
+		if md.DisplayPrimariesY[c], err = br.Read16(16); err != nil {
+			return nil, err
+		}
+	}
+	if md.WhitePointX, err = br.Read16(16); err != nil {
+		return nil, err
+	}
+	if md.WhitePointY, err = br.Read16(16); err != nil {
+		return nil, err
+	}
+	if md.MaxDisplayMasteringLuminance, err = br.Read32(32); err != nil {
+		return nil, err
+	}
+	if md.MinDisplayMasteringLuminance, err = br.Read32(32); err != nil {
+		return nil, err
+	}
+	return md, nil
+}
+
+func parseContentLightLevelInfo(data []byte) (*ContentLightLevelInfo, error) {
+	br := rbr.NewReader(data)
+	cll := &ContentLightLevelInfo{}
+	var err error
+	if cll.MaxContentLightLevel, err = br.Read16(16); err != nil {
+		return nil, err
+	}
+	if cll.MaxPicAverageLightLevel, err = br.Read16(16); err != nil {
+		return nil, err
+	}
+	return cll, nil
+}
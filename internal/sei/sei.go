@@ -0,0 +1,160 @@
+package sei
+
+import (
+	"fmt"
+
+	"github.com/LiveStudioSolution/h264decoder/internal"
+)
+
+// PayloadType of sei message
+// T-REC-H.264-201402-S!!PDF-E.pdf D.1.1 General SEI message syntax
+type PayloadType uint
+
+const (
+	PayloadBufferingPeriod              PayloadType = 0
+	PayloadPicTiming                    PayloadType = 1
+	PayloadPanScanRect                  PayloadType = 2
+	PayloadFiller                       PayloadType = 3
+	PayloadUserDataRegistered           PayloadType = 4
+	PayloadUserDataUnregistered         PayloadType = 5
+	PayloadRecoveryPoint                PayloadType = 6
+	PayloadMasteringDisplayColourVolume PayloadType = 137
+	PayloadContentLightLevelInfo        PayloadType = 144
+)
+
+func (pt PayloadType) String() string {
+	switch pt {
+	case PayloadBufferingPeriod:
+		return "BufferingPeriod"
+	case PayloadPicTiming:
+		return "PicTiming"
+	case PayloadPanScanRect:
+		return "PanScanRect"
+	case PayloadFiller:
+		return "Filler"
+	case PayloadUserDataRegistered:
+		return "UserDataRegistered"
+	case PayloadUserDataUnregistered:
+		return "UserDataUnregistered"
+	case PayloadRecoveryPoint:
+		return "RecoveryPoint"
+	case PayloadMasteringDisplayColourVolume:
+		return "MasteringDisplayColourVolume"
+	case PayloadContentLightLevelInfo:
+		return "ContentLightLevelInfo"
+	}
+	return fmt.Sprintf("Payload:%d", uint(pt))
+}
+
+// ParameterSets give sps needed by buffering_period and pic_timing,
+// implemented by internal.ParameterSetStore
+type ParameterSets interface {
+	Sps(id uint) *internal.SPS
+	ActiveSps() *internal.SPS
+}
+
+// Message sei_message, Payload is one of the typed payloads of this package
+// or nil when payload type is not supported, Raw always hold payload bytes
+type Message struct {
+	Type    PayloadType
+	Payload interface{}
+	Raw     []byte
+}
+
+func (m Message) String() string {
+	if m.Payload == nil {
+		return fmt.Sprintf("%v %x", m.Type, m.Raw)
+	}
+	return fmt.Sprintf("%v %+v", m.Type, m.Payload)
+}
+
+// Parse split sei nalu into sei messages, see ParseRBSP
+func Parse(nalu *internal.Nalu, ps ParameterSets) ([]Message, error) {
+	if nalu.Type() != internal.NaluSei {
+		return nil, fmt.Errorf("parse sei from nalu %v", nalu.Type())
+	}
+	return ParseRBSP(nalu.Rbsp(), ps)
+}
+
+// ParseRBSP split sei_rbsp into sei messages and decode their payloads.
+// pic_timing needs the sps, which is taken from a buffering_period earlier in
+// the same sei or else ps.ActiveSps. Without sps pic_timing is kept raw.
+// Messages parsed before an error are returned along with it.
+// T-REC-H.264-201402-S!!PDF-E.pdf 7.3.2.3 Supplemental enhancement information RBSP syntax
+func ParseRBSP(rbsp []byte, ps ParameterSets) ([]Message, error) {
+	var msgs []Message
+	var sps *internal.SPS
+	if ps != nil {
+		sps = ps.ActiveSps()
+	}
+	pos := 0
+	for moreMessages(rbsp[pos:]) {
+		payloadType, n, err := readFFCoded(rbsp[pos:])
+		if err != nil {
+			return msgs, err
+		}
+		pos += n
+		payloadSize, n, err := readFFCoded(rbsp[pos:])
+		if err != nil {
+			return msgs, err
+		}
+		pos += n
+		if pos+int(payloadSize) > len(rbsp) {
+			return msgs, fmt.Errorf("sei %v payload size %v exceeds rbsp", PayloadType(payloadType), payloadSize)
+		}
+		msg := Message{Type: PayloadType(payloadType), Raw: rbsp[pos : pos+int(payloadSize)]}
+		pos += int(payloadSize)
+
+		switch msg.Type {
+		case PayloadBufferingPeriod:
+			var bp *BufferingPeriod
+			if bp, err = parseBufferingPeriod(msg.Raw, ps); bp != nil {
+				msg.Payload = bp
+				if ps != nil && ps.Sps(bp.SeqParameterSetId) != nil {
+					sps = ps.Sps(bp.SeqParameterSetId)
+				}
+			}
+		case PayloadPicTiming:
+			if sps != nil {
+				msg.Payload, err = parsePicTiming(msg.Raw, sps)
+			}
+		case PayloadUserDataRegistered:
+			msg.Payload, err = parseUserDataRegistered(msg.Raw)
+		case PayloadUserDataUnregistered:
+			msg.Payload, err = parseUserDataUnregistered(msg.Raw)
+		case PayloadRecoveryPoint:
+			msg.Payload, err = parseRecoveryPoint(msg.Raw)
+		case PayloadMasteringDisplayColourVolume:
+			msg.Payload, err = parseMasteringDisplayColourVolume(msg.Raw)
+		case PayloadContentLightLevelInfo:
+			msg.Payload, err = parseContentLightLevelInfo(msg.Raw)
+		}
+		if err != nil {
+			return msgs, fmt.Errorf("sei %v: %v", msg.Type, err)
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
+
+// moreMessages report whether anything other than rbsp_trailing_bits is left
+func moreMessages(data []byte) bool {
+	end := len(data)
+	for end > 0 && data[end-1] == 0 {
+		end--
+	}
+	return end > 1 || (end == 1 && data[0] != 0x80)
+}
+
+// readFFCoded read payloadType or payloadSize, a run of 0xFF bytes each adding 255
+// followed by the last byte
+func readFFCoded(data []byte) (uint, int, error) {
+	v := uint(0)
+	for i, b := range data {
+		v += uint(b)
+		if b != 0xFF {
+			return v, i + 1, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("sei message truncated")
+}
@@ -0,0 +1,202 @@
+package sei
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/LiveStudioSolution/h264decoder/internal"
+	"github.com/LiveStudioSolution/h264decoder/internal/rbr"
+)
+
+type testParamSets struct {
+	sps    map[uint]*internal.SPS
+	active *internal.SPS
+}
+
+func (ps *testParamSets) Sps(id uint) *internal.SPS {
+	return ps.sps[id]
+}
+
+func (ps *testParamSets) ActiveSps() *internal.SPS {
+	return ps.active
+}
+
+func testHrdSps() *internal.SPS {
+	return &internal.SPS{
+		VuiParametersPresentFlag: true,
+		VuiParams: internal.VuiParameters{
+			NalHrdParametersPresentFlag: true,
+			PicStructPresentFlag:        true,
+			HrdParameters: internal.HrdParameters{
+				CpbCntMinus1:                       1,
+				InitialCpbRemovalDelayLengthMinus1: 23,
+				CpbRemovalDelayLengthMinus1:        15,
+				DpbOutputDelayLengthMinus1:         4,
+				TimeOffsetLengths:                  5,
+			},
+		},
+	}
+}
+
+// writeMessage write payloadType, payloadSize and payload of a sei_message
+func writeMessage(w *rbr.Writer, payloadType int, payload []byte) {
+	for _, v := range []int{payloadType, len(payload)} {
+		for ; v >= 255; v -= 255 {
+			w.WriteBits(0xFF, 8)
+		}
+		w.WriteBits(uint64(v), 8)
+	}
+	for _, b := range payload {
+		w.WriteBits(uint64(b), 8)
+	}
+}
+
+// payload return bytes written by f, padded with bit_equal_to_one and zeros
+func payload(f func(w *rbr.Writer)) []byte {
+	w := rbr.NewWriter()
+	f(w)
+	if w.BitPos()%8 != 0 {
+		w.WriteTrailingBits()
+	}
+	return w.Bytes()
+}
+
+func TestParseRBSP(t *testing.T) {
+	bp := payload(func(w *rbr.Writer) {
+		w.WriteUe(0)
+		w.WriteBits(90000, 24)
+		w.WriteBits(10, 24)
+		w.WriteBits(45000, 24)
+		w.WriteBits(20, 24)
+	})
+	pt := payload(func(w *rbr.Writer) {
+		w.WriteBits(2, 16) // cpb_removal_delay
+		w.WriteBits(4, 5)  // dpb_output_delay
+		w.WriteBits(3, 4)  // pic_struct, top bottom
+		// full timestamp
+		w.WriteFlag(true)
+		w.WriteBits(0, 2)
+		w.WriteFlag(false)
+		w.WriteBits(4, 5)
+		w.WriteFlag(true)
+		w.WriteFlag(false)
+		w.WriteFlag(true)
+		w.WriteBits(29, 8)
+		w.WriteBits(59, 6)
+		w.WriteBits(58, 6)
+		w.WriteBits(23, 5)
+		w.WriteBits(0x1F, 5) // time_offset -1
+		// seconds and minutes only
+		w.WriteFlag(true)
+		w.WriteBits(1, 2)
+		w.WriteFlag(true)
+		w.WriteBits(0, 5)
+		w.WriteFlag(false)
+		w.WriteFlag(false)
+		w.WriteFlag(false)
+		w.WriteBits(1, 8)
+		w.WriteFlag(true)
+		w.WriteBits(7, 6)
+		w.WriteFlag(true)
+		w.WriteBits(8, 6)
+		w.WriteFlag(false)
+		w.WriteBits(3, 5)
+	})
+	rp := payload(func(w *rbr.Writer) {
+		w.WriteUe(5)
+		w.WriteFlag(true)
+		w.WriteFlag(false)
+		w.WriteBits(2, 2)
+	})
+	t35 := []byte{0xB5, 0x00, 0x31, 'G', 'A', '9', '4', 0x03}
+	uuid := []byte{0xDC, 0x45, 0xE9, 0xBD, 0xE6, 0xD9, 0x48, 0xB7, 0x96, 0x2C, 0xD8, 0x20, 0xD9, 0x23, 0xEE, 0xEF}
+	unregistered := append(append([]byte{}, uuid...), bytes.Repeat([]byte{'x'}, 300)...)
+	md := []byte{0x33, 0xC2, 0x86, 0xC4, 0x1D, 0x4C, 0x0B, 0xB8, 0x84, 0xD0, 0x3E, 0x80,
+		0x3D, 0x13, 0x40, 0x42, 0x00, 0x98, 0x96, 0x80, 0x00, 0x00, 0x00, 0x32}
+	cll := []byte{0x03, 0xE8, 0x01, 0x90}
+	unknown := []byte{1, 2, 3}
+
+	w := rbr.NewWriter()
+	writeMessage(w, 0, bp)
+	writeMessage(w, 1, pt)
+	writeMessage(w, 6, rp)
+	writeMessage(w, 4, t35)
+	writeMessage(w, 5, unregistered)
+	writeMessage(w, 137, md)
+	writeMessage(w, 144, cll)
+	writeMessage(w, 300, unknown)
+	w.WriteTrailingBits()
+
+	ps := &testParamSets{sps: map[uint]*internal.SPS{0: testHrdSps()}}
+	msgs, err := ParseRBSP(w.Bytes(), ps)
+	if err != nil {
+		t.Fatalf("ParseRBSP() error = %v", err)
+	}
+	want := []Message{
+		{PayloadBufferingPeriod, &BufferingPeriod{
+			NalInitialCpbRemovalDelay:       []uint32{90000, 45000},
+			NalInitialCpbRemovalDelayOffset: []uint32{10, 20},
+		}, bp},
+		{PayloadPicTiming, &PicTiming{
+			CpbDpbDelaysPresent: true, CpbRemovalDelay: 2, DpbOutputDelay: 4,
+			PicStructPresent: true, PicStruct: 3,
+			ClockTimestamps: []*ClockTimestamp{
+				{CountingType: 4, FullTimestampFlag: true, CntDroppedFlag: true, NFrames: 29,
+					SecondsFlag: true, SecondsValue: 59, MinutesFlag: true, MinutesValue: 58,
+					HoursFlag: true, HoursValue: 23, TimeOffset: -1},
+				{CtType: 1, NuitFieldBasedFlag: true, NFrames: 1, SecondsFlag: true, SecondsValue: 7,
+					MinutesFlag: true, MinutesValue: 8, TimeOffset: 3},
+			},
+		}, pt},
+		{PayloadRecoveryPoint, &RecoveryPoint{RecoveryFrameCnt: 5, ExactMatchFlag: true, ChangingSliceGroupIdc: 2}, rp},
+		{PayloadUserDataRegistered, &UserDataRegistered{CountryCode: 0xB5, Payload: t35[1:]}, t35},
+		{PayloadUserDataUnregistered, &UserDataUnregistered{Payload: unregistered[16:]}, unregistered},
+		{PayloadMasteringDisplayColourVolume, &MasteringDisplayColourVolume{
+			DisplayPrimariesX: [3]uint16{13250, 7500, 34000}, DisplayPrimariesY: [3]uint16{34500, 3000, 16000},
+			WhitePointX: 15635, WhitePointY: 16450, MaxDisplayMasteringLuminance: 10000000, MinDisplayMasteringLuminance: 50,
+		}, md},
+		{PayloadContentLightLevelInfo, &ContentLightLevelInfo{MaxContentLightLevel: 1000, MaxPicAverageLightLevel: 400}, cll},
+		{300, nil, unknown},
+	}
+	copy(want[4].Payload.(*UserDataUnregistered).UUID[:], uuid)
+	if len(msgs) != len(want) {
+		t.Fatalf("ParseRBSP() got %v messages, want %v", len(msgs), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(msgs[i], want[i]) {
+			t.Errorf("message %v = %v, want %v", i, msgs[i], want[i])
+		}
+	}
+}
+
+func TestParseRBSPWithoutSps(t *testing.T) {
+	w := rbr.NewWriter()
+	writeMessage(w, 1, []byte{0x12, 0x34})
+	w.WriteTrailingBits()
+	msgs, err := ParseRBSP(w.Bytes(), &testParamSets{})
+	if err != nil {
+		t.Fatalf("ParseRBSP() error = %v", err)
+	}
+	if len(msgs) != 1 || msgs[0].Payload != nil || !bytes.Equal(msgs[0].Raw, []byte{0x12, 0x34}) {
+		t.Errorf("ParseRBSP() = %v, want raw pic timing", msgs)
+	}
+}
+
+func TestParseRBSPTruncated(t *testing.T) {
+	tests := []struct {
+		name string
+		rbsp []byte
+	}{
+		{"payload size", []byte{0x05, 0x20, 0x01, 0x80}},
+		{"payload type", []byte{0xFF, 0xFF}},
+		{"recovery point", []byte{0x06, 0x01, 0x00, 0x80}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseRBSP(tt.rbsp, nil); err == nil {
+				t.Errorf("ParseRBSP(%x) error = nil", tt.rbsp)
+			}
+		})
+	}
+}
@@ -1 +1,620 @@
 package slice
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/LiveStudioSolution/h264decoder/internal"
+	"github.com/LiveStudioSolution/h264decoder/internal/rbr"
+)
+
+// SliceType of h264 codec, slice_type modulo 5
+// T-REC-H.264-201402-S!!PDF-E.pdf Table 7-6 – Name association to slice_type
+type SliceType uint8
+
+const (
+	SliceP  SliceType = 0
+	SliceB  SliceType = 1
+	SliceI  SliceType = 2
+	SliceSP SliceType = 3
+	SliceSI SliceType = 4
+)
+
+func (st SliceType) String() string {
+	switch st {
+	case SliceP:
+		return "P"
+	case SliceB:
+		return "B"
+	case SliceI:
+		return "I"
+	case SliceSP:
+		return "SP"
+	case SliceSI:
+		return "SI"
+	}
+	return fmt.Sprintf("SliceType:%d", st)
+}
+
+// IsIntra report whether slice only contains intra macroblocks
+func (st SliceType) IsIntra() bool {
+	return st == SliceI || st == SliceSI
+}
+
+// ParameterSets activate the sps and pps a slice refers to by pic_parameter_set_id,
+// implemented by internal.ParameterSetStore
+type ParameterSets interface {
+	Activate(ppsId uint, idr bool) (*internal.SPS, *internal.PPS, error)
+}
+
+// RefPicListModification one ref_pic_list_modification command
+// T-REC-H.264-201402-S!!PDF-E.pdf 7.3.3.1 and H.7.3.3.1.1
+type RefPicListModification struct {
+	ModificationOfPicNumsIdc uint
+	AbsDiffPicNumMinus1      uint
+	LongTermPicNum           uint
+	AbsDiffViewIdxMinus1     uint
+}
+
+// PredWeight explicit weights of one reference index, inferred values are
+// filled in when the flags are not set
+type PredWeight struct {
+	LumaWeightFlag   bool
+	LumaWeight       int
+	LumaOffset       int
+	ChromaWeightFlag bool
+	ChromaWeight     [2]int
+	ChromaOffset     [2]int
+}
+
+// PredWeightTable T-REC-H.264-201402-S!!PDF-E.pdf 7.3.3.2 Prediction weight table syntax
+type PredWeightTable struct {
+	LumaLog2WeightDenom   uint
+	ChromaLog2WeightDenom uint
+	L0                    []PredWeight
+	L1                    []PredWeight
+}
+
+// MemoryManagementOperation one memory_management_control_operation command
+type MemoryManagementOperation struct {
+	MemoryManagementControlOperation uint
+	DifferenceOfPicNumsMinus1        uint
+	LongTermPicNum                   uint
+	LongTermFrameIdx                 uint
+	MaxLongTermFrameIdxPlus1         uint
+}
+
+// DecRefPicMarking T-REC-H.264-201402-S!!PDF-E.pdf 7.3.3.3 Decoded reference picture marking syntax
+type DecRefPicMarking struct {
+	NoOutputOfPriorPicsFlag       bool
+	LongTermReferenceFlag         bool
+	AdaptiveRefPicMarkingModeFlag bool
+	Operations                    []MemoryManagementOperation
+}
+
+// Header h264 slice header
+// T-REC-H.264-201402-S!!PDF-E.pdf 7.3.3 Slice header syntax
+type Header struct {
+	FirstMbInSlice    uint
+	RawSliceType      uint
+	SliceType         SliceType
+	PicParameterSetId uint
+	ColourPlaneId     uint8
+	FrameNum          uint
+	FieldPicFlag      bool
+	BottomFieldFlag   bool
+	IdrPicId          uint
+
+	PicOrderCntLsb         uint
+	DeltaPicOrderCntBottom int
+	DeltaPicOrderCnt       [2]int
+	RedundantPicCnt        uint
+
+	DirectSpatialMvPredFlag     bool
+	NumRefIdxActiveOverrideFlag bool
+	NumRefIdxL0ActiveMinus1     uint
+	NumRefIdxL1ActiveMinus1     uint
+
+	RefPicListModificationFlagL0 bool
+	RefPicListModificationL0     []RefPicListModification
+	RefPicListModificationFlagL1 bool
+	RefPicListModificationL1     []RefPicListModification
+
+	PredWeightTable  PredWeightTable
+	DecRefPicMarking DecRefPicMarking
+
+	CabacInitIdc               uint
+	SliceQpDelta               int
+	SpForSwitchFlag            bool
+	SliceQsDelta               int
+	DisableDeblockingFilterIdc uint
+	SliceAlphaC0OffsetDiv2     int
+	SliceBetaOffsetDiv2        int
+	SliceGroupChangeCycle      uint
+
+	// values of the nal unit carrying the slice
+	NalUnitType internal.NaluType
+	NalRefIdc   uint8
+	IdrPicFlag  bool
+
+	// derived values, 7.4.3
+	MbaffFrameFlag bool
+	SliceQPY       int
+	QSY            int
+	FilterOffsetA  int
+	FilterOffsetB  int
+	PicHeightInMbs uint
+	PicSizeInMbs   uint
+	MaxPicNum      uint
+	CurrPicNum     uint
+
+	// BitSize slice header size in bits, slice_data starts right after it
+	BitSize int
+
+	SPS *internal.SPS `json:"-"`
+	PPS *internal.PPS `json:"-"`
+
+	br *rbr.Reader
+}
+
+// ParseHeader parse slice header from a coded slice nalu
+func ParseHeader(nalu *internal.Nalu, ps ParameterSets) (*Header, error) {
+	h := &Header{}
+	if err := h.Load(rbr.NewReader(nalu.Rbsp()), nalu, ps); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// StartsNewPicture report whether slice h is the first vcl nalu of a new primary
+// coded picture, prev being a slice of the previous primary coded picture
+// T-REC-H.264-201402-S!!PDF-E.pdf 7.4.1.2.4 Detection of the first VCL NAL unit of a primary coded picture
+func (h *Header) StartsNewPicture(prev *Header) bool {
+	if prev == nil {
+		return true
+	}
+	switch {
+	case h.FrameNum != prev.FrameNum,
+		h.PicParameterSetId != prev.PicParameterSetId,
+		h.FieldPicFlag != prev.FieldPicFlag,
+		h.FieldPicFlag && h.BottomFieldFlag != prev.BottomFieldFlag,
+		h.NalRefIdc != prev.NalRefIdc && (h.NalRefIdc == 0 || prev.NalRefIdc == 0),
+		h.IdrPicFlag != prev.IdrPicFlag,
+		h.IdrPicFlag && h.IdrPicId != prev.IdrPicId:
+		return true
+	}
+	if h.SPS == nil || prev.SPS == nil || h.SPS.PicOrderCntType != prev.SPS.PicOrderCntType {
+		return false
+	}
+	switch h.SPS.PicOrderCntType {
+	case 0:
+		return h.PicOrderCntLsb != prev.PicOrderCntLsb || h.DeltaPicOrderCntBottom != prev.DeltaPicOrderCntBottom
+	case 1:
+		return h.DeltaPicOrderCnt != prev.DeltaPicOrderCnt
+	}
+	return false
+}
+
+// HasMmco5 report whether the picture includes a memory_management_control_operation
+// equal to 5, marking all the reference pictures as unused
+func (h *Header) HasMmco5() bool {
+	if h.NalRefIdc == 0 || !h.DecRefPicMarking.AdaptiveRefPicMarkingModeFlag {
+		return false
+	}
+	for _, op := range h.DecRefPicMarking.Operations {
+		if op.MemoryManagementControlOperation == 5 {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Header) String() string {
+	s, _ := json.Marshal(h)
+	return string(s)
+}
+
+// Load parse slice header from br, br is left positioned at slice_data
+func (h *Header) Load(br *rbr.Reader, nalu *internal.Nalu, ps ParameterSets) error {
+	h.br = br
+	h.NalUnitType = nalu.Type()
+	h.NalRefIdc = nalu.RefIdc()
+	h.IdrPicFlag = h.NalUnitType == internal.NaluSliceIdr
+
+	var err error
+	if h.FirstMbInSlice, err = rbr.DecUe(br); err != nil {
+		return err
+	}
+	if h.RawSliceType, err = rbr.DecUe(br); err != nil {
+		return err
+	}
+	if h.RawSliceType > 9 {
+		return fmt.Errorf("invalid slice_type %v", h.RawSliceType)
+	}
+	h.SliceType = SliceType(h.RawSliceType % 5)
+	if h.PicParameterSetId, err = rbr.DecUe(br); err != nil {
+		return err
+	}
+	if h.SPS, h.PPS, err = ps.Activate(h.PicParameterSetId, h.IdrPicFlag); err != nil {
+		return err
+	}
+	sps, pps := h.SPS, h.PPS
+	if h.IdrPicFlag && !h.SliceType.IsIntra() {
+		return fmt.Errorf("invalid slice_type %v of idr picture", h.SliceType)
+	}
+
+	if sps.SeparateColourPlaneFlag {
+		if h.ColourPlaneId, err = br.Read8(2); err != nil {
+			return err
+		}
+	}
+	frameNum, err := br.Read32(sps.Log2MaxFrameNumMinus4 + 4)
+	if err != nil {
+		return err
+	}
+	h.FrameNum = uint(frameNum)
+	if !sps.FrameMbsOnlyFlag {
+		if h.FieldPicFlag, err = br.Read1(); err != nil {
+			return err
+		}
+		if h.FieldPicFlag {
+			if h.BottomFieldFlag, err = br.Read1(); err != nil {
+				return err
+			}
+		}
+	}
+	if h.IdrPicFlag {
+		if h.IdrPicId, err = rbr.DecUe(br); err != nil {
+			return err
+		}
+	}
+	if sps.PicOrderCntType == 0 {
+		lsb, err := br.Read32(sps.Log2MaxPicOrderCntLsbMinus4L + 4)
+		if err != nil {
+			return err
+		}
+		h.PicOrderCntLsb = uint(lsb)
+		if pps.BottomFieldPicOrderInFramePresentFlag && !h.FieldPicFlag {
+			if h.DeltaPicOrderCntBottom, err = rbr.DecSe(br); err != nil {
+				return err
+			}
+		}
+	}
+	if sps.PicOrderCntType == 1 && !sps.DeltaPicOrderAlwaysZeroFlag {
+		if h.DeltaPicOrderCnt[0], err = rbr.DecSe(br); err != nil {
+			return err
+		}
+		if pps.BottomFieldPicOrderInFramePresentFlag && !h.FieldPicFlag {
+			if h.DeltaPicOrderCnt[1], err = rbr.DecSe(br); err != nil {
+				return err
+			}
+		}
+	}
+	if pps.RedundantPicCntPresentFlag {
+		if h.RedundantPicCnt, err = rbr.DecUe(br); err != nil {
+			return err
+		}
+	}
+	if h.SliceType == SliceB {
+		if h.DirectSpatialMvPredFlag, err = br.Read1(); err != nil {
+			return err
+		}
+	}
+
+	h.NumRefIdxL0ActiveMinus1 = pps.NumRefIdxL0DefaultActiveMinus1
+	h.NumRefIdxL1ActiveMinus1 = pps.NumRefIdxL1DefaultActiveMinus1
+	if h.SliceType == SliceP || h.SliceType == SliceSP || h.SliceType == SliceB {
+		if h.NumRefIdxActiveOverrideFlag, err = br.Read1(); err != nil {
+			return err
+		}
+		if h.NumRefIdxActiveOverrideFlag {
+			if h.NumRefIdxL0ActiveMinus1, err = rbr.DecUe(br); err != nil {
+				return err
+			}
+			if h.SliceType == SliceB {
+				if h.NumRefIdxL1ActiveMinus1, err = rbr.DecUe(br); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	// 7.4.3, up to 15 for frames and 31 for fields
+	maxNumRefIdxMinus1 := uint(15)
+	if h.FieldPicFlag {
+		maxNumRefIdxMinus1 = 31
+	}
+	if h.SliceType != SliceI && h.SliceType != SliceSI && h.NumRefIdxL0ActiveMinus1 > maxNumRefIdxMinus1 {
+		return fmt.Errorf("invalid num_ref_idx_l0_active_minus1 %v", h.NumRefIdxL0ActiveMinus1)
+	}
+	if h.SliceType == SliceB && h.NumRefIdxL1ActiveMinus1 > maxNumRefIdxMinus1 {
+		return fmt.Errorf("invalid num_ref_idx_l1_active_minus1 %v", h.NumRefIdxL1ActiveMinus1)
+	}
+
+	if err = h.parseRefPicListModification(); err != nil {
+		return err
+	}
+	if (pps.WeightedPredFlag && (h.SliceType == SliceP || h.SliceType == SliceSP)) ||
+		(pps.WeightedBipredIdc == 1 && h.SliceType == SliceB) {
+		if err = h.parsePredWeightTable(); err != nil {
+			return err
+		}
+	}
+	if h.NalRefIdc != 0 {
+		if err = h.parseDecRefPicMarking(); err != nil {
+			return err
+		}
+	}
+	if pps.EntropyCodingModeFlag && !h.SliceType.IsIntra() {
+		if h.CabacInitIdc, err = rbr.DecUe(br); err != nil {
+			return err
+		}
+		if h.CabacInitIdc > 2 {
+			return fmt.Errorf("invalid cabac_init_idc %v", h.CabacInitIdc)
+		}
+	}
+	if h.SliceQpDelta, err = rbr.DecSe(br); err != nil {
+		return err
+	}
+	if h.SliceType == SliceSP || h.SliceType == SliceSI {
+		if h.SliceType == SliceSP {
+			if h.SpForSwitchFlag, err = br.Read1(); err != nil {
+				return err
+			}
+		}
+		if h.SliceQsDelta, err = rbr.DecSe(br); err != nil {
+			return err
+		}
+	}
+	if pps.DeblockingFilterControlPresentFlag {
+		if h.DisableDeblockingFilterIdc, err = rbr.DecUe(br); err != nil {
+			return err
+		}
+		if h.DisableDeblockingFilterIdc > 2 {
+			return fmt.Errorf("invalid disable_deblocking_filter_idc %v", h.DisableDeblockingFilterIdc)
+		}
+		if h.DisableDeblockingFilterIdc != 1 {
+			if h.SliceAlphaC0OffsetDiv2, err = rbr.DecSe(br); err != nil {
+				return err
+			}
+			if h.SliceBetaOffsetDiv2, err = rbr.DecSe(br); err != nil {
+				return err
+			}
+		}
+	}
+	if pps.NumSliceGroupsMinus1 > 0 && pps.SliceGroupMapType >= 3 && pps.SliceGroupMapType <= 5 {
+		picSizeInMapUnits := sps.PicWidthInMbs() * sps.PicHeightInMapUnits()
+		cycle, err := br.Read32(sliceGroupChangeCycleBits(picSizeInMapUnits, pps.SliceGroupChangeRateMinus1+1))
+		if err != nil {
+			return err
+		}
+		h.SliceGroupChangeCycle = uint(cycle)
+	}
+
+	h.derive()
+	if h.FirstMbInSlice*(1+boolToUint(h.MbaffFrameFlag)) >= h.PicSizeInMbs {
+		return fmt.Errorf("invalid first_mb_in_slice %v", h.FirstMbInSlice)
+	}
+	h.BitSize = br.BitPos()
+	return nil
+}
+
+// derive compute the values derived from slice header and parameter sets
+func (h *Header) derive() {
+	sps, pps := h.SPS, h.PPS
+	h.MbaffFrameFlag = sps.MbAdaptiveFrameFieldFlag && !h.FieldPicFlag
+	h.SliceQPY = 26 + pps.PicInitQpMinus26 + h.SliceQpDelta
+	h.QSY = 26 + pps.PicInitQsMinus26 + h.SliceQsDelta
+	h.FilterOffsetA = h.SliceAlphaC0OffsetDiv2 << 1
+	h.FilterOffsetB = h.SliceBetaOffsetDiv2 << 1
+
+	h.PicHeightInMbs = sps.FrameHeightInMbs() / (1 + boolToUint(h.FieldPicFlag))
+	h.PicSizeInMbs = sps.PicWidthInMbs() * h.PicHeightInMbs
+
+	if h.FieldPicFlag {
+		h.MaxPicNum = 2 * sps.MaxFrameNum()
+		h.CurrPicNum = 2*h.FrameNum + 1
+	} else {
+		h.MaxPicNum = sps.MaxFrameNum()
+		h.CurrPicNum = h.FrameNum
+	}
+}
+
+// T-REC-H.264-201402-S!!PDF-E.pdf 7.3.3.1 Reference picture list modification syntax,
+// modification_of_pic_nums_idc 4 and 5 are the inter-view ones of H.7.3.3.1.1
+func (h *Header) parseRefPicListModification() error {
+	var err error
+	if !h.SliceType.IsIntra() {
+		if h.RefPicListModificationFlagL0, err = h.br.Read1(); err != nil {
+			return err
+		}
+		if h.RefPicListModificationFlagL0 {
+			if h.RefPicListModificationL0, err = h.parseRefPicListModificationCommands(); err != nil {
+				return err
+			}
+		}
+	}
+	if h.SliceType == SliceB {
+		if h.RefPicListModificationFlagL1, err = h.br.Read1(); err != nil {
+			return err
+		}
+		if h.RefPicListModificationFlagL1 {
+			if h.RefPicListModificationL1, err = h.parseRefPicListModificationCommands(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (h *Header) parseRefPicListModificationCommands() ([]RefPicListModification, error) {
+	var mods []RefPicListModification
+	for {
+		var mod RefPicListModification
+		var err error
+		if mod.ModificationOfPicNumsIdc, err = rbr.DecUe(h.br); err != nil {
+			return nil, err
+		}
+		switch mod.ModificationOfPicNumsIdc {
+		case 0, 1:
+			if mod.AbsDiffPicNumMinus1, err = rbr.DecUe(h.br); err != nil {
+				return nil, err
+			}
+		case 2:
+			if mod.LongTermPicNum, err = rbr.DecUe(h.br); err != nil {
+				return nil, err
+			}
+		case 3:
+			return mods, nil
+		case 4, 5:
+			if mod.AbsDiffViewIdxMinus1, err = rbr.DecUe(h.br); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("invalid modification_of_pic_nums_idc %v", mod.ModificationOfPicNumsIdc)
+		}
+		mods = append(mods, mod)
+		// one command per reference index at most, plus the terminating one
+		if len(mods) > 32 {
+			return nil, fmt.Errorf("too many ref_pic_list_modification commands")
+		}
+	}
+}
+
+// T-REC-H.264-201402-S!!PDF-E.pdf 7.3.3.2 Prediction weight table syntax
+func (h *Header) parsePredWeightTable() error {
+	pwt := &h.PredWeightTable
+	var err error
+	if pwt.LumaLog2WeightDenom, err = rbr.DecUe(h.br); err != nil {
+		return err
+	}
+	if h.SPS.ChromaArrayType() != 0 {
+		if pwt.ChromaLog2WeightDenom, err = rbr.DecUe(h.br); err != nil {
+			return err
+		}
+	}
+	if pwt.LumaLog2WeightDenom > 7 || pwt.ChromaLog2WeightDenom > 7 {
+		return fmt.Errorf("invalid log2_weight_denom %v %v", pwt.LumaLog2WeightDenom, pwt.ChromaLog2WeightDenom)
+	}
+	if pwt.L0, err = h.parsePredWeights(h.NumRefIdxL0ActiveMinus1 + 1); err != nil {
+		return err
+	}
+	if h.SliceType == SliceB {
+		if pwt.L1, err = h.parsePredWeights(h.NumRefIdxL1ActiveMinus1 + 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *Header) parsePredWeights(count uint) ([]PredWeight, error) {
+	pwt := &h.PredWeightTable
+	weights := make([]PredWeight, count)
+	var err error
+	for i := range weights {
+		w := &weights[i]
+		w.LumaWeight = 1 << pwt.LumaLog2WeightDenom
+		w.ChromaWeight = [2]int{1 << pwt.ChromaLog2WeightDenom, 1 << pwt.ChromaLog2WeightDenom}
+		if w.LumaWeightFlag, err = h.br.Read1(); err != nil {
+			return nil, err
+		}
+		if w.LumaWeightFlag {
+			if w.LumaWeight, err = rbr.DecSe(h.br); err != nil {
+				return nil, err
+			}
+			if w.LumaOffset, err = rbr.DecSe(h.br); err != nil {
+				return nil, err
+			}
+		}
+		if h.SPS.ChromaArrayType() == 0 {
+			continue
+		}
+		if w.ChromaWeightFlag, err = h.br.Read1(); err != nil {
+			return nil, err
+		}
+		if w.ChromaWeightFlag {
+			for j := 0; j < 2; j++ {
+				if w.ChromaWeight[j], err = rbr.DecSe(h.br); err != nil {
+					return nil, err
+				}
+				if w.ChromaOffset[j], err = rbr.DecSe(h.br); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return weights, nil
+}
+
+// T-REC-H.264-201402-S!!PDF-E.pdf 7.3.3.3 Decoded reference picture marking syntax
+func (h *Header) parseDecRefPicMarking() error {
+	drpm := &h.DecRefPicMarking
+	var err error
+	if h.IdrPicFlag {
+		if drpm.NoOutputOfPriorPicsFlag, err = h.br.Read1(); err != nil {
+			return err
+		}
+		if drpm.LongTermReferenceFlag, err = h.br.Read1(); err != nil {
+			return err
+		}
+		return nil
+	}
+	if drpm.AdaptiveRefPicMarkingModeFlag, err = h.br.Read1(); err != nil {
+		return err
+	}
+	if !drpm.AdaptiveRefPicMarkingModeFlag {
+		return nil
+	}
+	for {
+		var op MemoryManagementOperation
+		if op.MemoryManagementControlOperation, err = rbr.DecUe(h.br); err != nil {
+			return err
+		}
+		mmco := op.MemoryManagementControlOperation
+		if mmco == 0 {
+			return nil
+		}
+		if mmco > 6 {
+			return fmt.Errorf("invalid memory_management_control_operation %v", mmco)
+		}
+		if mmco == 1 || mmco == 3 {
+			if op.DifferenceOfPicNumsMinus1, err = rbr.DecUe(h.br); err != nil {
+				return err
+			}
+		}
+		if mmco == 2 {
+			if op.LongTermPicNum, err = rbr.DecUe(h.br); err != nil {
+				return err
+			}
+		}
+		if mmco == 3 || mmco == 6 {
+			if op.LongTermFrameIdx, err = rbr.DecUe(h.br); err != nil {
+				return err
+			}
+		}
+		if mmco == 4 {
+			if op.MaxLongTermFrameIdxPlus1, err = rbr.DecUe(h.br); err != nil {
+				return err
+			}
+		}
+		drpm.Operations = append(drpm.Operations, op)
+		if len(drpm.Operations) > 66 {
+			return fmt.Errorf("too many memory_management_control_operation commands")
+		}
+	}
+}
+
+// sliceGroupChangeCycleBits return Ceil( Log2( PicSizeInMapUnits ÷ SliceGroupChangeRate + 1 ) )
+func sliceGroupChangeCycleBits(picSizeInMapUnits, sliceGroupChangeRate uint) uint {
+	bits := uint(0)
+	for (uint(1)<<bits)*sliceGroupChangeRate < picSizeInMapUnits+sliceGroupChangeRate {
+		bits++
+	}
+	return bits
+}
+
+func boolToUint(b bool) uint {
+	if b {
+		return 1
+	}
+	return 0
+}
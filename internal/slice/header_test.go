@@ -0,0 +1,281 @@
+package slice
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/LiveStudioSolution/h264decoder/internal"
+	"github.com/LiveStudioSolution/h264decoder/internal/rbr"
+)
+
+type testParamSets struct {
+	sps *internal.SPS
+	pps *internal.PPS
+}
+
+func (ps testParamSets) Activate(ppsId uint, idr bool) (*internal.SPS, *internal.PPS, error) {
+	if ppsId != ps.pps.Id {
+		return nil, nil, fmt.Errorf("pps %v not received", ppsId)
+	}
+	return ps.sps, ps.pps, nil
+}
+
+func testNalu(t *testing.T, nalType internal.NaluType, refIdc uint8, w *rbr.Writer) *internal.Nalu {
+	nl := internal.NewNalu()
+	data := append([]byte{refIdc<<5 | uint8(nalType)}, internal.RBSPToEBSP(w.Bytes())...)
+	if err := nl.Load(data); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	return nl
+}
+
+func TestParseHeader(t *testing.T) {
+	sps := &internal.SPS{
+		ChromaFormatIdc:           1,
+		Log2MaxFrameNumMinus4:     0,
+		PicOrderCntType:           0,
+		PicWidthInMbsMinus1:       39,
+		PicHeightInMapUnitsMinus1: 22,
+		FrameMbsOnlyFlag:          true,
+	}
+	pps := &internal.PPS{
+		Id:                                 1,
+		PicInitQpMinus26:                   -2,
+		DeblockingFilterControlPresentFlag: true,
+		WeightedPredFlag:                   true,
+		NumRefIdxL0DefaultActiveMinus1:     2,
+	}
+	ps := testParamSets{sps, pps}
+
+	t.Run("idr", func(t *testing.T) {
+		w := rbr.NewWriter()
+		w.WriteUe(0)       // first_mb_in_slice
+		w.WriteUe(7)       // slice_type I
+		w.WriteUe(1)       // pic_parameter_set_id
+		w.WriteBits(0, 4)  // frame_num
+		w.WriteUe(3)       // idr_pic_id
+		w.WriteBits(0, 4)  // pic_order_cnt_lsb
+		w.WriteFlag(false) // no_output_of_prior_pics_flag
+		w.WriteFlag(true)  // long_term_reference_flag
+		w.WriteSe(-3)      // slice_qp_delta
+		w.WriteUe(0)       // disable_deblocking_filter_idc
+		w.WriteSe(1)       // slice_alpha_c0_offset_div2
+		w.WriteSe(-2)      // slice_beta_offset_div2
+		bitSize := w.BitPos()
+		w.WriteTrailingBits()
+
+		h, err := ParseHeader(testNalu(t, internal.NaluSliceIdr, 3, w), ps)
+		if err != nil {
+			t.Fatalf("ParseHeader() error = %v", err)
+		}
+		want := Header{
+			RawSliceType:            7,
+			SliceType:               SliceI,
+			PicParameterSetId:       1,
+			IdrPicId:                3,
+			NumRefIdxL0ActiveMinus1: 2,
+			DecRefPicMarking: DecRefPicMarking{
+				LongTermReferenceFlag: true,
+			},
+			SliceQpDelta:           -3,
+			SliceAlphaC0OffsetDiv2: 1,
+			SliceBetaOffsetDiv2:    -2,
+			NalUnitType:            internal.NaluSliceIdr,
+			NalRefIdc:              3,
+			IdrPicFlag:             true,
+			SliceQPY:               21,
+			QSY:                    26,
+			FilterOffsetA:          2,
+			FilterOffsetB:          -4,
+			PicHeightInMbs:         23,
+			PicSizeInMbs:           920,
+			MaxPicNum:              16,
+			BitSize:                bitSize,
+			SPS:                    sps,
+			PPS:                    pps,
+		}
+		h.br = nil
+		if !reflect.DeepEqual(*h, want) {
+			t.Errorf("ParseHeader() = %v, want %v", h, &want)
+		}
+	})
+
+	t.Run("p with modification and mmco", func(t *testing.T) {
+		w := rbr.NewWriter()
+		w.WriteUe(40)      // first_mb_in_slice
+		w.WriteUe(0)       // slice_type P
+		w.WriteUe(1)       // pic_parameter_set_id
+		w.WriteBits(5, 4)  // frame_num
+		w.WriteBits(10, 4) // pic_order_cnt_lsb
+		w.WriteFlag(true)  // num_ref_idx_active_override_flag
+		w.WriteUe(1)       // num_ref_idx_l0_active_minus1
+		w.WriteFlag(true)  // ref_pic_list_modification_flag_l0
+		w.WriteUe(0)       // modification_of_pic_nums_idc
+		w.WriteUe(2)       // abs_diff_pic_num_minus1
+		w.WriteUe(2)       // modification_of_pic_nums_idc
+		w.WriteUe(1)       // long_term_pic_num
+		w.WriteUe(3)       // end of modification
+		w.WriteUe(1)       // luma_log2_weight_denom
+		w.WriteUe(2)       // chroma_log2_weight_denom
+		w.WriteFlag(true)  // luma_weight_l0_flag
+		w.WriteSe(3)       // luma_weight_l0
+		w.WriteSe(-1)      // luma_offset_l0
+		w.WriteFlag(false) // chroma_weight_l0_flag
+		w.WriteFlag(false) // luma_weight_l0_flag
+		w.WriteFlag(true)  // chroma_weight_l0_flag
+		w.WriteSe(1)       // chroma_weight_l0[1][0]
+		w.WriteSe(2)       // chroma_offset_l0[1][0]
+		w.WriteSe(-3)      // chroma_weight_l0[1][1]
+		w.WriteSe(-4)      // chroma_offset_l0[1][1]
+		w.WriteFlag(true)  // adaptive_ref_pic_marking_mode_flag
+		w.WriteUe(1)       // memory_management_control_operation
+		w.WriteUe(0)       // difference_of_pic_nums_minus1
+		w.WriteUe(3)       // memory_management_control_operation
+		w.WriteUe(4)       // difference_of_pic_nums_minus1
+		w.WriteUe(1)       // long_term_frame_idx
+		w.WriteUe(0)       // end of mmco
+		w.WriteSe(2)       // slice_qp_delta
+		w.WriteUe(1)       // disable_deblocking_filter_idc
+		w.WriteTrailingBits()
+
+		h, err := ParseHeader(testNalu(t, internal.NaluSlice, 2, w), ps)
+		if err != nil {
+			t.Fatalf("ParseHeader() error = %v", err)
+		}
+		if h.FirstMbInSlice != 40 || h.SliceType != SliceP || h.FrameNum != 5 || h.PicOrderCntLsb != 10 {
+			t.Errorf("ParseHeader() = %v", h)
+		}
+		if h.NumRefIdxL0ActiveMinus1 != 1 {
+			t.Errorf("NumRefIdxL0ActiveMinus1 = %v, want 1", h.NumRefIdxL0ActiveMinus1)
+		}
+		wantMods := []RefPicListModification{
+			{ModificationOfPicNumsIdc: 0, AbsDiffPicNumMinus1: 2},
+			{ModificationOfPicNumsIdc: 2, LongTermPicNum: 1},
+		}
+		if !reflect.DeepEqual(h.RefPicListModificationL0, wantMods) {
+			t.Errorf("RefPicListModificationL0 = %v, want %v", h.RefPicListModificationL0, wantMods)
+		}
+		wantPwt := PredWeightTable{
+			LumaLog2WeightDenom:   1,
+			ChromaLog2WeightDenom: 2,
+			L0: []PredWeight{
+				{LumaWeightFlag: true, LumaWeight: 3, LumaOffset: -1, ChromaWeight: [2]int{4, 4}},
+				{LumaWeight: 2, ChromaWeightFlag: true, ChromaWeight: [2]int{1, -3}, ChromaOffset: [2]int{2, -4}},
+			},
+		}
+		if !reflect.DeepEqual(h.PredWeightTable, wantPwt) {
+			t.Errorf("PredWeightTable = %v, want %v", h.PredWeightTable, wantPwt)
+		}
+		wantOps := []MemoryManagementOperation{
+			{MemoryManagementControlOperation: 1},
+			{MemoryManagementControlOperation: 3, DifferenceOfPicNumsMinus1: 4, LongTermFrameIdx: 1},
+		}
+		if !h.DecRefPicMarking.AdaptiveRefPicMarkingModeFlag || !reflect.DeepEqual(h.DecRefPicMarking.Operations, wantOps) {
+			t.Errorf("DecRefPicMarking = %v, want %v", h.DecRefPicMarking, wantOps)
+		}
+		if h.SliceQPY != 26 || h.DisableDeblockingFilterIdc != 1 || h.CurrPicNum != 5 {
+			t.Errorf("SliceQPY = %v, DisableDeblockingFilterIdc = %v, CurrPicNum = %v", h.SliceQPY, h.DisableDeblockingFilterIdc, h.CurrPicNum)
+		}
+	})
+
+	t.Run("num_ref_idx beyond frame limit", func(t *testing.T) {
+		w := rbr.NewWriter()
+		w.WriteUe(0)       // first_mb_in_slice
+		w.WriteUe(0)       // slice_type P
+		w.WriteUe(1)       // pic_parameter_set_id
+		w.WriteBits(1, 4)  // frame_num
+		w.WriteBits(2, 4)  // pic_order_cnt_lsb
+		w.WriteFlag(true)  // num_ref_idx_active_override_flag
+		w.WriteUe(16)      // num_ref_idx_l0_active_minus1
+		w.WriteFlag(false) // ref_pic_list_modification_flag_l0
+		w.WriteSe(0)       // slice_qp_delta
+		w.WriteUe(1)       // disable_deblocking_filter_idc
+		w.WriteTrailingBits()
+		unweighted := *pps
+		unweighted.WeightedPredFlag = false
+		if _, err := ParseHeader(testNalu(t, internal.NaluSlice, 0, w), testParamSets{sps, &unweighted}); err == nil {
+			t.Errorf("ParseHeader() with num_ref_idx_l0_active_minus1 16 of a frame succeeded")
+		}
+	})
+
+	t.Run("missing pps", func(t *testing.T) {
+		w := rbr.NewWriter()
+		w.WriteUe(0)
+		w.WriteUe(2)
+		w.WriteUe(5)
+		w.WriteTrailingBits()
+		if _, err := ParseHeader(testNalu(t, internal.NaluSlice, 0, w), ps); err == nil {
+			t.Errorf("ParseHeader() with unknown pps id succeeded")
+		}
+	})
+}
+
+func TestSliceGroupChangeCycleBits(t *testing.T) {
+	tests := []struct {
+		picSizeInMapUnits, rate, want uint
+	}{
+		{99, 1, 7},
+		{100, 1, 7},
+		{127, 1, 7},
+		{128, 1, 8},
+		{396, 4, 7},
+		{1, 1, 1},
+	}
+	for _, tt := range tests {
+		if got := sliceGroupChangeCycleBits(tt.picSizeInMapUnits, tt.rate); got != tt.want {
+			t.Errorf("sliceGroupChangeCycleBits(%v, %v) = %v, want %v", tt.picSizeInMapUnits, tt.rate, got, tt.want)
+		}
+	}
+}
+
+func TestStartsNewPicture(t *testing.T) {
+	poc0 := &internal.SPS{PicOrderCntType: 0}
+	poc1 := &internal.SPS{PicOrderCntType: 1}
+	prev := Header{FrameNum: 1, PicParameterSetId: 0, NalRefIdc: 2, PicOrderCntLsb: 4, SPS: poc0}
+	tests := []struct {
+		name   string
+		modify func(h *Header)
+		want   bool
+	}{
+		{"same picture", func(h *Header) { h.FirstMbInSlice = 10 }, false},
+		{"frame_num", func(h *Header) { h.FrameNum = 2 }, true},
+		{"pps id", func(h *Header) { h.PicParameterSetId = 1 }, true},
+		{"field_pic_flag", func(h *Header) { h.FieldPicFlag = true }, true},
+		{"nal_ref_idc both non zero", func(h *Header) { h.NalRefIdc = 1 }, false},
+		{"nal_ref_idc zero", func(h *Header) { h.NalRefIdc = 0 }, true},
+		{"pic_order_cnt_lsb", func(h *Header) { h.PicOrderCntLsb = 6 }, true},
+		{"delta_pic_order_cnt_bottom", func(h *Header) { h.DeltaPicOrderCntBottom = 1 }, true},
+		{"delta_pic_order_cnt ignored for poc type 0", func(h *Header) { h.DeltaPicOrderCnt[0] = 1 }, false},
+		{"idr", func(h *Header) { h.IdrPicFlag = true }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := prev
+			tt.modify(&h)
+			if got := h.StartsNewPicture(&prev); got != tt.want {
+				t.Errorf("StartsNewPicture() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// second field of a frame
+	top := Header{FrameNum: 3, FieldPicFlag: true, NalRefIdc: 1, SPS: poc1, DeltaPicOrderCnt: [2]int{0, 0}}
+	bottom := top
+	bottom.BottomFieldFlag = true
+	if !bottom.StartsNewPicture(&top) {
+		t.Errorf("StartsNewPicture() bottom field after top = false")
+	}
+	// idr pictures back to back
+	idr := Header{IdrPicFlag: true, IdrPicId: 0, NalRefIdc: 3, SPS: poc1}
+	next := idr
+	next.IdrPicId = 1
+	if !next.StartsNewPicture(&idr) {
+		t.Errorf("StartsNewPicture() idr_pic_id change = false")
+	}
+	next = idr
+	next.DeltaPicOrderCnt[1] = 2
+	if !next.StartsNewPicture(&idr) || next.StartsNewPicture(&next) {
+		t.Errorf("StartsNewPicture() delta_pic_order_cnt of poc type 1 not compared")
+	}
+}
@@ -8,8 +8,6 @@ import (
 	"github.com/LiveStudioSolution/h264decoder/internal/rbr"
 )
 
-var errNotImplemented = fmt.Errorf("not implemented")
-
 // h264 sequence parameters set
 // T-REC-H.264-201402-S!!PDF-E.pdf 7.3.2.1.1 Sequence parameter set data syntax
 type SPS struct {
@@ -32,6 +30,8 @@ type SPS struct {
 	QpprimeYZeroTransformBypassFlag bool
 	SeqScalingMatrixPresentFlag     bool
 	SeqScalingListPresentFlag       []bool
+	// resolved sequence level scaling matrices, Flat_16 when not present
+	ScalingMatrices ScalingMatrices
 
 	Log2MaxFrameNumMinus4          uint
 	PicOrderCntType                uint
@@ -181,6 +181,7 @@ func (sps *SPS) Load(rbsp []byte) error {
 	}
 
 	sps.ChromaFormatIdc = 1 // default value 1
+	sps.ScalingMatrices = FlatScalingMatrices()
 
 	if sps.ProfileIdc == 100 || sps.ProfileIdc == 110 || sps.ProfileIdc == 122 || sps.ProfileIdc == 244 ||
 		sps.ProfileIdc == 44 || sps.ProfileIdc == 83 || sps.ProfileIdc == 86 || sps.ProfileIdc == 118 ||
@@ -450,12 +451,67 @@ func (sps *SPS) parsingVuiParams() error {
 	return nil
 }
 
+// T-REC-H.264-201402-S!!PDF-E.pdf 7.3.2.1.1 chroma_format_idc to seq_scaling_list_present_flag
 func (sps *SPS) parseChromaFormat() error {
-	// todo
-	panic(errNotImplemented)
+	br := sps.br
+	var err error
+	if sps.ChromaFormatIdc, err = rbr.DecUe(br); err != nil {
+		return err
+	}
+	if sps.ChromaFormatIdc > 3 {
+		return fmt.Errorf("invalid chroma_format_idc %v", sps.ChromaFormatIdc)
+	}
+	if sps.ChromaFormatIdc == 3 {
+		if sps.SeparateColourPlaneFlag, err = br.Read1(); err != nil {
+			return err
+		}
+	}
+	if sps.BitDepthLumaMinus8, err = rbr.DecUe(br); err != nil {
+		return err
+	}
+	if sps.BitDepthChromaMinus8, err = rbr.DecUe(br); err != nil {
+		return err
+	}
+	if sps.BitDepthLumaMinus8 > 6 || sps.BitDepthChromaMinus8 > 6 {
+		return fmt.Errorf("invalid bit depth minus8 luma %v chroma %v", sps.BitDepthLumaMinus8, sps.BitDepthChromaMinus8)
+	}
+	if sps.QpprimeYZeroTransformBypassFlag, err = br.Read1(); err != nil {
+		return err
+	}
+	if sps.SeqScalingMatrixPresentFlag, err = br.Read1(); err != nil {
+		return err
+	}
+	if sps.SeqScalingMatrixPresentFlag {
+		count := 8
+		if sps.ChromaFormatIdc == 3 {
+			count = 12
+		}
+		if sps.ScalingMatrices, sps.SeqScalingListPresentFlag, err = parseScalingMatrices(br, count, nil); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// ChromaArrayType return ChromaArrayType, chroma_format_idc or 0 when colour
+// planes are coded separately
+func (sps *SPS) ChromaArrayType() uint {
+	if sps.SeparateColourPlaneFlag {
+		return 0
+	}
+	return sps.ChromaFormatIdc
+}
+
+// BitDepthY return BitDepthY
+func (sps *SPS) BitDepthY() uint {
+	return 8 + sps.BitDepthLumaMinus8
+}
+
+// BitDepthC return BitDepthC
+func (sps *SPS) BitDepthC() uint {
+	return 8 + sps.BitDepthChromaMinus8
+}
+
 func (sps *SPS) parseHdrParameters() error {
 	hrd := &sps.VuiParams.HrdParameters
 	br := sps.br
@@ -0,0 +1,142 @@
+package internal
+
+import (
+	"image"
+)
+
+// T-REC-H.264-201402-S!!PDF-E.pdf Table E-1 – Meaning of sample aspect ratio indicator
+var sampleAspectRatios = [...][2]uint{
+	{0, 0}, {1, 1}, {12, 11}, {10, 11}, {16, 11}, {40, 33}, {24, 11}, {20, 11},
+	{32, 11}, {80, 33}, {18, 11}, {15, 11}, {64, 33}, {160, 99}, {4, 3}, {3, 2}, {2, 1},
+}
+
+// PicWidthInMbs return PicWidthInMbs
+func (sps *SPS) PicWidthInMbs() uint {
+	return sps.PicWidthInMbsMinus1 + 1
+}
+
+// PicHeightInMapUnits return PicHeightInMapUnits
+func (sps *SPS) PicHeightInMapUnits() uint {
+	return sps.PicHeightInMapUnitsMinus1 + 1
+}
+
+// FrameHeightInMbs return FrameHeightInMbs, height of a frame in macroblocks
+func (sps *SPS) FrameHeightInMbs() uint {
+	if sps.FrameMbsOnlyFlag {
+		return sps.PicHeightInMapUnits()
+	}
+	return 2 * sps.PicHeightInMapUnits()
+}
+
+// PicSizeInMbs return macroblock count of a frame, a field holds half of it
+func (sps *SPS) PicSizeInMbs() uint {
+	return sps.PicWidthInMbs() * sps.FrameHeightInMbs()
+}
+
+// CodedWidth return width in luma samples of decoded frames, cropping not applied
+func (sps *SPS) CodedWidth() int {
+	return int(sps.PicWidthInMbs()) * 16
+}
+
+// CodedHeight return height in luma samples of decoded frames, cropping not applied
+func (sps *SPS) CodedHeight() int {
+	return int(sps.FrameHeightInMbs()) * 16
+}
+
+// SubWidthC return horizontal chroma subsampling factor, Table 6-1, 0 for monochrome
+func (sps *SPS) SubWidthC() int {
+	switch sps.ChromaArrayType() {
+	case 1, 2:
+		return 2
+	case 3:
+		return 1
+	}
+	return 0
+}
+
+// SubHeightC return vertical chroma subsampling factor, Table 6-1, 0 for monochrome
+func (sps *SPS) SubHeightC() int {
+	switch sps.ChromaArrayType() {
+	case 1:
+		return 2
+	case 2, 3:
+		return 1
+	}
+	return 0
+}
+
+// CropUnitX return CropUnitX, 7.4.2.1.1
+func (sps *SPS) CropUnitX() int {
+	if sps.ChromaArrayType() == 0 {
+		return 1
+	}
+	return sps.SubWidthC()
+}
+
+// CropUnitY return CropUnitY, 7.4.2.1.1
+func (sps *SPS) CropUnitY() int {
+	frameMbsOnly := 0
+	if sps.FrameMbsOnlyFlag {
+		frameMbsOnly = 1
+	}
+	if sps.ChromaArrayType() == 0 {
+		return 2 - frameMbsOnly
+	}
+	return sps.SubHeightC() * (2 - frameMbsOnly)
+}
+
+// CropRect return the cropped display rectangle inside the coded frame,
+// the whole coded frame when frame_cropping_flag is 0
+func (sps *SPS) CropRect() image.Rectangle {
+	r := image.Rect(0, 0, sps.CodedWidth(), sps.CodedHeight())
+	if !sps.FrameCroppingFlag {
+		return r
+	}
+	crop := sps.FrameCrop
+	r.Min.X = sps.CropUnitX() * int(crop.LeftOffset)
+	r.Max.X -= sps.CropUnitX() * int(crop.RightOffset)
+	r.Min.Y = sps.CropUnitY() * int(crop.TopOffset)
+	r.Max.Y -= sps.CropUnitY() * int(crop.BottomOffset)
+	if r.Empty() {
+		return image.Rectangle{}
+	}
+	return r
+}
+
+// MaxFrameNum return MaxFrameNum
+func (sps *SPS) MaxFrameNum() uint {
+	return 1 << (sps.Log2MaxFrameNumMinus4 + 4)
+}
+
+// MaxPicOrderCntLsb return MaxPicOrderCntLsb, used by pic_order_cnt_type 0
+func (sps *SPS) MaxPicOrderCntLsb() uint {
+	return 1 << (sps.Log2MaxPicOrderCntLsbMinus4L + 4)
+}
+
+// SampleAspectRatio return sample aspect ratio width and height,
+// 0, 0 when unspecified
+func (sps *SPS) SampleAspectRatio() (uint, uint) {
+	vui := &sps.VuiParams
+	if !sps.VuiParametersPresentFlag || !vui.AspectRatioInfoPresentFlag {
+		return 0, 0
+	}
+	if vui.AspectRatioIdc == ExtendedSAR {
+		return uint(vui.SarWidth), uint(vui.SarHeight)
+	}
+	if int(vui.AspectRatioIdc) < len(sampleAspectRatios) {
+		sar := sampleAspectRatios[vui.AspectRatioIdc]
+		return sar[0], sar[1]
+	}
+	return 0, 0
+}
+
+// FrameRate return frame rate as numerator and denominator, a frame lasting
+// two clock ticks of NumUnitsInTick / TimeScale seconds. ok is false when
+// timing info is absent.
+func (sps *SPS) FrameRate() (num, den uint, ok bool) {
+	vui := &sps.VuiParams
+	if !sps.VuiParametersPresentFlag || !vui.TimingInfoPresentFlag || vui.NumUnitsInTick == 0 || vui.TimeScale == 0 {
+		return 0, 0, false
+	}
+	return uint(vui.TimeScale), 2 * uint(vui.NumUnitsInTick), true
+}
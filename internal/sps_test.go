@@ -1,8 +1,11 @@
 package internal
 
 import (
+	"image"
 	"reflect"
 	"testing"
+
+	"github.com/LiveStudioSolution/h264decoder/internal/rbr"
 )
 
 func TestSpsParseFromRBSP(t *testing.T) {
@@ -19,8 +22,10 @@ func TestSpsParseFromRBSP(t *testing.T) {
 		{
 			"1",
 			args{
+				// the sps of docs/videosamples/txjg.h264, its last byte 0xD4 holding the
+				// end of the VUI bitstream restriction and the rbsp trailing bits
 				[]byte{0x42, 0xC0, 0x1E, 0xDA, 0x02, 0x80, 0xBF, 0xE5, 0xC0,
-					0x5A, 0x80, 0x80, 0x80, 0xA0, 0x00, 0x00, 0x7D, 0x20, 0x00, 0x1D, 0x4C, 0x01, 0xE2, 0xC5},
+					0x5A, 0x80, 0x80, 0x80, 0xA0, 0x00, 0x00, 0x7D, 0x20, 0x00, 0x1D, 0x4C, 0x01, 0xE2, 0xC5, 0xD4},
 			},
 			&SPS{
 				ProfileIdc:66,
@@ -45,20 +50,181 @@ func TestSpsParseFromRBSP(t *testing.T) {
 				NumRefFramesInPicOrderCntCycle:0,
 
 				NumRefFrames:1,
+
+				ScalingMatrices: FlatScalingMatrices(),
+
+				PicWidthInMbsMinus1:       39,
+				PicHeightInMapUnitsMinus1: 22,
+				FrameMbsOnlyFlag:          true,
+				Direct8X8InferenceFlag:    true,
+				FrameCroppingFlag:         true,
+				FrameCrop:                 FrameCrop{BottomOffset: 4},
+				VuiParametersPresentFlag:  true,
+				VuiParams: VuiParameters{
+					AspectRatioInfoPresentFlag:         true,
+					AspectRatioIdc:                     1,
+					VideoSignalTypePresentFlag:         true,
+					VideoFormat:                        5,
+					ColourDescriptionPresentFlag:       true,
+					ColourPrimaries:                    1,
+					TransferCharacteristics:            1,
+					MatrixCoefficients:                 1,
+					TimingInfoPresentFlag:              true,
+					NumUnitsInTick:                     1001,
+					TimeScale:                          60000,
+					BitstreamRestrictionFlag:           true,
+					MotionVectorsOverPicBoundariesFlag: true,
+					Log2MaxMvLengthHorizontal:          10,
+					Log2MaxMvLengthVertical:            10,
+					MaxDecFrameBuffering:               1,
+				},
 			},
 			false,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := SpsParseFromRBSP(tt.args.rbsp)
+			got, err := ParseSpsFromRBSP(tt.args.rbsp)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("SpsParseFromRBSP() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("ParseSpsFromRBSP() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
+			got.br = nil
 			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("SpsParseFromRBSP() = %v, want %v", got, tt.want)
+				t.Errorf("ParseSpsFromRBSP() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+func TestSpsHighProfile(t *testing.T) {
+	w := rbr.NewWriter()
+	w.WriteBits(100, 8) // profile_idc
+	w.WriteBits(0, 8)   // constraint_set flags, reserved_zero_2bits
+	w.WriteBits(40, 8)  // level_idc
+	w.WriteUe(1)        // seq_parameter_set_id
+	w.WriteUe(1)        // chroma_format_idc
+	w.WriteUe(2)        // bit_depth_luma_minus8
+	w.WriteUe(1)        // bit_depth_chroma_minus8
+	w.WriteFlag(false)  // qpprime_y_zero_transform_bypass_flag
+	w.WriteFlag(true)   // seq_scaling_matrix_present_flag
+	// list 0, explicit: 8+2, then constant
+	w.WriteFlag(true)
+	w.WriteSe(2)
+	for j := 1; j < 16; j++ {
+		w.WriteSe(j % 2)
+	}
+	w.WriteFlag(false) // list 1, fall back to list 0
+	w.WriteFlag(true)  // list 2, use default
+	w.WriteSe(-8)
+	w.WriteFlag(false) // list 3, fall back to Default_4x4_Inter
+	w.WriteFlag(false) // list 4
+	w.WriteFlag(false) // list 5
+	w.WriteFlag(false) // list 6, fall back to Default_8x8_Intra
+	w.WriteFlag(true)  // list 7, 20 then repeat last
+	w.WriteSe(12)
+	w.WriteSe(0)
+	w.WriteSe(-20) // nextScale 0, rest repeats 20
+	w.WriteUe(0)   // log2_max_frame_num_minus4
+	w.WriteUe(2)   // pic_order_cnt_type
+	w.WriteUe(4)   // max_num_ref_frames
+	w.WriteFlag(false)
+	w.WriteUe(119) // pic_width_in_mbs_minus1
+	w.WriteUe(67)  // pic_height_in_map_units_minus1
+	w.WriteFlag(true)
+	w.WriteFlag(true)
+	w.WriteFlag(false)
+	w.WriteFlag(false)
+	w.WriteTrailingBits()
+
+	sps, err := ParseSpsFromRBSP(w.Bytes())
+	if err != nil {
+		t.Fatalf("ParseSpsFromRBSP() error = %v", err)
+	}
+	if sps.ChromaArrayType() != 1 || sps.BitDepthY() != 10 || sps.BitDepthC() != 9 || sps.NumRefFrames != 4 || sps.PicWidthInMbsMinus1 != 119 {
+		t.Fatalf("ParseSpsFromRBSP() = %v", sps)
+	}
+	if want := []bool{true, false, true, false, false, false, false, true}; !reflect.DeepEqual(sps.SeqScalingListPresentFlag, want) {
+		t.Errorf("SeqScalingListPresentFlag = %v, want %v", sps.SeqScalingListPresentFlag, want)
+	}
+
+	var list0 [16]uint8
+	scale := 8
+	for j := range list0 {
+		if j == 0 {
+			scale += 2
+		} else {
+			scale += j % 2
+		}
+		list0[ZigZag4x4[j]] = uint8(scale)
+	}
+	sm := sps.ScalingMatrices
+	if sm.Matrix4x4[0] != list0 || sm.Matrix4x4[1] != list0 {
+		t.Errorf("Matrix4x4[0], [1] = %v, %v, want %v", sm.Matrix4x4[0], sm.Matrix4x4[1], list0)
+	}
+	for j, pos := range ZigZag4x4 {
+		if sm.Matrix4x4[2][pos] != default4x4Intra[j] || sm.Matrix4x4[3][pos] != default4x4Inter[j] || sm.Matrix4x4[5][pos] != default4x4Inter[j] {
+			t.Fatalf("Matrix4x4[2..5] = %v, want defaults", sm.Matrix4x4[2:])
+		}
+	}
+	for j, pos := range ZigZag8x8 {
+		if sm.Matrix8x8[0][pos] != default8x8Intra[j] || sm.Matrix8x8[1][pos] != 20 {
+			t.Fatalf("Matrix8x8[0], [1] = %v, %v", sm.Matrix8x8[0], sm.Matrix8x8[1])
+		}
+	}
+	if sm.Matrix8x8[2] != sm.Matrix8x8[0] || sm.Matrix8x8[5] != sm.Matrix8x8[1] {
+		t.Errorf("chroma 8x8 matrices do not follow fall-back rule A")
+	}
+}
+
+func TestSpsGeometry(t *testing.T) {
+	sps, err := ParseSpsFromRBSP([]byte{0x42, 0xC0, 0x1E, 0xDA, 0x02, 0x80, 0xBF, 0xE5, 0xC0,
+		0x5A, 0x80, 0x80, 0x80, 0xA0, 0x00, 0x00, 0x7D, 0x20, 0x00, 0x1D, 0x4C, 0x01, 0xE2, 0xC5, 0xD4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"CodedWidth", sps.CodedWidth(), 640},
+		{"CodedHeight", sps.CodedHeight(), 368},
+		{"PicWidthInMbs", sps.PicWidthInMbs(), uint(40)},
+		{"FrameHeightInMbs", sps.FrameHeightInMbs(), uint(23)},
+		{"PicSizeInMbs", sps.PicSizeInMbs(), uint(920)},
+		{"CropUnitX", sps.CropUnitX(), 2},
+		{"CropUnitY", sps.CropUnitY(), 2},
+		{"CropRect", sps.CropRect(), image.Rect(0, 0, 640, 360)},
+		{"MaxFrameNum", sps.MaxFrameNum(), uint(16)},
+		{"MaxPicOrderCntLsb", sps.MaxPicOrderCntLsb(), uint(16)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("%v() = %v, want %v", tt.name, tt.got, tt.want)
+			}
+		})
+	}
+	if w, h := sps.SampleAspectRatio(); w != 1 || h != 1 {
+		t.Errorf("SampleAspectRatio() = %v:%v, want 1:1", w, h)
+	}
+	if num, den, ok := sps.FrameRate(); !ok || num != 60000 || den != 2002 {
+		t.Errorf("FrameRate() = %v/%v %v, want 60000/2002", num, den, ok)
+	}
+
+	// field coding and 4:2:2 double and halve vertical crop unit
+	sps.FrameMbsOnlyFlag = false
+	sps.ChromaFormatIdc = 2
+	if got := sps.CropUnitY(); got != 2 {
+		t.Errorf("CropUnitY() 4:2:2 field = %v, want 2", got)
+	}
+	if got := sps.CropRect(); got != image.Rect(0, 0, 640, 736-8) {
+		t.Errorf("CropRect() 4:2:2 field = %v", got)
+	}
+	sps.VuiParams.AspectRatioIdc = ExtendedSAR
+	sps.VuiParams.SarWidth, sps.VuiParams.SarHeight = 4, 3
+	if w, h := sps.SampleAspectRatio(); w != 4 || h != 3 {
+		t.Errorf("SampleAspectRatio() extended = %v:%v, want 4:3", w, h)
+	}
+}
@@ -0,0 +1,243 @@
+package transform
+
+import "github.com/LiveStudioSolution/h264decoder/internal"
+
+// normAdjust4x4 v of normAdjust4x4( m, i, j ), columns selected by the position in the 4x4 block
+var normAdjust4x4 = [6][3]int32{
+	{10, 16, 13},
+	{11, 18, 14},
+	{13, 20, 16},
+	{14, 23, 18},
+	{16, 25, 20},
+	{18, 29, 23},
+}
+
+// normAdjust8x8 v of normAdjust8x8( m, i, j ), columns selected by the position in the 8x8 block
+var normAdjust8x8 = [6][6]int32{
+	{20, 18, 32, 19, 25, 24},
+	{22, 19, 35, 21, 28, 26},
+	{26, 23, 42, 24, 33, 31},
+	{28, 25, 45, 26, 35, 33},
+	{32, 28, 51, 30, 40, 38},
+	{36, 32, 58, 34, 46, 43},
+}
+
+// qpcTable QPC of qPI from 30 to 51, Table 8-15
+var qpcTable = [22]int{29, 30, 31, 32, 32, 33, 34, 34, 35, 35, 36, 36, 37, 37, 37, 38, 38, 38, 39, 39, 39, 39}
+
+// ChromaQP QP'C of a chroma component from QPY and chroma_qp_index_offset for Cb or
+// second_chroma_qp_index_offset for Cr,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.5.8 Derivation process for chroma quantisation parameters
+func ChromaQP(qpy, qpIndexOffset, bitDepthC int) int {
+	qpBdOffsetC := 6 * (bitDepthC - 8)
+	qpi := qpy + qpIndexOffset
+	if qpi < -qpBdOffsetC {
+		qpi = -qpBdOffsetC
+	} else if qpi > 51 {
+		qpi = 51
+	}
+	qpc := qpi
+	if qpi >= 30 {
+		qpc = qpcTable[qpi-30]
+	}
+	return qpc + qpBdOffsetC
+}
+
+// LevelScale LevelScale4x4 and LevelScale8x8 functions of the scaling matrices in
+// use, indexed by matrix, qP % 6 and position in raster order. Matrices are indexed
+// as in internal.ScalingMatrices.
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.5.9 Derivation process for scaling functions
+type LevelScale struct {
+	scale4x4 [6][6][16]int32
+	scale8x8 [6][6][64]int32
+}
+
+// NewLevelScale derive the scaling functions of the resolved scaling matrices sm
+func NewLevelScale(sm *internal.ScalingMatrices) *LevelScale {
+	ls := &LevelScale{}
+	for i := range sm.Matrix4x4 {
+		for m := 0; m < 6; m++ {
+			for pos, w := range sm.Matrix4x4[i] {
+				ls.scale4x4[i][m][pos] = int32(w) * normAdjust4x4[m][normAdjust4x4Idx(pos%4, pos/4)]
+			}
+		}
+	}
+	for i := range sm.Matrix8x8 {
+		for m := 0; m < 6; m++ {
+			for pos, w := range sm.Matrix8x8[i] {
+				ls.scale8x8[i][m][pos] = int32(w) * normAdjust8x8[m][normAdjust8x8Idx(pos%8, pos/8)]
+			}
+		}
+	}
+	return ls
+}
+
+// normAdjust4x4Idx column of normAdjust4x4 at ( i, j )
+func normAdjust4x4Idx(i, j int) int {
+	switch {
+	case i%2 == 0 && j%2 == 0:
+		return 0
+	case i%2 == 1 && j%2 == 1:
+		return 1
+	}
+	return 2
+}
+
+// normAdjust8x8Idx column of normAdjust8x8 at ( i, j )
+func normAdjust8x8Idx(i, j int) int {
+	switch {
+	case i%4 == 0 && j%4 == 0:
+		return 0
+	case i%2 == 1 && j%2 == 1:
+		return 1
+	case i%4 == 2 && j%4 == 2:
+		return 2
+	case i%4 == 0 && j%2 == 1, i%2 == 1 && j%4 == 0:
+		return 3
+	case i%4 == 0 && j%4 == 2, i%4 == 2 && j%4 == 0:
+		return 4
+	}
+	return 5
+}
+
+// matrix4x4 index of the 4x4 matrix of colour component c, Y, Cb or Cr
+func matrix4x4(c int, intra bool) int {
+	if intra {
+		return c
+	}
+	return c + 3
+}
+
+// matrix8x8 index of the 8x8 matrix of colour component c
+func matrix8x8(c int, intra bool) int {
+	if intra {
+		return 2 * c
+	}
+	return 2*c + 1
+}
+
+// Scale4x4 scale the coefficients of a residual 4x4 block of component c in place,
+// qP being QP'Y or QP'C. With dc the DC coefficient of Intra_16x16 or chroma blocks,
+// scaled by LumaDC or ChromaDC, is left as is.
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.5.12.1 Scaling process for residual 4x4 blocks
+func (ls *LevelScale) Scale4x4(coeff *[16]int32, c int, intra bool, qP int, dc bool) {
+	scale := &ls.scale4x4[matrix4x4(c, intra)][qP%6]
+	start := 0
+	if dc {
+		start = 1
+	}
+	if qP >= 24 {
+		shift := uint(qP/6 - 4)
+		for i := start; i < 16; i++ {
+			coeff[i] = coeff[i] * scale[i] << shift
+		}
+		return
+	}
+	shift := uint(4 - qP/6)
+	round := int32(1) << (shift - 1)
+	for i := start; i < 16; i++ {
+		coeff[i] = (coeff[i]*scale[i] + round) >> shift
+	}
+}
+
+// Scale8x8 scale the coefficients of a residual 8x8 block of component c in place,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.5.13.1 Scaling process for residual 8x8 blocks
+func (ls *LevelScale) Scale8x8(coeff *[64]int32, c int, intra bool, qP int) {
+	scale := &ls.scale8x8[matrix8x8(c, intra)][qP%6]
+	if qP >= 36 {
+		shift := uint(qP/6 - 6)
+		for i := range coeff {
+			coeff[i] = coeff[i] * scale[i] << shift
+		}
+		return
+	}
+	shift := uint(6 - qP/6)
+	round := int32(1) << (shift - 1)
+	for i := range coeff {
+		coeff[i] = (coeff[i]*scale[i] + round) >> shift
+	}
+}
+
+// LumaDC transform and scale the 4x4 Intra16x16DCLevel coefficients of component c,
+// in raster order of the 4x4 blocks of the macroblock, in place. The result is the
+// DC coefficient of each 4x4 block.
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.5.10 Scaling and transformation process for DC transform coefficients for Intra_16x16 macroblock type
+func (ls *LevelScale) LumaDC(coeff *[16]int32, c int, qP int) {
+	hadamard4x4(coeff)
+	scale := ls.scale4x4[matrix4x4(c, true)][qP%6][0]
+	if qP >= 36 {
+		shift := uint(qP/6 - 6)
+		for i := range coeff {
+			coeff[i] = coeff[i] * scale << shift
+		}
+		return
+	}
+	shift := uint(6 - qP/6)
+	round := int32(1) << (shift - 1)
+	for i := range coeff {
+		coeff[i] = (coeff[i]*scale + round) >> shift
+	}
+}
+
+// ChromaDC transform and scale the chroma DC coefficients of Cb or Cr, c being 1 or 2,
+// in place. coeff holds the 4 coefficients of ChromaArrayType 1 or the 8 of
+// ChromaArrayType 2 as the 2x2 or 2x4 array c in raster order, the result is the DC
+// coefficient of each 4x4 block in chroma4x4BlkIdx order.
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.5.11 Scaling and transformation process for chroma DC transform coefficients
+func (ls *LevelScale) ChromaDC(coeff []int32, c int, intra bool, qP int) {
+	if len(coeff) == 4 {
+		c00, c01, c10, c11 := coeff[0], coeff[1], coeff[2], coeff[3]
+		coeff[0] = c00 + c01 + c10 + c11
+		coeff[1] = c00 - c01 + c10 - c11
+		coeff[2] = c00 + c01 - c10 - c11
+		coeff[3] = c00 - c01 - c10 + c11
+		scale := ls.scale4x4[matrix4x4(c, intra)][qP%6][0]
+		for i := range coeff {
+			coeff[i] = (coeff[i] * scale << uint(qP/6)) >> 5
+		}
+		return
+	}
+	// 2x4 array, 4x4 transform of the columns and 2x2 of the rows
+	for j := 0; j < 2; j++ {
+		c0, c1, c2, c3 := coeff[j], coeff[2+j], coeff[4+j], coeff[6+j]
+		coeff[j] = c0 + c1 + c2 + c3
+		coeff[2+j] = c0 + c1 - c2 - c3
+		coeff[4+j] = c0 - c1 - c2 + c3
+		coeff[6+j] = c0 - c1 + c2 - c3
+	}
+	for i := 0; i < 8; i += 2 {
+		coeff[i], coeff[i+1] = coeff[i]+coeff[i+1], coeff[i]-coeff[i+1]
+	}
+	qPDC := qP + 3
+	scale := ls.scale4x4[matrix4x4(c, intra)][qPDC%6][0]
+	if qPDC >= 36 {
+		shift := uint(qPDC/6 - 6)
+		for i := range coeff {
+			coeff[i] = coeff[i] * scale << shift
+		}
+		return
+	}
+	shift := uint(6 - qPDC/6)
+	round := int32(1) << (shift - 1)
+	for i := range coeff {
+		coeff[i] = (coeff[i]*scale + round) >> shift
+	}
+}
+
+// hadamard4x4 inverse transform of the Intra_16x16 DC coefficients
+func hadamard4x4(c *[16]int32) {
+	for i := 0; i < 16; i += 4 {
+		c0, c1, c2, c3 := c[i], c[i+1], c[i+2], c[i+3]
+		c[i] = c0 + c1 + c2 + c3
+		c[i+1] = c0 + c1 - c2 - c3
+		c[i+2] = c0 - c1 - c2 + c3
+		c[i+3] = c0 - c1 + c2 - c3
+	}
+	for j := 0; j < 4; j++ {
+		c0, c1, c2, c3 := c[j], c[4+j], c[8+j], c[12+j]
+		c[j] = c0 + c1 + c2 + c3
+		c[4+j] = c0 + c1 - c2 - c3
+		c[8+j] = c0 - c1 - c2 + c3
+		c[12+j] = c0 - c1 + c2 - c3
+	}
+}
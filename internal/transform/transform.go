@@ -0,0 +1,159 @@
+// Package transform turn transform coefficient levels into residual samples:
+// inverse scanning, scaling and the inverse transforms of the 4x4, 8x8 and DC blocks.
+// When TransformBypassModeFlag is set the scaling and transforms are skipped, the
+// residual being the coefficient levels themselves.
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.5 Transform coefficient decoding process and picture construction process prior to deblocking filter process
+package transform
+
+import (
+	"github.com/LiveStudioSolution/h264decoder/internal"
+	"github.com/LiveStudioSolution/h264decoder/internal/frame"
+)
+
+// Field4x4 raster index of each 4x4 field scan position, Table 8-13
+var Field4x4 = [16]int{0, 4, 1, 8, 12, 5, 9, 13, 2, 6, 10, 14, 3, 7, 11, 15}
+
+// Field8x8 raster index of each 8x8 field scan position, Table 8-14
+var Field8x8 = [64]int{
+	0, 8, 16, 1, 9, 24, 32, 17, 2, 25, 40, 48, 56, 33, 10, 3,
+	18, 41, 49, 57, 26, 11, 4, 19, 34, 42, 50, 58, 27, 12, 5, 20,
+	35, 43, 51, 59, 28, 13, 6, 21, 36, 44, 52, 60, 29, 14, 22, 37,
+	45, 53, 61, 30, 7, 15, 38, 46, 54, 62, 23, 31, 39, 47, 55, 63,
+}
+
+// InverseScan4x4 place the 16 levels in scan order into coeff in raster order, with
+// the field scan for field macroblocks and the zig-zag scan otherwise,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.5.6 Inverse scanning process for 4x4 transform coefficients and scaling lists
+func InverseScan4x4(levels []int32, field bool, coeff *[16]int32) {
+	scan := &internal.ZigZag4x4
+	if field {
+		scan = &Field4x4
+	}
+	for idx, pos := range scan {
+		coeff[pos] = levels[idx]
+	}
+}
+
+// InverseScan8x8 place the 64 levels in scan order into coeff in raster order,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.5.7 Inverse scanning process for 8x8 transform coefficients and scaling lists
+func InverseScan8x8(levels []int32, field bool, coeff *[64]int32) {
+	scan := &internal.ZigZag8x8
+	if field {
+		scan = &Field8x8
+	}
+	for idx, pos := range scan {
+		coeff[pos] = levels[idx]
+	}
+}
+
+// Inverse4x4 transform scaled coefficients d into residual samples in place,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.5.12.2 Transformation process for residual 4x4 blocks
+func Inverse4x4(d *[16]int32) {
+	for i := 0; i < 16; i += 4 {
+		e0 := d[i] + d[i+2]
+		e1 := d[i] - d[i+2]
+		e2 := d[i+1]>>1 - d[i+3]
+		e3 := d[i+1] + d[i+3]>>1
+		d[i] = e0 + e3
+		d[i+1] = e1 + e2
+		d[i+2] = e1 - e2
+		d[i+3] = e0 - e3
+	}
+	for j := 0; j < 4; j++ {
+		g0 := d[j] + d[8+j]
+		g1 := d[j] - d[8+j]
+		g2 := d[4+j]>>1 - d[12+j]
+		g3 := d[4+j] + d[12+j]>>1
+		d[j] = (g0 + g3 + 32) >> 6
+		d[4+j] = (g1 + g2 + 32) >> 6
+		d[8+j] = (g1 - g2 + 32) >> 6
+		d[12+j] = (g0 - g3 + 32) >> 6
+	}
+}
+
+// Inverse8x8 transform scaled coefficients d into residual samples in place,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.5.13.2 Transformation process for residual 8x8 blocks
+func Inverse8x8(d *[64]int32) {
+	for i := 0; i < 64; i += 8 {
+		inverse8(d[i:i+8], 1)
+	}
+	for j := 0; j < 8; j++ {
+		inverse8(d[j:], 8)
+	}
+	for i := range d {
+		d[i] = (d[i] + 32) >> 6
+	}
+}
+
+// inverse8 one dimensional 8 point transform of the elements of v step apart
+func inverse8(v []int32, step int) {
+	d0, d1, d2, d3 := v[0], v[step], v[2*step], v[3*step]
+	d4, d5, d6, d7 := v[4*step], v[5*step], v[6*step], v[7*step]
+
+	e0 := d0 + d4
+	e1 := -d3 + d5 - d7 - d7>>1
+	e2 := d0 - d4
+	e3 := d1 + d7 - d3 - d3>>1
+	e4 := d2>>1 - d6
+	e5 := -d1 + d7 + d5 + d5>>1
+	e6 := d2 + d6>>1
+	e7 := d3 + d5 + d1 + d1>>1
+
+	f0 := e0 + e6
+	f1 := e1 + e7>>2
+	f2 := e2 + e4
+	f3 := e3 + e5>>2
+	f4 := e2 - e4
+	f5 := e3>>2 - e5
+	f6 := e0 - e6
+	f7 := e7 - e1>>2
+
+	v[0] = f0 + f7
+	v[step] = f2 + f5
+	v[2*step] = f4 + f3
+	v[3*step] = f6 + f1
+	v[4*step] = f6 - f1
+	v[5*step] = f4 - f3
+	v[6*step] = f2 - f5
+	v[7*step] = f0 - f7
+}
+
+// BypassIntra intra residual transform-bypass decoding of the nW x nH residual r in
+// raster order, accumulating the residual along the prediction direction of Intra
+// Horizontal or Vertical prediction modes,
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.5.15 Intra residual transform-bypass decoding process
+func BypassIntra(r []int32, nW, nH int, horPredFlag bool) {
+	if horPredFlag {
+		for y := 0; y < nH; y++ {
+			for x := 1; x < nW; x++ {
+				r[y*nW+x] += r[y*nW+x-1]
+			}
+		}
+		return
+	}
+	for y := 1; y < nH; y++ {
+		for x := 0; x < nW; x++ {
+			r[y*nW+x] += r[(y-1)*nW+x]
+		}
+	}
+}
+
+// AddResidual add the w x h residual r in raster order to the prediction samples at
+// ( x, y ) of pl, u = Clip1( pred + r ), before the
+// T-REC-H.264-201402-S!!PDF-E.pdf 8.5.14 Picture construction process prior to deblocking filter process
+func AddResidual(pl *frame.Plane, x, y, w, h int, r []int32, bitDepth int) {
+	max := int32(1)<<uint(bitDepth) - 1
+	for j := 0; j < h; j++ {
+		row := pl.Pix[(y+j)*pl.Stride+x : (y+j)*pl.Stride+x+w]
+		res := r[j*w : j*w+w]
+		for i := range row {
+			u := int32(row[i]) + res[i]
+			if u < 0 {
+				u = 0
+			} else if u > max {
+				u = max
+			}
+			row[i] = uint16(u)
+		}
+	}
+}
@@ -0,0 +1,576 @@
+package transform
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/LiveStudioSolution/h264decoder/internal"
+	"github.com/LiveStudioSolution/h264decoder/internal/frame"
+)
+
+// spec4x4 the 4x4 transformation of 8.5.12.2 transcribed with the arrays of the equations
+func spec4x4(d [4][4]int32) [4][4]int32 {
+	var e, f, g, h, r [4][4]int32
+	for i := 0; i < 4; i++ {
+		e[i][0] = d[i][0] + d[i][2]
+		e[i][1] = d[i][0] - d[i][2]
+		e[i][2] = (d[i][1] >> 1) - d[i][3]
+		e[i][3] = d[i][1] + (d[i][3] >> 1)
+		f[i][0] = e[i][0] + e[i][3]
+		f[i][1] = e[i][1] + e[i][2]
+		f[i][2] = e[i][1] - e[i][2]
+		f[i][3] = e[i][0] - e[i][3]
+	}
+	for j := 0; j < 4; j++ {
+		g[0][j] = f[0][j] + f[2][j]
+		g[1][j] = f[0][j] - f[2][j]
+		g[2][j] = (f[1][j] >> 1) - f[3][j]
+		g[3][j] = f[1][j] + (f[3][j] >> 1)
+		h[0][j] = g[0][j] + g[3][j]
+		h[1][j] = g[1][j] + g[2][j]
+		h[2][j] = g[1][j] - g[2][j]
+		h[3][j] = g[0][j] - g[3][j]
+	}
+	for i := range r {
+		for j := range r[i] {
+			r[i][j] = (h[i][j] + 32) >> 6
+		}
+	}
+	return r
+}
+
+// spec8 the one dimensional 8 point transformation of 8.5.13.2
+func spec8(d [8]int32) [8]int32 {
+	var e, f, g [8]int32
+	e[0] = d[0] + d[4]
+	e[1] = -d[3] + d[5] - d[7] - (d[7] >> 1)
+	e[2] = d[0] - d[4]
+	e[3] = d[1] + d[7] - d[3] - (d[3] >> 1)
+	e[4] = (d[2] >> 1) - d[6]
+	e[5] = -d[1] + d[7] + d[5] + (d[5] >> 1)
+	e[6] = d[2] + (d[6] >> 1)
+	e[7] = d[3] + d[5] + d[1] + (d[1] >> 1)
+	f[0] = e[0] + e[6]
+	f[1] = e[1] + (e[7] >> 2)
+	f[2] = e[2] + e[4]
+	f[3] = e[3] + (e[5] >> 2)
+	f[4] = e[2] - e[4]
+	f[5] = (e[3] >> 2) - e[5]
+	f[6] = e[0] - e[6]
+	f[7] = e[7] - (e[1] >> 2)
+	g[0] = f[0] + f[7]
+	g[1] = f[2] + f[5]
+	g[2] = f[4] + f[3]
+	g[3] = f[6] + f[1]
+	g[4] = f[6] - f[1]
+	g[5] = f[4] - f[3]
+	g[6] = f[2] - f[5]
+	g[7] = f[0] - f[7]
+	return g
+}
+
+func spec8x8(d [8][8]int32) [8][8]int32 {
+	var g, m, r [8][8]int32
+	for i := 0; i < 8; i++ {
+		g[i] = spec8(d[i])
+	}
+	for j := 0; j < 8; j++ {
+		var col [8]int32
+		for i := 0; i < 8; i++ {
+			col[i] = g[i][j]
+		}
+		col = spec8(col)
+		for i := 0; i < 8; i++ {
+			m[i][j] = col[i]
+		}
+	}
+	for i := range r {
+		for j := range r[i] {
+			r[i][j] = (m[i][j] + 32) >> 6
+		}
+	}
+	return r
+}
+
+// randCoeff coefficient in the range allowed for scaled coefficients of 8 bit video
+func randCoeff(rnd *rand.Rand) int32 {
+	return rnd.Int31n(1<<13) - 1<<12
+}
+
+func TestInverse4x4(t *testing.T) {
+	var d [16]int32
+	d[0] = 64
+	Inverse4x4(&d)
+	for i, v := range d {
+		if v != 1 {
+			t.Fatalf("Inverse4x4(dc 64)[%v] = %v, want 1", i, v)
+		}
+	}
+	rnd := rand.New(rand.NewSource(1))
+	for n := 0; n < 10000; n++ {
+		var d [16]int32
+		var m [4][4]int32
+		for i := range d {
+			// sparse blocks as well as full ones
+			if n%2 == 0 || rnd.Intn(4) == 0 {
+				d[i] = randCoeff(rnd)
+			}
+			m[i/4][i%4] = d[i]
+		}
+		want := spec4x4(m)
+		Inverse4x4(&d)
+		for i, v := range d {
+			if v != want[i/4][i%4] {
+				t.Fatalf("Inverse4x4(%v) = %v, want %v", m, d, want)
+			}
+		}
+	}
+}
+
+func TestInverse8x8(t *testing.T) {
+	rnd := rand.New(rand.NewSource(2))
+	for n := 0; n < 10000; n++ {
+		var d [64]int32
+		var m [8][8]int32
+		for i := range d {
+			if n%2 == 0 || rnd.Intn(8) == 0 {
+				d[i] = randCoeff(rnd)
+			}
+			m[i/8][i%8] = d[i]
+		}
+		want := spec8x8(m)
+		Inverse8x8(&d)
+		for i, v := range d {
+			if v != want[i/8][i%8] {
+				t.Fatalf("Inverse8x8(%v) = %v, want %v", m, d, want)
+			}
+		}
+	}
+}
+
+// testMatrices scaling matrices with a distinct random matrix for each index
+func testMatrices(rnd *rand.Rand) *internal.ScalingMatrices {
+	sm := &internal.ScalingMatrices{}
+	for i := range sm.Matrix4x4 {
+		for j := range sm.Matrix4x4[i] {
+			sm.Matrix4x4[i][j] = uint8(4 + rnd.Intn(252))
+		}
+	}
+	for i := range sm.Matrix8x8 {
+		for j := range sm.Matrix8x8[i] {
+			sm.Matrix8x8[i][j] = uint8(4 + rnd.Intn(252))
+		}
+	}
+	return sm
+}
+
+// specNormAdjust4x4 normAdjust4x4( m, i, j ) of 8.5.9
+func specNormAdjust4x4(m, i, j int) int32 {
+	v := normAdjust4x4[m]
+	if i%2 == 0 && j%2 == 0 {
+		return v[0]
+	}
+	if i%2 == 1 && j%2 == 1 {
+		return v[1]
+	}
+	return v[2]
+}
+
+// specNormAdjust8x8 normAdjust8x8( m, i, j ) of 8.5.9
+func specNormAdjust8x8(m, i, j int) int32 {
+	v := normAdjust8x8[m]
+	switch {
+	case i%4 == 0 && j%4 == 0:
+		return v[0]
+	case i%2 == 1 && j%2 == 1:
+		return v[1]
+	case i%4 == 2 && j%4 == 2:
+		return v[2]
+	case i%4 == 0 && j%2 == 1 || i%2 == 1 && j%4 == 0:
+		return v[3]
+	case i%4 == 0 && j%4 == 2 || i%4 == 2 && j%4 == 0:
+		return v[4]
+	}
+	return v[5]
+}
+
+func TestNormAdjust(t *testing.T) {
+	// v of m 0 at a position of each column
+	want8x8 := map[[2]int]int32{{0, 0}: 20, {1, 1}: 18, {2, 2}: 32, {0, 1}: 19, {1, 4}: 19, {0, 2}: 25, {2, 4}: 25, {1, 2}: 24, {3, 6}: 24}
+	for pos, want := range want8x8 {
+		if got := specNormAdjust8x8(0, pos[0], pos[1]); got != want {
+			t.Errorf("normAdjust8x8(0, %v, %v) = %v, want %v", pos[0], pos[1], got, want)
+		}
+	}
+	for m := 0; m < 6; m++ {
+		for i := 0; i < 8; i++ {
+			for j := 0; j < 8; j++ {
+				if normAdjust8x8[m][normAdjust8x8Idx(i, j)] != specNormAdjust8x8(m, i, j) {
+					t.Errorf("normAdjust8x8Idx(%v, %v) mismatch", i, j)
+				}
+			}
+		}
+	}
+}
+
+func TestLevelScale(t *testing.T) {
+	rnd := rand.New(rand.NewSource(3))
+	sm := testMatrices(rnd)
+	ls := NewLevelScale(sm)
+	for k := range sm.Matrix4x4 {
+		for m := 0; m < 6; m++ {
+			for i := 0; i < 4; i++ {
+				for j := 0; j < 4; j++ {
+					want := int32(sm.Matrix4x4[k][4*i+j]) * specNormAdjust4x4(m, i, j)
+					if got := ls.scale4x4[k][m][4*i+j]; got != want {
+						t.Fatalf("LevelScale4x4 matrix %v ( %v, %v, %v ) = %v, want %v", k, m, i, j, got, want)
+					}
+				}
+			}
+		}
+	}
+	for k := range sm.Matrix8x8 {
+		for m := 0; m < 6; m++ {
+			for i := 0; i < 8; i++ {
+				for j := 0; j < 8; j++ {
+					want := int32(sm.Matrix8x8[k][8*i+j]) * specNormAdjust8x8(m, i, j)
+					if got := ls.scale8x8[k][m][8*i+j]; got != want {
+						t.Fatalf("LevelScale8x8 matrix %v ( %v, %v, %v ) = %v, want %v", k, m, i, j, got, want)
+					}
+				}
+			}
+		}
+	}
+	flat := internal.FlatScalingMatrices()
+	if got := NewLevelScale(&flat).scale4x4[0][0]; got[0] != 160 || got[5] != 256 || got[1] != 208 {
+		t.Errorf("flat LevelScale4x4( 0 ) = %v", got)
+	}
+}
+
+// maxQP largest QP'Y or QP'C, of 14 bit video
+const maxQP = 51 + 36
+
+func TestScale4x4(t *testing.T) {
+	rnd := rand.New(rand.NewSource(4))
+	ls := NewLevelScale(testMatrices(rnd))
+	for qP := 0; qP <= maxQP; qP++ {
+		for c := 0; c < 3; c++ {
+			for _, intra := range []bool{true, false} {
+				for _, dc := range []bool{true, false} {
+					var coeff, want [16]int32
+					for i := range coeff {
+						coeff[i] = rnd.Int31n(512) - 256
+						scale := ls.scale4x4[matrix4x4(c, intra)][qP%6][i]
+						switch {
+						case i == 0 && dc:
+							want[i] = coeff[i]
+						case qP >= 24:
+							want[i] = (coeff[i] * scale) << uint(qP/6-4)
+						default:
+							want[i] = (coeff[i]*scale + 1<<uint(3-qP/6)) >> uint(4-qP/6)
+						}
+					}
+					ls.Scale4x4(&coeff, c, intra, qP, dc)
+					if coeff != want {
+						t.Fatalf("Scale4x4(qP %v, c %v, intra %v, dc %v) = %v, want %v", qP, c, intra, dc, coeff, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestScale8x8(t *testing.T) {
+	rnd := rand.New(rand.NewSource(5))
+	ls := NewLevelScale(testMatrices(rnd))
+	for qP := 0; qP <= maxQP; qP++ {
+		for c := 0; c < 3; c++ {
+			for _, intra := range []bool{true, false} {
+				var coeff, want [64]int32
+				for i := range coeff {
+					coeff[i] = rnd.Int31n(512) - 256
+					scale := ls.scale8x8[matrix8x8(c, intra)][qP%6][i]
+					if qP >= 36 {
+						want[i] = (coeff[i] * scale) << uint(qP/6-6)
+					} else {
+						want[i] = (coeff[i]*scale + 1<<uint(5-qP/6)) >> uint(6-qP/6)
+					}
+				}
+				ls.Scale8x8(&coeff, c, intra, qP)
+				if coeff != want {
+					t.Fatalf("Scale8x8(qP %v, c %v, intra %v) = %v, want %v", qP, c, intra, coeff, want)
+				}
+			}
+		}
+	}
+}
+
+// matMul product of a and b
+func matMul(a, b [][]int32) [][]int32 {
+	p := make([][]int32, len(a))
+	for i := range a {
+		p[i] = make([]int32, len(b[0]))
+		for j := range b[0] {
+			for k := range b {
+				p[i][j] += a[i][k] * b[k][j]
+			}
+		}
+	}
+	return p
+}
+
+var (
+	hadamard4 = [][]int32{{1, 1, 1, 1}, {1, 1, -1, -1}, {1, -1, -1, 1}, {1, -1, 1, -1}}
+	hadamard2 = [][]int32{{1, 1}, {1, -1}}
+)
+
+func toMatrix(v []int32, cols int) [][]int32 {
+	m := make([][]int32, len(v)/cols)
+	for i := range m {
+		m[i] = append([]int32(nil), v[i*cols:i*cols+cols]...)
+	}
+	return m
+}
+
+func TestLumaDC(t *testing.T) {
+	rnd := rand.New(rand.NewSource(6))
+	ls := NewLevelScale(testMatrices(rnd))
+	for qP := 0; qP <= maxQP; qP++ {
+		for c := 0; c < 3; c++ {
+			var coeff [16]int32
+			for i := range coeff {
+				coeff[i] = rnd.Int31n(16) - 8
+			}
+			f := matMul(matMul(hadamard4, toMatrix(coeff[:], 4)), hadamard4)
+			scale := ls.scale4x4[matrix4x4(c, true)][qP%6][0]
+			var want [16]int32
+			for i := range want {
+				fij := f[i/4][i%4]
+				if qP >= 36 {
+					want[i] = (fij * scale) << uint(qP/6-6)
+				} else {
+					want[i] = (fij*scale + 1<<uint(5-qP/6)) >> uint(6-qP/6)
+				}
+			}
+			ls.LumaDC(&coeff, c, qP)
+			if coeff != want {
+				t.Fatalf("LumaDC(qP %v, c %v) = %v, want %v", qP, c, coeff, want)
+			}
+		}
+	}
+}
+
+func TestChromaDC(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	ls := NewLevelScale(testMatrices(rnd))
+	for qP := 0; qP <= maxQP; qP++ {
+		for c := 1; c < 3; c++ {
+			for _, intra := range []bool{true, false} {
+				scale := func(qP int) int32 {
+					return ls.scale4x4[matrix4x4(c, intra)][qP%6][0]
+				}
+				coeff := make([]int32, 4)
+				for i := range coeff {
+					coeff[i] = rnd.Int31n(8) - 4
+				}
+				f := matMul(matMul(hadamard2, toMatrix(coeff, 2)), hadamard2)
+				var want []int32
+				for i := range coeff {
+					want = append(want, ((f[i/2][i%2]*scale(qP))<<uint(qP/6))>>5)
+				}
+				ls.ChromaDC(coeff, c, intra, qP)
+				if !reflect.DeepEqual(coeff, want) {
+					t.Fatalf("ChromaDC(4:2:0, qP %v, c %v, intra %v) = %v, want %v", qP, c, intra, coeff, want)
+				}
+
+				coeff = make([]int32, 8)
+				for i := range coeff {
+					coeff[i] = rnd.Int31n(8) - 4
+				}
+				f = matMul(matMul(hadamard4, toMatrix(coeff, 2)), hadamard2)
+				qPDC := qP + 3
+				want = want[:0]
+				for i := range coeff {
+					fij := f[i/2][i%2]
+					if qPDC >= 36 {
+						want = append(want, (fij*scale(qPDC))<<uint(qPDC/6-6))
+					} else {
+						want = append(want, (fij*scale(qPDC)+1<<uint(5-qPDC/6))>>uint(6-qPDC/6))
+					}
+				}
+				ls.ChromaDC(coeff, c, intra, qP)
+				if !reflect.DeepEqual(coeff, want) {
+					t.Fatalf("ChromaDC(4:2:2, qP %v, c %v, intra %v) = %v, want %v", qP, c, intra, coeff, want)
+				}
+			}
+		}
+	}
+}
+
+func TestChromaQP(t *testing.T) {
+	// Table 8-15 from qPI 29
+	table := []int{29, 29, 30, 31, 32, 32, 33, 34, 34, 35, 35, 36, 36, 37, 37, 37, 38, 38, 38, 39, 39, 39, 39}
+	for bitDepthC := 8; bitDepthC <= 14; bitDepthC++ {
+		qpBdOffsetC := 6 * (bitDepthC - 8)
+		for qpy := -6 * (bitDepthC - 8); qpy <= 51; qpy++ {
+			for offset := -12; offset <= 12; offset++ {
+				qpi := qpy + offset
+				if qpi < -qpBdOffsetC {
+					qpi = -qpBdOffsetC
+				}
+				if qpi > 51 {
+					qpi = 51
+				}
+				want := qpi
+				if qpi >= 29 {
+					want = table[qpi-29]
+				}
+				if got := ChromaQP(qpy, offset, bitDepthC); got != want+qpBdOffsetC {
+					t.Fatalf("ChromaQP(%v, %v, %v) = %v, want %v", qpy, offset, bitDepthC, got, want+qpBdOffsetC)
+				}
+			}
+		}
+	}
+}
+
+func TestInverseScan(t *testing.T) {
+	levels := make([]int32, 64)
+	for i := range levels {
+		levels[i] = int32(i)
+	}
+	var c4 [16]int32
+	InverseScan4x4(levels, false, &c4)
+	if want := [16]int32{0, 1, 5, 6, 2, 4, 7, 12, 3, 8, 11, 13, 9, 10, 14, 15}; c4 != want {
+		t.Errorf("InverseScan4x4(zig-zag) = %v, want %v", c4, want)
+	}
+	InverseScan4x4(levels, true, &c4)
+	if want := [16]int32{0, 2, 8, 12, 1, 5, 9, 13, 3, 6, 10, 14, 4, 7, 11, 15}; c4 != want {
+		t.Errorf("InverseScan4x4(field) = %v, want %v", c4, want)
+	}
+	var c8 [64]int32
+	InverseScan8x8(levels, true, &c8)
+	// first column and row of the 8x8 field scan, Table 8-14
+	col := []int32{c8[0], c8[8], c8[16], c8[24], c8[32], c8[40], c8[48], c8[56]}
+	row := []int32{c8[0], c8[1], c8[2], c8[3], c8[4], c8[5], c8[6], c8[7]}
+	if !reflect.DeepEqual(col, []int32{0, 1, 2, 5, 6, 10, 11, 12}) || !reflect.DeepEqual(row, []int32{0, 3, 8, 15, 22, 30, 38, 52}) {
+		t.Errorf("InverseScan8x8(field) column 0 = %v, row 0 = %v", col, row)
+	}
+	seen := map[int]bool{}
+	for _, pos := range Field8x8 {
+		seen[pos] = true
+	}
+	if len(seen) != 64 {
+		t.Errorf("Field8x8 covers %v positions, want 64", len(seen))
+	}
+}
+
+func TestBypassIntra(t *testing.T) {
+	r := []int32{1, 2, 3, 4, 5, 6}
+	BypassIntra(r, 3, 2, true)
+	if want := []int32{1, 3, 6, 4, 9, 15}; !reflect.DeepEqual(r, want) {
+		t.Errorf("BypassIntra(horizontal) = %v, want %v", r, want)
+	}
+	r = []int32{1, 2, 3, 4, 5, 6}
+	BypassIntra(r, 3, 2, false)
+	if want := []int32{1, 2, 3, 5, 7, 9}; !reflect.DeepEqual(r, want) {
+		t.Errorf("BypassIntra(vertical) = %v, want %v", r, want)
+	}
+}
+
+func TestAddResidual(t *testing.T) {
+	pl := frame.NewPlane(4, 2)
+	for i := range pl.Pix {
+		pl.Pix[i] = 100
+	}
+	AddResidual(pl, 1, 0, 2, 2, []int32{-200, 5, 1000, -100}, 8)
+	if want := []uint16{100, 0, 105, 100, 100, 255, 0, 100}; !reflect.DeepEqual(pl.Pix, want) {
+		t.Errorf("AddResidual() = %v, want %v", pl.Pix, want)
+	}
+}
+
+func benchmarkBlocks4x4() [][16]int32 {
+	rnd := rand.New(rand.NewSource(8))
+	blocks := make([][16]int32, 256)
+	for i := range blocks {
+		for j := 0; j < 4; j++ {
+			blocks[i][internal.ZigZag4x4[j]] = rnd.Int31n(64) - 32
+		}
+	}
+	return blocks
+}
+
+func BenchmarkScale4x4(b *testing.B) {
+	flat := internal.FlatScalingMatrices()
+	ls := NewLevelScale(&flat)
+	blocks := benchmarkBlocks4x4()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		d := blocks[i%len(blocks)]
+		ls.Scale4x4(&d, 0, true, 28, false)
+	}
+}
+
+func BenchmarkInverse4x4(b *testing.B) {
+	blocks := benchmarkBlocks4x4()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		d := blocks[i%len(blocks)]
+		Inverse4x4(&d)
+	}
+}
+
+func BenchmarkInverse8x8(b *testing.B) {
+	rnd := rand.New(rand.NewSource(9))
+	blocks := make([][64]int32, 256)
+	for i := range blocks {
+		for j := 0; j < 10; j++ {
+			blocks[i][internal.ZigZag8x8[j]] = rnd.Int31n(256) - 128
+		}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		d := blocks[i%len(blocks)]
+		Inverse8x8(&d)
+	}
+}
+
+func BenchmarkScale8x8(b *testing.B) {
+	flat := internal.FlatScalingMatrices()
+	ls := NewLevelScale(&flat)
+	var d [64]int32
+	for i := range d {
+		d[i] = int32(i%7) - 3
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c := d
+		ls.Scale8x8(&c, 0, false, 30)
+	}
+}
+
+func BenchmarkLumaDC(b *testing.B) {
+	flat := internal.FlatScalingMatrices()
+	ls := NewLevelScale(&flat)
+	var d [16]int32
+	for i := range d {
+		d[i] = int32(i%5) - 2
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c := d
+		ls.LumaDC(&c, 0, 26)
+	}
+}
+
+func BenchmarkAddResidual(b *testing.B) {
+	pl := frame.NewPlane(64, 64)
+	r := make([]int32, 256)
+	for i := range r {
+		r[i] = int32(i%31) - 15
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		AddResidual(pl, 16, 16, 16, 16, r, 8)
+	}
+}
@@ -0,0 +1,246 @@
+// Package yuv write decoded frames as raw planar or semi-planar YUV, or as a
+// YUV4MPEG2 stream, to compare them with the output of reference decoders
+package yuv
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"strings"
+
+	"github.com/LiveStudioSolution/h264decoder/internal"
+	"github.com/LiveStudioSolution/h264decoder/internal/frame"
+)
+
+// Format layout of the written frames
+type Format uint8
+
+const (
+	// Planar Y, Cb and Cr planes one after the other, I420, I422 or I444 following the
+	// chroma format of the frames, Y alone for monochrome
+	Planar Format = iota
+	// NV12 Y plane followed by Cb and Cr interleaved, 4:2:0 frames only
+	NV12
+	// Y4M YUV4MPEG2 stream header followed by planar frames
+	Y4M
+)
+
+func (f Format) String() string {
+	switch f {
+	case Planar:
+		return "planar"
+	case NV12:
+		return "nv12"
+	case Y4M:
+		return "y4m"
+	}
+	return fmt.Sprintf("Format(%d)", f)
+}
+
+// Options of a Writer
+type Options struct {
+	Format Format
+	// Crop write the samples of the crop window of each frame, the frame cropping
+	// window of its SPS, else the whole coded frame
+	Crop bool
+}
+
+// Writer serialise decoded frames. Samples of bit depth 8 take one byte, higher bit
+// depths two bytes little-endian.
+type Writer struct {
+	w    io.Writer
+	sps  *internal.SPS
+	opts Options
+
+	header bool
+	// size of the frames of a Y4M stream
+	size image.Point
+	buf  []byte
+}
+
+// NewWriter return a Writer of frames to w, sps describing the stream for the Y4M
+// header, nil for the defaults
+func NewWriter(w io.Writer, sps *internal.SPS, opts Options) *Writer {
+	return &Writer{w: w, sps: sps, opts: opts}
+}
+
+// WriteFrame write frame f, after the stream header for the first frame of a Y4M stream
+func (w *Writer) WriteFrame(f *frame.VideoFrame) error {
+	r := w.rect(f)
+	switch w.opts.Format {
+	case Planar:
+	case NV12:
+		if f.ChromaFormat != frame.Chroma420 {
+			return fmt.Errorf("nv12 output of chroma format %v", f.ChromaFormat)
+		}
+	case Y4M:
+		if !w.header {
+			header, err := w.y4mHeader(f, r)
+			if err != nil {
+				return err
+			}
+			if _, err := io.WriteString(w.w, header); err != nil {
+				return err
+			}
+			w.header, w.size = true, r.Size()
+		} else if r.Size() != w.size {
+			return fmt.Errorf("frame of %v in a y4m stream of %v", r.Size(), w.size)
+		}
+		if _, err := io.WriteString(w.w, "FRAME\n"); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown output format %v", w.opts.Format)
+	}
+
+	if err := w.writePlane(f.Planes[0], r); err != nil {
+		return err
+	}
+	if f.ChromaFormat == frame.Monochrome {
+		return nil
+	}
+	subW, subH := f.ChromaFormat.SubWidthC(), f.ChromaFormat.SubHeightC()
+	rc := image.Rect(r.Min.X/subW, r.Min.Y/subH, (r.Max.X+subW-1)/subW, (r.Max.Y+subH-1)/subH)
+	if w.opts.Format == NV12 {
+		return w.writeInterleaved(f.Planes[1], f.Planes[2], rc)
+	}
+	for _, p := range f.Planes[1:] {
+		if err := w.writePlane(p, rc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rect luma rectangle of f to write
+func (w *Writer) rect(f *frame.VideoFrame) image.Rectangle {
+	y := f.Planes[0]
+	r := image.Rect(0, 0, y.Width, y.Height)
+	if w.opts.Crop {
+		r = r.Intersect(f.Crop)
+	}
+	return r
+}
+
+// writePlane write the samples of rectangle r of p
+func (w *Writer) writePlane(p *frame.VideoPlane, r image.Rectangle) error {
+	bps := bytesPerSample(p.BitDepth)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		row := p.Pix[y*p.Stride+r.Min.X*bps : y*p.Stride+r.Max.X*bps]
+		if _, err := w.w.Write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeInterleaved write the samples of rectangle r of cb and cr interleaved
+func (w *Writer) writeInterleaved(cb, cr *frame.VideoPlane, r image.Rectangle) error {
+	bps := bytesPerSample(cb.BitDepth)
+	n := 2 * r.Dx() * bps
+	if cap(w.buf) < n {
+		w.buf = make([]byte, n)
+	}
+	row := w.buf[:n]
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			i := 2 * (x - r.Min.X) * bps
+			copy(row[i:i+bps], cb.Pix[y*cb.Stride+x*bps:])
+			copy(row[i+bps:i+2*bps], cr.Pix[y*cr.Stride+x*bps:])
+		}
+		if _, err := w.w.Write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// y4mHeader the YUV4MPEG2 stream header of frames like f, of the luma rectangle r
+func (w *Writer) y4mHeader(f *frame.VideoFrame, r image.Rectangle) (string, error) {
+	colourSpace, err := y4mColourSpace(f, w.sps)
+	if err != nil {
+		return "", err
+	}
+	fields := []string{"YUV4MPEG2", fmt.Sprintf("W%d", r.Dx()), fmt.Sprintf("H%d", r.Dy())}
+
+	num, den, ok := uint(25), uint(1), false
+	sarW, sarH := uint(0), uint(0)
+	interlacing := "p"
+	fullRange := false
+	if sps := w.sps; sps != nil {
+		if n, d, present := sps.FrameRate(); present {
+			num, den, ok = n, d, true
+		}
+		sarW, sarH = sps.SampleAspectRatio()
+		vui := &sps.VuiParams
+		fullRange = sps.VuiParametersPresentFlag && vui.VideoSignalTypePresentFlag && vui.VideoFullRangeFlag
+		if !sps.FrameMbsOnlyFlag {
+			interlacing = "t"
+			if f.BottomPoc < f.TopPoc {
+				interlacing = "b"
+			}
+		}
+	}
+	if ok {
+		g := gcd(num, den)
+		num, den = num/g, den/g
+	}
+	fields = append(fields, fmt.Sprintf("F%d:%d", num, den), "I"+interlacing, fmt.Sprintf("A%d:%d", sarW, sarH), "C"+colourSpace)
+	if fullRange {
+		fields = append(fields, "XCOLORRANGE=FULL")
+	} else {
+		fields = append(fields, "XCOLORRANGE=LIMITED")
+	}
+	return strings.Join(fields, " ") + "\n", nil
+}
+
+// y4mColourSpace the C parameter of the Y4M header of frames like f, 4:2:0 chroma
+// siting from the VUI chroma sample location of sps
+func y4mColourSpace(f *frame.VideoFrame, sps *internal.SPS) (string, error) {
+	if f.ChromaFormat != frame.Monochrome && f.BitDepthC != f.BitDepthY {
+		return "", fmt.Errorf("y4m output of luma bit depth %v and chroma bit depth %v", f.BitDepthY, f.BitDepthC)
+	}
+	depth := ""
+	if f.BitDepthY > 8 {
+		depth = fmt.Sprintf("p%d", f.BitDepthY)
+	}
+	switch f.ChromaFormat {
+	case frame.Monochrome:
+		if depth != "" {
+			return fmt.Sprintf("mono%d", f.BitDepthY), nil
+		}
+		return "mono", nil
+	case frame.Chroma422:
+		return "422" + depth, nil
+	case frame.Chroma444:
+		return "444" + depth, nil
+	}
+	if depth != "" {
+		return "420" + depth, nil
+	}
+	loc := uint(0)
+	if sps != nil && sps.VuiParametersPresentFlag && sps.VuiParams.ChromaLocInfoPresentFlag {
+		loc = sps.VuiParams.ChromaSampleLocTypeTopField
+	}
+	switch loc {
+	case 1:
+		return "420jpeg", nil
+	case 2:
+		return "420paldv", nil
+	}
+	return "420mpeg2", nil
+}
+
+func bytesPerSample(bitDepth int) int {
+	if bitDepth > 8 {
+		return 2
+	}
+	return 1
+}
+
+func gcd(a, b uint) uint {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
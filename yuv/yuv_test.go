@@ -0,0 +1,186 @@
+package yuv
+
+import (
+	"bytes"
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/LiveStudioSolution/h264decoder/internal"
+	"github.com/LiveStudioSolution/h264decoder/internal/frame"
+)
+
+// testFrame a width x height frame of chroma format format, luma samples numbered
+// from 0, Cb from 100 and Cr from 200 in raster order
+func testFrame(width, height int, format frame.ChromaFormat, bitDepth int) *frame.VideoFrame {
+	var planes [3]*frame.Plane
+	for c := range planes {
+		w, h := width, height
+		if c > 0 {
+			if format == frame.Monochrome {
+				break
+			}
+			w, h = width/format.SubWidthC(), height/format.SubHeightC()
+		}
+		p := frame.NewPlane(w, h)
+		for i := 0; i < w*h; i++ {
+			p.Set(i%w, i/w, uint16(100*c+i))
+		}
+		planes[c] = p
+	}
+	return frame.NewVideoFrame(planes, format, bitDepth, bitDepth)
+}
+
+func TestWriteFrame(t *testing.T) {
+	cropped := testFrame(4, 4, frame.Chroma420, 8)
+	cropped.Crop = image.Rect(2, 2, 4, 4)
+	tests := []struct {
+		name string
+		f    *frame.VideoFrame
+		opts Options
+		want []byte
+	}{
+		{"I420", testFrame(4, 2, frame.Chroma420, 8), Options{Format: Planar},
+			[]byte{0, 1, 2, 3, 4, 5, 6, 7, 100, 101, 200, 201}},
+		{"I422", testFrame(2, 2, frame.Chroma422, 8), Options{Format: Planar},
+			[]byte{0, 1, 2, 3, 100, 101, 200, 201}},
+		{"I444", testFrame(2, 1, frame.Chroma444, 8), Options{Format: Planar},
+			[]byte{0, 1, 100, 101, 200, 201}},
+		{"monochrome", testFrame(2, 2, frame.Monochrome, 8), Options{Format: Planar},
+			[]byte{0, 1, 2, 3}},
+		{"NV12", testFrame(4, 2, frame.Chroma420, 8), Options{Format: NV12},
+			[]byte{0, 1, 2, 3, 4, 5, 6, 7, 100, 200, 101, 201}},
+		{"10 bits", testFrame(2, 2, frame.Chroma420, 10), Options{Format: Planar},
+			[]byte{0, 0, 1, 0, 2, 0, 3, 0, 100, 0, 200, 0}},
+		{"10 bits NV12", testFrame(2, 2, frame.Chroma420, 10), Options{Format: NV12},
+			[]byte{0, 0, 1, 0, 2, 0, 3, 0, 100, 0, 200, 0}},
+		{"cropped", cropped, Options{Format: Planar, Crop: true},
+			[]byte{10, 11, 14, 15, 103, 203}},
+		{"crop ignored", testFrame(2, 2, frame.Chroma420, 8), Options{Format: Planar},
+			[]byte{0, 1, 2, 3, 100, 200}},
+	}
+	tests[len(tests)-1].f.Crop = image.Rect(0, 0, 2, 0)
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := NewWriter(&buf, nil, tt.opts).WriteFrame(tt.f); err != nil {
+			t.Errorf("%v: WriteFrame() error %v", tt.name, err)
+			continue
+		}
+		if !bytes.Equal(buf.Bytes(), tt.want) {
+			t.Errorf("%v: WriteFrame() wrote % x, want % x", tt.name, buf.Bytes(), tt.want)
+		}
+	}
+}
+
+func TestWriteFrameOwnCrop(t *testing.T) {
+	// the crop window of the frame, set from its own sps, not the sps of the writer
+	sps := &internal.SPS{ChromaFormatIdc: 1, FrameMbsOnlyFlag: true}
+	f := testFrame(16, 16, frame.Chroma420, 8)
+	f.Crop = image.Rect(12, 0, 16, 4)
+	var buf bytes.Buffer
+	if err := NewWriter(&buf, sps, Options{Format: Planar, Crop: true}).WriteFrame(f); err != nil {
+		t.Fatalf("WriteFrame() error %v", err)
+	}
+	want := []byte{
+		12, 13, 14, 15, 28, 29, 30, 31, 44, 45, 46, 47, 60, 61, 62, 63,
+		106, 107, 114, 115, 206, 207, 214, 215,
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("WriteFrame() wrote % x, want % x", buf.Bytes(), want)
+	}
+}
+
+func TestY4M(t *testing.T) {
+	sps := &internal.SPS{FrameMbsOnlyFlag: true, VuiParametersPresentFlag: true}
+	vui := &sps.VuiParams
+	vui.TimingInfoPresentFlag, vui.NumUnitsInTick, vui.TimeScale = true, 1001, 60000
+	vui.AspectRatioInfoPresentFlag, vui.AspectRatioIdc = true, internal.ExtendedSAR
+	vui.SarWidth, vui.SarHeight = 4, 3
+	vui.VideoSignalTypePresentFlag, vui.VideoFullRangeFlag = true, true
+	vui.ChromaLocInfoPresentFlag, vui.ChromaSampleLocTypeTopField = true, 1
+	interlaced := *sps
+	interlaced.FrameMbsOnlyFlag = false
+	interlaced.VuiParametersPresentFlag = false
+	bottomFirst := testFrame(2, 2, frame.Chroma420, 8)
+	bottomFirst.TopPoc, bottomFirst.BottomPoc = 1, 0
+
+	tests := []struct {
+		name   string
+		sps    *internal.SPS
+		f      *frame.VideoFrame
+		header string
+	}{
+		{"VUI", sps, testFrame(2, 2, frame.Chroma420, 8),
+			"YUV4MPEG2 W2 H2 F30000:1001 Ip A4:3 C420jpeg XCOLORRANGE=FULL"},
+		{"defaults", nil, testFrame(2, 2, frame.Chroma420, 8),
+			"YUV4MPEG2 W2 H2 F25:1 Ip A0:0 C420mpeg2 XCOLORRANGE=LIMITED"},
+		{"top field first", &interlaced, testFrame(2, 2, frame.Chroma420, 8),
+			"YUV4MPEG2 W2 H2 F25:1 It A0:0 C420mpeg2 XCOLORRANGE=LIMITED"},
+		{"bottom field first", &interlaced, bottomFirst,
+			"YUV4MPEG2 W2 H2 F25:1 Ib A0:0 C420mpeg2 XCOLORRANGE=LIMITED"},
+		{"10 bits 4:2:0", nil, testFrame(2, 2, frame.Chroma420, 10),
+			"YUV4MPEG2 W2 H2 F25:1 Ip A0:0 C420p10 XCOLORRANGE=LIMITED"},
+		{"4:2:2", nil, testFrame(2, 2, frame.Chroma422, 8),
+			"YUV4MPEG2 W2 H2 F25:1 Ip A0:0 C422 XCOLORRANGE=LIMITED"},
+		{"12 bits 4:4:4", nil, testFrame(2, 2, frame.Chroma444, 12),
+			"YUV4MPEG2 W2 H2 F25:1 Ip A0:0 C444p12 XCOLORRANGE=LIMITED"},
+		{"monochrome", nil, testFrame(2, 2, frame.Monochrome, 8),
+			"YUV4MPEG2 W2 H2 F25:1 Ip A0:0 Cmono XCOLORRANGE=LIMITED"},
+		{"16 bits monochrome", nil, testFrame(2, 2, frame.Monochrome, 16),
+			"YUV4MPEG2 W2 H2 F25:1 Ip A0:0 Cmono16 XCOLORRANGE=LIMITED"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := NewWriter(&buf, tt.sps, Options{Format: Y4M}).WriteFrame(tt.f); err != nil {
+			t.Errorf("%v: WriteFrame() error %v", tt.name, err)
+			continue
+		}
+		if header, _, _ := strings.Cut(buf.String(), "\n"); header != tt.header {
+			t.Errorf("%v: header %q, want %q", tt.name, header, tt.header)
+		}
+	}
+
+	// header once, frames cropped
+	f := testFrame(4, 4, frame.Chroma420, 8)
+	f.Crop = image.Rect(0, 0, 2, 2)
+	var buf bytes.Buffer
+	w := NewWriter(&buf, nil, Options{Format: Y4M, Crop: true})
+	for i := 0; i < 2; i++ {
+		if err := w.WriteFrame(f); err != nil {
+			t.Fatalf("WriteFrame() error %v", err)
+		}
+	}
+	frameData := "FRAME\n\x00\x01\x04\x05\x64\xc8"
+	want := "YUV4MPEG2 W2 H2 F25:1 Ip A0:0 C420mpeg2 XCOLORRANGE=LIMITED\n" + frameData + frameData
+	if buf.String() != want {
+		t.Errorf("stream %q, want %q", buf.String(), want)
+	}
+}
+
+func TestWriteFrameErrors(t *testing.T) {
+	mixed := testFrame(2, 2, frame.Chroma420, 8)
+	mixed.BitDepthC = 10
+	tests := []struct {
+		name   string
+		format Format
+		frames []*frame.VideoFrame
+	}{
+		{"NV12 of 4:2:2", NV12, []*frame.VideoFrame{testFrame(2, 2, frame.Chroma422, 8)}},
+		{"NV12 of monochrome", NV12, []*frame.VideoFrame{testFrame(2, 2, frame.Monochrome, 8)}},
+		{"y4m size change", Y4M, []*frame.VideoFrame{testFrame(2, 2, frame.Chroma420, 8), testFrame(4, 2, frame.Chroma420, 8)}},
+		{"y4m bit depths", Y4M, []*frame.VideoFrame{mixed}},
+		{"unknown format", Format(9), []*frame.VideoFrame{testFrame(2, 2, frame.Chroma420, 8)}},
+	}
+	for _, tt := range tests {
+		w := NewWriter(&bytes.Buffer{}, nil, Options{Format: tt.format})
+		var err error
+		for _, f := range tt.frames {
+			if err = w.WriteFrame(f); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			t.Errorf("%v: WriteFrame() no error", tt.name)
+		}
+	}
+}